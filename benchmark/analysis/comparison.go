@@ -2,6 +2,7 @@ package analysis
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/discochess/stockpile/benchmark/simulation"
 )
@@ -14,9 +15,12 @@ type StrategyComparison struct {
 	Stats2          *DescriptiveStats
 	MannWhitney     *MannWhitneyResult
 	EffectSize      *EffectSize
+	CliffsDelta     *CliffsDeltaResult
 	BootstrapCI     *BootstrapResult
-	Winner          string // Name of strategy with fewer switches, or "tie".
-	WinnerConfident bool   // True if statistically significant.
+	AdjustedPValue  float64 // Mann-Whitney p-value after multiple-comparison correction.
+	Significant     bool    // True if the adjusted p < 0.05.
+	Winner          string  // Name of strategy with fewer switches, or "tie".
+	WinnerConfident bool    // True if statistically significant.
 }
 
 // CompareStrategies performs a full statistical comparison between two strategies.
@@ -31,6 +35,7 @@ func CompareStrategies(
 
 	mw := MannWhitneyU(sample1, sample2)
 	es := ComputeEffectSize(sample1, sample2)
+	cd := CliffsDelta(sample1, sample2)
 	bs := BootstrapConfidenceInterval(sample1, sample2, bootstrapIterations, confidence)
 
 	// Determine winner.
@@ -58,7 +63,10 @@ func CompareStrategies(
 		Stats2:          stats2,
 		MannWhitney:     mw,
 		EffectSize:      es,
+		CliffsDelta:     cd,
 		BootstrapCI:     bs,
+		AdjustedPValue:  mw.PValue,
+		Significant:     mw.Significant,
 		Winner:          winner,
 		WinnerConfident: confident,
 	}
@@ -67,8 +75,11 @@ func CompareStrategies(
 // Summary returns a human-readable summary of the comparison.
 func (c *StrategyComparison) Summary() string {
 	sig := "not statistically significant"
-	if c.MannWhitney.Significant {
-		sig = fmt.Sprintf("statistically significant (p=%.4f)", c.MannWhitney.PValue)
+	if c.Significant {
+		sig = fmt.Sprintf("statistically significant (p=%.4f)", c.AdjustedPValue)
+		if c.AdjustedPValue != c.MannWhitney.PValue {
+			sig = fmt.Sprintf("statistically significant (adjusted p=%.4f)", c.AdjustedPValue)
+		}
 	}
 
 	return fmt.Sprintf(
@@ -106,15 +117,36 @@ func safePctDiff(a, b float64) float64 {
 // MultiStrategyComparison compares multiple strategies against a baseline.
 type MultiStrategyComparison struct {
 	Baseline    string
+	Omnibus     *KruskalWallisResult // Nil when only two strategies are compared.
+	Correction  Correction           // Applied to the pairwise p-values.
 	Comparisons []*StrategyComparison
 }
 
-// CompareAll compares all strategies against the first one (baseline).
+// CompareAll compares all strategies against the baseline, applying a
+// Bonferroni correction to the pairwise p-values. See CompareAllCorrected.
 func CompareAll(
 	results map[string]*simulation.AggregateResult,
 	baseline string,
 	bootstrapIterations int,
 	confidence float64,
+) *MultiStrategyComparison {
+	return CompareAllCorrected(results, baseline, bootstrapIterations, confidence, CorrectionBonferroni)
+}
+
+// CompareAllCorrected compares all strategies against the baseline.
+//
+// With three or more strategies, a Kruskal-Wallis test first checks whether
+// any strategy differs at all; pairwise comparisons are only made when it
+// is significant, which keeps repeated Mann-Whitney tests from producing
+// false positives. Each comparison's AdjustedPValue, Significant and
+// WinnerConfident reflect the given correction over all pairwise tests.
+// Comparisons are ordered by strategy name.
+func CompareAllCorrected(
+	results map[string]*simulation.AggregateResult,
+	baseline string,
+	bootstrapIterations int,
+	confidence float64,
+	correction Correction,
 ) *MultiStrategyComparison {
 	baseResult, ok := results[baseline]
 	if !ok {
@@ -122,16 +154,59 @@ func CompareAll(
 	}
 
 	multi := &MultiStrategyComparison{
-		Baseline: baseline,
+		Baseline:   baseline,
+		Correction: correction,
 	}
 
-	for name, result := range results {
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) > 2 {
+		samples := make([][]float64, len(names))
+		for i, name := range names {
+			samples[i] = intsToFloats(results[name].SwitchesPerGame)
+		}
+		multi.Omnibus = KruskalWallis(samples...)
+		if !multi.Omnibus.Significant {
+			return multi
+		}
+	}
+
+	for _, name := range names {
 		if name == baseline {
 			continue
 		}
-		comp := CompareStrategies(baseResult, result, bootstrapIterations, confidence)
+		comp := CompareStrategies(baseResult, results[name], bootstrapIterations, confidence)
 		multi.Comparisons = append(multi.Comparisons, comp)
 	}
 
+	pValues := make([]float64, len(multi.Comparisons))
+	for i, comp := range multi.Comparisons {
+		pValues[i] = comp.MannWhitney.PValue
+	}
+	for i, adjusted := range AdjustPValues(pValues, correction) {
+		comp := multi.Comparisons[i]
+		comp.AdjustedPValue = adjusted
+		comp.Significant = adjusted < 0.05
+		comp.WinnerConfident = comp.Significant && comp.Winner != "tie"
+	}
+
 	return multi
 }
+
+// OmnibusSummary returns a human-readable summary of the Kruskal-Wallis
+// test, or "" if none was run.
+func (m *MultiStrategyComparison) OmnibusSummary() string {
+	if m.Omnibus == nil {
+		return ""
+	}
+	result := "no strategy differs significantly; pairwise comparisons skipped"
+	if m.Omnibus.Significant {
+		result = "at least one strategy differs significantly"
+	}
+	return fmt.Sprintf("Kruskal-Wallis: H=%.2f, df=%d, p=%.4f (%s)",
+		m.Omnibus.H, m.Omnibus.DF, m.Omnibus.PValue, result)
+}
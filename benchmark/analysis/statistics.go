@@ -3,9 +3,12 @@ package analysis
 
 import (
 	"math"
+	"math/rand"
 	"sort"
 
+	"gonum.org/v1/gonum/floats"
 	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
 )
 
 // MannWhitneyResult contains the result of a Mann-Whitney U test.
@@ -98,6 +101,137 @@ func normalCDF(x float64) float64 {
 	return 0.5 * (1 + math.Erf(x/math.Sqrt2))
 }
 
+// KruskalWallisResult contains the result of a Kruskal-Wallis H test.
+type KruskalWallisResult struct {
+	H           float64 // H statistic, corrected for ties.
+	DF          int     // Degrees of freedom (groups - 1).
+	PValue      float64 // P-value (chi-square approximation).
+	Significant bool    // True if p < 0.05.
+}
+
+// KruskalWallis performs the Kruskal-Wallis H test on two or more samples.
+// It is the non-parametric omnibus test for whether any sample comes from a
+// different distribution; a significant result justifies pairwise
+// comparisons. Empty samples are ignored.
+func KruskalWallis(samples ...[]float64) *KruskalWallisResult {
+	type rankedValue struct {
+		value  float64
+		sample int
+	}
+
+	var combined []rankedValue
+	groups := 0
+	for i, sample := range samples {
+		if len(sample) == 0 {
+			continue
+		}
+		groups++
+		for _, v := range sample {
+			combined = append(combined, rankedValue{value: v, sample: i})
+		}
+	}
+
+	// Fewer than two groups cannot differ.
+	if groups < 2 {
+		return &KruskalWallisResult{PValue: 1}
+	}
+
+	sort.Slice(combined, func(i, j int) bool {
+		return combined[i].value < combined[j].value
+	})
+
+	// Sum ranks per sample (handling ties) and accumulate the tie correction.
+	rankSums := make([]float64, len(samples))
+	var tieSum float64
+	i := 0
+	for i < len(combined) {
+		j := i
+		for j < len(combined) && combined[j].value == combined[i].value {
+			j++
+		}
+		avgRank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			rankSums[combined[k].sample] += avgRank
+		}
+		t := float64(j - i)
+		tieSum += t*t*t - t
+		i = j
+	}
+
+	n := float64(len(combined))
+	var h float64
+	for i, sample := range samples {
+		if len(sample) > 0 {
+			h += rankSums[i] * rankSums[i] / float64(len(sample))
+		}
+	}
+	h = 12/(n*(n+1))*h - 3*(n+1)
+
+	correction := 1 - tieSum/(n*n*n-n)
+	if correction <= 0 {
+		// Every value is tied.
+		return &KruskalWallisResult{DF: groups - 1, PValue: 1}
+	}
+	h /= correction
+
+	df := groups - 1
+	pValue := distuv.ChiSquared{K: float64(df)}.Survival(h)
+
+	return &KruskalWallisResult{
+		H:           h,
+		DF:          df,
+		PValue:      pValue,
+		Significant: pValue < 0.05,
+	}
+}
+
+// Correction is a multiple-comparison correction method for p-values.
+type Correction string
+
+const (
+	// CorrectionNone leaves p-values unadjusted.
+	CorrectionNone Correction = "none"
+	// CorrectionBonferroni multiplies each p-value by the number of tests,
+	// controlling the family-wise error rate.
+	CorrectionBonferroni Correction = "bonferroni"
+	// CorrectionBenjaminiHochberg controls the false discovery rate; it is
+	// less conservative than Bonferroni.
+	CorrectionBenjaminiHochberg Correction = "benjamini-hochberg"
+)
+
+// AdjustPValues returns p-values adjusted for multiple comparisons, in the
+// same order as the input. Adjusted values are capped at 1.
+func AdjustPValues(pValues []float64, method Correction) []float64 {
+	m := float64(len(pValues))
+	adjusted := make([]float64, len(pValues))
+
+	switch method {
+	case CorrectionBonferroni:
+		for i, p := range pValues {
+			adjusted[i] = math.Min(p*m, 1)
+		}
+	case CorrectionBenjaminiHochberg:
+		order := make([]int, len(pValues))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(i, j int) bool {
+			return pValues[order[i]] < pValues[order[j]]
+		})
+		// Step up from the largest p-value, keeping adjustments monotone.
+		running := 1.0
+		for k := len(order) - 1; k >= 0; k-- {
+			i := order[k]
+			running = math.Min(running, pValues[i]*m/float64(k+1))
+			adjusted[i] = running
+		}
+	default:
+		copy(adjusted, pValues)
+	}
+
+	return adjusted
+}
+
 // EffectSize contains effect size metrics.
 type EffectSize struct {
 	CohensD     float64 // Cohen's d: (mean1 - mean2) / pooled_std.
@@ -145,6 +279,53 @@ func interpretCohensD(d float64) string {
 	}
 }
 
+// CliffsDeltaResult contains Cliff's delta, a non-parametric effect size.
+type CliffsDeltaResult struct {
+	Delta          float64 // P(x1 > x2) - P(x1 < x2), in [-1, 1].
+	Interpretation string  // "negligible", "small", "medium", "large".
+}
+
+// CliffsDelta computes Cliff's delta: how often values in sample1 exceed
+// values in sample2, minus how often they fall below. Unlike Cohen's d it
+// makes no normality assumption, matching the Mann-Whitney U test.
+func CliffsDelta(sample1, sample2 []float64) *CliffsDeltaResult {
+	if len(sample1) == 0 || len(sample2) == 0 {
+		return &CliffsDeltaResult{Interpretation: "undefined"}
+	}
+
+	sorted := make([]float64, len(sample2))
+	copy(sorted, sample2)
+	sort.Float64s(sorted)
+
+	// Count dominance pairs with binary search rather than comparing all pairs.
+	var dominance int
+	for _, v := range sample1 {
+		below := sort.SearchFloat64s(sorted, v)
+		above := len(sorted) - sort.Search(len(sorted), func(i int) bool { return sorted[i] > v })
+		dominance += below - above
+	}
+
+	delta := float64(dominance) / float64(len(sample1)*len(sample2))
+	return &CliffsDeltaResult{
+		Delta:          delta,
+		Interpretation: interpretCliffsDelta(math.Abs(delta)),
+	}
+}
+
+// interpretCliffsDelta applies the thresholds of Romano et al. (2006).
+func interpretCliffsDelta(d float64) string {
+	switch {
+	case d < 0.147:
+		return "negligible"
+	case d < 0.33:
+		return "small"
+	case d < 0.474:
+		return "medium"
+	default:
+		return "large"
+	}
+}
+
 // BootstrapCI computes a bootstrap confidence interval for the mean difference.
 type BootstrapResult struct {
 	MeanDiff   float64
@@ -153,9 +334,21 @@ type BootstrapResult struct {
 	Confidence float64 // e.g., 0.95 for 95% CI.
 }
 
-// BootstrapConfidenceInterval computes a confidence interval using bootstrap.
+// DefaultBootstrapSeed seeds BootstrapConfidenceInterval so reports are
+// reproducible.
+const DefaultBootstrapSeed = 1
+
+// BootstrapConfidenceInterval computes a confidence interval using bootstrap,
+// seeded with DefaultBootstrapSeed.
 func BootstrapConfidenceInterval(sample1, sample2 []float64, iterations int, confidence float64) *BootstrapResult {
-	if len(sample1) == 0 || len(sample2) == 0 {
+	return BootstrapConfidenceIntervalSeed(sample1, sample2, iterations, confidence, DefaultBootstrapSeed)
+}
+
+// BootstrapConfidenceIntervalSeed computes a confidence interval using
+// bootstrap. Each iteration resamples both samples independently from a
+// random source seeded with seed, so equal seeds give equal intervals.
+func BootstrapConfidenceIntervalSeed(sample1, sample2 []float64, iterations int, confidence float64, seed int64) *BootstrapResult {
+	if len(sample1) == 0 || len(sample2) == 0 || iterations <= 0 {
 		return &BootstrapResult{Confidence: confidence}
 	}
 
@@ -164,50 +357,141 @@ func BootstrapConfidenceInterval(sample1, sample2 []float64, iterations int, con
 	mean2 := stat.Mean(sample2, nil)
 	actualDiff := mean1 - mean2
 
-	// Bootstrap resampling.
-	diffs := make([]float64, iterations)
-	for i := 0; i < iterations; i++ {
-		resample1 := resample(sample1)
-		resample2 := resample(sample2)
-		diffs[i] = stat.Mean(resample1, nil) - stat.Mean(resample2, nil)
-	}
-
-	sort.Float64s(diffs)
+	diffs := bootstrapDiffs(rand.New(rand.NewSource(seed)), sample1, sample2, iterations)
 
 	// Percentile method for CI.
 	alpha := 1 - confidence
-	lowerIdx := int(alpha / 2 * float64(iterations))
-	upperIdx := int((1 - alpha/2) * float64(iterations))
+	return &BootstrapResult{
+		MeanDiff:   actualDiff,
+		LowerBound: percentileAt(diffs, alpha/2),
+		UpperBound: percentileAt(diffs, 1-alpha/2),
+		Confidence: confidence,
+	}
+}
 
-	if lowerIdx < 0 {
-		lowerIdx = 0
+// BootstrapBCa computes a bias-corrected and accelerated (BCa) bootstrap
+// confidence interval, seeded with DefaultBootstrapSeed. Unlike the
+// percentile method of BootstrapConfidenceInterval, it stays accurate for
+// skewed distributions such as switches per game, at the cost of a
+// jackknife pass over both samples.
+func BootstrapBCa(sample1, sample2 []float64, iterations int, confidence float64) *BootstrapResult {
+	return BootstrapBCaSeed(sample1, sample2, iterations, confidence, DefaultBootstrapSeed)
+}
+
+// BootstrapBCaSeed is BootstrapBCa with an explicit random seed.
+func BootstrapBCaSeed(sample1, sample2 []float64, iterations int, confidence float64, seed int64) *BootstrapResult {
+	if len(sample1) == 0 || len(sample2) == 0 || iterations <= 0 {
+		return &BootstrapResult{Confidence: confidence}
 	}
-	if upperIdx >= iterations {
-		upperIdx = iterations - 1
+
+	actualDiff := stat.Mean(sample1, nil) - stat.Mean(sample2, nil)
+	diffs := bootstrapDiffs(rand.New(rand.NewSource(seed)), sample1, sample2, iterations)
+
+	// Bias correction: how far the bootstrap distribution's median is from
+	// the observed difference.
+	below := sort.SearchFloat64s(diffs, actualDiff)
+	z0 := distuv.UnitNormal.Quantile(clampProbability(float64(below)/float64(iterations), iterations))
+
+	// Acceleration from the jackknife skewness of the difference.
+	a := jackknifeAcceleration(sample1, sample2)
+
+	adjust := func(p float64) float64 {
+		z := distuv.UnitNormal.Quantile(p)
+		return distuv.UnitNormal.CDF(z0 + (z0+z)/(1-a*(z0+z)))
 	}
 
+	alpha := 1 - confidence
 	return &BootstrapResult{
 		MeanDiff:   actualDiff,
-		LowerBound: diffs[lowerIdx],
-		UpperBound: diffs[upperIdx],
+		LowerBound: percentileAt(diffs, adjust(alpha/2)),
+		UpperBound: percentileAt(diffs, adjust(1-alpha/2)),
 		Confidence: confidence,
 	}
 }
 
+// bootstrapDiffs returns the sorted mean differences of iterations
+// independent resamples of both samples.
+func bootstrapDiffs(rng *rand.Rand, sample1, sample2 []float64, iterations int) []float64 {
+	diffs := make([]float64, iterations)
+	for i := 0; i < iterations; i++ {
+		resample1 := resample(rng, sample1)
+		resample2 := resample(rng, sample2)
+		diffs[i] = stat.Mean(resample1, nil) - stat.Mean(resample2, nil)
+	}
+	sort.Float64s(diffs)
+	return diffs
+}
+
+// percentileAt returns the value at quantile p of sorted bootstrap values.
+func percentileAt(sorted []float64, p float64) float64 {
+	idx := int(p * float64(len(sorted)))
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// clampProbability keeps a bootstrap proportion away from 0 and 1, where
+// the normal quantile is infinite.
+func clampProbability(p float64, iterations int) float64 {
+	eps := 1 / (2 * float64(iterations))
+	return math.Max(eps, math.Min(1-eps, p))
+}
+
+// jackknifeAcceleration estimates the BCa acceleration constant by leaving
+// out each observation of either sample in turn.
+func jackknifeAcceleration(sample1, sample2 []float64) float64 {
+	sum1, sum2 := floats.Sum(sample1), floats.Sum(sample2)
+	n1, n2 := float64(len(sample1)), float64(len(sample2))
+	mean1, mean2 := sum1/n1, sum2/n2
+
+	var estimates []float64
+	if len(sample1) > 1 {
+		for _, v := range sample1 {
+			estimates = append(estimates, (sum1-v)/(n1-1)-mean2)
+		}
+	}
+	if len(sample2) > 1 {
+		for _, v := range sample2 {
+			estimates = append(estimates, mean1-(sum2-v)/(n2-1))
+		}
+	}
+	if len(estimates) == 0 {
+		return 0
+	}
+
+	jackMean := stat.Mean(estimates, nil)
+	var num, den float64
+	for _, e := range estimates {
+		d := jackMean - e
+		num += d * d * d
+		den += d * d
+	}
+	if den == 0 {
+		return 0
+	}
+	return num / (6 * math.Pow(den, 1.5))
+}
+
 // resample performs bootstrap resampling with replacement.
-func resample(sample []float64) []float64 {
-	n := len(sample)
-	result := make([]float64, n)
-	for i := 0; i < n; i++ {
-		result[i] = sample[pseudoRandom(i, n)%n]
+func resample(rng *rand.Rand, sample []float64) []float64 {
+	result := make([]float64, len(sample))
+	for i, idx := range resampleIndices(rng, len(sample)) {
+		result[i] = sample[idx]
 	}
 	return result
 }
 
-// pseudoRandom generates a deterministic pseudo-random index for reproducibility.
-func pseudoRandom(seed, n int) int {
-	// Simple LCG for reproducible results.
-	return (seed*1103515245 + 12345) % (1 << 31) % n
+// resampleIndices draws n indices in [0, n) with replacement.
+func resampleIndices(rng *rand.Rand, n int) []int {
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = rng.Intn(n)
+	}
+	return indices
 }
 
 // DescriptiveStats contains basic descriptive statistics.
@@ -235,7 +519,7 @@ func Describe(sample []float64) *DescriptiveStats {
 	return &DescriptiveStats{
 		N:      len(sample),
 		Mean:   stat.Mean(sample, nil),
-		Median: sorted[len(sorted)/2],
+		Median: percentileFloat(sorted, 50),
 		StdDev: stat.StdDev(sample, nil),
 		Min:    sorted[0],
 		Max:    sorted[len(sorted)-1],
@@ -244,10 +528,14 @@ func Describe(sample []float64) *DescriptiveStats {
 	}
 }
 
+// percentileFloat returns the p-th percentile of sorted values,
+// interpolating linearly between the closest ranks (NumPy's default method).
 func percentileFloat(sorted []float64, p float64) float64 {
-	idx := int(float64(len(sorted)-1) * p / 100)
-	if idx >= len(sorted) {
-		idx = len(sorted) - 1
+	pos := float64(len(sorted)-1) * p / 100
+	lo := int(pos)
+	if lo >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
 	}
-	return sorted[idx]
+	frac := pos - float64(lo)
+	return sorted[lo] + frac*(sorted[lo+1]-sorted[lo])
 }
@@ -2,6 +2,8 @@ package analysis
 
 import (
 	"math"
+	"math/rand"
+	"slices"
 	"testing"
 )
 
@@ -49,6 +51,103 @@ func TestMannWhitneyU_Empty(t *testing.T) {
 	}
 }
 
+func TestKruskalWallis(t *testing.T) {
+	tests := []struct {
+		name       string
+		samples    [][]float64
+		wantH      float64
+		wantDF     int
+		wantP      float64
+		wantSignif bool
+	}{
+		{
+			name:    "interleaved samples",
+			samples: [][]float64{{1, 3, 5, 7, 9}, {2, 4, 6, 8, 10}},
+			wantH:   0.2727,
+			wantDF:  1,
+			wantP:   0.6015,
+		},
+		{
+			name:       "ties",
+			samples:    [][]float64{{1, 1, 1}, {2, 2, 2}, {2, 2}},
+			wantH:      7.0,
+			wantDF:     2,
+			wantP:      0.0302,
+			wantSignif: true,
+		},
+		{
+			name:       "three separated samples",
+			samples:    [][]float64{{1, 2, 3, 4, 5}, {10, 11, 12, 13, 14}, {20, 21, 22, 23, 24}},
+			wantH:      12.5,
+			wantDF:     2,
+			wantP:      0.0019,
+			wantSignif: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := KruskalWallis(tt.samples...)
+			if math.Abs(result.H-tt.wantH) > 0.001 {
+				t.Errorf("H = %f, want %f", result.H, tt.wantH)
+			}
+			if result.DF != tt.wantDF {
+				t.Errorf("DF = %d, want %d", result.DF, tt.wantDF)
+			}
+			if math.Abs(result.PValue-tt.wantP) > 0.001 {
+				t.Errorf("PValue = %f, want %f", result.PValue, tt.wantP)
+			}
+			if result.Significant != tt.wantSignif {
+				t.Errorf("Significant = %v, want %v", result.Significant, tt.wantSignif)
+			}
+		})
+	}
+}
+
+func TestKruskalWallis_Degenerate(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples [][]float64
+	}{
+		{"no samples", nil},
+		{"one non-empty sample", [][]float64{{1, 2, 3}, {}}},
+		{"all values tied", [][]float64{{4, 4}, {4, 4, 4}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := KruskalWallis(tt.samples...)
+			if result.PValue != 1 || result.Significant {
+				t.Errorf("got p=%f significant=%v, want p=1 not significant", result.PValue, result.Significant)
+			}
+		})
+	}
+}
+
+func TestAdjustPValues(t *testing.T) {
+	pValues := []float64{0.01, 0.04, 0.03, 0.5}
+
+	tests := []struct {
+		method Correction
+		want   []float64
+	}{
+		{CorrectionNone, []float64{0.01, 0.04, 0.03, 0.5}},
+		{CorrectionBonferroni, []float64{0.04, 0.16, 0.12, 1}},
+		{CorrectionBenjaminiHochberg, []float64{0.04, 0.05333, 0.05333, 0.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.method), func(t *testing.T) {
+			got := AdjustPValues(pValues, tt.method)
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 0.0001 {
+					t.Errorf("AdjustPValues()[%d] = %f, want %f", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
 func TestEffectSize(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -80,6 +179,42 @@ func TestEffectSize(t *testing.T) {
 	}
 }
 
+func TestCliffsDelta(t *testing.T) {
+	tests := []struct {
+		name       string
+		sample1    []float64
+		sample2    []float64
+		wantDelta  float64
+		wantInterp string
+	}{
+		{"identical samples", []float64{1, 2, 3}, []float64{1, 2, 3}, 0, "negligible"},
+		{"complete dominance", []float64{10, 11, 12}, []float64{1, 2, 3}, 1, "large"},
+		{"complete subordination", []float64{1, 2}, []float64{5, 6, 7}, -1, "large"},
+		// 6 of 9 pairs greater, 3 less.
+		{"partial overlap", []float64{2, 4, 6}, []float64{1, 3, 5}, 3.0 / 9, "medium"},
+		// Pairs: (1,1) tie, (1,2) less, (2,1) greater, (2,2) tie.
+		{"ties", []float64{1, 2}, []float64{1, 2}, 0, "negligible"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CliffsDelta(tt.sample1, tt.sample2)
+			if math.Abs(result.Delta-tt.wantDelta) > 1e-9 {
+				t.Errorf("Delta = %f, want %f", result.Delta, tt.wantDelta)
+			}
+			if result.Interpretation != tt.wantInterp {
+				t.Errorf("Interpretation = %q, want %q", result.Interpretation, tt.wantInterp)
+			}
+		})
+	}
+}
+
+func TestCliffsDelta_Empty(t *testing.T) {
+	if result := CliffsDelta(nil, []float64{1}); result.Interpretation != "undefined" {
+		t.Errorf("Interpretation = %q, want undefined", result.Interpretation)
+	}
+}
+
 func TestDescribe(t *testing.T) {
 	sample := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 	stats := Describe(sample)
@@ -96,6 +231,15 @@ func TestDescribe(t *testing.T) {
 	if stats.Max != 10 {
 		t.Errorf("Max = %f, want 10", stats.Max)
 	}
+	if stats.Median != 5.5 {
+		t.Errorf("Median = %f, want 5.5", stats.Median)
+	}
+	if math.Abs(stats.P25-3.25) > 1e-9 {
+		t.Errorf("P25 = %f, want 3.25", stats.P25)
+	}
+	if math.Abs(stats.P75-7.75) > 1e-9 {
+		t.Errorf("P75 = %f, want 7.75", stats.P75)
+	}
 }
 
 func TestDescribe_Empty(t *testing.T) {
@@ -121,3 +265,78 @@ func TestBootstrapConfidenceInterval(t *testing.T) {
 		t.Errorf("CI [%f, %f] does not contain mean diff %f", result.LowerBound, result.UpperBound, result.MeanDiff)
 	}
 }
+
+func TestResampleIndices_Independent(t *testing.T) {
+	rng := rand.New(rand.NewSource(DefaultBootstrapSeed))
+	first := resampleIndices(rng, 20)
+	second := resampleIndices(rng, 20)
+	if slices.Equal(first, second) {
+		t.Errorf("consecutive resamples drew the same indices: %v", first)
+	}
+}
+
+func TestBootstrapConfidenceInterval_Reproducible(t *testing.T) {
+	sample1 := []float64{3, 8, 1, 9, 4, 7, 2, 6}
+	sample2 := []float64{5, 12, 6, 14, 9, 10, 7, 11}
+
+	a := BootstrapConfidenceIntervalSeed(sample1, sample2, 500, 0.95, 42)
+	b := BootstrapConfidenceIntervalSeed(sample1, sample2, 500, 0.95, 42)
+	if *a != *b {
+		t.Errorf("same seed gave different intervals: %+v and %+v", a, b)
+	}
+	if a.UpperBound <= a.LowerBound {
+		t.Errorf("CI [%f, %f] has no width", a.LowerBound, a.UpperBound)
+	}
+}
+
+func TestBootstrapConfidenceInterval_MoreIterationsMoreStable(t *testing.T) {
+	sample1 := []float64{3, 8, 1, 9, 4, 7, 2, 6, 5, 10}
+	sample2 := []float64{5, 12, 6, 14, 9, 10, 7, 11, 8, 13}
+
+	// The spread of CI widths across seeds is the Monte Carlo error of the
+	// interval; it should shrink as iterations grow.
+	widthSpread := func(iterations int) float64 {
+		widths := make([]float64, 20)
+		for seed := range widths {
+			r := BootstrapConfidenceIntervalSeed(sample1, sample2, iterations, 0.95, int64(seed))
+			widths[seed] = r.UpperBound - r.LowerBound
+		}
+		return Describe(widths).StdDev
+	}
+
+	few, many := widthSpread(50), widthSpread(5000)
+	if many >= few {
+		t.Errorf("CI width spread with 5000 iterations = %f, want < %f (50 iterations)", many, few)
+	}
+}
+
+func TestBootstrapBCa_SkewedSample(t *testing.T) {
+	// Right-skewed switch counts: a few games switch far more than the rest.
+	skewed := []float64{1, 1, 1, 2, 2, 2, 3, 3, 4, 5, 8, 15, 30, 60}
+	symmetric := []float64{5, 6, 6, 7, 7, 7, 8, 8, 9, 9, 10, 10}
+
+	pct := BootstrapConfidenceInterval(skewed, symmetric, 10000, 0.95)
+	bca := BootstrapBCa(skewed, symmetric, 10000, 0.95)
+
+	if bca.MeanDiff != pct.MeanDiff {
+		t.Errorf("MeanDiff = %f, want %f", bca.MeanDiff, pct.MeanDiff)
+	}
+	if bca.LowerBound > bca.MeanDiff || bca.UpperBound < bca.MeanDiff {
+		t.Errorf("BCa CI [%f, %f] does not contain mean diff %f", bca.LowerBound, bca.UpperBound, bca.MeanDiff)
+	}
+
+	// Positive skew should shift the BCa interval toward the long tail.
+	if bca.LowerBound <= pct.LowerBound || bca.UpperBound <= pct.UpperBound {
+		t.Errorf("BCa CI [%f, %f] not shifted right of percentile CI [%f, %f]",
+			bca.LowerBound, bca.UpperBound, pct.LowerBound, pct.UpperBound)
+	}
+}
+
+func TestJackknifeAcceleration(t *testing.T) {
+	if a := jackknifeAcceleration([]float64{1, 2, 3, 4, 5}, []float64{2, 4, 6}); math.Abs(a) > 1e-12 {
+		t.Errorf("acceleration for symmetric samples = %f, want 0", a)
+	}
+	if a := jackknifeAcceleration([]float64{1, 1, 1, 2, 50}, []float64{3, 4, 5}); a <= 0 {
+		t.Errorf("acceleration for right-skewed sample = %f, want > 0", a)
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/discochess/stockpile"
 	"github.com/discochess/stockpile/internal/codec/zstdcodec"
+	"github.com/discochess/stockpile/internal/store"
 	"github.com/discochess/stockpile/internal/store/cachedstore"
 	"github.com/discochess/stockpile/internal/store/cachedstore/cachestrategy/lru"
 	"github.com/discochess/stockpile/internal/store/cachedstore/memory"
@@ -262,3 +263,56 @@ func TestMicroBenchmarksCompile(t *testing.T) {
 	// This test just ensures the benchmark code compiles.
 	_ = fmt.Sprintf("benchmarks compile")
 }
+
+// BenchmarkLookup_SameShard measures warm repeated lookups into one shard
+// through each disk store. The persistent store keeps the shard file open
+// and, with a line index, searches the shard without splitting it again.
+// It builds its own shard, so it needs no DATA_DIR.
+func BenchmarkLookup_SameShard(b *testing.B) {
+	dir := b.TempDir()
+	disk, err := diskstore.New(dir, zstdcodec.New())
+	if err != nil {
+		b.Fatalf("creating store: %v", err)
+	}
+	var shard []byte
+	for i := range 50000 {
+		shard = fmt.Appendf(shard, `{"fen":"pos%05d","evals":[{"pvs":[{"cp":1,"line":"e4"}],"knodes":1,"depth":20}]}`+"\n", i)
+	}
+	if err := disk.WriteShard(context.Background(), 0, shard); err != nil {
+		b.Fatalf("writing shard: %v", err)
+	}
+
+	stores := []struct {
+		name string
+		open func() (store.Store, error)
+	}{
+		{"Disk", func() (store.Store, error) { return diskstore.New(dir, zstdcodec.New()) }},
+		{"Persistent", func() (store.Store, error) { return diskstore.NewPersistent(dir, zstdcodec.New()) }},
+		{"PersistentLineIndex", func() (store.Store, error) {
+			return diskstore.NewPersistent(dir, zstdcodec.New(), diskstore.WithLineIndex(true))
+		}},
+	}
+	for _, sc := range stores {
+		b.Run(sc.name, func(b *testing.B) {
+			st, err := sc.open()
+			if err != nil {
+				b.Fatalf("creating store: %v", err)
+			}
+			client, err := stockpile.New(stockpile.WithStore(st), stockpile.WithTotalShards(1))
+			if err != nil {
+				b.Fatalf("creating client: %v", err)
+			}
+			defer client.Close()
+
+			ctx := context.Background()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				fen := fmt.Sprintf("pos%05d", i*7919%50000)
+				if _, err := client.Lookup(ctx, fen); err != nil {
+					b.Fatalf("lookup error: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package pgn
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -72,25 +73,47 @@ func ExtractFENs(r io.Reader) ([]string, error) {
 
 // ExtractFENsFromGames extracts FENs from multiple PGN games in a reader.
 // Unlike ExtractFENs, this preserves duplicates and game boundaries.
+// It holds every game in memory; use StreamGames for large inputs.
 func ExtractFENsFromGames(r io.Reader) ([][]string, error) {
 	var games [][]string
+	err := StreamGames(r, func(fens []string) error {
+		games = append(games, fens)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return games, nil
+}
 
+// StreamGames parses PGN games from r one at a time and calls fn with the
+// FENs of each game, in order. Games that fail to parse or contain no
+// positions are skipped. If fn returns an error, StreamGames stops and
+// returns that error unchanged.
+func StreamGames(r io.Reader, fn func(fens []string) error) error {
 	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
 
 	var gameText strings.Builder
 	inGame := false
 
+	emit := func() error {
+		gameFENs, err := extractFENsFromGame(gameText.String())
+		gameText.Reset()
+		if err != nil || len(gameFENs) == 0 {
+			return nil
+		}
+		return fn(gameFENs)
+	}
+
 	for scanner.Scan() {
 		line := scanner.Text()
 
 		if strings.HasPrefix(line, "[Event ") {
 			if inGame && gameText.Len() > 0 {
-				gameFENs, err := extractFENsFromGame(gameText.String())
-				if err == nil && len(gameFENs) > 0 {
-					games = append(games, gameFENs)
+				if err := emit(); err != nil {
+					return err
 				}
-				gameText.Reset()
 			}
 			inGame = true
 		}
@@ -101,22 +124,33 @@ func ExtractFENsFromGames(r io.Reader) ([][]string, error) {
 		}
 	}
 
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading PGN: %w", err)
+	}
+
 	// Process last game.
 	if gameText.Len() > 0 {
-		gameFENs, err := extractFENsFromGame(gameText.String())
-		if err == nil && len(gameFENs) > 0 {
-			games = append(games, gameFENs)
+		if err := emit(); err != nil {
+			return err
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("reading PGN: %w", err)
-	}
-
-	return games, nil
+	return nil
 }
 
+// startFEN is the standard starting position, normalized to 4 fields.
+const startFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -"
+
+// errUnsupportedVariant is returned for games whose positions cannot be
+// reproduced faithfully, such as Chess960 games from a shuffled start.
+var errUnsupportedVariant = errors.New("unsupported variant")
+
 func extractFENsFromGame(pgnText string) ([]string, error) {
+	if err := checkVariant(gameTags(pgnText)); err != nil {
+		return nil, err
+	}
+
+	// chess.PGN honours the FEN tag as the starting position.
 	pgnFunc, err := chess.PGN(strings.NewReader(pgnText))
 	if err != nil {
 		return nil, err
@@ -136,6 +170,41 @@ func extractFENsFromGame(pgnText string) ([]string, error) {
 	return fens, nil
 }
 
+// checkVariant reports whether a game with the given tags can be replayed
+// with standard move rules. Chess960 is accepted only from the standard
+// start position, since its castling rules differ everywhere else.
+func checkVariant(tags map[string]string) error {
+	variant := strings.ToLower(strings.ReplaceAll(tags["Variant"], " ", ""))
+	switch variant {
+	case "", "standard", "fromposition":
+		return nil
+	case "chess960":
+		if fen, ok := tags["FEN"]; ok && normalizeFEN(fen) != startFEN {
+			return fmt.Errorf("%w: %s from %q", errUnsupportedVariant, tags["Variant"], fen)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", errUnsupportedVariant, tags["Variant"])
+	}
+}
+
+// gameTags returns the tag pairs of a single PGN game.
+func gameTags(pgnText string) map[string]string {
+	tags := make(map[string]string)
+	for _, line := range strings.Split(pgnText, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+			continue
+		}
+		key, value, ok := strings.Cut(line[1:len(line)-1], " ")
+		if !ok {
+			continue
+		}
+		tags[key] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return tags
+}
+
 // normalizeFEN normalizes a FEN to 4 fields (piece placement, side, castling, en passant).
 func normalizeFEN(fen string) string {
 	parts := strings.Fields(fen)
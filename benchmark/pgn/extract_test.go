@@ -0,0 +1,99 @@
+package pgn
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExtractFENsFromGames_FromPosition(t *testing.T) {
+	const game = `[Event "Endgame study"]
+[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"]
+
+1. e4 Kd7 *
+`
+
+	games, err := ExtractFENsFromGames(strings.NewReader(game))
+	if err != nil {
+		t.Fatalf("ExtractFENsFromGames: %v", err)
+	}
+	if len(games) != 1 {
+		t.Fatalf("got %d games, want 1", len(games))
+	}
+
+	want := []string{
+		"4k3/8/8/8/8/8/4P3/4K3 w - -",
+		"4k3/8/8/8/4P3/8/8/4K3 b - e3",
+		"8/3k4/8/8/4P3/8/8/4K3 w - -",
+	}
+	got := games[0]
+	if len(got) != len(want) {
+		t.Fatalf("got %d positions, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("position %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestExtractFENsFromGames_Chess960(t *testing.T) {
+	const pgnText = `[Event "Shuffled 960"]
+[Variant "Chess960"]
+[SetUp "1"]
+[FEN "nrbbqkrn/pppppppp/8/8/8/8/PPPPPPPP/NRBBQKRN w KQkq - 0 1"]
+
+1. e4 e5 2. Ng3 Ng6 3. O-O O-O *
+
+[Event "Standard 960"]
+[Variant "Chess960"]
+
+1. e4 e5 *
+
+[Event "Atomic"]
+[Variant "Atomic"]
+
+1. e4 e5 *
+`
+
+	games, err := ExtractFENsFromGames(strings.NewReader(pgnText))
+	if err != nil {
+		t.Fatalf("ExtractFENsFromGames: %v", err)
+	}
+
+	// Only the 960 game from the standard start position is kept.
+	if len(games) != 1 {
+		t.Fatalf("got %d games, want 1", len(games))
+	}
+	if len(games[0]) != 3 {
+		t.Errorf("got %d positions, want 3", len(games[0]))
+	}
+	if games[0][0] != startFEN {
+		t.Errorf("first position = %q, want %q", games[0][0], startFEN)
+	}
+}
+
+func TestStreamGames_StopsOnError(t *testing.T) {
+	const pgnText = `[Event "One"]
+
+1. e4 e5 *
+
+[Event "Two"]
+
+1. d4 d5 *
+`
+
+	stop := errors.New("stop")
+	var calls int
+	err := StreamGames(strings.NewReader(pgnText), func(fens []string) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("StreamGames error = %v, want %v", err, stop)
+	}
+	if calls != 1 {
+		t.Errorf("callback called %d times, want 1", calls)
+	}
+}
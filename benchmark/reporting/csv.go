@@ -0,0 +1,78 @@
+package reporting
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/discochess/stockpile/benchmark/analysis"
+	"github.com/discochess/stockpile/benchmark/simulation"
+)
+
+// csvCacheCapacity is the cache size (in shards) used for the estimated
+// hit rate column, matching the text and markdown reports.
+const csvCacheCapacity = 100
+
+// WriteCSV writes one row per strategy with its core metrics, sorted by
+// name. When comp is non-nil, a blank line and a comparison block with one
+// row per pairwise comparison follow.
+func WriteCSV(w io.Writer, results map[string]*simulation.AggregateResult, comp *analysis.MultiStrategyComparison) error {
+	cw := csv.NewWriter(w)
+
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cw.Write([]string{
+		"strategy", "avg_switches", "median_switches", "p90_switches",
+		"p99_switches", "unique_shards", "est_cache_hit_pct", "avg_cost",
+	})
+	for _, name := range names {
+		res := results[name]
+		m := simulation.ComputeMetrics(res)
+		cw.Write([]string{
+			name,
+			formatFloat(m.AvgSwitchesPerGame),
+			formatFloat(m.MedianSwitchesPerGame),
+			formatFloat(m.P90SwitchesPerGame),
+			formatFloat(m.P99SwitchesPerGame),
+			strconv.Itoa(m.UniqueShards),
+			formatFloat(res.CacheHitRate(csvCacheCapacity)),
+			formatFloat(m.AvgCostPerGame),
+		})
+	}
+
+	if comp != nil && len(comp.Comparisons) > 0 {
+		cw.Write(nil)
+		cw.Write([]string{
+			"strategy1", "strategy2", "u", "z", "p_value", "adjusted_p_value",
+			"cohens_d", "cliffs_delta", "ci_lower", "ci_upper", "winner", "significant",
+		})
+		for _, c := range comp.Comparisons {
+			cw.Write([]string{
+				c.Strategy1,
+				c.Strategy2,
+				formatFloat(c.MannWhitney.U),
+				formatFloat(c.MannWhitney.Z),
+				formatFloat(c.MannWhitney.PValue),
+				formatFloat(c.AdjustedPValue),
+				formatFloat(c.EffectSize.CohensD),
+				formatFloat(c.CliffsDelta.Delta),
+				formatFloat(c.BootstrapCI.LowerBound),
+				formatFloat(c.BootstrapCI.UpperBound),
+				c.Winner,
+				strconv.FormatBool(c.Significant),
+			})
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
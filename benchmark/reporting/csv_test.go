@@ -0,0 +1,40 @@
+package reporting
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/discochess/stockpile/benchmark/analysis"
+	"github.com/discochess/stockpile/benchmark/simulation"
+)
+
+func TestWriteCSV(t *testing.T) {
+	results := map[string]*simulation.AggregateResult{
+		"material": {StrategyName: "material", TotalLookups: 6, UniqueShards: 2, AvgSwitchesPerGame: 1, SwitchesPerGame: []int{1, 1, 1}},
+		"a,b":      {StrategyName: "a,b", TotalLookups: 6, UniqueShards: 6, AvgSwitchesPerGame: 2, SwitchesPerGame: []int{2, 2, 2}},
+	}
+	comp := analysis.CompareAllCorrected(results, "material", 100, 0.95, analysis.CorrectionNone)
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, results, comp); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("got %d lines, want 6:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "strategy,avg_switches,") {
+		t.Errorf("header = %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], `"a,b",2,2,2,2,6,`) {
+		t.Errorf("row = %q, want quoted name and metrics", lines[1])
+	}
+	if lines[3] != "" {
+		t.Errorf("separator = %q, want blank line", lines[3])
+	}
+	if !strings.HasPrefix(lines[5], `material,"a,b",`) {
+		t.Errorf("comparison row = %q", lines[5])
+	}
+}
@@ -0,0 +1,193 @@
+package reporting
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/discochess/stockpile/benchmark/analysis"
+	"github.com/discochess/stockpile/benchmark/simulation"
+)
+
+// JSONSchemaVersion is bumped whenever the JSON report layout changes in
+// a way that breaks consumers.
+const JSONSchemaVersion = 1
+
+// JSONReport is the machine-readable benchmark report. Field names are part
+// of the schema; strategies are sorted by name so reports diff cleanly.
+type JSONReport struct {
+	SchemaVersion int                 `json:"schema_version"`
+	Games         int                 `json:"games"`
+	Positions     int                 `json:"positions"`
+	Shards        int                 `json:"shards"`
+	Correction    analysis.Correction `json:"correction,omitempty"`
+	Strategies    []JSONStrategy      `json:"strategies"`
+	Omnibus       *JSONOmnibus        `json:"omnibus,omitempty"`
+	Comparisons   []JSONComparison    `json:"comparisons"`
+}
+
+// JSONStrategy holds the simulation metrics for one strategy.
+type JSONStrategy struct {
+	Name                  string  `json:"name"`
+	TotalLookups          int     `json:"total_lookups"`
+	TotalSwitches         int     `json:"total_switches"`
+	UniqueShards          int     `json:"unique_shards"`
+	AvgSwitchesPerGame    float64 `json:"avg_switches_per_game"`
+	MedianSwitchesPerGame float64 `json:"median_switches_per_game"`
+	P90SwitchesPerGame    float64 `json:"p90_switches_per_game"`
+	P99SwitchesPerGame    float64 `json:"p99_switches_per_game"`
+	MinSwitchesPerGame    int     `json:"min_switches_per_game"`
+	MaxSwitchesPerGame    int     `json:"max_switches_per_game"`
+	ShardConcentration    float64 `json:"shard_concentration"`
+	TopShardPct           float64 `json:"top_shard_pct"`
+	WorkingSetSize        float64 `json:"working_set_size"`
+	TotalCost             float64 `json:"total_cost"`
+	AvgCostPerGame        float64 `json:"avg_cost_per_game"`
+	MedianCostPerGame     float64 `json:"median_cost_per_game"`
+	P90CostPerGame        float64 `json:"p90_cost_per_game"`
+}
+
+// JSONOmnibus holds the Kruskal-Wallis result for multi-strategy runs.
+type JSONOmnibus struct {
+	H           float64 `json:"h"`
+	DF          int     `json:"df"`
+	PValue      float64 `json:"p_value"`
+	Significant bool    `json:"significant"`
+}
+
+// JSONComparison holds a pairwise statistical comparison.
+type JSONComparison struct {
+	Strategy1       string          `json:"strategy1"`
+	Strategy2       string          `json:"strategy2"`
+	Stats1          JSONDescriptive `json:"stats1"`
+	Stats2          JSONDescriptive `json:"stats2"`
+	U               float64         `json:"u"`
+	Z               float64         `json:"z"`
+	PValue          float64         `json:"p_value"`
+	AdjustedPValue  float64         `json:"adjusted_p_value"`
+	CohensD         float64         `json:"cohens_d"`
+	CliffsDelta     float64         `json:"cliffs_delta"`
+	MeanDiff        float64         `json:"mean_diff"`
+	CILower         float64         `json:"ci_lower"`
+	CIUpper         float64         `json:"ci_upper"`
+	CIConfidence    float64         `json:"ci_confidence"`
+	Significant     bool            `json:"significant"`
+	Winner          string          `json:"winner"`
+	WinnerConfident bool            `json:"winner_confident"`
+}
+
+// JSONDescriptive holds descriptive statistics of switches per game.
+type JSONDescriptive struct {
+	N      int     `json:"n"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	StdDev float64 `json:"std_dev"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+// NewJSONReport builds a JSONReport from simulation results and an optional
+// statistical comparison.
+func NewJSONReport(
+	gamesCount, positionsCount, totalShards int,
+	results map[string]*simulation.AggregateResult,
+	comp *analysis.MultiStrategyComparison,
+) *JSONReport {
+	report := &JSONReport{
+		SchemaVersion: JSONSchemaVersion,
+		Games:         gamesCount,
+		Positions:     positionsCount,
+		Shards:        totalShards,
+		Strategies:    make([]JSONStrategy, 0, len(results)),
+		Comparisons:   []JSONComparison{},
+	}
+
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		m := simulation.ComputeMetrics(results[name])
+		report.Strategies = append(report.Strategies, JSONStrategy{
+			Name:                  name,
+			TotalLookups:          m.TotalLookups,
+			TotalSwitches:         m.TotalSwitches,
+			UniqueShards:          m.UniqueShards,
+			AvgSwitchesPerGame:    m.AvgSwitchesPerGame,
+			MedianSwitchesPerGame: m.MedianSwitchesPerGame,
+			P90SwitchesPerGame:    m.P90SwitchesPerGame,
+			P99SwitchesPerGame:    m.P99SwitchesPerGame,
+			MinSwitchesPerGame:    m.MinSwitchesPerGame,
+			MaxSwitchesPerGame:    m.MaxSwitchesPerGame,
+			ShardConcentration:    m.ShardConcentration,
+			TopShardPct:           m.TopShardPct,
+			WorkingSetSize:        m.WorkingSetSize,
+			TotalCost:             m.TotalCost,
+			AvgCostPerGame:        m.AvgCostPerGame,
+			MedianCostPerGame:     m.MedianCostPerGame,
+			P90CostPerGame:        m.P90CostPerGame,
+		})
+	}
+
+	if comp == nil {
+		return report
+	}
+
+	report.Correction = comp.Correction
+	if kw := comp.Omnibus; kw != nil {
+		report.Omnibus = &JSONOmnibus{
+			H:           kw.H,
+			DF:          kw.DF,
+			PValue:      kw.PValue,
+			Significant: kw.Significant,
+		}
+	}
+	for _, c := range comp.Comparisons {
+		report.Comparisons = append(report.Comparisons, JSONComparison{
+			Strategy1:       c.Strategy1,
+			Strategy2:       c.Strategy2,
+			Stats1:          jsonDescriptive(c.Stats1),
+			Stats2:          jsonDescriptive(c.Stats2),
+			U:               c.MannWhitney.U,
+			Z:               c.MannWhitney.Z,
+			PValue:          c.MannWhitney.PValue,
+			AdjustedPValue:  c.AdjustedPValue,
+			CohensD:         c.EffectSize.CohensD,
+			CliffsDelta:     c.CliffsDelta.Delta,
+			MeanDiff:        c.BootstrapCI.MeanDiff,
+			CILower:         c.BootstrapCI.LowerBound,
+			CIUpper:         c.BootstrapCI.UpperBound,
+			CIConfidence:    c.BootstrapCI.Confidence,
+			Significant:     c.Significant,
+			Winner:          c.Winner,
+			WinnerConfident: c.WinnerConfident,
+		})
+	}
+
+	return report
+}
+
+func jsonDescriptive(s *analysis.DescriptiveStats) JSONDescriptive {
+	return JSONDescriptive{
+		N:      s.N,
+		Mean:   s.Mean,
+		Median: s.Median,
+		StdDev: s.StdDev,
+		Min:    s.Min,
+		Max:    s.Max,
+	}
+}
+
+// WriteJSON writes the benchmark results to w as an indented JSON report.
+func WriteJSON(
+	w io.Writer,
+	gamesCount, positionsCount, totalShards int,
+	results map[string]*simulation.AggregateResult,
+	comp *analysis.MultiStrategyComparison,
+) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewJSONReport(gamesCount, positionsCount, totalShards, results, comp))
+}
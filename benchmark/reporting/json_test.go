@@ -0,0 +1,57 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/discochess/stockpile/benchmark/analysis"
+	"github.com/discochess/stockpile/benchmark/simulation"
+)
+
+func TestWriteJSON(t *testing.T) {
+	results := map[string]*simulation.AggregateResult{
+		"material": {StrategyName: "material", TotalLookups: 6, TotalSwitches: 3, UniqueShards: 2, SwitchesPerGame: []int{1, 1, 1}},
+		"fnv32":    {StrategyName: "fnv32", TotalLookups: 6, TotalSwitches: 6, UniqueShards: 6, SwitchesPerGame: []int{2, 2, 2}},
+	}
+	comp := analysis.CompareAllCorrected(results, "material", 100, 0.95, analysis.CorrectionNone)
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, 3, 6, 32768, results, comp); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+
+	if v, ok := got["games"].(float64); !ok || v != 3 {
+		t.Errorf("games = %v, want number 3", got["games"])
+	}
+
+	strategies := got["strategies"].([]any)
+	if len(strategies) != 2 {
+		t.Fatalf("got %d strategies, want 2", len(strategies))
+	}
+	if name := strategies[0].(map[string]any)["name"]; name != "fnv32" {
+		t.Errorf("strategies not sorted by name: first = %v", name)
+	}
+
+	for _, key := range []string{"total_cost", "avg_cost_per_game"} {
+		if _, ok := strategies[0].(map[string]any)[key].(float64); !ok {
+			t.Errorf("strategy %q = %v, want a number", key, strategies[0].(map[string]any)[key])
+		}
+	}
+
+	comparisons := got["comparisons"].([]any)
+	if len(comparisons) != 1 {
+		t.Fatalf("got %d comparisons, want 1", len(comparisons))
+	}
+	c := comparisons[0].(map[string]any)
+	for _, key := range []string{"u", "z", "p_value", "cohens_d", "ci_lower", "ci_upper"} {
+		if _, ok := c[key].(float64); !ok {
+			t.Errorf("comparison %q = %v, want a number", key, c[key])
+		}
+	}
+}
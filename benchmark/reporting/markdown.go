@@ -4,6 +4,8 @@ package reporting
 import (
 	"fmt"
 	"io"
+	"math"
+	"sort"
 	"strings"
 	"time"
 
@@ -27,30 +29,57 @@ func (r *MarkdownReport) WriteHeader(title string) {
 	fmt.Fprintf(r.w, "Generated: %s\n\n", time.Now().Format(time.RFC3339))
 }
 
-// WriteMethodology writes the methodology section.
-func (r *MarkdownReport) WriteMethodology(gamesCount, positionsCount int) {
+// WriteMethodology writes the methodology section, including the
+// multiple-comparison correction applied to pairwise p-values.
+func (r *MarkdownReport) WriteMethodology(gamesCount, positionsCount int, correction analysis.Correction) {
 	fmt.Fprintln(r.w, "## Methodology")
 	fmt.Fprintln(r.w)
 	fmt.Fprintf(r.w, "- **Games analyzed:** %d\n", gamesCount)
 	fmt.Fprintf(r.w, "- **Positions evaluated:** %d\n", positionsCount)
 	fmt.Fprintln(r.w, "- **Metric:** Shard switches per game (lower is better)")
-	fmt.Fprintln(r.w, "- **Statistical tests:** Mann-Whitney U (non-parametric), Cohen's d effect size")
+	fmt.Fprintln(r.w, "- **Statistical tests:** Kruskal-Wallis H (3+ strategies), Mann-Whitney U (non-parametric), Cohen's d and Cliff's delta effect sizes")
+	fmt.Fprintf(r.w, "- **Multiple-comparison correction:** %s\n", correctionName(correction))
 	fmt.Fprintln(r.w)
 }
 
+func correctionName(c analysis.Correction) string {
+	switch c {
+	case analysis.CorrectionBonferroni:
+		return "Bonferroni (family-wise error rate)"
+	case analysis.CorrectionBenjaminiHochberg:
+		return "Benjamini-Hochberg (false discovery rate)"
+	default:
+		return "none"
+	}
+}
+
 // WriteSummaryTable writes the summary comparison table.
 func (r *MarkdownReport) WriteSummaryTable(results map[string]*simulation.AggregateResult) {
 	fmt.Fprintln(r.w, "## Summary")
 	fmt.Fprintln(r.w)
-	fmt.Fprintln(r.w, "| Strategy | Avg Switches | Median | Unique Shards | Est. Cache Hit Rate |")
-	fmt.Fprintln(r.w, "|----------|--------------|--------|---------------|---------------------|")
+	fmt.Fprintln(r.w, "| Strategy | Avg Switches | Median | Avg Cost | Unique Shards | Est. Cache Hit Rate |")
+	fmt.Fprintln(r.w, "|----------|--------------|--------|----------|---------------|---------------------|")
 
 	for name, res := range results {
 		metrics := simulation.ComputeMetrics(res)
 		cacheHitRate := res.CacheHitRate(100) // Assume 100-shard cache.
-		fmt.Fprintf(r.w, "| %s | %.2f | %.0f | %d | %.1f%% |\n",
+		fmt.Fprintf(r.w, "| %s | %.2f | %.1f | %.0f | %d | %.1f%% |\n",
 			name, metrics.AvgSwitchesPerGame, metrics.MedianSwitchesPerGame,
-			metrics.UniqueShards, cacheHitRate)
+			metrics.AvgCostPerGame, metrics.UniqueShards, cacheHitRate)
+	}
+	fmt.Fprintln(r.w)
+}
+
+// WriteOmnibus writes the Kruskal-Wallis section for multi-strategy runs.
+func (r *MarkdownReport) WriteOmnibus(kw *analysis.KruskalWallisResult) {
+	fmt.Fprintln(r.w, "## Omnibus Test")
+	fmt.Fprintln(r.w)
+	fmt.Fprintf(r.w, "- **Kruskal-Wallis H:** %.2f (df=%d, p=%.4f)\n", kw.H, kw.DF, kw.PValue)
+	fmt.Fprintln(r.w)
+	if kw.Significant {
+		fmt.Fprintln(r.w, "At least one strategy differs significantly (p < 0.05); pairwise comparisons follow.")
+	} else {
+		fmt.Fprintln(r.w, "No statistically significant difference detected among strategies (p >= 0.05); pairwise comparisons skipped.")
 	}
 	fmt.Fprintln(r.w)
 }
@@ -76,8 +105,13 @@ func (r *MarkdownReport) WriteComparison(comp *analysis.StrategyComparison) {
 	fmt.Fprintln(r.w)
 	fmt.Fprintf(r.w, "- **Mann-Whitney U:** %.2f (z=%.2f, p=%.4f)\n",
 		comp.MannWhitney.U, comp.MannWhitney.Z, comp.MannWhitney.PValue)
+	if comp.AdjustedPValue != comp.MannWhitney.PValue {
+		fmt.Fprintf(r.w, "- **Adjusted p-value:** %.4f\n", comp.AdjustedPValue)
+	}
 	fmt.Fprintf(r.w, "- **Effect size (Cohen's d):** %.2f (%s)\n",
 		comp.EffectSize.CohensD, comp.EffectSize.Interpretation)
+	fmt.Fprintf(r.w, "- **Effect size (Cliff's delta):** %.2f (%s)\n",
+		comp.CliffsDelta.Delta, comp.CliffsDelta.Interpretation)
 	fmt.Fprintf(r.w, "- **95%% CI for mean difference:** [%.2f, %.2f]\n",
 		comp.BootstrapCI.LowerBound, comp.BootstrapCI.UpperBound)
 	fmt.Fprintln(r.w)
@@ -131,6 +165,89 @@ func (r *MarkdownReport) WriteDistributionChart(name string, data []int) {
 	fmt.Fprintln(r.w)
 }
 
+// WriteDistributionComparison writes an ASCII chart overlaying the
+// distributions of several strategies. All inputs share one bucket range
+// and bar scale, so bars for the same bucket are directly comparable.
+func (r *MarkdownReport) WriteDistributionComparison(strategies map[string][]int) {
+	const buckets = 10
+
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(r.w, "### Distribution Comparison")
+	fmt.Fprintln(r.w)
+	fmt.Fprintln(r.w, "```")
+
+	min, max, ok := dataRange(strategies)
+	if !ok {
+		fmt.Fprintln(r.w, "(no data)")
+		fmt.Fprintln(r.w, "```")
+		fmt.Fprintln(r.w)
+		return
+	}
+
+	hists := make(map[string][]int, len(names))
+	maxCount := 0
+	nameWidth := 0
+	for _, name := range names {
+		hist := makeHistogramRange(strategies[name], buckets, min, max)
+		hists[name] = hist
+		for _, count := range hist {
+			if count > maxCount {
+				maxCount = count
+			}
+		}
+		if len(name) > nameWidth {
+			nameWidth = len(name)
+		}
+	}
+
+	width := 40
+	bucketSize := bucketWidth(min, max, buckets)
+	for i := 0; i < buckets; i++ {
+		lo := min + int(math.Ceil(float64(i)*bucketSize))
+		hi := min + int(math.Ceil(float64(i+1)*bucketSize)) - 1
+		if lo > hi {
+			continue // Narrow ranges leave some buckets without any integer.
+		}
+		for j, name := range names {
+			label := ""
+			if j == 0 {
+				label = fmt.Sprintf("%3d-%3d", lo, hi)
+			}
+			count := hists[name][i]
+			barLen := 0
+			if maxCount > 0 {
+				barLen = count * width / maxCount
+			}
+			bar := strings.Repeat("█", barLen)
+			fmt.Fprintf(r.w, "%7s │ %-*s %s %d\n", label, nameWidth, name, bar, count)
+		}
+	}
+
+	fmt.Fprintln(r.w, "```")
+	fmt.Fprintln(r.w)
+}
+
+// dataRange returns the smallest and largest value across all inputs.
+func dataRange(series map[string][]int) (min, max int, ok bool) {
+	for _, data := range series {
+		for _, v := range data {
+			if !ok || v < min {
+				min = v
+			}
+			if !ok || v > max {
+				max = v
+			}
+			ok = true
+		}
+	}
+	return min, max, ok
+}
+
 func makeHistogram(data []int, buckets int) []int {
 	if len(data) == 0 {
 		return make([]int, buckets)
@@ -146,24 +263,36 @@ func makeHistogram(data []int, buckets int) []int {
 		}
 	}
 
-	if max == min {
-		max = min + 1
-	}
+	return makeHistogramRange(data, buckets, min, max)
+}
 
+// makeHistogramRange buckets data over the fixed range [min, max], so
+// histograms of different inputs built with the same range align.
+func makeHistogramRange(data []int, buckets, min, max int) []int {
 	hist := make([]int, buckets)
-	bucketSize := float64(max-min+1) / float64(buckets)
+	bucketSize := bucketWidth(min, max, buckets)
 
 	for _, v := range data {
 		bucket := int(float64(v-min) / bucketSize)
 		if bucket >= buckets {
 			bucket = buckets - 1
 		}
+		if bucket < 0 {
+			bucket = 0
+		}
 		hist[bucket]++
 	}
 
 	return hist
 }
 
+func bucketWidth(min, max, buckets int) float64 {
+	if max == min {
+		max = min + 1
+	}
+	return float64(max-min+1) / float64(buckets)
+}
+
 // WriteFooter writes the report footer.
 func (r *MarkdownReport) WriteFooter() {
 	fmt.Fprintln(r.w, "---")
@@ -0,0 +1,36 @@
+package reporting
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteDistributionComparison(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewMarkdownReport(&buf)
+	r.WriteDistributionComparison(map[string][]int{
+		"material": {0, 1, 1, 2},
+		"fnv32":    {10, 12, 19},
+	})
+	out := buf.String()
+
+	// Buckets span the shared range 0-19, so fnv32's values land in the
+	// upper buckets rather than being rescaled to their own range.
+	for _, want := range []string{
+		"  0-  1 │ fnv32     0",
+		"        │ material " + strings.Repeat("█", 40) + " 3",
+		" 18- 19 │ fnv32    █████████████ 1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestMakeHistogramRange(t *testing.T) {
+	hist := makeHistogramRange([]int{5, 5, 5}, 4, 5, 5)
+	if hist[0] != 3 {
+		t.Errorf("hist = %v, want all values in the first bucket", hist)
+	}
+}
@@ -0,0 +1,144 @@
+package simulation
+
+import "sort"
+
+// MaxWorkingSetWindow is the longest window WorkingSetSize measures exactly;
+// longer windows are treated as this long. It bounds the memory the
+// aggregate spends on working-set statistics.
+const MaxWorkingSetWindow = 1 << 16
+
+// accessStats summarizes a shard access sequence as it is recorded, so that
+// CacheHitRate and WorkingSetSize can be computed exactly for any cache
+// capacity or window without keeping the sequence. Its memory grows with
+// the number of distinct shards accessed, not with the number of accesses.
+type accessStats struct {
+	n      int               // Accesses recorded.
+	shards map[int]*shardUse // Per-shard access history.
+
+	// distances[d] counts re-accesses with d distinct other shards accessed
+	// since the previous access to the same shard: an LRU cache of more than
+	// d shards hits them. First accesses miss at any capacity.
+	distances []int
+
+	// gaps[g] counts accesses repeated g accesses later, with longer gaps
+	// counted at MaxWorkingSetWindow.
+	gaps []int
+
+	// Recency ranks: tree is a Fenwick tree over slots in access order,
+	// holding 1 at the slot of each shard's latest access. The next access
+	// takes slot next.
+	tree []int
+	next int
+}
+
+// shardUse is the access history of one shard.
+type shardUse struct {
+	first int // Index of the first access.
+	last  int // Index of the latest access.
+	slot  int // Recency slot of the latest access.
+}
+
+func newAccessStats() *accessStats {
+	return &accessStats{shards: make(map[int]*shardUse)}
+}
+
+// record appends an access to shardID to the summarized sequence.
+func (a *accessStats) record(shardID int) {
+	if a.next == len(a.tree) {
+		a.compact()
+	}
+
+	u, ok := a.shards[shardID]
+	if ok {
+		d := a.marked(a.next) - a.marked(u.slot+1)
+		if d >= len(a.distances) {
+			a.distances = append(a.distances, make([]int, d+1-len(a.distances))...)
+		}
+		a.distances[d]++
+
+		g := min(a.n-u.last, MaxWorkingSetWindow)
+		if g >= len(a.gaps) {
+			a.gaps = append(a.gaps, make([]int, g+1-len(a.gaps))...)
+		}
+		a.gaps[g]++
+
+		a.mark(u.slot, -1)
+	} else {
+		u = &shardUse{first: a.n}
+		a.shards[shardID] = u
+	}
+
+	u.last = a.n
+	u.slot = a.next
+	a.mark(a.next, 1)
+	a.next++
+	a.n++
+}
+
+// hits returns the number of accesses an LRU cache of the given capacity
+// would serve.
+func (a *accessStats) hits(capacity int) int {
+	var hits int
+	for d := 0; d < min(capacity, len(a.distances)); d++ {
+		hits += a.distances[d]
+	}
+	return hits
+}
+
+// workingSetSize returns the average number of distinct shards in each
+// full window of window consecutive accesses, for 1 <= window <= a.n.
+//
+// Counting the partial windows that overhang either end of the sequence
+// too, an access is the latest access to its shard in min(window, gap)
+// windows, where gap is how many accesses later its shard is accessed
+// again (window if never), so summing that over all accesses counts the
+// distinct shards of every window. The partial windows are then taken back
+// out: a prefix window of k accesses holds the shards first accessed within
+// it, and a suffix window those last accessed within it.
+func (a *accessStats) workingSetSize(window int) float64 {
+	window = min(window, MaxWorkingSetWindow)
+	var total int
+	for g, count := range a.gaps {
+		total += min(window, g) * count
+	}
+	for _, u := range a.shards {
+		total += window // The latest access is never repeated.
+		total -= max(window-1-u.first, 0)
+		total -= max(window-1-(a.n-1-u.last), 0)
+	}
+	return float64(total) / float64(a.n-window+1)
+}
+
+// marked returns the number of marked slots before slot.
+func (a *accessStats) marked(slot int) int {
+	var sum int
+	for i := slot; i > 0; i -= i & -i {
+		sum += a.tree[i-1]
+	}
+	return sum
+}
+
+// mark adds delta at slot.
+func (a *accessStats) mark(slot, delta int) {
+	for i := slot + 1; i <= len(a.tree); i += i & -i {
+		a.tree[i-1] += delta
+	}
+}
+
+// compact renumbers the recency slots of the shards' latest accesses from
+// zero, keeping their order, into a tree with room for as many accesses
+// again.
+func (a *accessStats) compact() {
+	uses := make([]*shardUse, 0, len(a.shards))
+	for _, u := range a.shards {
+		uses = append(uses, u)
+	}
+	sort.Slice(uses, func(i, j int) bool { return uses[i].slot < uses[j].slot })
+
+	a.tree = make([]int, max(2*len(uses), 64))
+	for i, u := range uses {
+		u.slot = i
+		a.mark(i, 1)
+	}
+	a.next = len(uses)
+}
@@ -0,0 +1,45 @@
+package simulation
+
+// CostFunc returns the modeled cost of fetching a shard, for example its
+// decompressed size in bytes. The simulator charges it once per shard
+// switch, since that is when a reader has to fetch and decompress a shard.
+type CostFunc func(shardID int) float64
+
+// FixedCost charges the same penalty for every fetch. With a penalty of 1
+// the modeled cost equals the number of shard switches.
+func FixedCost(penalty float64) CostFunc {
+	return func(int) float64 { return penalty }
+}
+
+// ShardSizeCost charges the size of each fetched shard plus a fixed
+// penalty per fetch. Shards missing from sizes are empty and cost only the
+// penalty. sizes usually comes from a database manifest; see
+// builder.ShardInfo.
+func ShardSizeCost(sizes map[int]int64, penalty float64) CostFunc {
+	return func(shardID int) float64 {
+		return float64(sizes[shardID]) + penalty
+	}
+}
+
+// SetCost sets the cost function used for strategies without one of their
+// own. It defaults to FixedCost(1).
+func (s *Simulator) SetCost(cost CostFunc) {
+	s.cost = cost
+}
+
+// SetStrategyCost sets the cost function for the named strategy, for when
+// real shard sizes are only known for the layout that strategy produces.
+func (s *Simulator) SetStrategyCost(name string, cost CostFunc) {
+	if s.strategyCost == nil {
+		s.strategyCost = make(map[string]CostFunc)
+	}
+	s.strategyCost[name] = cost
+}
+
+// costFor returns the cost function for the named strategy.
+func (s *Simulator) costFor(name string) CostFunc {
+	if cost, ok := s.strategyCost[name]; ok {
+		return cost
+	}
+	return s.cost
+}
@@ -2,8 +2,14 @@ package simulation
 
 import (
 	"sort"
+
+	"github.com/discochess/stockpile/internal/stats"
 )
 
+// WorkingSetWindow is the window, in lookups, of Metrics.WorkingSetSize.
+// It is about the length of a game.
+const WorkingSetWindow = 100
+
 // Metrics contains computed metrics from simulation results.
 type Metrics struct {
 	// Core metrics.
@@ -12,6 +18,12 @@ type Metrics struct {
 	UniqueShards       int
 	AvgSwitchesPerGame float64
 
+	// Cost metrics, in the units of the simulator's CostFunc.
+	TotalCost         float64
+	AvgCostPerGame    float64
+	MedianCostPerGame float64
+	P90CostPerGame    float64
+
 	// Distribution metrics.
 	MedianSwitchesPerGame float64
 	P90SwitchesPerGame    float64
@@ -22,6 +34,7 @@ type Metrics struct {
 	// Locality metrics.
 	ShardConcentration float64 // Gini coefficient of shard usage.
 	TopShardPct        float64 // Percentage of lookups in top 10% of shards.
+	WorkingSetSize     float64 // Distinct shards per WorkingSetWindow lookups.
 }
 
 // ComputeMetrics computes detailed metrics from aggregate results.
@@ -31,6 +44,8 @@ func ComputeMetrics(result *AggregateResult) *Metrics {
 		TotalSwitches:      result.TotalSwitches,
 		UniqueShards:       result.UniqueShards,
 		AvgSwitchesPerGame: result.AvgSwitchesPerGame,
+		TotalCost:          result.TotalCost,
+		AvgCostPerGame:     result.AvgCostPerGame,
 	}
 
 	if len(result.SwitchesPerGame) > 0 {
@@ -46,51 +61,60 @@ func ComputeMetrics(result *AggregateResult) *Metrics {
 		m.P99SwitchesPerGame = percentile(sorted, 99)
 	}
 
+	if len(result.CostPerGame) > 0 {
+		sorted := make([]float64, len(result.CostPerGame))
+		copy(sorted, result.CostPerGame)
+		sort.Float64s(sorted)
+
+		m.MedianCostPerGame = percentileFloat(sorted, 50)
+		m.P90CostPerGame = percentileFloat(sorted, 90)
+	}
+
 	// Compute shard concentration (Gini coefficient).
 	if len(result.ShardHits) > 0 {
 		m.ShardConcentration = computeGini(result.ShardHits)
 		m.TopShardPct = computeTopShardPct(result.ShardHits, result.TotalLookups, 0.1)
 	}
+	m.WorkingSetSize = result.WorkingSetSize(WorkingSetWindow)
 
 	return m
 }
 
+// percentile returns the p-th percentile of sorted values, interpolating
+// linearly between the closest ranks (NumPy's default method).
 func percentile(sorted []int, p float64) float64 {
 	if len(sorted) == 0 {
 		return 0
 	}
-	idx := int(float64(len(sorted)-1) * p / 100)
-	if idx >= len(sorted) {
-		idx = len(sorted) - 1
+	pos := float64(len(sorted)-1) * p / 100
+	lo := int(pos)
+	if lo >= len(sorted)-1 {
+		return float64(sorted[len(sorted)-1])
 	}
-	return float64(sorted[idx])
+	frac := pos - float64(lo)
+	return float64(sorted[lo]) + frac*float64(sorted[lo+1]-sorted[lo])
 }
 
-func computeGini(hits map[int]int) float64 {
-	if len(hits) == 0 {
+// percentileFloat is percentile for float values.
+func percentileFloat(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
 		return 0
 	}
-
-	// Extract values and sort.
-	values := make([]int, 0, len(hits))
-	for _, v := range hits {
-		values = append(values, v)
-	}
-	sort.Ints(values)
-
-	n := float64(len(values))
-	var sum, cumulativeSum float64
-	for i, v := range values {
-		sum += float64(v)
-		cumulativeSum += float64(i+1) * float64(v)
+	pos := float64(len(sorted)-1) * p / 100
+	lo := int(pos)
+	if lo >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
 	}
+	frac := pos - float64(lo)
+	return sorted[lo] + frac*(sorted[lo+1]-sorted[lo])
+}
 
-	if sum == 0 {
-		return 0
+func computeGini(hits map[int]int) float64 {
+	values := make([]int64, 0, len(hits))
+	for _, v := range hits {
+		values = append(values, int64(v))
 	}
-
-	// Gini coefficient formula.
-	return (2*cumulativeSum)/(n*sum) - (n+1)/n
+	return stats.Gini(values)
 }
 
 func computeTopShardPct(hits map[int]int, total int, topFraction float64) float64 {
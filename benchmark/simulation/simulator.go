@@ -2,13 +2,19 @@
 package simulation
 
 import (
+	"runtime"
+	"sync"
+
 	"github.com/discochess/stockpile/internal/shard"
 )
 
 // Simulator simulates shard access patterns for different strategies.
 type Simulator struct {
-	strategies  []shard.Strategy
-	totalShards int
+	strategies   []shard.Strategy
+	totalShards  int
+	cost         CostFunc
+	strategyCost map[string]CostFunc
+	workers      int
 }
 
 // NewSimulator creates a new Simulator with the given strategies.
@@ -16,9 +22,18 @@ func NewSimulator(totalShards int, strategies ...shard.Strategy) *Simulator {
 	return &Simulator{
 		strategies:  strategies,
 		totalShards: totalShards,
+		cost:        FixedCost(1),
+		workers:     runtime.GOMAXPROCS(0),
 	}
 }
 
+// SetWorkers sets how many games SimulateGames and Aggregator.AddGames
+// simulate in parallel. It defaults to GOMAXPROCS; n < 1 means 1. Results
+// are the same for any number of workers.
+func (s *Simulator) SetWorkers(n int) {
+	s.workers = max(n, 1)
+}
+
 // SimulateGame simulates a single game lookup sequence and returns
 // shard access patterns for each strategy.
 func (s *Simulator) SimulateGame(fens []string) map[string]*GameResult {
@@ -30,6 +45,7 @@ func (s *Simulator) SimulateGame(fens []string) map[string]*GameResult {
 			ShardAccess:  make([]int, 0, len(fens)),
 		}
 
+		cost := s.costFor(strategy.Name())
 		lastShard := -1
 		for _, fen := range fens {
 			shardID := strategy.ShardID(fen, s.totalShards)
@@ -37,6 +53,7 @@ func (s *Simulator) SimulateGame(fens []string) map[string]*GameResult {
 
 			if shardID != lastShard {
 				result.ShardSwitches++
+				result.Cost += cost(shardID)
 				lastShard = shardID
 			}
 		}
@@ -47,50 +64,119 @@ func (s *Simulator) SimulateGame(fens []string) map[string]*GameResult {
 	return results
 }
 
-// SimulateGames simulates multiple games and aggregates results.
+// SimulateGames simulates multiple games in parallel and aggregates
+// results; see SetWorkers.
 func (s *Simulator) SimulateGames(games [][]string) map[string]*AggregateResult {
-	results := make(map[string]*AggregateResult, len(s.strategies))
+	agg := s.NewAggregator()
+	agg.AddGames(games)
+	return agg.Results()
+}
 
-	// Initialize results for each strategy.
+// NewAggregator returns an Aggregator that accumulates results for the
+// simulator's strategies one game at a time.
+func (s *Simulator) NewAggregator() *Aggregator {
+	results := make(map[string]*AggregateResult, len(s.strategies))
 	for _, strategy := range s.strategies {
 		results[strategy.Name()] = &AggregateResult{
-			StrategyName:    strategy.Name(),
-			ShardHits:       make(map[int]int),
-			SwitchesPerGame: make([]int, 0, len(games)),
+			StrategyName: strategy.Name(),
+			ShardHits:    make(map[int]int),
+			accesses:     newAccessStats(),
 		}
 	}
+	return &Aggregator{sim: s, results: results}
+}
+
+// Aggregator maintains a running aggregate of simulation results, so games
+// can be fed in as they are parsed rather than held in memory together.
+type Aggregator struct {
+	sim     *Simulator
+	results map[string]*AggregateResult
+	games   int
+}
 
-	// Simulate each game.
-	for _, game := range games {
-		gameResults := s.SimulateGame(game)
-		for name, gr := range gameResults {
-			agg := results[name]
-			agg.TotalLookups += len(game)
-			agg.TotalSwitches += gr.ShardSwitches
-			agg.SwitchesPerGame = append(agg.SwitchesPerGame, gr.ShardSwitches)
-
-			for _, shardID := range gr.ShardAccess {
-				agg.ShardHits[shardID]++
+// Add simulates a single game and folds it into the running aggregate.
+func (a *Aggregator) Add(fens []string) {
+	a.fold(a.sim.SimulateGame(fens))
+}
+
+// AddGames simulates a batch of games in parallel and folds them into the
+// running aggregate in order, exactly as calling Add for each would.
+func (a *Aggregator) AddGames(games [][]string) {
+	workers := min(a.sim.workers, len(games))
+	if workers <= 1 {
+		for _, fens := range games {
+			a.Add(fens)
+		}
+		return
+	}
+
+	// Workers only simulate; folding stays in game order so the access
+	// statistics and per-game distributions do not depend on scheduling.
+	results := make([]map[string]*GameResult, len(games))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = a.sim.SimulateGame(games[i])
 			}
+		}()
+	}
+	for i := range games {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for _, gr := range results {
+		a.fold(gr)
+	}
+}
+
+// fold adds the per-strategy results of one game to the aggregate.
+func (a *Aggregator) fold(game map[string]*GameResult) {
+	a.games++
+	for name, gr := range game {
+		agg := a.results[name]
+		agg.TotalLookups += len(gr.ShardAccess)
+		agg.TotalSwitches += gr.ShardSwitches
+		agg.SwitchesPerGame = append(agg.SwitchesPerGame, gr.ShardSwitches)
+		agg.TotalCost += gr.Cost
+		agg.CostPerGame = append(agg.CostPerGame, gr.Cost)
+
+		for _, shardID := range gr.ShardAccess {
+			agg.ShardHits[shardID]++
+			agg.accesses.record(shardID)
 		}
 	}
+}
+
+// Games returns the number of games added so far.
+func (a *Aggregator) Games() int {
+	return a.games
+}
 
-	// Calculate derived metrics.
-	for _, agg := range results {
+// Results returns the aggregated results keyed by strategy name, with
+// derived metrics computed over all games added so far.
+func (a *Aggregator) Results() map[string]*AggregateResult {
+	for _, agg := range a.results {
 		agg.UniqueShards = len(agg.ShardHits)
-		if len(games) > 0 {
-			agg.AvgSwitchesPerGame = float64(agg.TotalSwitches) / float64(len(games))
+		if a.games > 0 {
+			agg.AvgSwitchesPerGame = float64(agg.TotalSwitches) / float64(a.games)
+			agg.AvgCostPerGame = agg.TotalCost / float64(a.games)
 		}
 	}
-
-	return results
+	return a.results
 }
 
 // GameResult contains the shard access pattern for a single game.
 type GameResult struct {
 	StrategyName  string
-	ShardAccess   []int // Shard IDs accessed in order.
-	ShardSwitches int   // Number of times shard changed.
+	ShardAccess   []int   // Shard IDs accessed in order.
+	ShardSwitches int     // Number of times shard changed.
+	Cost          float64 // Modeled cost of the shard fetches; see CostFunc.
 }
 
 // AggregateResult contains aggregated results across multiple games.
@@ -100,35 +186,37 @@ type AggregateResult struct {
 	TotalSwitches      int
 	UniqueShards       int
 	AvgSwitchesPerGame float64
+	TotalCost          float64 // Modeled cost of all shard fetches; see CostFunc.
+	AvgCostPerGame     float64
 	ShardHits          map[int]int // Shard ID -> hit count.
 	SwitchesPerGame    []int       // Switches per game for statistical analysis.
+	CostPerGame        []float64   // Modeled cost per game.
+
+	// Statistics of the shard accesses across all games, in order; nil
+	// unless the result came from an Aggregator.
+	accesses *accessStats
 }
 
-// CacheHitRate estimates the cache hit rate assuming an LRU cache
-// with the given capacity (number of shards).
+// CacheHitRate returns the hit rate (0-100) of an LRU cache with the given
+// capacity (number of shards). For results from an Aggregator it is exact,
+// as if the games' accesses were replayed through the cache; otherwise the
+// rate is estimated from aggregate counts.
 func (a *AggregateResult) CacheHitRate(cacheCapacity int) float64 {
 	if a.TotalLookups == 0 {
 		return 0
 	}
 
-	// Simple LRU simulation.
-	cache := make(map[int]struct{})
-	var order []int
-	var hits int
-
-	for _, switches := range a.SwitchesPerGame {
-		// This is a simplified model; in reality we'd need the actual access sequence.
-		_ = switches
+	if a.accesses != nil && a.accesses.n > 0 {
+		return float64(a.accesses.hits(cacheCapacity)) / float64(a.accesses.n) * 100
 	}
 
-	// For now, estimate based on unique shards vs cache capacity.
+	var hits int
 	if a.UniqueShards <= cacheCapacity {
 		// All shards fit in cache after warmup.
 		// Estimate: first access to each shard is a miss.
 		hits = a.TotalLookups - a.UniqueShards
 	} else {
 		// Shards exceed cache; use locality-based estimate.
-		// Higher switches = worse cache performance.
 		avgAccessesPerShard := float64(a.TotalLookups) / float64(a.UniqueShards)
 		hitRateEstimate := (avgAccessesPerShard - 1) / avgAccessesPerShard
 		if hitRateEstimate < 0 {
@@ -137,9 +225,29 @@ func (a *AggregateResult) CacheHitRate(cacheCapacity int) float64 {
 		hits = int(float64(a.TotalLookups) * hitRateEstimate)
 	}
 
-	// Clear unused variable warning.
-	_ = cache
-	_ = order
-
 	return float64(hits) / float64(a.TotalLookups) * 100
 }
+
+// CacheHitRateCurve returns the LRU hit rate (0-100) at each of the given
+// capacities, in the same order. It is useful for choosing a cache size.
+func (a *AggregateResult) CacheHitRateCurve(capacities []int) []float64 {
+	rates := make([]float64, len(capacities))
+	for i, capacity := range capacities {
+		rates[i] = a.CacheHitRate(capacity)
+	}
+	return rates
+}
+
+// WorkingSetSize returns the average number of distinct shards touched in
+// each window of the given number of consecutive lookups, over every such
+// window across all games. An LRU cache at least this large holds
+// everything a typical window needs. If there are fewer lookups than the
+// window, they are treated as a single window; windows longer than
+// MaxWorkingSetWindow are treated as that long. It is 0 for results not
+// from an Aggregator.
+func (a *AggregateResult) WorkingSetSize(window int) float64 {
+	if window <= 0 || a.accesses == nil || a.accesses.n == 0 {
+		return 0
+	}
+	return a.accesses.workingSetSize(min(window, a.accesses.n))
+}
@@ -1,6 +1,11 @@
 package simulation
 
 import (
+	"container/list"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"reflect"
 	"testing"
 
 	"github.com/discochess/stockpile/internal/shard/fnvshard"
@@ -74,6 +79,47 @@ func TestSimulator_SimulateGames(t *testing.T) {
 	}
 }
 
+func TestAggregator_MatchesSimulateGames(t *testing.T) {
+	sim := NewSimulator(32768, materialshard.New(), fnvshard.New())
+
+	games := [][]string{
+		{
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -",
+			"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3",
+		},
+		{
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -",
+			"rnbqkbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBQKBNR b KQkq d3",
+			"rnbqkbnr/ppp1pppp/8/3p4/3P4/8/PPP1PPPP/RNBQKBNR w KQkq d6",
+		},
+	}
+
+	agg := sim.NewAggregator()
+	for _, game := range games {
+		agg.Add(game)
+	}
+	if agg.Games() != 2 {
+		t.Errorf("Games() = %d, want 2", agg.Games())
+	}
+
+	got := agg.Results()
+	want := sim.SimulateGames(games)
+	for name, w := range want {
+		g, ok := got[name]
+		if !ok {
+			t.Errorf("missing result for strategy %s", name)
+			continue
+		}
+		if g.TotalLookups != w.TotalLookups || g.TotalSwitches != w.TotalSwitches ||
+			g.UniqueShards != w.UniqueShards || g.AvgSwitchesPerGame != w.AvgSwitchesPerGame {
+			t.Errorf("%s: aggregate = %+v, want %+v", name, g, w)
+		}
+		if g.accesses.n != 5 {
+			t.Errorf("%s: recorded %d accesses, want 5", name, g.accesses.n)
+		}
+	}
+}
+
 func TestAggregateResult_CacheHitRate(t *testing.T) {
 	result := &AggregateResult{
 		TotalLookups: 100,
@@ -87,6 +133,56 @@ func TestAggregateResult_CacheHitRate(t *testing.T) {
 	}
 }
 
+func TestAggregateResult_CacheHitRateReplay(t *testing.T) {
+	result := recordedResult([]int{1, 2, 1, 3, 1, 2, 3, 1})
+
+	tests := []struct {
+		capacity int
+		want     float64
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 25},   // Hits: 1, 1.
+		{3, 62.5}, // Only the first access to each shard misses.
+		{100, 62.5},
+	}
+
+	for _, tt := range tests {
+		if got := result.CacheHitRate(tt.capacity); got != tt.want {
+			t.Errorf("CacheHitRate(%d) = %v, want %v", tt.capacity, got, tt.want)
+		}
+	}
+}
+
+func TestAggregateResult_CacheHitRateCurve(t *testing.T) {
+	sim := NewSimulator(32768, materialshard.New())
+	games := [][]string{
+		{
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -",
+			"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3",
+		},
+		{
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -",
+			"rnbqkbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBQKBNR b KQkq d3",
+		},
+	}
+	result := sim.SimulateGames(games)["material"]
+
+	if result.accesses.n != 4 {
+		t.Fatalf("recorded %d accesses, want 4", result.accesses.n)
+	}
+
+	curve := result.CacheHitRateCurve([]int{1, 10, 100})
+	if len(curve) != 3 {
+		t.Fatalf("CacheHitRateCurve length = %d, want 3", len(curve))
+	}
+	for i := 1; i < len(curve); i++ {
+		if curve[i] < curve[i-1] {
+			t.Errorf("hit rate decreased with capacity: %v", curve)
+		}
+	}
+}
+
 func TestMetrics_Computation(t *testing.T) {
 	result := &AggregateResult{
 		StrategyName:       "test",
@@ -112,3 +208,257 @@ func TestMetrics_Computation(t *testing.T) {
 		t.Errorf("MaxSwitchesPerGame = %d, want 12", metrics.MaxSwitchesPerGame)
 	}
 }
+
+func TestMetrics_Percentiles(t *testing.T) {
+	result := &AggregateResult{
+		SwitchesPerGame: []int{10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+	}
+
+	metrics := ComputeMetrics(result)
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"median", metrics.MedianSwitchesPerGame, 5.5},
+		{"P90", metrics.P90SwitchesPerGame, 9.1},
+		{"P99", metrics.P99SwitchesPerGame, 9.91},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 1e-9 {
+			t.Errorf("%s = %f, want %f", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestPercentile_SingleValue(t *testing.T) {
+	if got := percentile([]int{7}, 90); got != 7 {
+		t.Errorf("percentile() = %f, want 7", got)
+	}
+}
+
+func TestSimulator_Cost(t *testing.T) {
+	// With a single shard every game fetches it exactly once.
+	sim := NewSimulator(1, materialshard.New(), fnvshard.New())
+	sim.SetStrategyCost("fnv32", ShardSizeCost(map[int]int64{0: 100}, 5))
+
+	games := [][]string{
+		{
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -",
+			"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3",
+		},
+		{
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -",
+		},
+	}
+	results := sim.SimulateGames(games)
+
+	tests := []struct {
+		name        string
+		wantTotal   float64
+		wantPerGame float64
+	}{
+		{"material", 2, 1}, // Default cost: one per switch.
+		{"fnv32", 210, 105},
+	}
+	for _, tt := range tests {
+		res := results[tt.name]
+		if res.TotalCost != tt.wantTotal || res.AvgCostPerGame != tt.wantPerGame {
+			t.Errorf("%s: TotalCost = %v, AvgCostPerGame = %v, want %v and %v",
+				tt.name, res.TotalCost, res.AvgCostPerGame, tt.wantTotal, tt.wantPerGame)
+		}
+		if len(res.CostPerGame) != 2 {
+			t.Errorf("%s: CostPerGame length = %d, want 2", tt.name, len(res.CostPerGame))
+		}
+	}
+
+	if got := ShardSizeCost(map[int]int64{0: 100}, 5)(1); got != 5 {
+		t.Errorf("cost of an empty shard = %v, want the fetch penalty 5", got)
+	}
+}
+
+func TestMetrics_CostPercentiles(t *testing.T) {
+	metrics := ComputeMetrics(&AggregateResult{
+		TotalCost:      100,
+		AvgCostPerGame: 25,
+		CostPerGame:    []float64{40, 10, 30, 20},
+	})
+	if metrics.TotalCost != 100 || metrics.AvgCostPerGame != 25 {
+		t.Errorf("TotalCost = %v, AvgCostPerGame = %v, want 100 and 25", metrics.TotalCost, metrics.AvgCostPerGame)
+	}
+	if metrics.MedianCostPerGame != 25 || math.Abs(metrics.P90CostPerGame-37) > 1e-9 {
+		t.Errorf("MedianCostPerGame = %v, P90CostPerGame = %v, want 25 and 37", metrics.MedianCostPerGame, metrics.P90CostPerGame)
+	}
+}
+
+func TestAggregateResult_WorkingSetSize(t *testing.T) {
+	result := recordedResult([]int{1, 1, 2, 3, 3, 1})
+
+	tests := []struct {
+		window int
+		want   float64
+	}{
+		{0, 0},
+		{1, 1},
+		{2, 1.6},  // {1}, {1,2}, {2,3}, {3}, {3,1}.
+		{3, 2.25}, // {1,2}, {1,2,3}, {2,3}, {1,3}.
+		{100, 3},  // Shorter than the window: one window of everything.
+	}
+	for _, tt := range tests {
+		if got := result.WorkingSetSize(tt.window); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("WorkingSetSize(%d) = %v, want %v", tt.window, got, tt.want)
+		}
+	}
+
+	if got := (&AggregateResult{}).WorkingSetSize(10); got != 0 {
+		t.Errorf("WorkingSetSize() with no accesses = %v, want 0", got)
+	}
+}
+
+// recordedResult returns the aggregate of a single game accessing the
+// given shards in order.
+func recordedResult(seq []int) *AggregateResult {
+	result := &AggregateResult{TotalLookups: len(seq), accesses: newAccessStats()}
+	for _, shardID := range seq {
+		result.accesses.record(shardID)
+	}
+	return result
+}
+
+// replayLRU replays an access sequence through an LRU cache of the given
+// capacity and returns the hit rate (0-100).
+func replayLRU(accesses []int, capacity int) float64 {
+	if len(accesses) == 0 || capacity <= 0 {
+		return 0
+	}
+
+	order := list.New() // Front is most recently used.
+	entries := make(map[int]*list.Element, capacity)
+	var hits int
+
+	for _, shardID := range accesses {
+		if e, ok := entries[shardID]; ok {
+			hits++
+			order.MoveToFront(e)
+			continue
+		}
+
+		entries[shardID] = order.PushFront(shardID)
+		if order.Len() > capacity {
+			oldest := order.Back()
+			order.Remove(oldest)
+			delete(entries, oldest.Value.(int))
+		}
+	}
+
+	return float64(hits) / float64(len(accesses)) * 100
+}
+
+// slidingWorkingSet averages the distinct shards of every window of seq.
+func slidingWorkingSet(seq []int, window int) float64 {
+	window = min(window, len(seq))
+	var total int
+	for i := window; i <= len(seq); i++ {
+		distinct := make(map[int]bool)
+		for _, shardID := range seq[i-window : i] {
+			distinct[shardID] = true
+		}
+		total += len(distinct)
+	}
+	return float64(total) / float64(len(seq)-window+1)
+}
+
+// TestAggregateResult_MatchesReplay checks the access statistics against
+// replaying a long sequence, which also exercises recency slot compaction.
+func TestAggregateResult_MatchesReplay(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	seq := make([]int, 5000)
+	for i := range seq {
+		// Mostly a drifting local neighborhood, sometimes a far shard.
+		if rng.IntN(10) == 0 {
+			seq[i] = rng.IntN(500)
+		} else {
+			seq[i] = i/50 + rng.IntN(8)
+		}
+	}
+	result := recordedResult(seq)
+
+	for _, capacity := range []int{1, 2, 5, 10, 50, 200, 1000} {
+		got, want := result.CacheHitRate(capacity), replayLRU(seq, capacity)
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("CacheHitRate(%d) = %v, want %v", capacity, got, want)
+		}
+	}
+	for _, window := range []int{1, 7, 100, 4999, 5000, 6000} {
+		got, want := result.WorkingSetSize(window), slidingWorkingSet(seq, window)
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("WorkingSetSize(%d) = %v, want %v", window, got, want)
+		}
+	}
+}
+
+// syntheticGames returns n games of plies positions drawn from a small pool
+// of openings, with a fixed seed so runs are repeatable.
+func syntheticGames(n, plies int) [][]string {
+	pool := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -",
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3",
+		"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6",
+		"rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq -",
+		"r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq -",
+		"rnbqkbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBQKBNR b KQkq d3",
+		"rnbqkbnr/ppp1pppp/8/3p4/3P4/8/PPP1PPPP/RNBQKBNR w KQkq d6",
+		"8/8/8/8/8/8/8/4K2k w - -",
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	games := make([][]string, n)
+	for i := range games {
+		games[i] = make([]string, plies)
+		for j := range games[i] {
+			games[i][j] = pool[rng.IntN(len(pool))]
+		}
+	}
+	return games
+}
+
+func TestSimulator_SimulateGamesParallel(t *testing.T) {
+	games := syntheticGames(500, 40)
+	newSim := func(workers int) *Simulator {
+		sim := NewSimulator(32768, materialshard.New(), fnvshard.New())
+		sim.SetWorkers(workers)
+		return sim
+	}
+
+	want := newSim(1).SimulateGames(games)
+	for _, workers := range []int{2, 8} {
+		got := newSim(workers).SimulateGames(games)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("SimulateGames() with %d workers differs from serial", workers)
+		}
+	}
+
+	// Batches and single games fold into the same aggregate.
+	agg := newSim(4).NewAggregator()
+	agg.AddGames(games[:100])
+	for _, game := range games[100:200] {
+		agg.Add(game)
+	}
+	agg.AddGames(games[200:])
+	if got := agg.Results(); !reflect.DeepEqual(got, want) {
+		t.Error("mixing AddGames and Add differs from SimulateGames()")
+	}
+}
+
+func BenchmarkSimulator_SimulateGames(b *testing.B) {
+	games := syntheticGames(2000, 80)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			sim := NewSimulator(32768, materialshard.New(), fnvshard.New())
+			sim.SetWorkers(workers)
+			for b.Loop() {
+				sim.SimulateGames(games)
+			}
+		})
+	}
+}
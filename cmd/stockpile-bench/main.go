@@ -6,18 +6,23 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"runtime"
+	"sort"
 	"strings"
 
-	"github.com/klauspost/compress/zstd"
 	"github.com/spf13/cobra"
 
 	"github.com/discochess/stockpile/benchmark/analysis"
 	"github.com/discochess/stockpile/benchmark/pgn"
 	"github.com/discochess/stockpile/benchmark/reporting"
 	"github.com/discochess/stockpile/benchmark/simulation"
+	"github.com/discochess/stockpile/internal/builder"
+	"github.com/discochess/stockpile/internal/codec/detect"
 	"github.com/discochess/stockpile/internal/shard"
-	"github.com/discochess/stockpile/internal/shard/fnvshard"
-	"github.com/discochess/stockpile/internal/shard/materialshard"
+	_ "github.com/discochess/stockpile/internal/shard/fnvshard" // Register built-in strategies.
+	_ "github.com/discochess/stockpile/internal/shard/materialshard"
+	_ "github.com/discochess/stockpile/internal/shard/pawnshard"
+	_ "github.com/discochess/stockpile/internal/shard/ringshard"
 )
 
 var (
@@ -26,16 +31,28 @@ var (
 	totalShards   int
 	outputFormat  string
 	outputFile    string
+	cacheSizes    []int
+	correction    string
+	manifestDirs  []string
+	fetchCost     float64
+	workers       int
 	verbose       bool
 )
 
+// gameBatchSize is how many parsed games are buffered and simulated in
+// parallel at a time.
+const gameBatchSize = 1024
+
 var rootCmd = &cobra.Command{
 	Use:   "stockpile-bench",
 	Short: "Benchmark sharding strategies for stockpile",
 	Long: `stockpile-bench compares different sharding strategies using real game data.
 
 It simulates lookup patterns from PGN games and measures shard switching
-frequency to determine which strategy provides better cache locality.
+frequency to determine which strategy provides better cache locality. Each
+switch is also charged a modeled fetch cost: a fixed --fetch-cost by
+default, or the real decompressed shard size from database manifests given
+with --manifest, one built with each benchmarked strategy.
 
 Examples:
   # Run benchmark with default strategies
@@ -44,8 +61,19 @@ Examples:
   # Run benchmark with specific strategies
   stockpile-bench run --games games.pgn --strategies material,fnv32
 
+  # Measure whether keeping colors in separate shards helps locality
+  stockpile-bench run --games games.pgn --strategies material,material-side
+
+  # Model decompression work with real shard sizes from built databases,
+  # one per strategy
+  stockpile-bench run --games games.pgn --strategies material,fnv32 \
+    --manifest ./data-material --manifest ./data-fnv32
+
   # Output as markdown report
-  stockpile-bench run --games games.pgn --format markdown --output report.md`,
+  stockpile-bench run --games games.pgn --format markdown --output report.md
+
+  # Output machine-readable results for CI
+  stockpile-bench run --games games.pgn --format json --output results.json`,
 }
 
 var runCmd = &cobra.Command{
@@ -55,11 +83,16 @@ var runCmd = &cobra.Command{
 }
 
 func init() {
-	runCmd.Flags().StringVarP(&gamesFile, "games", "g", "", "PGN file containing games (supports .zst)")
-	runCmd.Flags().StringSliceVarP(&strategyNames, "strategies", "s", []string{"material", "fnv32"}, "strategies to compare")
+	runCmd.Flags().StringVarP(&gamesFile, "games", "g", "", "PGN file containing games (supports zstd, gzip, bzip2)")
+	runCmd.Flags().StringSliceVarP(&strategyNames, "strategies", "s", []string{"material", "material-side", "fnv32"}, "strategies to compare")
 	runCmd.Flags().IntVar(&totalShards, "shards", 32768, "total number of shards")
-	runCmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "output format: text, markdown")
+	runCmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "output format: text, markdown, json, csv")
 	runCmd.Flags().StringVarP(&outputFile, "output", "o", "", "output file (default: stdout)")
+	runCmd.Flags().IntSliceVar(&cacheSizes, "cache-sizes", []int{10, 100, 1000, 10000}, "cache capacities (in shards) for the hit-rate table")
+	runCmd.Flags().StringVar(&correction, "correction", "bonferroni", "multiple-comparison correction: bonferroni, benjamini-hochberg, none")
+	runCmd.Flags().StringSliceVar(&manifestDirs, "manifest", nil, "data directory whose manifest gives real shard sizes for its strategy (repeatable; one per benchmarked strategy)")
+	runCmd.Flags().Float64Var(&fetchCost, "fetch-cost", 1, "fixed cost charged per shard fetch, on top of the shard size with --manifest")
+	runCmd.Flags().IntVar(&workers, "workers", runtime.GOMAXPROCS(0), "number of games simulated in parallel")
 	runCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	runCmd.MarkFlagRequired("games")
 
@@ -73,48 +106,26 @@ func main() {
 }
 
 func runBenchmark(cmd *cobra.Command, args []string) error {
+	method := analysis.Correction(strings.ToLower(correction))
+	switch method {
+	case analysis.CorrectionBonferroni, analysis.CorrectionBenjaminiHochberg, analysis.CorrectionNone:
+	default:
+		return fmt.Errorf("unknown correction %q", correction)
+	}
+
 	// Open games file.
-	var reader io.Reader
 	file, err := os.Open(gamesFile)
 	if err != nil {
 		return fmt.Errorf("opening games file: %w", err)
 	}
 	defer file.Close()
 
-	// Handle zstd compression.
-	if strings.HasSuffix(gamesFile, ".zst") {
-		decoder, err := zstd.NewReader(file)
-		if err != nil {
-			return fmt.Errorf("creating zstd decoder: %w", err)
-		}
-		defer decoder.Close()
-		reader = decoder
-	} else {
-		reader = file
-	}
-
-	// Extract FENs from games.
-	if verbose {
-		fmt.Fprintln(os.Stderr, "Extracting positions from games...")
-	}
-
-	games, err := pgn.ExtractFENsFromGames(reader)
+	// Handle compression (zstd, gzip, bzip2) by sniffing magic bytes.
+	reader, _, err := detect.NewReader(file)
 	if err != nil {
-		return fmt.Errorf("extracting FENs: %w", err)
-	}
-
-	if len(games) == 0 {
-		return fmt.Errorf("no games found in %s", gamesFile)
-	}
-
-	var totalPositions int
-	for _, g := range games {
-		totalPositions += len(g)
-	}
-
-	if verbose {
-		fmt.Fprintf(os.Stderr, "Extracted %d positions from %d games\n", totalPositions, len(games))
+		return fmt.Errorf("detecting games file compression: %w", err)
 	}
+	defer reader.Close()
 
 	// Create strategies.
 	strategies := make([]shard.Strategy, 0, len(strategyNames))
@@ -126,22 +137,53 @@ func runBenchmark(cmd *cobra.Command, args []string) error {
 		strategies = append(strategies, s)
 	}
 
-	// Run simulation.
+	// Stream games through the simulator in batches so the whole PGN never
+	// has to fit in memory.
 	if verbose {
-		fmt.Fprintln(os.Stderr, "Running simulation...")
+		fmt.Fprintln(os.Stderr, "Simulating games...")
 	}
 
 	sim := simulation.NewSimulator(totalShards, strategies...)
-	results := sim.SimulateGames(games)
+	sim.SetWorkers(workers)
+	if err := configureCost(sim, strategies); err != nil {
+		return err
+	}
+	agg := sim.NewAggregator()
+	var totalPositions int
+	batch := make([][]string, 0, gameBatchSize)
+	err = pgn.StreamGames(reader, func(fens []string) error {
+		batch = append(batch, fens)
+		totalPositions += len(fens)
+		if len(batch) == gameBatchSize {
+			agg.AddGames(batch)
+			batch = batch[:0]
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("extracting FENs: %w", err)
+	}
+	agg.AddGames(batch)
 
-	// Perform statistical comparison.
-	var comparison *analysis.StrategyComparison
+	if agg.Games() == 0 {
+		return fmt.Errorf("no games found in %s", gamesFile)
+	}
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Simulated %d positions from %d games\n", totalPositions, agg.Games())
+	}
+
+	results := agg.Results()
+
+	// Perform statistical comparison against the first strategy.
+	var comparison *analysis.MultiStrategyComparison
 	if len(strategies) >= 2 {
-		comparison = analysis.CompareStrategies(
-			results[strategies[0].Name()],
-			results[strategies[1].Name()],
+		comparison = analysis.CompareAllCorrected(
+			results,
+			strategies[0].Name(),
 			10000, // Bootstrap iterations.
 			0.95,  // 95% confidence.
+			method,
 		)
 	}
 
@@ -157,33 +199,76 @@ func runBenchmark(cmd *cobra.Command, args []string) error {
 	}
 
 	switch outputFormat {
+	case "csv":
+		return reporting.WriteCSV(output, results, comparison)
+	case "json":
+		return reporting.WriteJSON(output, agg.Games(), totalPositions, totalShards, results, comparison)
 	case "markdown":
-		return writeMarkdownReport(output, games, results, comparison)
+		return writeMarkdownReport(output, agg.Games(), totalPositions, results, comparison)
 	default:
-		return writeTextReport(output, games, results, comparison)
+		return writeTextReport(output, agg.Games(), totalPositions, results, comparison)
 	}
 }
 
 func createStrategy(name string) (shard.Strategy, error) {
-	switch strings.ToLower(name) {
-	case "material":
-		return materialshard.New(), nil
-	case "fnv32":
-		return fnvshard.New(), nil
-	default:
-		return nil, fmt.Errorf("unknown strategy: %s", name)
-	}
+	return shard.New(strings.ToLower(name))
 }
 
-func writeTextReport(w io.Writer, games [][]string, results map[string]*simulation.AggregateResult, comp *analysis.StrategyComparison) error {
-	var totalPositions int
-	for _, g := range games {
-		totalPositions += len(g)
+// configureCost sets the simulator's fetch cost model from --fetch-cost and
+// --manifest. A manifest gives the decompressed size of every shard in the
+// layout of the strategy it was built with, so with --manifest every
+// benchmarked strategy needs one: charging any of them an estimate instead
+// would bias the comparison.
+func configureCost(sim *simulation.Simulator, strategies []shard.Strategy) error {
+	if len(manifestDirs) == 0 {
+		sim.SetCost(simulation.FixedCost(fetchCost))
+		return nil
 	}
 
+	benchmarked := make(map[string]bool, len(strategies))
+	for _, s := range strategies {
+		benchmarked[s.Name()] = true
+	}
+
+	sized := make(map[string]string, len(manifestDirs)) // Strategy -> manifest dir.
+	for _, dir := range manifestDirs {
+		m, err := builder.ReadManifest(dir)
+		if err != nil {
+			return err
+		}
+		if m.TotalShards != totalShards {
+			return fmt.Errorf("manifest in %s has %d shards, benchmarking %d", dir, m.TotalShards, totalShards)
+		}
+		if !benchmarked[m.Strategy] {
+			return fmt.Errorf("manifest in %s is for strategy %q, which is not being benchmarked", dir, m.Strategy)
+		}
+		if prev, ok := sized[m.Strategy]; ok {
+			return fmt.Errorf("manifests in %s and %s are both for strategy %q", prev, dir, m.Strategy)
+		}
+		sized[m.Strategy] = dir
+
+		sizes := make(map[int]int64, len(m.Shards))
+		for _, si := range m.Shards {
+			sizes[si.ID] = si.UncompressedSize
+		}
+		sim.SetStrategyCost(m.Strategy, simulation.ShardSizeCost(sizes, fetchCost))
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Using shard sizes from %s for %s\n", dir, m.Strategy)
+		}
+	}
+
+	for _, s := range strategies {
+		if _, ok := sized[s.Name()]; !ok {
+			return fmt.Errorf("no --manifest for strategy %q: with --manifest, every benchmarked strategy needs one", s.Name())
+		}
+	}
+	return nil
+}
+
+func writeTextReport(w io.Writer, games, totalPositions int, results map[string]*simulation.AggregateResult, comp *analysis.MultiStrategyComparison) error {
 	fmt.Fprintf(w, "Stockpile Sharding Strategy Benchmark\n")
 	fmt.Fprintf(w, "=====================================\n\n")
-	fmt.Fprintf(w, "Games: %d\n", len(games))
+	fmt.Fprintf(w, "Games: %d\n", games)
 	fmt.Fprintf(w, "Positions: %d\n", totalPositions)
 	fmt.Fprintf(w, "Shards: %d\n\n", totalShards)
 
@@ -194,34 +279,80 @@ func writeTextReport(w io.Writer, games [][]string, results map[string]*simulati
 		metrics := simulation.ComputeMetrics(res)
 		fmt.Fprintf(w, "%s:\n", name)
 		fmt.Fprintf(w, "  Avg switches/game: %.2f\n", metrics.AvgSwitchesPerGame)
-		fmt.Fprintf(w, "  Median switches:   %.0f\n", metrics.MedianSwitchesPerGame)
-		fmt.Fprintf(w, "  P90 switches:      %.0f\n", metrics.P90SwitchesPerGame)
+		fmt.Fprintf(w, "  Median switches:   %.1f\n", metrics.MedianSwitchesPerGame)
+		fmt.Fprintf(w, "  P90 switches:      %.1f\n", metrics.P90SwitchesPerGame)
+		fmt.Fprintf(w, "  Avg cost/game:     %.0f\n", metrics.AvgCostPerGame)
+		fmt.Fprintf(w, "  Total cost:        %.0f\n", metrics.TotalCost)
 		fmt.Fprintf(w, "  Unique shards:     %d\n", metrics.UniqueShards)
+		fmt.Fprintf(w, "  Working set:       %.1f shards per %d lookups\n", metrics.WorkingSetSize, simulation.WorkingSetWindow)
 		fmt.Fprintf(w, "  Est. cache hit:    %.1f%%\n\n", res.CacheHitRate(100))
 	}
 
+	if len(cacheSizes) > 0 {
+		fmt.Fprintf(w, "Cache Hit Rate by Capacity:\n")
+		fmt.Fprintf(w, "---------------------------\n\n")
+		names := make([]string, 0, len(results))
+		curves := make(map[string][]float64, len(results))
+		for name, res := range results {
+			names = append(names, name)
+			curves[name] = res.CacheHitRateCurve(cacheSizes)
+		}
+		sort.Strings(names)
+
+		fmt.Fprintf(w, "  %-10s", "shards")
+		for _, name := range names {
+			fmt.Fprintf(w, "  %12s", name)
+		}
+		fmt.Fprintln(w)
+		for i, size := range cacheSizes {
+			fmt.Fprintf(w, "  %-10d", size)
+			for _, name := range names {
+				fmt.Fprintf(w, "  %11.1f%%", curves[name][i])
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w)
+	}
+
 	if comp != nil {
 		fmt.Fprintf(w, "Statistical Analysis:\n")
 		fmt.Fprintf(w, "---------------------\n\n")
-		fmt.Fprintln(w, comp.Summary())
+		if summary := comp.OmnibusSummary(); summary != "" {
+			fmt.Fprintf(w, "%s\n\n", summary)
+		}
+		for _, c := range comp.Comparisons {
+			fmt.Fprintf(w, "%s\n\n", c.Summary())
+		}
 	}
 
 	return nil
 }
 
-func writeMarkdownReport(w io.Writer, games [][]string, results map[string]*simulation.AggregateResult, comp *analysis.StrategyComparison) error {
-	var totalPositions int
-	for _, g := range games {
-		totalPositions += len(g)
-	}
-
+func writeMarkdownReport(w io.Writer, games, totalPositions int, results map[string]*simulation.AggregateResult, comp *analysis.MultiStrategyComparison) error {
 	report := reporting.NewMarkdownReport(w)
 	report.WriteHeader("Stockpile Sharding Strategy Benchmark")
-	report.WriteMethodology(len(games), totalPositions)
+	var method analysis.Correction
+	if comp != nil {
+		method = comp.Correction
+	}
+	report.WriteMethodology(games, totalPositions, method)
 	report.WriteSummaryTable(results)
 
 	if comp != nil {
-		report.WriteComparison(comp)
+		if comp.Omnibus != nil {
+			report.WriteOmnibus(comp.Omnibus)
+		}
+		for _, c := range comp.Comparisons {
+			report.WriteComparison(c)
+		}
+	}
+
+	if len(results) >= 2 {
+		distributions := make(map[string][]int, len(results))
+		for name, res := range results {
+			distributions[name] = res.SwitchesPerGame
+		}
+		report.WriteDistributionComparison(distributions)
 	}
 
 	report.WriteFooter()
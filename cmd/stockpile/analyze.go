@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/discochess/stockpile"
+	"github.com/discochess/stockpile/benchmark/pgn"
+	"github.com/discochess/stockpile/internal/codec/detect"
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Look up every position of the games in a PGN file",
+	Long: `Replay the games in a PGN file and look up each position in the
+evaluation database, reporting per-game coverage, the overall hit rate and
+lookup timing. Compressed PGNs (zstd, gzip, bzip2) are detected automatically.
+
+Examples:
+  # Analyze the first 10 games
+  stockpile analyze --pgn games.pgn
+
+  # Analyze every game with a larger shard cache, as JSON
+  stockpile analyze --pgn games.pgn.zst --games 0 --cache-size 1000 --json`,
+	RunE: runAnalyze,
+}
+
+var (
+	analyzePGN           string
+	analyzeMaxGames      int
+	analyzeCacheSize     int
+	analyzeCacheStrategy string
+	analyzeJSON          bool
+)
+
+func init() {
+	analyzeCmd.Flags().StringVar(&analyzePGN, "pgn", "", "PGN file to analyze")
+	analyzeCmd.Flags().IntVar(&analyzeMaxGames, "games", 10, "maximum games to analyze (0 = all)")
+	analyzeCmd.Flags().StringVar(&analyzeCacheStrategy, "cache-strategy", "lru", cacheStrategyUsage)
+	analyzeCmd.Flags().IntVar(&analyzeCacheSize, "cache-size", 1000, cacheSizeUsage)
+	analyzeCmd.Flags().BoolVar(&analyzeJSON, "json", false, "output results as JSON")
+	analyzeCmd.MarkFlagRequired("pgn")
+	rootCmd.AddCommand(analyzeCmd)
+}
+
+// errAnalyzeDone stops the PGN stream once --games games have been read.
+var errAnalyzeDone = errors.New("game limit reached")
+
+// gameCoverage is the lookup coverage of a single game.
+type gameCoverage struct {
+	Game      int     `json:"game"`
+	Positions int     `json:"positions"`
+	Found     int     `json:"found"`
+	HitRate   float64 `json:"hit_rate_pct"`
+}
+
+// analyzeReport is the result of analyze, printed as text or JSON.
+type analyzeReport struct {
+	Games          []gameCoverage `json:"games"`
+	TotalPositions int            `json:"total_positions"`
+	Found          int            `json:"found"`
+	HitRate        float64        `json:"hit_rate_pct"`
+	ElapsedMS      int64          `json:"elapsed_ms"`
+	AvgLookupUS    float64        `json:"avg_lookup_us"`
+}
+
+func runAnalyze(cmd *cobra.Command, args []string) error {
+	dataOpt, err := stockpile.WithDataDir(dataDir)
+	if err != nil {
+		return fmt.Errorf("opening data directory: %w", err)
+	}
+
+	client, err := newCachedClient(dataOpt, analyzeCacheStrategy, analyzeCacheSize, nil)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	file, err := os.Open(analyzePGN)
+	if err != nil {
+		return fmt.Errorf("opening PGN file: %w", err)
+	}
+	defer file.Close()
+
+	reader, _, err := detect.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("detecting PGN compression: %w", err)
+	}
+	defer reader.Close()
+
+	ctx := context.Background()
+	report := &analyzeReport{Games: []gameCoverage{}}
+	var lookupTime time.Duration
+	start := time.Now()
+
+	err = pgn.StreamGames(reader, func(fens []string) error {
+		cov := gameCoverage{Game: len(report.Games) + 1, Positions: len(fens)}
+		for _, fen := range fens {
+			lookupStart := time.Now()
+			_, err := client.Lookup(ctx, fen)
+			lookupTime += time.Since(lookupStart)
+			if isNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("game %d: looking up %q: %w", cov.Game, fen, err)
+			}
+			cov.Found++
+		}
+		cov.HitRate = percent(cov.Found, cov.Positions)
+
+		report.Games = append(report.Games, cov)
+		report.TotalPositions += cov.Positions
+		report.Found += cov.Found
+		if !analyzeJSON {
+			fmt.Printf("Game %d: %d/%d positions found (%.1f%%)\n",
+				cov.Game, cov.Found, cov.Positions, cov.HitRate)
+		}
+
+		if analyzeMaxGames > 0 && len(report.Games) >= analyzeMaxGames {
+			return errAnalyzeDone
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errAnalyzeDone) {
+		return fmt.Errorf("analyzing games: %w", err)
+	}
+
+	elapsed := time.Since(start)
+	report.HitRate = percent(report.Found, report.TotalPositions)
+	report.ElapsedMS = elapsed.Milliseconds()
+	if report.TotalPositions > 0 {
+		report.AvgLookupUS = float64(lookupTime.Microseconds()) / float64(report.TotalPositions)
+	}
+
+	if analyzeJSON {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("encoding JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Printf("Games analyzed:  %d\n", len(report.Games))
+	fmt.Printf("Total positions: %d\n", report.TotalPositions)
+	fmt.Printf("Found:           %d (%.1f%%)\n", report.Found, report.HitRate)
+	fmt.Printf("Elapsed:         %s\n", elapsed.Round(time.Millisecond))
+	if report.TotalPositions > 0 {
+		fmt.Printf("Avg lookup:      %s\n", lookupTime/time.Duration(report.TotalPositions))
+	}
+	return nil
+}
+
+// percent returns n as a percentage (0-100) of total.
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/discochess/stockpile/benchmark/pgn"
+	"github.com/discochess/stockpile/internal/builder"
+	"github.com/discochess/stockpile/internal/fen"
+	"github.com/discochess/stockpile/internal/shard/fnvshard"
+)
+
+const testGame = `[Event "Test"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 4. Ba4 Nf6 5. O-O Be7 *
+`
+
+// setFlag sets a command's flag variable for the duration of the test.
+func setFlag[T any](t *testing.T, flag *T, value T) {
+	t.Helper()
+	prev := *flag
+	t.Cleanup(func() { *flag = prev })
+	*flag = value
+}
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- data
+	}()
+	fnErr := fn()
+	w.Close()
+	return string(<-done), fnErr
+}
+
+func TestAnalyzeAndCoverage_MissingShardFile(t *testing.T) {
+	// Build a database of every position of the game, then delete one of
+	// its shard files.
+	var fens []string
+	if err := pgn.StreamGames(strings.NewReader(testGame), func(game []string) error {
+		fens = game
+		return nil
+	}); err != nil {
+		t.Fatalf("StreamGames() error = %v", err)
+	}
+	var source []byte
+	for _, f := range fens {
+		normalized, err := fen.NormalizeStrict(f)
+		if err != nil {
+			t.Fatalf("NormalizeStrict(%q) error = %v", f, err)
+		}
+		source = fmt.Appendf(source, `{"fen":%q,"evals":[{"pvs":[{"cp":0,"line":"e2e4"}],"knodes":1,"depth":20}]}`+"\n", normalized)
+	}
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "source.jsonl")
+	pgnPath := filepath.Join(dir, "game.pgn")
+	if err := os.WriteFile(sourcePath, source, 0644); err != nil {
+		t.Fatalf("writing source: %v", err)
+	}
+	if err := os.WriteFile(pgnPath, []byte(testGame), 0644); err != nil {
+		t.Fatalf("writing PGN: %v", err)
+	}
+	b := builder.NewBuilder(
+		builder.WithOutputDir(dir),
+		builder.WithTotalShards(4),
+		builder.WithStrategy(fnvshard.New()),
+		builder.WithProgress(nil),
+	)
+	if err := b.BuildFromFile(context.Background(), sourcePath, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+	m, err := builder.ReadManifest(dir)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	missing := m.Shards[0]
+	if err := os.Remove(filepath.Join(dir, "shards", fmt.Sprintf("%05d.zst", missing.ID))); err != nil {
+		t.Fatalf("removing shard: %v", err)
+	}
+	wantFound := len(fens) - missing.RecordCount
+
+	setFlag(t, &dataDir, dir)
+
+	t.Run("analyze", func(t *testing.T) {
+		setFlag(t, &analyzePGN, pgnPath)
+		setFlag(t, &analyzeMaxGames, 0)
+		setFlag(t, &analyzeCacheStrategy, "lru")
+		setFlag(t, &analyzeCacheSize, 10)
+		setFlag(t, &analyzeJSON, true)
+
+		out, err := captureStdout(t, func() error { return runAnalyze(nil, nil) })
+		if err != nil {
+			t.Fatalf("runAnalyze() error = %v", err)
+		}
+		var report analyzeReport
+		if err := json.Unmarshal([]byte(out), &report); err != nil {
+			t.Fatalf("decoding report %q: %v", out, err)
+		}
+		if report.TotalPositions != len(fens) || report.Found != wantFound {
+			t.Errorf("found %d of %d positions, want %d of %d", report.Found, report.TotalPositions, wantFound, len(fens))
+		}
+	})
+
+	t.Run("coverage", func(t *testing.T) {
+		setFlag(t, &coveragePGN, pgnPath)
+		setFlag(t, &coverageMaxGames, 0)
+		setFlag(t, &coverageByMove, false)
+		setFlag(t, &coverageJSON, true)
+
+		out, err := captureStdout(t, func() error { return runCoverage(nil, nil) })
+		if err != nil {
+			t.Fatalf("runCoverage() error = %v", err)
+		}
+		var report coverageReport
+		if err := json.Unmarshal([]byte(out), &report); err != nil {
+			t.Fatalf("decoding report %q: %v", out, err)
+		}
+		if report.TotalPositions != len(fens) || report.Found != wantFound {
+			t.Errorf("found %d of %d positions, want %d of %d", report.Found, report.TotalPositions, wantFound, len(fens))
+		}
+	})
+}
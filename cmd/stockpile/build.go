@@ -5,15 +5,20 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/spf13/cobra"
 
 	"github.com/discochess/stockpile/internal/builder"
+	"github.com/discochess/stockpile/internal/seekable"
 	"github.com/discochess/stockpile/internal/shard"
-	"github.com/discochess/stockpile/internal/shard/fnvshard"
-	"github.com/discochess/stockpile/internal/shard/materialshard"
+	_ "github.com/discochess/stockpile/internal/shard/fnvshard" // Register built-in strategies.
+	_ "github.com/discochess/stockpile/internal/shard/materialshard"
+	_ "github.com/discochess/stockpile/internal/shard/pawnshard"
+	_ "github.com/discochess/stockpile/internal/shard/ringshard"
 )
 
 var buildCmd = &cobra.Command{
@@ -40,42 +45,63 @@ Examples:
   # Specify number of shards and strategy
   stockpile build --output ./data --shards 32768 --strategy material
 
+  # Resume an interrupted build (rerun the same command)
+  stockpile build --source ./lichess_db_eval.jsonl.zst --output ./data --resume
+
   # Build and upload to GCS (for cronjobs)
   stockpile build --output-gcs gs://my-bucket/stockpile`,
 	RunE: runBuild,
 }
 
 var (
-	sourceURL    string
-	outputDir    string
-	outputGCS    string
-	totalShards  int
-	strategyName string
-	workers      int
-	maxMemoryMB  int
+	sourceURL     string
+	outputDir     string
+	outputGCS     string
+	totalShards   int
+	strategyName  string
+	workers       int
+	maxMemoryMB   int
+	downloadLimit int64
+	resume        bool
+	buildIndex    bool
+	compression   string
+	frameSizeKB   int
+	skipUnchanged bool
+	skipIllegal   bool
+	trainDict     bool
+	symmetric     bool
 )
 
 func init() {
 	buildCmd.Flags().StringVar(&sourceURL, "source", builder.DefaultSourceURL, "source URL or local file path")
 	buildCmd.Flags().StringVarP(&outputDir, "output", "o", "./data", "output directory for shards (local builds)")
 	buildCmd.Flags().StringVar(&outputGCS, "output-gcs", "", "GCS path for output (gs://bucket/prefix)")
+	buildCmd.Flags().BoolVar(&skipUnchanged, "skip-unchanged", false, "with --output-gcs, skip shards whose remote checksum matches")
 	buildCmd.Flags().IntVar(&totalShards, "shards", builder.DefaultTotalShards, "number of shards to create")
-	buildCmd.Flags().StringVar(&strategyName, "strategy", "material", "sharding strategy: material, fnv32")
-	buildCmd.Flags().IntVar(&workers, "workers", 4, "number of parallel workers for compression")
+	buildCmd.Flags().StringVar(&strategyName, "strategy", "material", "sharding strategy: "+strings.Join(shard.Names(), ", "))
+	buildCmd.Flags().IntVar(&workers, "workers", 4, "number of parallel workers for sorting and compression")
+	buildCmd.Flags().StringVar(&compression, "compression-level", "best", "zstd level: fastest, default, better, best")
+	buildCmd.Flags().IntVar(&frameSizeKB, "frame-size", seekable.DefaultFrameSize/1024, "uncompressed KB per seekable zstd frame (smaller = faster lookups, larger shards)")
+	buildCmd.Flags().BoolVar(&buildIndex, "index", false, "write a sparse offset index (.idx) next to each shard")
+	buildCmd.Flags().BoolVar(&trainDict, "dictionary", false, "train a zstd dictionary on sampled records and compress shards with it")
+	buildCmd.Flags().BoolVar(&skipIllegal, "skip-illegal", false, "drop records whose FEN is not a legal position (they are counted either way)")
+	buildCmd.Flags().BoolVar(&symmetric, "symmetric", false, "also store the color-swapped mirror of positions missing it (roughly doubles the size)")
+	buildCmd.Flags().BoolVar(&resume, "resume", false, "checkpoint progress and resume an interrupted local build")
+	buildCmd.Flags().Int64Var(&downloadLimit, "download-limit", 0, "max download speed in KB/s (0 = unlimited)")
 	buildCmd.Flags().IntVar(&maxMemoryMB, "max-memory", 1024, "max memory in MB before spilling to disk (lower = less RAM usage)")
 	rootCmd.AddCommand(buildCmd)
 }
 
 func runBuild(cmd *cobra.Command, args []string) error {
 	// Select strategy.
-	var strategy shard.Strategy
-	switch strategyName {
-	case "material":
-		strategy = materialshard.New()
-	case "fnv32":
-		strategy = fnvshard.New()
-	default:
-		return fmt.Errorf("unknown strategy: %s", strategyName)
+	strategy, err := shard.New(strategyName)
+	if err != nil {
+		return err
+	}
+
+	ok, level := zstd.EncoderLevelFromString(compression)
+	if !ok {
+		return fmt.Errorf("unknown compression level: %s", compression)
 	}
 
 	// Check if source is a local file.
@@ -123,7 +149,15 @@ func runBuild(cmd *cobra.Command, args []string) error {
 		builder.WithStrategy(strategy),
 		builder.WithWorkers(workers),
 		builder.WithMaxMemoryMB(maxMemoryMB),
+		builder.WithDownloadRateLimit(downloadLimit*1024),
 		builder.WithProgress(builder.DefaultProgressFunc),
+		builder.WithResume(resume),
+		builder.WithBuildIndex(buildIndex),
+		builder.WithCompressionLevel(level),
+		builder.WithFrameSize(frameSizeKB*1024),
+		builder.WithSkipIllegal(skipIllegal),
+		builder.WithDictionary(trainDict),
+		builder.WithStoreSymmetric(symmetric),
 	)
 
 	fmt.Printf("Building stockpile database\n")
@@ -136,11 +170,11 @@ func runBuild(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Shards:     %d\n", totalShards)
 	fmt.Printf("  Strategy:   %s\n", strategy.Name())
 	fmt.Printf("  Workers:    %d\n", workers)
+	fmt.Printf("  Level:      %s\n", level)
 	fmt.Printf("  Max Memory: %d MB\n", maxMemoryMB)
 	fmt.Println()
 
 	// Run build.
-	var err error
 	if isLocalFile {
 		err = b.BuildFromFile(ctx, sourceURL, time.Time{})
 	} else {
@@ -150,12 +184,18 @@ func runBuild(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Surface hot shards while the build is fresh.
+	if manifest, err := builder.ReadManifest(localOutput); err == nil {
+		fmt.Println()
+		printBalance(manifest.Balance)
+	}
+
 	// Upload to GCS if specified.
 	if outputGCS != "" {
 		fmt.Println()
 		fmt.Printf("[Upload] Uploading to %s...\n", outputGCS)
 
-		uploader, err := builder.NewGCSUploader(ctx, outputGCS)
+		uploader, err := builder.NewGCSUploader(ctx, outputGCS, builder.WithSkipUnchanged(skipUnchanged))
 		if err != nil {
 			return fmt.Errorf("creating GCS uploader: %w", err)
 		}
@@ -165,7 +205,7 @@ func runBuild(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("uploading to GCS: %w", err)
 		}
 
-		fmt.Println("[Upload] Done")
+		fmt.Println("\n[Upload] Done")
 	}
 
 	return nil
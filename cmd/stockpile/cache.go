@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/discochess/stockpile"
+	"github.com/discochess/stockpile/internal/stats"
+	"github.com/discochess/stockpile/internal/store/cachedstore"
+	"github.com/discochess/stockpile/internal/store/cachedstore/cachestrategy"
+	"github.com/discochess/stockpile/internal/store/cachedstore/cachestrategy/lfu"
+	"github.com/discochess/stockpile/internal/store/cachedstore/cachestrategy/lru"
+	"github.com/discochess/stockpile/internal/store/cachedstore/cachestrategy/sizelru"
+	"github.com/discochess/stockpile/internal/store/cachedstore/memory"
+)
+
+// Usage strings for the --cache-strategy and --cache-size flags shared by
+// lookup, serve and analyze.
+const (
+	cacheStrategyUsage = "shard cache eviction: lru, lfu, or size (LRU bounded by bytes)"
+	cacheSizeUsage     = "shard cache size: number of shards, or megabytes with --cache-strategy size"
+)
+
+// newCacheStrategy returns the eviction strategy named by --cache-strategy,
+// holding size shards, or size megabytes of shards for "size".
+func newCacheStrategy(name string, size int) (cachestrategy.Strategy, error) {
+	switch name {
+	case "lru":
+		s, err := lru.New(size)
+		if err != nil {
+			return nil, fmt.Errorf("creating LRU strategy: %w", err)
+		}
+		return s, nil
+	case "lfu":
+		s, err := lfu.New(size)
+		if err != nil {
+			return nil, fmt.Errorf("creating LFU strategy: %w", err)
+		}
+		return s, nil
+	case "size":
+		s, err := sizelru.New(int64(size) << 20)
+		if err != nil {
+			return nil, fmt.Errorf("creating size-bounded LRU strategy: %w", err)
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown cache strategy %q (want lru, lfu or size)", name)
+	}
+}
+
+// newCachedClient returns a client for the database opened by dataOpt,
+// with a shard cache of the named strategy and size in front of its store.
+// collector, which may be nil, receives the cache metrics; opts are applied
+// to the client after the data directory and the cached store.
+func newCachedClient(dataOpt stockpile.Option, strategy string, size int, collector stats.Collector, opts ...stockpile.Option) (*stockpile.Client, error) {
+	// WithDataDir supplies the manifest's strategy and shard count; borrow
+	// its disk store so the cache can sit in front of it.
+	base, err := stockpile.New(dataOpt)
+	if err != nil {
+		return nil, fmt.Errorf("creating client: %w", err)
+	}
+	cacheStrategy, err := newCacheStrategy(strategy, size)
+	if err != nil {
+		return nil, err
+	}
+	st := cachedstore.New(base.Store(), memory.New(cacheStrategy, collector))
+
+	client, err := stockpile.New(append([]stockpile.Option{dataOpt, stockpile.WithStore(st)}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("creating client: %w", err)
+	}
+	return client, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/discochess/stockpile/internal/builder"
+	"github.com/discochess/stockpile/internal/shard"
+)
+
+var compactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Re-sort and dedup the shards of an existing database in place",
+	Long: `Repair a database written by an older build: each shard is re-sorted by FEN
+and records sharing a FEN are collapsed into the deepest evaluation, exactly
+as a full build would. Only shards that need it are rewritten, and the
+manifest's record counts and per-shard info are updated. Use --dry-run to
+only report how many duplicate and out-of-order records there are.
+
+Examples:
+  # See whether the database needs compacting
+  stockpile compact --data-dir ./data --dry-run
+
+  # Fix it without re-downloading the source
+  stockpile compact --data-dir ./data`,
+	Args: cobra.NoArgs,
+	RunE: runCompact,
+}
+
+var (
+	compactWorkers     int
+	compactMaxMemoryMB int
+	compactIndex       bool
+	compactDryRun      bool
+)
+
+func init() {
+	compactCmd.Flags().IntVar(&compactWorkers, "workers", 4, "number of shards compacted in parallel")
+	compactCmd.Flags().IntVar(&compactMaxMemoryMB, "max-memory", 1024, "max memory in MB per shard before spilling to disk")
+	compactCmd.Flags().BoolVar(&compactIndex, "index", false, "write a sparse offset index (.idx) next to each rewritten shard")
+	compactCmd.Flags().BoolVar(&compactDryRun, "dry-run", false, "only report duplicate and out-of-order records, do not rewrite shards")
+	rootCmd.AddCommand(compactCmd)
+}
+
+func runCompact(cmd *cobra.Command, args []string) error {
+	manifest, err := builder.ReadManifest(dataDir)
+	if err != nil {
+		return err
+	}
+	strategy, err := shard.New(manifest.Strategy)
+	if err != nil {
+		return fmt.Errorf("strategy in manifest: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	progress := builder.DefaultProgressFunc
+	if compactDryRun {
+		progress = nil
+	}
+	b := builder.NewBuilder(
+		builder.WithOutputDir(dataDir),
+		builder.WithTotalShards(manifest.TotalShards),
+		builder.WithStrategy(strategy),
+		builder.WithWorkers(compactWorkers),
+		builder.WithMaxMemoryMB(compactMaxMemoryMB),
+		builder.WithBuildIndex(compactIndex),
+		builder.WithProgress(progress),
+	)
+
+	report, err := b.Compact(ctx, compactDryRun)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Scanned %d shards in %s\n", report.ShardsScanned, dataDir)
+	fmt.Printf("  Duplicates:   %d\n", report.Duplicates)
+	fmt.Printf("  Out of order: %d\n", report.OutOfOrder)
+	switch {
+	case report.Duplicates == 0 && report.OutOfOrder == 0:
+		fmt.Println("Nothing to compact")
+	case compactDryRun:
+		fmt.Println("Dry run, no shards rewritten")
+	default:
+		fmt.Printf("Rewrote %d shards\n", report.ShardsRewritten)
+	}
+	return nil
+}
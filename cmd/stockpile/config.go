@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// configFile is the --config flag; empty means the default location.
+var configFile string
+
+// configFlags maps config keys to the command flags they provide defaults
+// for. A key applies to every command that defines the flag.
+var configFlags = map[string]string{
+	"data-dir":       "data-dir",
+	"cache-size":     "cache-size",
+	"cache-strategy": "cache-strategy",
+	"strategy":       "strategy",
+	"compression":    "compression-level",
+}
+
+// defaultConfigPath returns ~/.config/stockpile/config.yaml, or the
+// platform equivalent.
+func defaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "stockpile", "config.yaml"), nil
+}
+
+// loadConfig reads the config file and STOCKPILE_* environment variables.
+// A missing default config file is not an error; a missing --config is.
+func loadConfig() (*viper.Viper, error) {
+	v := viper.New()
+	v.SetEnvPrefix("stockpile")
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.AutomaticEnv()
+	// STOCKPILE_DATA predates the config layer and is used by the examples.
+	if err := v.BindEnv("data-dir", "STOCKPILE_DATA_DIR", "STOCKPILE_DATA"); err != nil {
+		return nil, err
+	}
+
+	path := configFile
+	if path == "" {
+		p, err := defaultConfigPath()
+		if err != nil {
+			return v, nil
+		}
+		if _, err := os.Stat(p); errors.Is(err, os.ErrNotExist) {
+			return v, nil
+		}
+		path = p
+	}
+
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
+	}
+	return v, nil
+}
+
+// applyConfig fills in flags the user did not pass on the command line from
+// the config file and environment, so flags take precedence over env vars,
+// which take precedence over the config file and then the flag defaults.
+func applyConfig(cmd *cobra.Command, args []string) error {
+	v, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	for key, name := range configFlags {
+		f := cmd.Flags().Lookup(name)
+		if f == nil || f.Changed || !v.IsSet(key) {
+			continue
+		}
+		if err := cmd.Flags().Set(name, v.GetString(key)); err != nil {
+			return fmt.Errorf("config %s: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/discochess/stockpile"
+	"github.com/discochess/stockpile/benchmark/pgn"
+	"github.com/discochess/stockpile/internal/codec/detect"
+)
+
+var coverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Report what fraction of the positions in a PGN file are in the database",
+	Long: `Replay the games in a PGN file and report how many of their positions
+the evaluation database covers: overall, per game and, with --by-move, by
+move number, which shows how coverage falls off from the opening into the
+endgame. Each game is looked up as one batch. Compressed PGNs (zstd, gzip,
+bzip2) are detected automatically.
+
+Move numbers count from the first position of each game, so games that
+start from a FEN tag are binned as if they began at move 1.
+
+Examples:
+  # Coverage of every game
+  stockpile coverage --pgn games.pgn
+
+  # Coverage in 5-move bins, as JSON
+  stockpile coverage --pgn games.pgn.zst --by-move --bin-size 5 --json`,
+	RunE: runCoverage,
+}
+
+var (
+	coveragePGN      string
+	coverageMaxGames int
+	coverageByMove   bool
+	coverageBinSize  int
+	coverageJSON     bool
+)
+
+func init() {
+	coverageCmd.Flags().StringVar(&coveragePGN, "pgn", "", "PGN file to check")
+	coverageCmd.Flags().IntVar(&coverageMaxGames, "games", 0, "maximum games to check (0 = all)")
+	coverageCmd.Flags().BoolVar(&coverageByMove, "by-move", false, "also report coverage by move number")
+	coverageCmd.Flags().IntVar(&coverageBinSize, "bin-size", 10, "moves per bin with --by-move")
+	coverageCmd.Flags().BoolVar(&coverageJSON, "json", false, "output results as JSON")
+	coverageCmd.MarkFlagRequired("pgn")
+	rootCmd.AddCommand(coverageCmd)
+}
+
+// moveCoverage is the coverage of the positions before moves First..Last.
+type moveCoverage struct {
+	First     int     `json:"first_move"`
+	Last      int     `json:"last_move"`
+	Positions int     `json:"positions"`
+	Found     int     `json:"found"`
+	Coverage  float64 `json:"coverage_pct"`
+}
+
+// coverageReport is the result of coverage, printed as text or JSON.
+type coverageReport struct {
+	Games          []gameCoverage `json:"games"`
+	TotalPositions int            `json:"total_positions"`
+	Found          int            `json:"found"`
+	NotFound       int            `json:"not_found"`
+	Coverage       float64        `json:"coverage_pct"`
+	ByMove         []moveCoverage `json:"by_move,omitempty"`
+}
+
+func runCoverage(cmd *cobra.Command, args []string) error {
+	if coverageBinSize < 1 {
+		return fmt.Errorf("--bin-size must be at least 1, got %d", coverageBinSize)
+	}
+
+	dataOpt, err := stockpile.WithDataDir(dataDir)
+	if err != nil {
+		return fmt.Errorf("opening data directory: %w", err)
+	}
+	client, err := stockpile.New(dataOpt)
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	defer client.Close()
+
+	file, err := os.Open(coveragePGN)
+	if err != nil {
+		return fmt.Errorf("opening PGN file: %w", err)
+	}
+	defer file.Close()
+
+	reader, _, err := detect.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("detecting PGN compression: %w", err)
+	}
+	defer reader.Close()
+
+	ctx := context.Background()
+	report := &coverageReport{Games: []gameCoverage{}}
+
+	err = pgn.StreamGames(reader, func(fens []string) error {
+		cov := gameCoverage{Game: len(report.Games) + 1, Positions: len(fens)}
+		_, errs := client.LookupBatch(ctx, fens)
+		for i, err := range errs {
+			found := err == nil
+			if err != nil && !isNotFound(err) {
+				return fmt.Errorf("game %d: looking up %q: %w", cov.Game, fens[i], err)
+			}
+			if found {
+				cov.Found++
+			}
+			if coverageByMove {
+				report.addByMove(i, found)
+			}
+		}
+		cov.HitRate = percent(cov.Found, cov.Positions)
+
+		report.Games = append(report.Games, cov)
+		report.TotalPositions += cov.Positions
+		report.Found += cov.Found
+
+		if coverageMaxGames > 0 && len(report.Games) >= coverageMaxGames {
+			return errAnalyzeDone
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errAnalyzeDone) {
+		return fmt.Errorf("checking games: %w", err)
+	}
+
+	report.NotFound = report.TotalPositions - report.Found
+	report.Coverage = percent(report.Found, report.TotalPositions)
+	for i := range report.ByMove {
+		b := &report.ByMove[i]
+		b.Coverage = percent(b.Found, b.Positions)
+	}
+
+	if coverageJSON {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("encoding JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	for _, g := range report.Games {
+		fmt.Printf("Game %d: %d/%d positions found (%.1f%%)\n", g.Game, g.Found, g.Positions, g.HitRate)
+	}
+	fmt.Println()
+	fmt.Printf("Games:           %d\n", len(report.Games))
+	fmt.Printf("Total positions: %d\n", report.TotalPositions)
+	fmt.Printf("Found:           %d (%.1f%%)\n", report.Found, report.Coverage)
+	fmt.Printf("Not found:       %d\n", report.NotFound)
+
+	if len(report.ByMove) > 0 {
+		fmt.Println()
+		fmt.Printf("%-9s %9s %9s %9s\n", "Moves", "Positions", "Found", "Coverage")
+		for _, b := range report.ByMove {
+			if b.Positions == 0 {
+				continue
+			}
+			line := fmt.Sprintf("%-9s %9d %9d %8.1f%% %s",
+				fmt.Sprintf("%d-%d", b.First, b.Last), b.Positions, b.Found, b.Coverage,
+				strings.Repeat("#", int(b.Coverage/5)))
+			fmt.Println(strings.TrimRight(line, " "))
+		}
+	}
+	return nil
+}
+
+// addByMove counts the position at index ply of a game, which is the
+// position before move ply/2+1, in its --by-move bin.
+func (r *coverageReport) addByMove(ply int, found bool) {
+	bin := ply / 2 / coverageBinSize
+	for len(r.ByMove) <= bin {
+		first := len(r.ByMove)*coverageBinSize + 1
+		r.ByMove = append(r.ByMove, moveCoverage{First: first, Last: first + coverageBinSize - 1})
+	}
+	r.ByMove[bin].Positions++
+	if found {
+		r.ByMove[bin].Found++
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/discochess/stockpile/internal/builder"
+	"github.com/discochess/stockpile/internal/search"
+	"github.com/discochess/stockpile/internal/shard"
+	"github.com/discochess/stockpile/internal/store"
+	"github.com/discochess/stockpile/internal/store/diskstore"
+)
+
+var dumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Write a shard's records as plaintext JSONL",
+	Long: `Decompress a shard and write its JSONL records, in stored order, to
+stdout or --output. This is useful when diagnosing sort or encoding issues.
+
+With --fen, only the matching record is written, pretty-printed. If --shard
+is omitted, the shard is derived from the FEN using the manifest's strategy.
+
+Examples:
+  # Dump shard 42
+  stockpile dump --shard 42 --output shard42.jsonl
+
+  # Show the stored record for a position
+  stockpile dump --fen "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -"`,
+	RunE: runDump,
+}
+
+var (
+	dumpShard  int
+	dumpFEN    string
+	dumpOutput string
+)
+
+func init() {
+	dumpCmd.Flags().IntVar(&dumpShard, "shard", -1, "shard ID to dump (derived from --fen when omitted)")
+	dumpCmd.Flags().StringVar(&dumpFEN, "fen", "", "only write the record for this FEN, pretty-printed")
+	dumpCmd.Flags().StringVarP(&dumpOutput, "output", "o", "", "output file (default: stdout)")
+	rootCmd.AddCommand(dumpCmd)
+}
+
+func runDump(cmd *cobra.Command, args []string) error {
+	shardID := dumpShard
+	if shardID < 0 {
+		if dumpFEN == "" {
+			return fmt.Errorf("pass --shard, --fen, or both")
+		}
+		manifest, err := builder.ReadManifest(dataDir)
+		if err != nil {
+			return fmt.Errorf("deriving shard from --fen: %w", err)
+		}
+		strategy, err := shard.New(manifest.Strategy)
+		if err != nil {
+			return fmt.Errorf("strategy in manifest: %w", err)
+		}
+		shardID = strategy.ShardID(dumpFEN, manifest.TotalShards)
+		if verbose {
+			fmt.Fprintf(os.Stderr, "FEN is in shard %d\n", shardID)
+		}
+	}
+
+	c, err := dataDirCodec()
+	if err != nil {
+		return err
+	}
+	st, err := diskstore.New(dataDir, c)
+	if err != nil {
+		return fmt.Errorf("opening data directory: %w", err)
+	}
+	defer st.Close()
+
+	data, err := st.ReadShard(context.Background(), shardID)
+	if errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("shard %d does not exist in %s", shardID, dataDir)
+	}
+	if err != nil {
+		return fmt.Errorf("reading shard %d: %w", shardID, err)
+	}
+
+	var w io.Writer = os.Stdout
+	if dumpOutput != "" {
+		f, err := os.Create(dumpOutput)
+		if err != nil {
+			return fmt.Errorf("creating output file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if dumpFEN == "" {
+		_, err := w.Write(data)
+		return err
+	}
+
+	line, ok := search.Line(data, dumpFEN)
+	if !ok {
+		return fmt.Errorf("position not found in shard %d", shardID)
+	}
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, line, "", "  "); err != nil {
+		return fmt.Errorf("formatting record: %w", err)
+	}
+	pretty.WriteByte('\n')
+	_, err = w.Write(pretty.Bytes())
+	return err
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/discochess/stockpile"
+	"github.com/discochess/stockpile/internal/fen"
+	"github.com/discochess/stockpile/internal/polyglot"
+	"github.com/discochess/stockpile/internal/search"
+)
+
+var exportBookCmd = &cobra.Command{
+	Use:   "export-book",
+	Short: "Export best moves as a Polyglot opening book",
+	Long: `Export the database as a Polyglot (.bin) opening book.
+
+Each position contributes one entry: the first move of its best line,
+weighted by evaluation depth and win probability.
+
+By default every shard is scanned. With --fens, only the listed positions
+(one FEN per line) are looked up, which is much faster for small books.
+
+Filters keep the book to openings:
+  --min-pieces  skip positions with fewer pieces on the board (kings count)
+  --max-ply     skip positions past this ply; requires --fens with full
+                FENs, since the database does not store move numbers
+
+Examples:
+  # Positions with at least 28 pieces
+  stockpile export-book --min-pieces 28 --output openings.bin
+
+  # The first 20 plies of positions from a FEN list
+  stockpile export-book --fens positions.txt --max-ply 20`,
+	Args: cobra.NoArgs,
+	RunE: runExportBook,
+}
+
+var (
+	bookOutput    string
+	bookFENs      string
+	bookMinPieces int
+	bookMaxPly    int
+)
+
+func init() {
+	exportBookCmd.Flags().StringVarP(&bookOutput, "output", "o", "book.bin", "output book file")
+	exportBookCmd.Flags().StringVar(&bookFENs, "fens", "", "file of FENs to export instead of scanning all shards")
+	exportBookCmd.Flags().IntVar(&bookMinPieces, "min-pieces", 0, "minimum pieces on the board, including kings (0 = no limit)")
+	exportBookCmd.Flags().IntVar(&bookMaxPly, "max-ply", 0, "maximum ply from the start of the game (0 = no limit; requires --fens)")
+	rootCmd.AddCommand(exportBookCmd)
+}
+
+func runExportBook(cmd *cobra.Command, args []string) error {
+	if bookMaxPly > 0 && bookFENs == "" {
+		return fmt.Errorf("--max-ply requires --fens")
+	}
+
+	var entries []polyglot.Entry
+	var err error
+	if bookFENs != "" {
+		entries, err = bookEntriesFromFENs(cmd.Context(), bookFENs)
+	} else {
+		entries, err = bookEntriesFromShards()
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(bookOutput)
+	if err != nil {
+		return fmt.Errorf("creating book: %w", err)
+	}
+	if err := polyglot.WriteBook(f, entries); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing book: %w", err)
+	}
+
+	fmt.Printf("Wrote %d entries to %s\n", len(entries), bookOutput)
+	return nil
+}
+
+// bookEntriesFromFENs looks up each FEN listed in path.
+func bookEntriesFromFENs(ctx context.Context, path string) ([]polyglot.Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening FEN list: %w", err)
+	}
+	defer f.Close()
+
+	dataOpt, err := stockpile.WithDataDir(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("opening data directory: %w", err)
+	}
+	client, err := stockpile.New(dataOpt)
+	if err != nil {
+		return nil, fmt.Errorf("creating client: %w", err)
+	}
+	defer client.Close()
+
+	var entries []polyglot.Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || !keepBookPosition(line) {
+			continue
+		}
+		if bookMaxPly > 0 {
+			ply, ok := fenPly(line)
+			if !ok || ply > bookMaxPly {
+				continue
+			}
+		}
+
+		normalized, err := fen.NormalizeStrict(line)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipping %q: %v\n", line, err)
+			continue
+		}
+		eval, err := client.Lookup(ctx, normalized)
+		if errors.Is(err, stockpile.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("looking up %q: %w", normalized, err)
+		}
+		if pv := eval.BestPV(); pv != nil {
+			if e, ok := bookEntry(eval.FEN, eval.Depth, pv); ok {
+				entries = append(entries, e)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading FEN list: %w", err)
+	}
+	return entries, nil
+}
+
+// bookEntriesFromShards scans every shard in the data directory.
+func bookEntriesFromShards() ([]polyglot.Entry, error) {
+	c, err := dataDirCodec()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dataDir, "shards", "*"+shardExtension(c)))
+	if err != nil {
+		return nil, fmt.Errorf("listing shards: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no shards found in %q", dataDir)
+	}
+
+	var entries []polyglot.Entry
+	for i, path := range paths {
+		if verbose {
+			fmt.Printf("  [%d/%d] %s\n", i+1, len(paths), filepath.Base(path))
+		}
+
+		compressed, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading shard: %w", err)
+		}
+		r, err := c.Reader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, fmt.Errorf("%s: creating decompressor: %w", filepath.Base(path), err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: decompressing: %w", filepath.Base(path), err)
+		}
+
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if fenStr := extractFENForVerify(line); fenStr == "" || !keepBookPosition(fenStr) {
+				continue
+			}
+
+			var record search.EvalRecord
+			if err := json.Unmarshal(line, &record); err != nil {
+				return nil, fmt.Errorf("%s: parsing record: %w", filepath.Base(path), err)
+			}
+			if len(record.Evals) == 0 || len(record.Evals[0].PVs) == 0 {
+				continue
+			}
+			best := record.Evals[0]
+			pv := &stockpile.PV{
+				Centipawns: best.PVs[0].CP,
+				Mate:       best.PVs[0].Mate,
+				Line:       best.PVs[0].Line,
+			}
+			if e, ok := bookEntry(record.FEN, best.Depth, pv); ok {
+				entries = append(entries, e)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// keepBookPosition applies the --min-pieces filter.
+func keepBookPosition(fenStr string) bool {
+	if bookMinPieces <= 0 {
+		return true
+	}
+	m, err := fen.ParseMaterial(fenStr, fen.WithKings())
+	if err != nil {
+		return false
+	}
+	pieces := m.WhiteKings + m.BlackKings +
+		m.WhitePawns + m.WhiteKnights + m.WhiteBishops + m.WhiteRooks + m.WhiteQueens +
+		m.BlackPawns + m.BlackKnights + m.BlackBishops + m.BlackRooks + m.BlackQueens
+	return pieces >= bookMinPieces
+}
+
+// fenPly returns the number of half-moves played before a position, from
+// its fullmove number and side to move. It reports false if the FEN has no
+// move counters.
+func fenPly(fenStr string) (int, bool) {
+	parts := strings.Fields(fenStr)
+	if len(parts) < 6 {
+		return 0, false
+	}
+	fullmove, err := strconv.Atoi(parts[5])
+	if err != nil || fullmove < 1 {
+		return 0, false
+	}
+	ply := 2 * (fullmove - 1)
+	if parts[1] == "b" {
+		ply++
+	}
+	return ply, true
+}
+
+// bookEntry builds the book entry for a position's best line. It reports
+// false if the line has no moves or cannot be encoded.
+func bookEntry(fenStr string, depth int, pv *stockpile.PV) (polyglot.Entry, bool) {
+	moves := strings.Fields(pv.Line)
+	if len(moves) == 0 {
+		return polyglot.Entry{}, false
+	}
+	key, err := fen.Zobrist(fenStr)
+	if err != nil {
+		return polyglot.Entry{}, false
+	}
+	move, err := polyglot.EncodeMove(fenStr, moves[0])
+	if err != nil {
+		return polyglot.Entry{}, false
+	}
+	return polyglot.Entry{
+		Key:    key,
+		Move:   move,
+		Weight: polyglot.Weight(depth, pv.WinProbability()),
+	}, true
+}
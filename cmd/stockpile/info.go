@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/discochess/stockpile/internal/builder"
+)
+
+var infoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Summarize the manifest and on-disk state of a data directory",
+	Long: `Print what a data directory contains: the manifest's version, strategy,
+shard counts, record count, build time, source and compression, followed by
+the shard files actually present and their total size.
+
+Mismatches between the manifest and the shards directory are flagged, which
+makes this the first thing to run when a database seems wrong.
+
+With --balance, also show how evenly records are spread across shards:
+min/median/max record counts and sizes, their Gini coefficients, and the
+largest shards. Hot shards hurt cache locality.`,
+	RunE: runInfo,
+}
+
+var infoBalance bool
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+	infoCmd.Flags().BoolVar(&infoBalance, "balance", false, "show the distribution of shard record counts and sizes")
+}
+
+func runInfo(cmd *cobra.Command, args []string) error {
+	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
+		return fmt.Errorf("data directory %q does not exist; run 'stockpile build' first", dataDir)
+	}
+
+	fmt.Printf("Data directory: %s\n", dataDir)
+	fmt.Println()
+
+	manifest, err := builder.ReadManifest(dataDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if manifest != nil {
+		compression := manifest.Compression
+		if manifest.CompressionLevel != "" {
+			compression += " (" + manifest.CompressionLevel + ")"
+		}
+		fmt.Println("Manifest:")
+		fmt.Printf("  Version:        %d\n", manifest.Version)
+		fmt.Printf("  Strategy:       %s\n", manifest.Strategy)
+		fmt.Printf("  Total shards:   %d\n", manifest.TotalShards)
+		fmt.Printf("  Created shards: %d\n", manifest.ShardCount)
+		fmt.Printf("  Records:        %d\n", manifest.RecordCount)
+		fmt.Printf("  Built at:       %s\n", manifest.BuiltAt.Format(time.RFC3339))
+		if manifest.SourceURL != "" {
+			fmt.Printf("  Source:         %s\n", manifest.SourceURL)
+		}
+		fmt.Printf("  Compression:    %s\n", compression)
+		if manifest.Dictionary != "" {
+			fmt.Printf("  Dictionary:     %s\n", manifest.Dictionary)
+		}
+		if manifest.FrameSize > 0 {
+			fmt.Printf("  Frame size:     %s\n", builder.FormatBytes(int64(manifest.FrameSize)))
+		}
+		if infoBalance {
+			fmt.Println()
+			printBalance(manifestBalance(manifest))
+		}
+	} else {
+		fmt.Println("Manifest: not found")
+	}
+	fmt.Println()
+
+	c, err := dataDirCodec()
+	if err != nil {
+		return err
+	}
+	ext := shardExtension(c)
+
+	// Collect shard files for the codec and their total size.
+	ids, err := dataDirShards(cmd.Context(), c)
+	if err != nil {
+		return err
+	}
+	var files []string
+	var totalSize int64
+	for _, id := range ids {
+		name := shardFilename(id, c)
+		files = append(files, name)
+		if info, err := os.Stat(filepath.Join(dataDir, "shards", name)); err == nil {
+			totalSize += info.Size()
+		}
+	}
+
+	fmt.Println("On disk:")
+	fmt.Printf("  Shard files:    %d\n", len(files))
+	fmt.Printf("  Total size:     %s\n", builder.FormatBytes(totalSize))
+
+	problems := infoProblems(manifest, files, ext)
+	if len(problems) == 0 {
+		return nil
+	}
+	fmt.Println()
+	fmt.Println("Discrepancies:")
+	for _, p := range problems {
+		fmt.Printf("  WARNING: %s\n", p)
+	}
+	return nil
+}
+
+// manifestBalance returns the manifest's balance summary, computing it for
+// manifests written before builds recorded one.
+func manifestBalance(m *builder.Manifest) *builder.Balance {
+	if m.Balance != nil {
+		return m.Balance
+	}
+	return builder.ComputeBalance(m.Shards)
+}
+
+// printBalance prints a shard balance summary.
+func printBalance(b *builder.Balance) {
+	fmt.Println("Balance:")
+	if b == nil {
+		fmt.Println("  not available; the manifest lists no shards")
+		return
+	}
+	fmt.Printf("  Records:        min %d, median %d, max %d (Gini %.3f)\n",
+		b.Records.Min, b.Records.Median, b.Records.Max, b.Records.Gini)
+	fmt.Printf("  Size:           min %s, median %s, max %s (Gini %.3f)\n",
+		builder.FormatBytes(b.Size.Min), builder.FormatBytes(b.Size.Median),
+		builder.FormatBytes(b.Size.Max), b.Size.Gini)
+	fmt.Println("  Largest shards:")
+	for _, s := range b.Largest {
+		fmt.Printf("    %05d  %d records, %s\n", s.ID, s.RecordCount, builder.FormatBytes(s.UncompressedSize))
+	}
+}
+
+// infoMaxListed caps how many missing or unexpected shard files are named
+// individually before the rest are summarized.
+const infoMaxListed = 10
+
+// infoProblems compares the manifest against the shard files present and
+// describes each mismatch.
+func infoProblems(manifest *builder.Manifest, files []string, ext string) []string {
+	if manifest == nil {
+		if len(files) > 0 {
+			return []string{"shard files present but no manifest.json; lookups will assume default settings"}
+		}
+		return []string{"no manifest.json and no shard files; run 'stockpile build' first"}
+	}
+
+	var problems []string
+	if manifest.ShardCount != len(files) {
+		problems = append(problems, fmt.Sprintf("manifest lists %d created shards but %d shard files are present",
+			manifest.ShardCount, len(files)))
+	}
+
+	if len(manifest.Shards) == 0 {
+		return problems
+	}
+
+	present := make(map[string]bool, len(files))
+	for _, name := range files {
+		present[name] = true
+	}
+	expected := make(map[string]bool, len(manifest.Shards))
+	var missing []string
+	for _, si := range manifest.Shards {
+		name := fmt.Sprintf("%05d", si.ID) + ext
+		expected[name] = true
+		if !present[name] {
+			missing = append(missing, name)
+		}
+	}
+	var unexpected []string
+	for _, name := range files {
+		if !expected[name] {
+			unexpected = append(unexpected, name)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(unexpected)
+
+	if len(missing) > 0 {
+		problems = append(problems, "missing from disk: "+listNames(missing))
+	}
+	if len(unexpected) > 0 {
+		problems = append(problems, "not in manifest: "+listNames(unexpected))
+	}
+	return problems
+}
+
+func listNames(names []string) string {
+	if len(names) <= infoMaxListed {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:infoMaxListed], ", "), len(names)-infoMaxListed)
+}
@@ -1,20 +1,20 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/discochess/stockpile"
-	"github.com/discochess/stockpile/internal/codec/zstdcodec"
-	"github.com/discochess/stockpile/internal/store/cachedstore"
-	"github.com/discochess/stockpile/internal/store/cachedstore/cachestrategy/lru"
-	"github.com/discochess/stockpile/internal/store/cachedstore/memory"
-	"github.com/discochess/stockpile/internal/store/diskstore"
+	"github.com/discochess/stockpile/internal/store"
 )
 
 var lookupCmd = &cobra.Command{
@@ -25,55 +25,74 @@ var lookupCmd = &cobra.Command{
 The FEN string should include at least the piece placement and side to move.
 Castling rights and en passant square are optional.
 
+With --file, or with no FEN argument, FENs are read one per line from the
+file or stdin and looked up in batches, so positions sharing a shard fetch
+it once. One result line is printed per input (NDJSON with --json), followed
+by a found/not-found summary on stderr. Blank lines and lines starting with
+# are skipped.
+
 Examples:
   # Starting position
   stockpile lookup "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -"
 
   # After 1.e4
-  stockpile lookup "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3"`,
-	Args: cobra.ExactArgs(1),
+  stockpile lookup "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3"
+
+  # Many positions from a file, as NDJSON
+  stockpile lookup --file positions.txt --json
+
+  # Many positions from stdin
+  cat positions.txt | stockpile lookup`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runLookup,
 }
 
 var (
-	outputJSON bool
-	showTiming bool
+	outputJSON          bool
+	showTiming          bool
+	lookupFile          string
+	lookupCacheSize     int
+	lookupCacheStrategy string
 )
 
+// lookupBatchSize is the number of FENs passed to each LookupBatch call
+// when reading from a file or stdin.
+const lookupBatchSize = 1000
+
 func init() {
 	lookupCmd.Flags().BoolVar(&outputJSON, "json", false, "output result as JSON")
+	lookupCmd.Flags().StringVar(&lookupCacheStrategy, "cache-strategy", "lru", cacheStrategyUsage)
+	lookupCmd.Flags().IntVar(&lookupCacheSize, "cache-size", 100, cacheSizeUsage)
+	lookupCmd.Flags().StringVar(&lookupFile, "file", "", "read FENs from this file, one per line (default: stdin when no FEN is given)")
 	lookupCmd.Flags().BoolVar(&showTiming, "timing", false, "show lookup timing")
 	rootCmd.AddCommand(lookupCmd)
 }
 
 func runLookup(cmd *cobra.Command, args []string) error {
-	fen := args[0]
-
-	// Check if data directory exists.
-	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
-		return fmt.Errorf("data directory %q does not exist; run 'stockpile build' first", dataDir)
+	if len(args) == 1 && lookupFile != "" {
+		return fmt.Errorf("pass either a FEN or --file, not both")
 	}
 
-	// Create store with caching.
-	baseStore, err := diskstore.New(dataDir, zstdcodec.New())
+	client, err := newLookupClient()
 	if err != nil {
-		return fmt.Errorf("opening data directory: %w", err)
+		return err
 	}
+	defer client.Close()
 
-	lruStrategy, err := lru.New(100)
-	if err != nil {
-		return fmt.Errorf("creating LRU strategy: %w", err)
+	if len(args) == 0 {
+		in := io.Reader(os.Stdin)
+		if lookupFile != "" {
+			f, err := os.Open(lookupFile)
+			if err != nil {
+				return fmt.Errorf("opening FEN file: %w", err)
+			}
+			defer f.Close()
+			in = f
+		}
+		return runLookupBatch(client, in)
 	}
-	st := cachedstore.New(baseStore, memory.New(lruStrategy, nil))
 
-	// Create client.
-	client, err := stockpile.New(
-		stockpile.WithStore(st),
-	)
-	if err != nil {
-		return fmt.Errorf("creating client: %w", err)
-	}
-	defer client.Close()
+	fen := args[0]
 
 	// Perform lookup.
 	ctx := context.Background()
@@ -99,6 +118,127 @@ func runLookup(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// newLookupClient opens the data directory behind a shard cache.
+func newLookupClient() (*stockpile.Client, error) {
+	// Check if data directory exists.
+	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("data directory %q does not exist; run 'stockpile build' first", dataDir)
+	}
+
+	dataOpt, err := stockpile.WithDataDir(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("opening data directory: %w", err)
+	}
+	return newCachedClient(dataOpt, lookupCacheStrategy, lookupCacheSize, nil)
+}
+
+// runLookupBatch looks up every FEN read from r, one per line, printing a
+// result line per input and a summary to stderr.
+func runLookupBatch(client *stockpile.Client, r io.Reader) error {
+	ctx := context.Background()
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	enc := json.NewEncoder(out)
+
+	var found, notFound, failed int
+	start := time.Now()
+
+	flush := func(fens []string) error {
+		evals, errs := client.LookupBatch(ctx, fens)
+		for i, fen := range fens {
+			err := errs[i]
+			switch {
+			case err == nil:
+				found++
+			case isNotFound(err):
+				notFound++
+			default:
+				failed++
+			}
+
+			if outputJSON {
+				if err != nil {
+					if encErr := enc.Encode(lookupErrorJSON{FEN: fen, Error: lookupErrorText(err)}); encErr != nil {
+						return fmt.Errorf("encoding JSON: %w", encErr)
+					}
+					continue
+				}
+				if encErr := enc.Encode(evals[i]); encErr != nil {
+					return fmt.Errorf("encoding JSON: %w", encErr)
+				}
+				continue
+			}
+
+			if err != nil {
+				fmt.Fprintf(out, "%s\t%s\n", fen, lookupErrorText(err))
+				continue
+			}
+			fmt.Fprintf(out, "%s\t%s\t%d\n", fen, evals[i].Score(), evals[i].Depth)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	batch := make([]string, 0, lookupBatchSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		batch = append(batch, line)
+		if len(batch) == lookupBatchSize {
+			if err := flush(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading FENs: %w", err)
+	}
+	if len(batch) > 0 {
+		if err := flush(batch); err != nil {
+			return err
+		}
+	}
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("writing results: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Found: %d, not found: %d", found, notFound)
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, ", failed: %d", failed)
+	}
+	if showTiming {
+		fmt.Fprintf(os.Stderr, " (%s)", time.Since(start))
+	}
+	fmt.Fprintln(os.Stderr)
+
+	if failed > 0 {
+		return fmt.Errorf("%d lookups failed", failed)
+	}
+	return nil
+}
+
+// lookupErrorJSON is the NDJSON line for a position that was not returned.
+type lookupErrorJSON struct {
+	FEN   string `json:"fen"`
+	Error string `json:"error"`
+}
+
+func lookupErrorText(err error) string {
+	if isNotFound(err) {
+		return "not found"
+	}
+	return err.Error()
+}
+
+// isNotFound reports whether err means the position is absent. As with
+// Client.Contains, a missing shard counts as the position being absent.
+func isNotFound(err error) bool {
+	return errors.Is(err, stockpile.ErrNotFound) || errors.Is(err, store.ErrNotFound)
+}
+
 func printEvalText(eval *stockpile.Eval, elapsed time.Duration) {
 	fmt.Printf("FEN:   %s\n", eval.FEN)
 	fmt.Printf("Score: %s\n", eval.Score())
@@ -111,24 +251,40 @@ func printEvalText(eval *stockpile.Eval, elapsed time.Duration) {
 	}
 }
 
-func printEvalJSON(eval *stockpile.Eval, elapsed time.Duration) {
-	fmt.Printf(`{"fen":%q,"score":%q,"depth":%d,"pvs":[`, eval.FEN, eval.Score(), eval.Depth)
-	for i, pv := range eval.PVs {
-		if i > 0 {
-			fmt.Print(",")
-		}
-		fmt.Print("{")
-		if pv.Centipawns != nil {
-			fmt.Printf(`"cp":%d,`, *pv.Centipawns)
-		}
-		if pv.Mate != nil {
-			fmt.Printf(`"mate":%d,`, *pv.Mate)
-		}
-		fmt.Printf(`"line":%q}`, pv.Line)
+// timedEvalJSON is the JSON form of an evaluation followed by the lookup
+// time.
+type timedEvalJSON struct {
+	Eval      *stockpile.Eval
+	ElapsedMS int64
+}
+
+// MarshalJSON encodes the evaluation as by Eval.MarshalJSON and appends an
+// elapsed_ms field to the object, keeping the evaluation's field order.
+func (t timedEvalJSON) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(t.Eval)
+	if err != nil {
+		return nil, err
 	}
-	fmt.Print("]")
+	if len(data) < 2 || data[len(data)-1] != '}' {
+		return nil, fmt.Errorf("evaluation is not a JSON object: %s", data)
+	}
+	if len(data) > 2 {
+		data[len(data)-1] = ','
+	} else {
+		data = data[:1]
+	}
+	return fmt.Appendf(data, `"elapsed_ms":%d}`, t.ElapsedMS), nil
+}
+
+func printEvalJSON(eval *stockpile.Eval, elapsed time.Duration) {
+	var out any = eval
 	if showTiming {
-		fmt.Printf(`,"elapsed_ms":%d`, elapsed.Milliseconds())
+		out = timedEvalJSON{Eval: eval, ElapsedMS: elapsed.Milliseconds()}
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "encoding JSON: %v\n", err)
+		return
 	}
-	fmt.Println("}")
+	fmt.Println(string(data))
 }
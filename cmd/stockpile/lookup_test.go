@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/discochess/stockpile"
+	"github.com/discochess/stockpile/internal/builder"
+	"github.com/discochess/stockpile/internal/shard/fnvshard"
+)
+
+func TestNewLookupClient_UsesManifest(t *testing.T) {
+	dir := t.TempDir()
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -",
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq -",
+		"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq -",
+		"8/8/8/8/8/8/8/4K2k w - -",
+	}
+	var source []byte
+	for i, fen := range fens {
+		source = fmt.Appendf(source, `{"fen":%q,"evals":[{"pvs":[{"cp":%d,"line":"e2e4"}],"knodes":10,"depth":20}]}`+"\n", fen, i)
+	}
+	sourcePath := filepath.Join(dir, "source.jsonl")
+	if err := os.WriteFile(sourcePath, source, 0644); err != nil {
+		t.Fatalf("writing source: %v", err)
+	}
+	b := builder.NewBuilder(
+		builder.WithOutputDir(dir),
+		builder.WithTotalShards(7),
+		builder.WithStrategy(fnvshard.New()),
+		builder.WithProgress(nil),
+	)
+	if err := b.BuildFromFile(context.Background(), sourcePath, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+
+	setFlag(t, &dataDir, dir)
+	setFlag(t, &lookupCacheStrategy, "lru")
+	setFlag(t, &lookupCacheSize, 10)
+
+	client, err := newLookupClient()
+	if err != nil {
+		t.Fatalf("newLookupClient() error = %v", err)
+	}
+	defer client.Close()
+
+	if got := client.ShardStrategy().Name(); got != "fnv32" {
+		t.Errorf("ShardStrategy().Name() = %q, want %q", got, "fnv32")
+	}
+	for i, fen := range fens {
+		eval, err := client.Lookup(context.Background(), fen)
+		if err != nil {
+			t.Errorf("Lookup(%q) error = %v", fen, err)
+			continue
+		}
+		if got := *eval.BestPV().Centipawns; got != i {
+			t.Errorf("Lookup(%q) cp = %d, want %d", fen, got, i)
+		}
+	}
+}
+
+func TestTimedEvalJSON(t *testing.T) {
+	cp := 20
+	eval := &stockpile.Eval{
+		FEN:    "8/8/8/8/8/8/8/4K2k w - -",
+		Depth:  30,
+		Knodes: 5,
+		PVs:    []stockpile.PV{{Centipawns: &cp, Line: "e1d1"}},
+	}
+	plain, err := json.Marshal(eval)
+	if err != nil {
+		t.Fatalf("Marshal(eval) error = %v", err)
+	}
+	got, err := json.Marshal(timedEvalJSON{Eval: eval, ElapsedMS: 1500})
+	if err != nil {
+		t.Fatalf("Marshal(timedEvalJSON) error = %v", err)
+	}
+	if want := strings.TrimSuffix(string(plain), "}") + `,"elapsed_ms":1500}`; string(got) != want {
+		t.Errorf("Marshal(timedEvalJSON) = %s, want %s", got, want)
+	}
+}
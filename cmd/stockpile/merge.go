@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/discochess/stockpile/internal/builder"
+	"github.com/discochess/stockpile/internal/shard"
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge DELTA",
+	Short: "Merge a JSONL delta into an existing database",
+	Long: `Fold new evaluations into a database built by 'stockpile build', rewriting
+only the shards that receive new records.
+
+For each position the deepest evaluation wins, exactly as in a full build,
+so merging a delta gives the same shards as rebuilding from the combined
+source. The strategy, shard count, compression level and frame size are
+taken from the database's manifest, whose record counts and per-shard info
+are updated. The delta may be plain or compressed JSONL.
+
+Examples:
+  # Apply today's new evaluations
+  stockpile merge --data-dir ./data ./evals-2025-06-01.jsonl.zst`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMerge,
+}
+
+var (
+	mergeWorkers     int
+	mergeMaxMemoryMB int
+	mergeIndex       bool
+	mergeSkipIllegal bool
+)
+
+func init() {
+	mergeCmd.Flags().IntVar(&mergeWorkers, "workers", 4, "number of parallel workers for sorting and compression")
+	mergeCmd.Flags().IntVar(&mergeMaxMemoryMB, "max-memory", 1024, "max memory in MB before spilling to disk")
+	mergeCmd.Flags().BoolVar(&mergeIndex, "index", false, "write a sparse offset index (.idx) next to each rewritten shard")
+	mergeCmd.Flags().BoolVar(&mergeSkipIllegal, "skip-illegal", false, "drop delta records whose FEN is not a legal position")
+	rootCmd.AddCommand(mergeCmd)
+}
+
+func runMerge(cmd *cobra.Command, args []string) error {
+	manifest, err := builder.ReadManifest(dataDir)
+	if err != nil {
+		return fmt.Errorf("%w; run 'stockpile build' first", err)
+	}
+	strategy, err := shard.New(manifest.Strategy)
+	if err != nil {
+		return fmt.Errorf("strategy in manifest: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	b := builder.NewBuilder(
+		builder.WithOutputDir(dataDir),
+		builder.WithTotalShards(manifest.TotalShards),
+		builder.WithStrategy(strategy),
+		builder.WithWorkers(mergeWorkers),
+		builder.WithMaxMemoryMB(mergeMaxMemoryMB),
+		builder.WithBuildIndex(mergeIndex),
+		builder.WithSkipIllegal(mergeSkipIllegal),
+		builder.WithStoreSymmetric(manifest.Symmetric),
+		builder.WithProgress(builder.DefaultProgressFunc),
+	)
+
+	fmt.Printf("Merging %s into %s\n", args[0], dataDir)
+	fmt.Printf("  Strategy:   %s\n", strategy.Name())
+	fmt.Printf("  Shards:     %d\n", manifest.TotalShards)
+	fmt.Printf("  Records:    %d\n", manifest.RecordCount)
+	fmt.Println()
+
+	return b.Merge(ctx, args[0])
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/discochess/stockpile/internal/builder"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete remote shards that are not in the manifest",
+	Long: `Reconcile a remote copy of the database against the manifest in the data
+directory: shard files under the remote shards/ prefix that the manifest does
+not list are deleted. Use --dry-run to only report them.
+
+This recovers bucket space after rebuilding with fewer shards.
+
+Examples:
+  # See what would be deleted
+  stockpile prune --gcs gs://my-bucket/stockpile --dry-run
+
+  # Delete stale shards from S3
+  stockpile prune --s3 s3://my-bucket/stockpile`,
+	RunE: runPrune,
+}
+
+var (
+	pruneGCS    string
+	pruneS3     string
+	pruneDryRun bool
+)
+
+func init() {
+	pruneCmd.Flags().StringVar(&pruneGCS, "gcs", "", "GCS path of the remote database (gs://bucket/prefix)")
+	pruneCmd.Flags().StringVar(&pruneS3, "s3", "", "S3 path of the remote database (s3://bucket/prefix)")
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "only report stale shards, do not delete them")
+	pruneCmd.MarkFlagsOneRequired("gcs", "s3")
+	pruneCmd.MarkFlagsMutuallyExclusive("gcs", "s3")
+	rootCmd.AddCommand(pruneCmd)
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	manifest, err := builder.ReadManifest(dataDir)
+	if err != nil {
+		return err
+	}
+	keep, err := builder.ManifestShardFiles(manifest)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	var remote builder.RemoteShards
+	var location string
+	if pruneGCS != "" {
+		uploader, err := builder.NewGCSUploader(ctx, pruneGCS)
+		if err != nil {
+			return fmt.Errorf("creating GCS client: %w", err)
+		}
+		defer uploader.Close()
+		remote, location = uploader, pruneGCS
+	} else {
+		s3Shards, err := builder.NewS3Shards(ctx, pruneS3)
+		if err != nil {
+			return fmt.Errorf("creating S3 client: %w", err)
+		}
+		remote, location = s3Shards, pruneS3
+	}
+
+	stale, err := builder.PruneShards(ctx, remote, keep, pruneDryRun)
+	for _, name := range stale {
+		if pruneDryRun {
+			fmt.Printf("would delete %s\n", name)
+		} else if verbose {
+			fmt.Printf("deleted %s\n", name)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("pruning %s: %w", location, err)
+	}
+
+	switch {
+	case len(stale) == 0:
+		fmt.Printf("No stale shards in %s\n", location)
+	case pruneDryRun:
+		fmt.Printf("%d stale shards in %s (dry run, nothing deleted)\n", len(stale), location)
+	default:
+		fmt.Printf("Deleted %d stale shards from %s\n", len(stale), location)
+	}
+	return nil
+}
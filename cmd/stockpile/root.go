@@ -1,7 +1,18 @@
 package main
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/spf13/cobra"
+
+	"github.com/discochess/stockpile/internal/builder"
+	"github.com/discochess/stockpile/internal/codec"
+	_ "github.com/discochess/stockpile/internal/codec/gzipcodec" // Register codecs for manifests.
+	_ "github.com/discochess/stockpile/internal/codec/lz4codec"
+	_ "github.com/discochess/stockpile/internal/codec/noopcodec"
+	"github.com/discochess/stockpile/internal/codec/zstdcodec"
+	"github.com/discochess/stockpile/internal/store/diskstore"
 )
 
 var (
@@ -27,10 +38,69 @@ Examples:
   stockpile build --output ./data
 
   # Show statistics
-  stockpile stats`,
+  stockpile stats
+
+Defaults for --data-dir, --cache-size, --cache-strategy, --strategy and
+--compression-level can be set in ~/.config/stockpile/config.yaml (keys
+data-dir, cache-size, cache-strategy, strategy, compression) or with
+STOCKPILE_DATA_DIR, STOCKPILE_CACHE_SIZE, STOCKPILE_CACHE_STRATEGY,
+STOCKPILE_STRATEGY and STOCKPILE_COMPRESSION. Flags take precedence over
+environment variables, which take precedence over the config file.`,
+	PersistentPreRunE: applyConfig,
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&dataDir, "data-dir", "d", "./data", "directory containing evaluation data")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose output")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "config file (default ~/.config/stockpile/config.yaml)")
+}
+
+// dataDirCodec returns the codec recorded in the data directory's manifest,
+// with its dictionary if it has one. Without a manifest, shards are assumed
+// to be zstd-compressed.
+func dataDirCodec() (codec.Codec, error) {
+	m, err := builder.ReadManifest(dataDir)
+	if err != nil {
+		m = &builder.Manifest{}
+	}
+	name := "zstd"
+	if m.Compression != "" {
+		name = m.Compression
+	}
+	c, err := codec.ByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("compression in manifest: %w", err)
+	}
+	dict, err := builder.ReadDictionary(dataDir, m)
+	if err != nil {
+		return nil, err
+	}
+	if dict != nil {
+		c = zstdcodec.NewWithDict(dict)
+	}
+	return c, nil
+}
+
+// dataDirShards returns the IDs of the shards in the data directory that are
+// stored with c, ignoring indexes and other files.
+func dataDirShards(ctx context.Context, c codec.Codec) ([]int, error) {
+	st, err := diskstore.New(dataDir, c)
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+	return st.ListShards(ctx)
+}
+
+// shardFilename returns the name of a shard file stored with c.
+func shardFilename(shardID int, c codec.Codec) string {
+	return fmt.Sprintf("%05d", shardID) + shardExtension(c)
+}
+
+// shardExtension returns the shard file suffix for a codec, including the dot.
+func shardExtension(c codec.Codec) string {
+	if ext := c.Extension(); ext != "" {
+		return "." + ext
+	}
+	return ""
 }
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/spf13/cobra"
+
+	"github.com/discochess/stockpile"
+	promstats "github.com/discochess/stockpile/internal/stats/prometheus"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve lookups over an HTTP JSON API",
+	Long: `Serve position lookups over HTTP so stockpile can be used from any language.
+
+Endpoints:
+  GET /lookup?fen=...   Evaluation as JSON (same shape as lookup --json)
+  GET /healthz          Liveness check
+  GET /metrics          Prometheus metrics
+
+With --shard-metrics, /metrics also reports hits and misses per shard
+(stockpile_shard_hits_total and stockpile_shard_misses_total, labeled by
+shard), to find hot shards worth pinning in cache.
+
+Unknown positions return 404. The server shuts down gracefully on SIGINT
+or SIGTERM, letting in-flight requests finish.
+
+Examples:
+  stockpile serve --data-dir ./data --addr :8080
+
+  curl 'localhost:8080/lookup?fen=rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR%20b%20KQkq%20-'`,
+	RunE: runServe,
+}
+
+var (
+	serveAddr          string
+	serveCacheSize     int
+	serveCacheStrategy string
+	serveShardMetrics  bool
+)
+
+// serveShutdownTimeout bounds how long shutdown waits for in-flight requests.
+const serveShutdownTimeout = 10 * time.Second
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8080", "address to listen on")
+	serveCmd.Flags().StringVar(&serveCacheStrategy, "cache-strategy", "lru", cacheStrategyUsage)
+	serveCmd.Flags().IntVar(&serveCacheSize, "cache-size", 100, cacheSizeUsage)
+	serveCmd.Flags().BoolVar(&serveShardMetrics, "shard-metrics", false, "report hits and misses per shard in /metrics")
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	dataOpt, err := stockpile.WithDataDir(dataDir)
+	if err != nil {
+		return fmt.Errorf("opening data directory: %w", err)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	collector := promstats.New(registry)
+
+	client, err := newCachedClient(dataOpt, serveCacheStrategy, serveCacheSize, collector,
+		stockpile.WithStats(collector),
+		stockpile.WithShardMetrics(serveShardMetrics),
+	)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /lookup", lookupHandler(client))
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.Handle("GET /metrics", collector.Handler())
+
+	srv := &http.Server{
+		Addr:              serveAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		fmt.Printf("Listening on %s\n", serveAddr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("serving: %w", err)
+	case <-ctx.Done():
+	}
+
+	fmt.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutting down: %w", err)
+	}
+	return nil
+}
+
+// lookupHandler serves GET /lookup?fen=... from client.
+func lookupHandler(client *stockpile.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fen := r.URL.Query().Get("fen")
+		if fen == "" {
+			writeJSONError(w, http.StatusBadRequest, "missing fen parameter")
+			return
+		}
+
+		eval, err := client.Lookup(r.Context(), fen)
+		if errors.Is(err, stockpile.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "position not found in database")
+			return
+		}
+		if errors.Is(err, stockpile.ErrInvalidFEN) {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, eval)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/discochess/stockpile"
+	"github.com/discochess/stockpile/internal/store/memstore"
+)
+
+func TestLookupHandler_Errors(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":0,"line":"e1d1"}],"knodes":1,"depth":1}]}`+"\n"))
+	client, err := stockpile.New(stockpile.WithStore(mem), stockpile.WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+	handler := lookupHandler(client)
+
+	tests := []struct {
+		name string
+		fen  string
+		want int
+	}{
+		{"missing", "", http.StatusBadRequest},
+		{"malformed", "not a fen", http.StatusBadRequest},
+		{"not found", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/lookup?fen="+url.QueryEscape(tt.fen), nil)
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -4,9 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/discochess/stockpile/internal/builder"
 )
 
 var statsCmd = &cobra.Command{
@@ -15,7 +16,8 @@ var statsCmd = &cobra.Command{
 	Long: `Display statistics about the evaluation database including:
 - Number of shards
 - Total size on disk
-- Compression ratio (if available)`,
+- Compression ratio (if available)
+- Per-shard record balance (if the manifest lists shards)`,
 	RunE: runStats,
 }
 
@@ -31,21 +33,20 @@ func runStats(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("data directory %q does not exist; run 'stockpile build' first", dataDir)
 	}
 
-	// List shard files.
-	entries, err := os.ReadDir(shardsDir)
+	c, err := dataDirCodec()
 	if err != nil {
-		return fmt.Errorf("reading shards directory: %w", err)
+		return err
 	}
 
-	// Filter to only .zst files and calculate total size.
-	var shardCount int
+	// List shard files for the codec and calculate total size.
+	ids, err := dataDirShards(cmd.Context(), c)
+	if err != nil {
+		return err
+	}
+	shardCount := len(ids)
 	var totalSize int64
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".zst") {
-			continue
-		}
-		shardCount++
-		info, err := entry.Info()
+	for _, id := range ids {
+		info, err := os.Stat(filepath.Join(shardsDir, shardFilename(id, c)))
 		if err != nil {
 			continue
 		}
@@ -62,6 +63,34 @@ func runStats(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Shards:         %d\n", shardCount)
 	fmt.Printf("Total size:     %s\n", formatBytes(totalSize))
 
+	manifest, err := builder.ReadManifest(dataDir)
+	if err != nil || len(manifest.Shards) == 0 {
+		return nil
+	}
+
+	var uncompressed int64
+	minShard, maxShard := manifest.Shards[0], manifest.Shards[0]
+	for _, si := range manifest.Shards {
+		uncompressed += si.UncompressedSize
+		if si.RecordCount < minShard.RecordCount {
+			minShard = si
+		}
+		if si.RecordCount > maxShard.RecordCount {
+			maxShard = si
+		}
+	}
+
+	fmt.Printf("Records:        %d\n", manifest.RecordCount)
+	fmt.Printf("Uncompressed:   %s\n", formatBytes(uncompressed))
+	if totalSize > 0 {
+		fmt.Printf("Compression:    %.1fx\n", float64(uncompressed)/float64(totalSize))
+	}
+	fmt.Println()
+	fmt.Printf("Shard balance (records per shard):\n")
+	fmt.Printf("  Min:  %d (shard %d)\n", minShard.RecordCount, minShard.ID)
+	fmt.Printf("  Max:  %d (shard %d)\n", maxShard.RecordCount, maxShard.ID)
+	fmt.Printf("  Avg:  %.1f\n", float64(manifest.RecordCount)/float64(len(manifest.Shards)))
+
 	return nil
 }
 
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/notnil/chess"
+	"github.com/spf13/cobra"
+
+	"github.com/discochess/stockpile"
+	"github.com/discochess/stockpile/internal/fen"
+)
+
+var uciCmd = &cobra.Command{
+	Use:   "uci",
+	Short: "Answer UCI engine commands from the database",
+	Long: `Run as a UCI engine so chess GUIs can consult the database.
+
+Commands are read from stdin. On "go", the current position is looked up
+and the stored evaluation is reported as "info" lines followed by
+"bestmove". Positions not in the database answer "bestmove 0000", the
+UCI null move. No search is performed.
+
+Supported commands: uci, isready, ucinewgame, position, go, stop, quit.
+
+Example:
+  printf 'uci\nposition startpos moves e2e4\ngo\nquit\n' | stockpile uci`,
+	Args: cobra.NoArgs,
+	RunE: runUCI,
+}
+
+func init() {
+	rootCmd.AddCommand(uciCmd)
+}
+
+// startFEN is the standard starting position.
+const startFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+func runUCI(cmd *cobra.Command, args []string) error {
+	dataOpt, err := stockpile.WithDataDir(dataDir)
+	if err != nil {
+		return fmt.Errorf("opening data directory: %w", err)
+	}
+	client, err := stockpile.New(dataOpt)
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	defer client.Close()
+
+	s := &uciSession{client: client, out: os.Stdout, fen: startFEN}
+	return s.run(cmd.Context(), os.Stdin)
+}
+
+// uciSession holds the state of a UCI conversation.
+type uciSession struct {
+	client stockpile.Lookuper
+	out    io.Writer
+	fen    string // Current position, set by "position".
+}
+
+// run processes commands from r until "quit" or EOF.
+func (s *uciSession) run(ctx context.Context, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "uci":
+			fmt.Fprintln(s.out, "id name Stockpile")
+			fmt.Fprintln(s.out, "id author discochess")
+			fmt.Fprintln(s.out, "uciok")
+		case "isready":
+			fmt.Fprintln(s.out, "readyok")
+		case "ucinewgame":
+			s.fen = startFEN
+		case "position":
+			if err := s.setPosition(fields[1:]); err != nil {
+				fmt.Fprintf(s.out, "info string %v\n", err)
+			}
+		case "go":
+			s.search(ctx)
+		case "quit":
+			return nil
+		default:
+			// "stop" needs no action since "go" answers immediately;
+			// other commands, such as setoption, are ignored.
+		}
+	}
+	return scanner.Err()
+}
+
+// setPosition handles "position [startpos | fen <fen>] [moves <m1> ...]".
+func (s *uciSession) setPosition(args []string) error {
+	if len(args) == 0 {
+		return errors.New("position: missing arguments")
+	}
+
+	var base string
+	var rest []string
+	switch args[0] {
+	case "startpos":
+		base, rest = startFEN, args[1:]
+	case "fen":
+		end := 1
+		for end < len(args) && args[end] != "moves" {
+			end++
+		}
+		base, rest = strings.Join(args[1:end], " "), args[end:]
+	default:
+		return fmt.Errorf("position: unknown argument %q", args[0])
+	}
+
+	var moves []string
+	if len(rest) > 0 && rest[0] == "moves" {
+		moves = rest[1:]
+	}
+
+	pos, err := applyMoves(base, moves)
+	if err != nil {
+		return fmt.Errorf("position: %w", err)
+	}
+	s.fen = pos
+	return nil
+}
+
+// applyMoves plays UCI moves from a FEN and returns the resulting FEN.
+func applyMoves(fenStr string, moves []string) (string, error) {
+	if len(moves) == 0 {
+		return fenStr, nil
+	}
+
+	// The chess package needs all six FEN fields; counters don't affect lookups.
+	normalized, err := fen.Normalize(fenStr)
+	if err != nil {
+		return "", err
+	}
+	opt, err := chess.FEN(normalized + " 0 1")
+	if err != nil {
+		return "", err
+	}
+	game := chess.NewGame(opt, chess.UseNotation(chess.UCINotation{}))
+	for _, m := range moves {
+		if err := game.MoveStr(m); err != nil {
+			return "", err
+		}
+	}
+	return game.FEN(), nil
+}
+
+// search looks up the current position and reports it as engine output.
+func (s *uciSession) search(ctx context.Context) {
+	normalized, err := fen.NormalizeStrict(s.fen)
+	if err != nil {
+		fmt.Fprintf(s.out, "info string invalid position: %v\n", err)
+		fmt.Fprintln(s.out, "bestmove 0000")
+		return
+	}
+
+	eval, err := s.client.Lookup(ctx, normalized)
+	if err != nil {
+		if !errors.Is(err, stockpile.ErrNotFound) {
+			fmt.Fprintf(s.out, "info string lookup failed: %v\n", err)
+		}
+		fmt.Fprintln(s.out, "bestmove 0000")
+		return
+	}
+
+	for _, line := range eval.UCIInfoLines() {
+		fmt.Fprintln(s.out, line)
+	}
+
+	best := "0000"
+	if pv := eval.BestPV(); pv != nil {
+		if moves := strings.Fields(pv.Line); len(moves) > 0 {
+			best = moves[0]
+		}
+	}
+	fmt.Fprintf(s.out, "bestmove %s\n", best)
+}
@@ -2,15 +2,25 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
 
-	"github.com/discochess/stockpile/internal/codec/zstdcodec"
+	"github.com/discochess/stockpile/internal/builder"
+	"github.com/discochess/stockpile/internal/codec"
+	"github.com/discochess/stockpile/internal/store"
+	"github.com/discochess/stockpile/internal/store/gcsstore"
+	"github.com/discochess/stockpile/internal/store/s3store"
 )
 
 var verifyCmd = &cobra.Command{
@@ -21,20 +31,49 @@ var verifyCmd = &cobra.Command{
 This command checks:
 - Each shard can be decompressed
 - Each shard contains valid JSONL
-- Positions are sorted within each shard`,
+- Positions are sorted within each shard
+
+With --manifest, each shard's SHA-256 is also compared against the
+checksum recorded in manifest.json at build time, and shards listed in
+the manifest but missing on disk are reported. This catches truncated
+or swapped files from a bad upload.
+
+With --gcs or --s3, the shards of a remote copy are listed and verified in
+place, e.g. to check a fresh upload before serving from it. They are
+decoded with the codec and dictionary of the manifest in --data-dir, if
+any. Checksums are not compared for remote shards.
+
+Examples:
+  stockpile verify --data-dir ./data --manifest
+  stockpile verify --gcs gs://my-bucket/stockpile --quick --workers 32`,
 	RunE: runVerify,
 }
 
 var (
-	verifyQuick bool
+	verifyQuick    bool
+	verifyManifest bool
+	verifyWorkers  int
+	verifyGCS      string
+	verifyS3       string
 )
 
 func init() {
 	verifyCmd.Flags().BoolVar(&verifyQuick, "quick", false, "only check first and last entries in each shard")
+	verifyCmd.Flags().IntVar(&verifyWorkers, "workers", runtime.NumCPU(), "number of shards to verify in parallel")
+	verifyCmd.Flags().BoolVar(&verifyManifest, "manifest", false, "compare shard checksums against manifest.json")
+	verifyCmd.Flags().StringVar(&verifyGCS, "gcs", "", "verify a remote database in GCS (gs://bucket/prefix)")
+	verifyCmd.Flags().StringVar(&verifyS3, "s3", "", "verify a remote database in S3 (s3://bucket/prefix)")
+	verifyCmd.MarkFlagsMutuallyExclusive("gcs", "s3")
+	verifyCmd.MarkFlagsMutuallyExclusive("gcs", "manifest")
+	verifyCmd.MarkFlagsMutuallyExclusive("s3", "manifest")
 	rootCmd.AddCommand(verifyCmd)
 }
 
 func runVerify(cmd *cobra.Command, args []string) error {
+	if verifyGCS != "" || verifyS3 != "" {
+		return runVerifyRemote(cmd.Context())
+	}
+
 	shardsDir := filepath.Join(dataDir, "shards")
 
 	// Check if shards directory exists.
@@ -42,19 +81,20 @@ func runVerify(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("data directory %q does not exist", dataDir)
 	}
 
-	// List shard files.
-	entries, err := os.ReadDir(shardsDir)
+	c, err := dataDirCodec()
 	if err != nil {
-		return fmt.Errorf("reading shards directory: %w", err)
+		return err
 	}
+	ext := shardExtension(c)
 
-	// Filter to only .zst files.
+	// List shard files for the codec.
+	ids, err := dataDirShards(cmd.Context(), c)
+	if err != nil {
+		return err
+	}
 	var shardFiles []string
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".zst") {
-			continue
-		}
-		shardFiles = append(shardFiles, filepath.Join(shardsDir, entry.Name()))
+	for _, id := range ids {
+		shardFiles = append(shardFiles, filepath.Join(shardsDir, shardFilename(id, c)))
 	}
 
 	if len(shardFiles) == 0 {
@@ -62,55 +102,194 @@ func runVerify(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	fmt.Printf("Verifying %d shards...\n", len(shardFiles))
+	// Load expected checksums, keyed by shard file name.
+	var expected map[string]string
+	if verifyManifest {
+		manifest, err := builder.ReadManifest(dataDir)
+		if err != nil {
+			return err
+		}
+		expected = make(map[string]string, len(manifest.Shards))
+		for _, si := range manifest.Shards {
+			if si.SHA256 == "" {
+				return fmt.Errorf("manifest has no checksum for shard %d; rebuild to add checksums", si.ID)
+			}
+			expected[fmt.Sprintf("%05d", si.ID)+ext] = si.SHA256
+		}
+	}
 
-	codec := zstdcodec.New()
+	fmt.Printf("Verifying %d shards...\n", len(shardFiles))
 
-	var errCount int
+	names := make([]string, len(shardFiles))
+	seen := make(map[string]bool, len(shardFiles))
 	for i, path := range shardFiles {
-		name := filepath.Base(path)
-		if verbose {
-			fmt.Printf("  [%d/%d] %s\n", i+1, len(shardFiles), name)
+		names[i] = filepath.Base(path)
+		seen[names[i]] = true
+	}
+	errCount := verifyEach(names, func(i int) error {
+		return verifyShard(c, shardFiles[i], expected)
+	})
+
+	// Report shards the manifest expects but that are missing on disk.
+	var missing []string
+	for name := range expected {
+		if !seen[name] {
+			missing = append(missing, name)
 		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		fmt.Printf("  ERROR: %s: listed in manifest but missing\n", name)
+		errCount++
+	}
+
+	if errCount > 0 {
+		return fmt.Errorf("%d shards failed verification", errCount)
+	}
+
+	fmt.Println("All shards verified successfully.")
+	return nil
+}
+
+// remoteStore is a store that verify --gcs and --s3 can list and read
+// shards from.
+type remoteStore interface {
+	store.Store
+	store.Lister
+	store.Releaser
+}
 
-		// Read shard.
-		compressed, err := os.ReadFile(path)
+// runVerifyRemote verifies the shards of a database in GCS or S3.
+func runVerifyRemote(ctx context.Context) error {
+	c, err := dataDirCodec()
+	if err != nil {
+		return err
+	}
+
+	var st remoteStore
+	location := verifyGCS
+	if verifyGCS != "" {
+		bucket, prefix, err := builder.ParseGCSPath(verifyGCS)
 		if err != nil {
-			fmt.Printf("  ERROR: %s: %v\n", name, err)
-			errCount++
-			continue
+			return err
 		}
-
-		// Decompress using codec.
-		reader, err := codec.Reader(bytes.NewReader(compressed))
+		if st, err = gcsstore.New(ctx, bucket, c, gcsstore.WithPrefix(prefix)); err != nil {
+			return fmt.Errorf("creating GCS client: %w", err)
+		}
+	} else {
+		location = verifyS3
+		bucket, prefix, err := builder.ParseS3Path(verifyS3)
 		if err != nil {
-			fmt.Printf("  ERROR: %s: creating decompressor: %v\n", name, err)
-			errCount++
-			continue
+			return err
+		}
+		if st, err = s3store.New(ctx, bucket, c, s3store.WithPrefix(prefix)); err != nil {
+			return fmt.Errorf("creating S3 client: %w", err)
 		}
+	}
+	defer st.Close()
+
+	ids, err := st.ListShards(ctx)
+	if err != nil {
+		return fmt.Errorf("listing %s: %w", location, err)
+	}
+	if len(ids) == 0 {
+		fmt.Printf("No shards found in %s.\n", location)
+		return nil
+	}
+
+	fmt.Printf("Verifying %d shards in %s...\n", len(ids), location)
 
-		decompressed, err := io.ReadAll(reader)
-		reader.Close()
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = shardFilename(id, c)
+	}
+	errCount := verifyEach(names, func(i int) error {
+		data, err := st.ReadShard(ctx, ids[i])
 		if err != nil {
-			fmt.Printf("  ERROR: %s: decompression failed: %v\n", name, err)
-			errCount++
-			continue
+			return err
 		}
+		defer st.ReleaseShard(data)
+		return verifyJSONL(data, verifyQuick)
+	})
+
+	if errCount > 0 {
+		return fmt.Errorf("%d shards failed verification", errCount)
+	}
+	fmt.Println("All shards verified successfully.")
+	return nil
+}
+
+// verifyEach runs check for each named shard on verifyWorkers goroutines
+// and prints failures in shard order. It returns the number of failures.
+func verifyEach(names []string, check func(i int) error) int {
+	results := make([]error, len(names))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(verifyWorkers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i] = check(i)
+			}
+		}()
+	}
+	for i := range names {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
 
-		// Check JSONL structure.
-		if err := verifyJSONL(decompressed, verifyQuick); err != nil {
-			fmt.Printf("  ERROR: %s: %v\n", name, err)
+	var errCount int
+	for i, name := range names {
+		if verbose {
+			fmt.Printf("  [%d/%d] %s\n", i+1, len(names), name)
+		}
+		if results[i] != nil {
+			fmt.Printf("  ERROR: %s: %v\n", name, results[i])
 			errCount++
-			continue
 		}
 	}
+	return errCount
+}
 
-	if errCount > 0 {
-		return fmt.Errorf("%d shards failed verification", errCount)
+// verifyShard checks a single shard file. If expected is non-nil, the
+// file's SHA-256 must match the entry for its name.
+func verifyShard(c codec.Codec, path string, expected map[string]string) error {
+	name := filepath.Base(path)
+
+	// Read shard.
+	compressed, err := os.ReadFile(path)
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("All shards verified successfully.")
-	return nil
+	// Compare against the manifest checksum.
+	if expected != nil {
+		want, ok := expected[name]
+		if !ok {
+			return fmt.Errorf("not listed in manifest")
+		}
+		sum := sha256.Sum256(compressed)
+		if got := hex.EncodeToString(sum[:]); got != want {
+			return fmt.Errorf("checksum mismatch: expected %s, got %s", want, got)
+		}
+	}
+
+	// Decompress using codec.
+	reader, err := c.Reader(bytes.NewReader(compressed))
+	if err != nil {
+		return fmt.Errorf("creating decompressor: %w", err)
+	}
+
+	decompressed, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("decompression failed: %w", err)
+	}
+
+	// Check JSONL structure.
+	return verifyJSONL(decompressed, verifyQuick)
 }
 
 func verifyJSONL(data []byte, quick bool) error {
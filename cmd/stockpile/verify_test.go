@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestVerifyEach(t *testing.T) {
+	const workers = 3
+	setFlag(t, &verifyWorkers, workers)
+	setFlag(t, &verbose, false)
+
+	names := make([]string, 100)
+	for i := range names {
+		names[i] = fmt.Sprintf("%05d.zst", i)
+	}
+
+	var mu sync.Mutex
+	var running, peak int
+	checked := make([]bool, len(names))
+	var errCount int
+	out, _ := captureStdout(t, func() error {
+		errCount = verifyEach(names, func(i int) error {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			checked[i] = true
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				running--
+				mu.Unlock()
+			}()
+
+			if i%10 == 0 {
+				return errors.New("bad")
+			}
+			return nil
+		})
+		return nil
+	})
+
+	if errCount != 10 {
+		t.Errorf("verifyEach() = %d, want 10", errCount)
+	}
+	if got := strings.Count(out, "ERROR:"); got != 10 {
+		t.Errorf("printed %d errors, want 10", got)
+	}
+	if peak > workers {
+		t.Errorf("%d checks ran at once, want at most %d", peak, workers)
+	}
+	for i, ok := range checked {
+		if !ok {
+			t.Errorf("shard %d not checked", i)
+		}
+	}
+}
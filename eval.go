@@ -1,6 +1,13 @@
 package stockpile
 
-import "strconv"
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/discochess/stockpile/internal/fen"
+)
 
 // Eval represents a chess position evaluation from the Lichess database.
 type Eval struct {
@@ -16,17 +23,37 @@ type Eval struct {
 	// PVs contains all principal variations from multi-PV analysis.
 	// The first PV is the best line.
 	PVs []PV
+
+	// AllEvals contains every evaluation stored for the position, in database
+	// order. Depth, Knodes and PVs above mirror the first (best) entry, or
+	// the deepest with WithPreferDeepest.
+	AllEvals []EvalEntry
+}
+
+// EvalEntry is a single engine evaluation of a position.
+// A position may have several, e.g. computed at different depths.
+type EvalEntry struct {
+	// Depth is the search depth used to compute this evaluation.
+	Depth int `json:"depth"`
+
+	// Knodes is the number of kilo-nodes searched.
+	Knodes int `json:"knodes"`
+
+	// PVs contains the principal variations of this evaluation.
+	// The first PV is the best line.
+	PVs []PV `json:"pvs"`
 }
 
 // PV represents a principal variation (line of play) from the engine.
 type PV struct {
-	// Centipawns is the evaluation in centipawns from White's perspective.
-	// Positive values favor White, negative values favor Black.
+	// Centipawns is the evaluation in centipawns as stored in the database,
+	// relative to the side to move. Positive values favor the side to move.
+	// Use Eval.CentipawnsWhite for a White-relative value.
 	// Nil if the position has a forced mate.
 	Centipawns *int
 
-	// Mate is the number of moves until checkmate.
-	// Positive values mean White delivers mate, negative means Black.
+	// Mate is the number of moves until checkmate, relative to the side to
+	// move. Positive values mean the side to move delivers mate.
 	// Nil if there is no forced mate.
 	Mate *int
 
@@ -42,6 +69,50 @@ func (e *Eval) BestPV() *PV {
 	return &e.PVs[0]
 }
 
+// TopPVs returns up to n principal variations, strongest first from the
+// side to move's perspective. A mate for the side to move beats any
+// centipawn score, and a shorter mate beats a longer one; being mated ranks
+// below any centipawn score, with a longer defense ranking higher. Lines
+// without a score come last, and ties keep their database order.
+// The returned slice is a copy; e.PVs is not reordered.
+func (e *Eval) TopPVs(n int) []PV {
+	if n <= 0 || len(e.PVs) == 0 {
+		return nil
+	}
+	pvs := make([]PV, len(e.PVs))
+	copy(pvs, e.PVs)
+	sort.SliceStable(pvs, func(i, j int) bool {
+		return pvs[i].stronger(&pvs[j])
+	})
+	return pvs[:min(n, len(pvs))]
+}
+
+// stronger reports whether pv scores strictly better than other for the
+// side to move.
+func (pv *PV) stronger(other *PV) bool {
+	ci, vi := pv.rank()
+	cj, vj := other.rank()
+	if ci != cj {
+		return ci < cj
+	}
+	return vi > vj
+}
+
+// rank returns the score class of the PV (lower is better) and a value
+// that is higher for better scores within the class.
+func (pv *PV) rank() (class, value int) {
+	switch {
+	case pv.Mate != nil && *pv.Mate > 0:
+		return 0, -*pv.Mate // Shorter mates first.
+	case pv.Mate != nil:
+		return 2, -*pv.Mate // Mated: longer defenses first.
+	case pv.Centipawns != nil:
+		return 1, *pv.Centipawns
+	default:
+		return 3, 0
+	}
+}
+
 // IsMate returns true if the best line is a forced checkmate.
 func (e *Eval) IsMate() bool {
 	if pv := e.BestPV(); pv != nil {
@@ -60,6 +131,92 @@ func (e *Eval) Score() string {
 	return pv.Score()
 }
 
+// ScoreWhite returns the score of the best line from White's perspective.
+// It returns "?" if there is no PV or the side to move cannot be determined.
+func (e *Eval) ScoreWhite() string {
+	pv := e.bestPVWhite()
+	if pv == nil {
+		return "?"
+	}
+	return pv.Score()
+}
+
+// CentipawnsWhite returns the centipawn score of the best line from White's
+// perspective, or nil if there is no PV, the line is a forced mate, or the
+// side to move cannot be determined.
+func (e *Eval) CentipawnsWhite() *int {
+	pv := e.bestPVWhite()
+	if pv == nil {
+		return nil
+	}
+	return pv.Centipawns
+}
+
+// bestPVWhite returns a copy of the best PV with its score flipped to
+// White's perspective, or nil if unavailable.
+func (e *Eval) bestPVWhite() *PV {
+	pv := e.BestPV()
+	if pv == nil {
+		return nil
+	}
+	side, err := fen.SideToMove(e.FEN)
+	if err != nil {
+		return nil
+	}
+
+	white := *pv
+	if side == "b" {
+		white.Centipawns = negate(pv.Centipawns)
+		white.Mate = negate(pv.Mate)
+	}
+	return &white
+}
+
+// negate returns a pointer to -*v, or nil if v is nil.
+func negate(v *int) *int {
+	if v == nil {
+		return nil
+	}
+	n := -*v
+	return &n
+}
+
+// UCIInfo returns the best line as a UCI info line, without a trailing
+// newline: "info depth 36 score cp 20 nodes 4000 pv e7e5", or "score mate
+// 3" for a forced mate. The score is relative to the side to move, as UCI
+// expects, and nodes is Knodes in nodes, left out if unknown. Without a PV
+// only the depth and nodes are given.
+func (e *Eval) UCIInfo() string {
+	return uciInfo(e.Depth, 0, e.Knodes, e.BestPV())
+}
+
+// UCIInfoLines returns a UCI info line for each PV, as UCIInfo does for the
+// best one. With several PVs each line carries its 1-based rank in e.PVs
+// as "multipv N", for multi-PV output. It returns nil without PVs.
+func (e *Eval) UCIInfoLines() []string {
+	if len(e.PVs) <= 1 {
+		if len(e.PVs) == 0 {
+			return nil
+		}
+		return []string{e.UCIInfo()}
+	}
+	lines := make([]string, len(e.PVs))
+	for i := range e.PVs {
+		lines[i] = uciInfo(e.Depth, i+1, e.Knodes, &e.PVs[i])
+	}
+	return lines
+}
+
+// WinProbability returns the expected win probability in [0, 1] for the
+// best line. It returns 0.5 if no PV is available.
+func (e *Eval) WinProbability() float64 {
+	pv := e.BestPV()
+	if pv == nil {
+		return 0.5
+	}
+	return pv.WinProbability()
+}
+
 // Score returns a human-readable score string.
 // Examples: "+1.25", "-0.50", "#3", "#-5"
 func (pv *PV) Score() string {
@@ -83,7 +240,69 @@ func (pv *PV) Score() string {
 	return sign + strconv.Itoa(whole) + "." + strconv.Itoa(frac)
 }
 
+// UCIInfo returns this line as a UCI info line searched to depth, without
+// a trailing newline, e.g. "info depth 30 multipv 2 score cp -35 pv e7e5
+// g1f3". multiPV is the line's 1-based rank for multi-PV output, or 0 to
+// leave it out. A missing score or empty line is left out. Eval.UCIInfoLines
+// formats every PV of an evaluation this way and also reports the nodes.
+func (pv PV) UCIInfo(depth, multiPV int) string {
+	return uciInfo(depth, multiPV, 0, &pv)
+}
+
+// uciInfo formats a UCI info line for pv, which may be nil. multiPV is
+// the line's 1-based index, or 0 to leave it out, and knodes the kilo-nodes
+// searched, or 0 if unknown.
+func uciInfo(depth, multiPV, knodes int, pv *PV) string {
+	var b strings.Builder
+	b.WriteString("info depth ")
+	b.WriteString(strconv.Itoa(depth))
+	if multiPV > 0 {
+		b.WriteString(" multipv ")
+		b.WriteString(strconv.Itoa(multiPV))
+	}
+	if pv != nil {
+		switch {
+		case pv.Mate != nil:
+			b.WriteString(" score mate ")
+			b.WriteString(strconv.Itoa(*pv.Mate))
+		case pv.Centipawns != nil:
+			b.WriteString(" score cp ")
+			b.WriteString(strconv.Itoa(*pv.Centipawns))
+		}
+	}
+	if knodes > 0 {
+		b.WriteString(" nodes ")
+		b.WriteString(strconv.FormatInt(int64(knodes)*1000, 10))
+	}
+	if pv != nil && pv.Line != "" {
+		b.WriteString(" pv ")
+		b.WriteString(pv.Line)
+	}
+	return b.String()
+}
+
 // IsMate returns true if this PV is a forced checkmate.
 func (pv *PV) IsMate() bool {
 	return pv.Mate != nil
 }
+
+// winProbabilityK is the logistic slope used by Lichess to map centipawns
+// to win probability.
+const winProbabilityK = 0.00368208
+
+// WinProbability converts this line's score to a win probability in [0, 1]
+// using the Lichess logistic model, from the same perspective as the score.
+// Forced mates map to 1.0 or 0.0 depending on sign. It returns 0.5 if the
+// PV has no score.
+func (pv *PV) WinProbability() float64 {
+	if pv.Mate != nil {
+		if *pv.Mate > 0 {
+			return 1
+		}
+		return 0
+	}
+	if pv.Centipawns == nil {
+		return 0.5
+	}
+	return 1 / (1 + math.Exp(-winProbabilityK*float64(*pv.Centipawns)))
+}
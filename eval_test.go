@@ -1,6 +1,10 @@
 package stockpile
 
-import "testing"
+import (
+	"math"
+	"slices"
+	"testing"
+)
 
 func TestEval_BestPV(t *testing.T) {
 	tests := []struct {
@@ -32,6 +36,43 @@ func TestEval_BestPV(t *testing.T) {
 	}
 }
 
+func TestEval_TopPVs(t *testing.T) {
+	eval := Eval{PVs: []PV{
+		{Centipawns: intPtr(30), Line: "cp30"},
+		{Mate: intPtr(-2), Line: "mated2"},
+		{Line: "none"},
+		{Mate: intPtr(5), Line: "mate5"},
+		{Centipawns: intPtr(-400), Line: "cp-400"},
+		{Mate: intPtr(1), Line: "mate1"},
+		{Mate: intPtr(-7), Line: "mated7"},
+		{Centipawns: intPtr(30), Line: "cp30b"},
+	}}
+
+	var got []string
+	for _, pv := range eval.TopPVs(len(eval.PVs) + 1) {
+		got = append(got, pv.Line)
+	}
+	want := []string{"mate1", "mate5", "cp30", "cp30b", "cp-400", "mated7", "mated2", "none"}
+	if len(got) != len(want) {
+		t.Fatalf("TopPVs() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("TopPVs() = %v, want %v", got, want)
+		}
+	}
+
+	if top := eval.TopPVs(2); len(top) != 2 || top[1].Line != "mate5" {
+		t.Errorf("TopPVs(2) = %v, want [mate1 mate5]", top)
+	}
+	if eval.PVs[0].Line != "cp30" {
+		t.Error("TopPVs() reordered e.PVs")
+	}
+	if top := eval.TopPVs(0); top != nil {
+		t.Errorf("TopPVs(0) = %v, want nil", top)
+	}
+}
+
 func TestEval_IsMate(t *testing.T) {
 	tests := []struct {
 		name string
@@ -176,6 +217,243 @@ func TestEval_Score(t *testing.T) {
 	}
 }
 
+func TestPV_WinProbability(t *testing.T) {
+	tests := []struct {
+		name string
+		pv   PV
+		want float64
+	}{
+		{
+			name: "equal",
+			pv:   PV{Centipawns: intPtr(0)},
+			want: 0.5,
+		},
+		{
+			name: "one pawn up",
+			pv:   PV{Centipawns: intPtr(100)},
+			want: 0.591,
+		},
+		{
+			name: "one pawn down",
+			pv:   PV{Centipawns: intPtr(-100)},
+			want: 0.409,
+		},
+		{
+			name: "mating",
+			pv:   PV{Mate: intPtr(3)},
+			want: 1,
+		},
+		{
+			name: "getting mated",
+			pv:   PV{Mate: intPtr(-2)},
+			want: 0,
+		},
+		{
+			name: "no score",
+			pv:   PV{},
+			want: 0.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pv.WinProbability(); math.Abs(got-tt.want) > 0.001 {
+				t.Errorf("WinProbability() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEval_WinProbability(t *testing.T) {
+	if got := (&Eval{}).WinProbability(); got != 0.5 {
+		t.Errorf("WinProbability() with no PVs = %v, want 0.5", got)
+	}
+
+	eval := Eval{PVs: []PV{{Mate: intPtr(1)}, {Centipawns: intPtr(-300)}}}
+	if got := eval.WinProbability(); got != 1 {
+		t.Errorf("WinProbability() = %v, want 1 (best PV is mate)", got)
+	}
+}
+
+func TestEval_ScoreWhite(t *testing.T) {
+	const (
+		whiteToMove = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -"
+		blackToMove = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq -"
+	)
+
+	tests := []struct {
+		name      string
+		eval      Eval
+		wantScore string
+		wantCP    *int
+	}{
+		{
+			name:      "white to move keeps sign",
+			eval:      Eval{FEN: whiteToMove, PVs: []PV{{Centipawns: intPtr(30)}}},
+			wantScore: "+0.30",
+			wantCP:    intPtr(30),
+		},
+		{
+			name:      "black to move flips sign",
+			eval:      Eval{FEN: blackToMove, PVs: []PV{{Centipawns: intPtr(30)}}},
+			wantScore: "-0.30",
+			wantCP:    intPtr(-30),
+		},
+		{
+			name:      "black to move flips mate",
+			eval:      Eval{FEN: blackToMove, PVs: []PV{{Mate: intPtr(-4)}}},
+			wantScore: "#4",
+		},
+		{
+			name:      "no PVs",
+			eval:      Eval{FEN: blackToMove},
+			wantScore: "?",
+		},
+		{
+			name:      "invalid FEN",
+			eval:      Eval{FEN: "garbage", PVs: []PV{{Centipawns: intPtr(30)}}},
+			wantScore: "?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.eval.ScoreWhite(); got != tt.wantScore {
+				t.Errorf("ScoreWhite() = %q, want %q", got, tt.wantScore)
+			}
+			got := tt.eval.CentipawnsWhite()
+			switch {
+			case got == nil && tt.wantCP == nil:
+			case got == nil || tt.wantCP == nil || *got != *tt.wantCP:
+				t.Errorf("CentipawnsWhite() = %v, want %v", got, tt.wantCP)
+			}
+		})
+	}
+
+	// The stored PV must not be modified.
+	eval := Eval{FEN: blackToMove, PVs: []PV{{Centipawns: intPtr(30)}}}
+	eval.ScoreWhite()
+	if *eval.PVs[0].Centipawns != 30 {
+		t.Errorf("ScoreWhite() mutated stored PV: cp = %d", *eval.PVs[0].Centipawns)
+	}
+}
+
 func intPtr(i int) *int {
 	return &i
 }
+
+func TestPV_UCIInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		pv      PV
+		multiPV int
+		want    string
+	}{
+		{
+			name: "centipawns",
+			pv:   PV{Centipawns: intPtr(-35), Line: "e7e5 g1f3"},
+			want: "info depth 30 score cp -35 pv e7e5 g1f3",
+		},
+		{
+			name:    "multipv",
+			pv:      PV{Centipawns: intPtr(12), Line: "d7d5"},
+			multiPV: 2,
+			want:    "info depth 30 multipv 2 score cp 12 pv d7d5",
+		},
+		{
+			name: "mate",
+			pv:   PV{Mate: intPtr(3), Line: "h1g2"},
+			want: "info depth 30 score mate 3 pv h1g2",
+		},
+		{
+			name: "being mated",
+			pv:   PV{Mate: intPtr(-2), Line: "e1d2"},
+			want: "info depth 30 score mate -2 pv e1d2",
+		},
+		{
+			name: "no score or line",
+			pv:   PV{},
+			want: "info depth 30",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pv.UCIInfo(30, tt.multiPV); got != tt.want {
+				t.Errorf("UCIInfo() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEval_UCIInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		eval Eval
+		want string
+	}{
+		{
+			name: "best of several lines",
+			eval: Eval{Depth: 36, Knodes: 4, PVs: []PV{
+				{Centipawns: intPtr(20), Line: "e7e5"},
+				{Centipawns: intPtr(10), Line: "c7c5"},
+			}},
+			want: "info depth 36 score cp 20 nodes 4000 pv e7e5",
+		},
+		{
+			name: "mate",
+			eval: Eval{Depth: 40, PVs: []PV{{Mate: intPtr(1), Line: "d8h4"}}},
+			want: "info depth 40 score mate 1 pv d8h4",
+		},
+		{
+			name: "no PVs",
+			eval: Eval{Depth: 12, Knodes: 7},
+			want: "info depth 12 nodes 7000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.eval.UCIInfo(); got != tt.want {
+				t.Errorf("UCIInfo() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEval_UCIInfoLines(t *testing.T) {
+	tests := []struct {
+		name string
+		eval Eval
+		want []string
+	}{
+		{
+			name: "multipv",
+			eval: Eval{Depth: 36, Knodes: 2500, PVs: []PV{
+				{Centipawns: intPtr(20), Line: "e7e5"},
+				{Mate: intPtr(-4), Line: "c7c5"},
+			}},
+			want: []string{
+				"info depth 36 multipv 1 score cp 20 nodes 2500000 pv e7e5",
+				"info depth 36 multipv 2 score mate -4 nodes 2500000 pv c7c5",
+			},
+		},
+		{
+			name: "single line",
+			eval: Eval{Depth: 20, Knodes: 1, PVs: []PV{{Centipawns: intPtr(0), Line: "e1e2"}}},
+			want: []string{"info depth 20 score cp 0 nodes 1000 pv e1e2"},
+		},
+		{
+			name: "no PVs",
+			eval: Eval{Depth: 12},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.eval.UCIInfoLines(); !slices.Equal(got, tt.want) {
+				t.Errorf("UCIInfoLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -72,8 +72,8 @@ func main() {
 
 		fmt.Printf("%s:\n", s.Name())
 		fmt.Printf("  Avg shard switches/game: %.2f\n", metrics.AvgSwitchesPerGame)
-		fmt.Printf("  Median switches/game:    %.0f\n", metrics.MedianSwitchesPerGame)
-		fmt.Printf("  P90 switches/game:       %.0f\n", metrics.P90SwitchesPerGame)
+		fmt.Printf("  Median switches/game:    %.1f\n", metrics.MedianSwitchesPerGame)
+		fmt.Printf("  P90 switches/game:       %.1f\n", metrics.P90SwitchesPerGame)
 		fmt.Printf("  Unique shards used:      %d (%.2f%%)\n",
 			metrics.UniqueShards,
 			float64(metrics.UniqueShards)/float64(totalShards)*100)
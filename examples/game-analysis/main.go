@@ -88,14 +88,7 @@ func main() {
 	fmt.Println(strings.Repeat("-", 50))
 
 	for i, pos := range positions {
-		// Normalize FEN (4 fields).
-		fenParts := strings.Fields(pos.String())
-		if len(fenParts) > 4 {
-			fenParts = fenParts[:4]
-		}
-		fen := strings.Join(fenParts, " ")
-
-		eval, err := client.Lookup(ctx, fen)
+		eval, err := client.Lookup(ctx, pos.String())
 
 		moveStr := ""
 		if i > 0 && i-1 < len(moves) {
@@ -128,7 +121,7 @@ func main() {
 			notFound++
 			fmt.Printf("%-4s %-8s %-10s %s\n", moveStr, "N/A", "-", "(not in DB)")
 		} else {
-			log.Printf("Lookup error for %s: %v", fen, err)
+			log.Printf("Lookup error for %s: %v", pos, err)
 		}
 	}
 
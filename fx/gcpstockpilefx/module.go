@@ -29,6 +29,11 @@ type Config struct {
 	// CacheSize is the number of shards to cache in memory.
 	// Default is 100.
 	CacheSize int
+
+	// CacheTTL is how long a cached shard is served before it is re-read
+	// from GCS, so rebuilt databases are picked up without a restart.
+	// Zero means cached shards never expire.
+	CacheTTL time.Duration
 }
 
 // Module provides a GCS-backed stockpile client.
@@ -86,7 +91,9 @@ func newClient(p Params) (Result, error) {
 		return Result{}, err
 	}
 
-	st := cachedstore.New(baseStore, memory.New(lruStrategy, p.Collector))
+	st := cachedstore.New(baseStore, memory.New(lruStrategy, p.Collector),
+		cachedstore.WithTTL(p.Config.CacheTTL),
+	)
 
 	client, err := stockpile.New(
 		stockpile.WithStore(st),
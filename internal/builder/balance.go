@@ -0,0 +1,74 @@
+package builder
+
+import (
+	"sort"
+
+	"github.com/discochess/stockpile/internal/stats"
+)
+
+// balanceTopShards is how many of the largest shards a Balance lists.
+const balanceTopShards = 5
+
+// Balance summarizes how evenly records are spread across the non-empty
+// shards of a database. A skewed distribution means a few hot shards are
+// decompressed for a large share of lookups.
+type Balance struct {
+	Records Distribution `json:"records"`
+	Size    Distribution `json:"uncompressed_size"`
+	Largest []ShardSize  `json:"largest"` // By record count, largest first.
+}
+
+// Distribution describes one per-shard quantity.
+type Distribution struct {
+	Min    int64   `json:"min"`
+	Median int64   `json:"median"`
+	Max    int64   `json:"max"`
+	Gini   float64 `json:"gini"` // 0 for perfectly even shards, near 1 for one hot shard.
+}
+
+// ShardSize identifies a shard by its size.
+type ShardSize struct {
+	ID               int   `json:"id"`
+	RecordCount      int   `json:"record_count"`
+	UncompressedSize int64 `json:"uncompressed_size"`
+}
+
+// ComputeBalance summarizes the record counts and uncompressed sizes of
+// shards. It returns nil if there are no shards.
+func ComputeBalance(shards []ShardInfo) *Balance {
+	if len(shards) == 0 {
+		return nil
+	}
+
+	records := make([]int64, len(shards))
+	sizes := make([]int64, len(shards))
+	for i, s := range shards {
+		records[i] = int64(s.RecordCount)
+		sizes[i] = s.UncompressedSize
+	}
+
+	bySize := make([]ShardInfo, len(shards))
+	copy(bySize, shards)
+	sort.SliceStable(bySize, func(i, j int) bool { return bySize[i].RecordCount > bySize[j].RecordCount })
+	largest := make([]ShardSize, 0, balanceTopShards)
+	for _, s := range bySize[:min(len(bySize), balanceTopShards)] {
+		largest = append(largest, ShardSize{ID: s.ID, RecordCount: s.RecordCount, UncompressedSize: s.UncompressedSize})
+	}
+
+	return &Balance{
+		Records: distribution(records),
+		Size:    distribution(sizes),
+		Largest: largest,
+	}
+}
+
+func distribution(values []int64) Distribution {
+	gini := stats.Gini(values)
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return Distribution{
+		Min:    values[0],
+		Median: values[len(values)/2],
+		Max:    values[len(values)-1],
+		Gini:   gini,
+	}
+}
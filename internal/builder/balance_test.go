@@ -0,0 +1,47 @@
+package builder
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeBalance(t *testing.T) {
+	if b := ComputeBalance(nil); b != nil {
+		t.Errorf("ComputeBalance(nil) = %+v, want nil", b)
+	}
+
+	var shards []ShardInfo
+	for id, n := range []int{10, 10, 10, 10, 10, 10, 500} {
+		shards = append(shards, ShardInfo{ID: id, RecordCount: n, UncompressedSize: int64(n) * 100})
+	}
+	b := ComputeBalance(shards)
+
+	want := Distribution{Min: 10, Median: 10, Max: 500}
+	if got := b.Records; got.Min != want.Min || got.Median != want.Median || got.Max != want.Max {
+		t.Errorf("Records = %+v, want min/median/max %d/%d/%d", got, want.Min, want.Median, want.Max)
+	}
+	if b.Size.Max != 50000 {
+		t.Errorf("Size.Max = %d, want 50000", b.Size.Max)
+	}
+
+	// One shard holds 500 of 560 records.
+	if b.Records.Gini < 0.7 {
+		t.Errorf("Records.Gini = %.3f, want a skewed distribution", b.Records.Gini)
+	}
+	if math.Abs(b.Records.Gini-b.Size.Gini) > 1e-9 {
+		t.Errorf("Size.Gini = %.3f, want %.3f for proportional sizes", b.Size.Gini, b.Records.Gini)
+	}
+
+	if len(b.Largest) != balanceTopShards {
+		t.Fatalf("len(Largest) = %d, want %d", len(b.Largest), balanceTopShards)
+	}
+	if b.Largest[0].ID != 6 || b.Largest[1].ID != 0 {
+		t.Errorf("Largest = %+v, want shard 6 first then ties by ID", b.Largest)
+	}
+
+	// Even shards have no concentration.
+	even := ComputeBalance(shards[:6])
+	if even.Records.Gini != 0 {
+		t.Errorf("even Records.Gini = %.3f, want 0", even.Records.Gini)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"bytes"
 	"container/heap"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -16,6 +18,10 @@ import (
 
 	"github.com/klauspost/compress/zstd"
 
+	"github.com/discochess/stockpile/internal/codec/detect"
+	"github.com/discochess/stockpile/internal/fen"
+	"github.com/discochess/stockpile/internal/search"
+	"github.com/discochess/stockpile/internal/seekable"
 	"github.com/discochess/stockpile/internal/shard"
 	"github.com/discochess/stockpile/internal/shard/materialshard"
 )
@@ -30,14 +36,24 @@ const (
 
 // Builder builds the stockpile database from source data.
 type Builder struct {
-	sourceURL     string
-	outputDir     string
-	totalShards   int
-	strategy      shard.Strategy
-	progress      ProgressFunc
-	tempDir       string
-	maxMemoryMB   int
-	workersCount  int
+	sourceURL          string
+	outputDir          string
+	totalShards        int
+	strategy           shard.Strategy
+	progress           ProgressFunc
+	tempDir            string
+	maxMemoryMB        int
+	workersCount       int
+	resume             bool
+	checkpointInterval int64
+	buildIndex         bool
+	compressionLevel   zstd.EncoderLevel
+	frameSize          int
+	downloadRate       int64
+	skipIllegal        bool
+	trainDict          bool
+	storeSymmetric     bool
+	dictionary         []byte // Trained or adopted zstd dictionary; nil for none.
 }
 
 // Option configures the Builder.
@@ -78,21 +94,90 @@ func WithMaxMemoryMB(mb int) Option {
 	return func(b *Builder) { b.maxMemoryMB = mb }
 }
 
-// WithWorkers sets the number of parallel workers for compression.
+// WithWorkers sets the number of parallel workers used to shard source
+// records during the sort phase and to compress shards afterwards.
 func WithWorkers(n int) Option {
 	return func(b *Builder) { b.workersCount = n }
 }
 
+// WithResume enables checkpointing so an interrupted build can be restarted
+// without redoing completed work. Progress is recorded in the temp directory,
+// which is kept after a failed build and removed once the build succeeds.
+func WithResume(resume bool) Option {
+	return func(b *Builder) { b.resume = resume }
+}
+
+// WithCheckpointInterval sets how many source lines are consumed between
+// checkpoints when resume is enabled. Each checkpoint spills every shard's
+// in-memory records to disk, so smaller intervals lose less work on a crash
+// at the cost of more spill files.
+func WithCheckpointInterval(lines int64) Option {
+	return func(b *Builder) { b.checkpointInterval = lines }
+}
+
+// WithBuildIndex enables writing a sparse offset index next to each shard
+// (e.g. shards/00042.idx) for use with search.SearchIndexed.
+func WithBuildIndex(build bool) Option {
+	return func(b *Builder) { b.buildIndex = build }
+}
+
+// WithCompressionLevel sets the zstd level used to compress shards.
+// Lower levels build faster; higher levels produce smaller shards.
+// The default is zstd.SpeedBestCompression.
+func WithCompressionLevel(level zstd.EncoderLevel) Option {
+	return func(b *Builder) { b.compressionLevel = level }
+}
+
+// WithFrameSize sets the uncompressed size of each independently compressed
+// zstd frame within a shard. Smaller frames let readers decompress less data
+// per lookup at some cost in compression ratio.
+// The default is seekable.DefaultFrameSize.
+func WithFrameSize(bytes int) Option {
+	return func(b *Builder) { b.frameSize = bytes }
+}
+
+// WithDownloadRateLimit caps the source download speed in bytes per second.
+// Zero means unlimited.
+func WithDownloadRateLimit(bytesPerSec int64) Option {
+	return func(b *Builder) { b.downloadRate = bytesPerSec }
+}
+
+// WithSkipIllegal drops source records whose FEN fails fen.Validate, such
+// as positions without exactly one king per side. Illegal records are
+// counted in Progress.IllegalRecords either way.
+func WithSkipIllegal(skip bool) Option {
+	return func(b *Builder) { b.skipIllegal = skip }
+}
+
+// WithDictionary trains a zstd dictionary on a sample of the source records
+// and compresses every shard with it. The dictionary is written next to the
+// manifest as dictionary.zdict; readers load it through the manifest.
+// Training needs a few hundred records, so tiny inputs are built without one.
+func WithDictionary(train bool) Option {
+	return func(b *Builder) { b.trainDict = train }
+}
+
+// WithStoreSymmetric also stores, for each source position whose
+// color-swapped equivalent (see fen.Mirror) is not in the source, a mirrored
+// record with the same scores and mirrored lines, so that lookups of either
+// side succeed. This roughly doubles the database size.
+func WithStoreSymmetric(symmetric bool) Option {
+	return func(b *Builder) { b.storeSymmetric = symmetric }
+}
+
 // NewBuilder creates a new Builder with the given options.
 func NewBuilder(opts ...Option) *Builder {
 	b := &Builder{
-		sourceURL:    DefaultSourceURL,
-		outputDir:    "./data",
-		totalShards:  DefaultTotalShards,
-		strategy:     materialshard.New(),
-		progress:     DefaultProgressFunc,
-		maxMemoryMB:  2048,
-		workersCount: 4,
+		sourceURL:          DefaultSourceURL,
+		outputDir:          "./data",
+		totalShards:        DefaultTotalShards,
+		strategy:           materialshard.New(),
+		progress:           DefaultProgressFunc,
+		maxMemoryMB:        2048,
+		workersCount:       4,
+		checkpointInterval: DefaultCheckpointInterval,
+		compressionLevel:   zstd.SpeedBestCompression,
+		frameSize:          seekable.DefaultFrameSize,
 	}
 	for _, opt := range opts {
 		opt(b)
@@ -101,7 +186,7 @@ func NewBuilder(opts ...Option) *Builder {
 }
 
 // Build downloads and processes the evaluation database.
-func (b *Builder) Build(ctx context.Context) error {
+func (b *Builder) Build(ctx context.Context) (err error) {
 	startTime := time.Now()
 
 	// Create output directory.
@@ -117,15 +202,18 @@ func (b *Builder) Build(ctx context.Context) error {
 	if err := os.MkdirAll(b.tempDir, 0755); err != nil {
 		return fmt.Errorf("creating temp directory: %w", err)
 	}
-	defer os.RemoveAll(b.tempDir)
+	defer b.cleanupTempDir(&err)
 
-	// Download source file.
+	// Download source file. A resumable build that already checkpointed
+	// has a complete download, so it is reused.
 	downloadPath := filepath.Join(b.tempDir, "source.jsonl.zst")
-	b.reportProgress(Progress{Phase: "download", StartTime: startTime})
+	if cp, _ := b.resumeCheckpoint(downloadPath); cp == nil {
+		b.reportProgress(Progress{Phase: "download", StartTime: startTime})
 
-	downloader := NewDownloader()
-	if err := downloader.DownloadToFile(ctx, b.sourceURL, downloadPath, b.progress); err != nil {
-		return fmt.Errorf("downloading source: %w", err)
+		downloader := NewDownloader(WithRateLimit(b.downloadRate))
+		if err := downloader.DownloadToFile(ctx, b.sourceURL, downloadPath, b.progress); err != nil {
+			return fmt.Errorf("downloading source: %w", err)
+		}
 	}
 
 	// Process the downloaded file.
@@ -133,20 +221,13 @@ func (b *Builder) Build(ctx context.Context) error {
 }
 
 // BuildFromFile builds the database from a local file.
-func (b *Builder) BuildFromFile(ctx context.Context, sourcePath string, startTime time.Time) error {
+// With WithResume, a compatible checkpoint from an earlier run is picked up
+// and completed work is skipped.
+func (b *Builder) BuildFromFile(ctx context.Context, sourcePath string, startTime time.Time) (err error) {
 	if startTime.IsZero() {
 		startTime = time.Now()
 	}
 
-	// Clean and create output directory.
-	shardsDir := filepath.Join(b.outputDir, "shards")
-	if err := os.RemoveAll(shardsDir); err != nil {
-		return fmt.Errorf("cleaning shards directory: %w", err)
-	}
-	if err := os.MkdirAll(shardsDir, 0755); err != nil {
-		return fmt.Errorf("creating output directory: %w", err)
-	}
-
 	// Create temp directory.
 	if b.tempDir == "" {
 		b.tempDir = filepath.Join(b.outputDir, ".tmp")
@@ -154,7 +235,25 @@ func (b *Builder) BuildFromFile(ctx context.Context, sourcePath string, startTim
 	if err := os.MkdirAll(b.tempDir, 0755); err != nil {
 		return fmt.Errorf("creating temp directory: %w", err)
 	}
-	defer os.RemoveAll(b.tempDir)
+	defer b.cleanupTempDir(&err)
+
+	cp, err := b.resumeCheckpoint(sourcePath)
+	if err != nil {
+		return err
+	}
+
+	// Clean and create output directory. Shards from an interrupted run
+	// are kept when resuming.
+	shardsDir := filepath.Join(b.outputDir, "shards")
+	if cp == nil {
+		if err := os.RemoveAll(shardsDir); err != nil {
+			return fmt.Errorf("cleaning shards directory: %w", err)
+		}
+		cp = b.newCheckpoint(sourcePath)
+	}
+	if err := os.MkdirAll(shardsDir, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
 
 	// Open source file.
 	file, err := os.Open(sourcePath)
@@ -163,23 +262,38 @@ func (b *Builder) BuildFromFile(ctx context.Context, sourcePath string, startTim
 	}
 	defer file.Close()
 
-	// Wrap with zstd decoder if needed.
-	var reader io.Reader = file
-	if filepath.Ext(sourcePath) == ".zst" {
-		decoder, err := zstd.NewReader(file)
-		if err != nil {
-			return fmt.Errorf("creating zstd decoder: %w", err)
-		}
-		defer decoder.Close()
-		reader = decoder
+	// Wrap with a decompressor based on the file's magic bytes.
+	reader, _, err := detect.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("detecting source compression: %w", err)
 	}
+	defer reader.Close()
 
 	// Process records into shards.
-	return b.processRecords(ctx, reader, shardsDir, startTime)
+	return b.processRecords(ctx, reader, shardsDir, cp, startTime)
+}
+
+// resumeCheckpoint returns the checkpoint to resume from, or nil when resume
+// is disabled or no compatible checkpoint exists.
+func (b *Builder) resumeCheckpoint(sourcePath string) (*checkpoint, error) {
+	if !b.resume {
+		return nil, nil
+	}
+	return b.loadCheckpoint(sourcePath)
+}
+
+// cleanupTempDir removes the temp directory unless a resumable build failed,
+// in which case its spill files and checkpoint are kept for the next run.
+func (b *Builder) cleanupTempDir(errp *error) {
+	if b.resume && *errp != nil {
+		return
+	}
+	os.RemoveAll(b.tempDir)
 }
 
 // processRecords reads records and distributes them to shards.
-func (b *Builder) processRecords(ctx context.Context, reader io.Reader, shardsDir string, startTime time.Time) error {
+// Work already recorded in cp is skipped.
+func (b *Builder) processRecords(ctx context.Context, reader io.Reader, shardsDir string, cp *checkpoint, startTime time.Time) error {
 	// Create shard collectors with memory tracking.
 	collectors := make([]*shardCollector, b.totalShards)
 	tracker := newMemoryTracker(b.maxMemoryMB, collectors)
@@ -187,50 +301,22 @@ func (b *Builder) processRecords(ctx context.Context, reader io.Reader, shardsDi
 		collectors[i] = newShardCollector(i, b.tempDir, tracker)
 	}
 	tracker.collectors = collectors // Update reference after creation
+	cp.restoreSpills(collectors)
 
-	// Read and distribute records.
-	b.reportProgress(Progress{Phase: "sort", StartTime: startTime})
-
-	scanner := bufio.NewScanner(reader)
-	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024) // 10MB max line.
-
-	var recordsRead int64
-	for scanner.Scan() {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-
-		// Extract FEN for sharding.
-		fen := extractFEN(line)
-		if fen == "" {
-			continue
-		}
-
-		// Determine shard.
-		shardID := b.strategy.ShardID(fen, b.totalShards)
-		if err := collectors[shardID].Add(line); err != nil {
-			return fmt.Errorf("adding to shard %d: %w", shardID, err)
-		}
-
-		recordsRead++
-		if recordsRead%100000 == 0 {
-			b.reportProgress(Progress{
-				Phase:       "sort",
-				RecordsRead: recordsRead,
-				StartTime:   startTime,
-			})
+	var sampler *dictSampler
+	if b.trainDict {
+		sampler = newDictSampler()
+	}
+	recordsRead := cp.RecordsRead
+	if !cp.SortDone {
+		if err := b.distributeRecords(ctx, reader, collectors, tracker, cp, sampler, startTime); err != nil {
+			return err
 		}
+		recordsRead = cp.RecordsRead
 	}
-
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("reading source: %w", err)
+	training, err := b.prepareDictionary(sampler)
+	if err != nil {
+		return err
 	}
 
 	// Write shards.
@@ -242,19 +328,44 @@ func (b *Builder) processRecords(ctx context.Context, reader io.Reader, shardsDi
 	})
 
 	var recordsWritten int64
+	var duplicatesMerged int64
 	var shardsCreated int
+	var shardInfos []ShardInfo
 	var mu sync.Mutex
 
-	// Process shards in parallel.
-	sem := make(chan struct{}, b.workersCount)
-	errCh := make(chan error, b.totalShards)
-	var wg sync.WaitGroup
+	// Rates and the ETA count only shards written by this run.
+	var pending, written int
+	var writtenRecords int64
+	shardStart := time.Now()
 
+	// Collect the shards to write before starting any, so the ETA sees
+	// the full count from the first shard on.
+	var todo []int
 	for i, collector := range collectors {
 		if collector.Count() == 0 {
 			continue
 		}
 
+		// Skip shards completed by an earlier run.
+		if done, ok := cp.Shards[i]; ok && done.Input == collector.Count() {
+			if _, err := os.Stat(b.shardPath(i)); err == nil {
+				recordsWritten += int64(done.RecordCount)
+				duplicatesMerged += int64(done.Duplicates)
+				shardsCreated++
+				shardInfos = append(shardInfos, done.ShardInfo)
+				continue
+			}
+		}
+		todo = append(todo, i)
+	}
+	pending = len(todo)
+
+	// Process shards in parallel.
+	sem := make(chan struct{}, b.workersCount)
+	errCh := make(chan error, b.totalShards)
+	var wg sync.WaitGroup
+
+	for _, i := range todo {
 		wg.Add(1)
 		go func(shardID int, c *shardCollector) {
 			defer wg.Done()
@@ -263,56 +374,96 @@ func (b *Builder) processRecords(ctx context.Context, reader io.Reader, shardsDi
 			defer func() { <-sem }()
 
 			// Sort and write shard.
-			count, err := b.writeShard(ctx, shardID, c)
+			st, err := b.writeShard(ctx, shardID, c)
 			if err != nil {
 				errCh <- fmt.Errorf("writing shard %d: %w", shardID, err)
 				return
 			}
 
 			mu.Lock()
-			recordsWritten += int64(count)
+			recordsWritten += int64(st.RecordCount)
+			duplicatesMerged += int64(st.Duplicates)
 			shardsCreated++
+			written++
+			writtenRecords += int64(st.RecordCount)
+			shardInfos = append(shardInfos, st.ShardInfo)
+			if b.resume {
+				cp.Shards[shardID] = completedShard{Input: c.Count(), shardStats: st}
+				if shardsCreated%checkpointShardInterval == 0 {
+					if err := cp.save(b.tempDir); err != nil {
+						errCh <- err
+					}
+				}
+			}
+			elapsed := time.Since(shardStart)
 			b.reportProgress(Progress{
-				Phase:          "shard",
-				RecordsRead:    recordsRead,
-				RecordsWritten: recordsWritten,
-				ShardsCreated:  shardsCreated,
-				ShardsTotal:    b.totalShards,
-				StartTime:      startTime,
+				Phase:            "shard",
+				RecordsRead:      recordsRead,
+				RecordsWritten:   recordsWritten,
+				DuplicatesMerged: duplicatesMerged,
+				IllegalRecords:   cp.IllegalRecords,
+				ShardsCreated:    shardsCreated,
+				ShardsTotal:      b.totalShards,
+				StartTime:        startTime,
+				RecordsPerSec:    perSecond(writtenRecords, elapsed),
+				ETA:              estimateRemaining(elapsed, int64(written), int64(pending)),
 			})
 			mu.Unlock()
-		}(i, collector)
+		}(i, collectors[i])
 	}
 
 	wg.Wait()
 	close(errCh)
 
-	// Check for errors.
+	// Check for errors. Shards fail with wrapped context errors once ctx
+	// is done; report the cancellation itself.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	for err := range errCh {
 		if err != nil {
 			return err
 		}
 	}
 
+	if b.resume {
+		if err := cp.save(b.tempDir); err != nil {
+			return err
+		}
+	}
+
 	b.reportProgress(Progress{
-		Phase:          "done",
-		RecordsRead:    recordsRead,
-		RecordsWritten: recordsWritten,
-		ShardsCreated:  shardsCreated,
-		ShardsTotal:    b.totalShards,
-		StartTime:      startTime,
+		Phase:            "done",
+		RecordsRead:      recordsRead,
+		RecordsWritten:   recordsWritten,
+		DuplicatesMerged: duplicatesMerged,
+		IllegalRecords:   cp.IllegalRecords,
+		ShardsCreated:    shardsCreated,
+		ShardsTotal:      b.totalShards,
+		StartTime:        startTime,
+		PlainRatio:       training.plainRatio,
+		DictRatio:        training.dictRatio,
 	})
 
 	// Write manifest.
+	sort.Slice(shardInfos, func(i, j int) bool { return shardInfos[i].ID < shardInfos[j].ID })
 	manifest := &Manifest{
-		Version:     1,
-		TotalShards: b.totalShards,
-		Strategy:    b.strategy.Name(),
-		RecordCount: recordsWritten,
-		ShardCount:  shardsCreated,
-		BuiltAt:     time.Now(),
-		SourceURL:   b.sourceURL,
-		Compression: "zstd",
+		Version:          1,
+		TotalShards:      b.totalShards,
+		Strategy:         b.strategy.Name(),
+		RecordCount:      recordsWritten,
+		ShardCount:       shardsCreated,
+		BuiltAt:          time.Now(),
+		SourceURL:        b.sourceURL,
+		Compression:      "zstd",
+		CompressionLevel: b.compressionLevel.String(),
+		FrameSize:        b.frameSize,
+		Shards:           shardInfos,
+		Balance:          ComputeBalance(shardInfos),
+		Symmetric:        b.storeSymmetric,
+	}
+	if b.dictionary != nil {
+		manifest.Dictionary = dictionaryFilename
 	}
 	if err := WriteManifest(b.outputDir, manifest); err != nil {
 		return fmt.Errorf("writing manifest: %w", err)
@@ -321,46 +472,410 @@ func (b *Builder) processRecords(ctx context.Context, reader io.Reader, shardsDi
 	return nil
 }
 
-// writeShard streams sorted records to a compressed shard file.
-func (b *Builder) writeShard(ctx context.Context, shardID int, collector *shardCollector) (int, error) {
+// prepareDictionary sets up the dictionary shards are compressed with when
+// training is enabled. It trains one on the sampled records and saves it
+// next to the manifest, or, when a resumed build sampled nothing because
+// sorting had already finished, reuses the one saved by the earlier run.
+// Without enough samples the shards are written without a dictionary.
+func (b *Builder) prepareDictionary(sampler *dictSampler) (dictTraining, error) {
+	b.dictionary = nil
+	if sampler == nil {
+		return dictTraining{}, nil
+	}
+	path := filepath.Join(b.outputDir, dictionaryFilename)
+	if sampler.seen == 0 {
+		d, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return dictTraining{}, fmt.Errorf("reading dictionary: %w", err)
+		}
+		b.dictionary = d
+		return dictTraining{}, nil
+	}
+
+	training, err := trainDictionary(sampler.samples, b.compressionLevel, b.frameSize)
+	if err != nil {
+		os.Remove(path) // Don't leave an older dictionary for a resume to pick up.
+		return dictTraining{}, nil
+	}
+	if err := os.WriteFile(path, training.dict, 0644); err != nil {
+		return dictTraining{}, fmt.Errorf("writing dictionary: %w", err)
+	}
+	b.dictionary = training.dict
+	return *training, nil
+}
+
+// shardStats summarizes a written shard.
+type shardStats struct {
+	ShardInfo
+	Duplicates int `json:"duplicates"`
+}
+
+// writeShard streams sorted records to a compressed shard file in the
+// seekable zstd format, cutting frames only between records. Consecutive
+// records with the same FEN are collapsed into the one with the deepest
+// evaluation, or into the source record if the others are mirrors. The
+// file is written under a temporary name and renamed into place, so an
+// existing shard is only replaced by a complete one.
+func (b *Builder) writeShard(ctx context.Context, shardID int, collector *shardCollector) (shardStats, error) {
+	st := shardStats{ShardInfo: ShardInfo{ID: shardID}}
 	if collector.Count() == 0 {
-		return 0, nil
+		return st, nil
 	}
 
-	// Create output file with streaming zstd compression.
-	shardPath := filepath.Join(b.outputDir, "shards", fmt.Sprintf("%05d.zst", shardID))
-	file, err := os.Create(shardPath)
+	// Create output file with seekable zstd compression.
+	path := b.shardPath(shardID)
+	file, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+shardFilename(shardID)+"-*")
 	if err != nil {
-		return 0, err
+		return st, err
 	}
+	defer os.Remove(file.Name()) // No-op after a successful rename.
 	defer file.Close()
+	if err := file.Chmod(0644); err != nil {
+		return st, err
+	}
 
-	encoder, err := zstd.NewWriter(file, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	// Hash the compressed bytes as they are written.
+	hasher := sha256.New()
+	var opts []zstd.EOption
+	if b.dictionary != nil {
+		opts = append(opts, zstd.WithEncoderDict(b.dictionary))
+	}
+	encoder, err := seekable.NewWriter(io.MultiWriter(file, hasher), b.frameSize, b.compressionLevel, opts...)
 	if err != nil {
-		return 0, err
+		return st, err
 	}
 	defer encoder.Close()
 
+	var index *search.Index
+	if b.buildIndex {
+		index = &search.Index{}
+	}
+
+	var line []byte
+	write := func(record []byte, fen string) error {
+		if index != nil && st.RecordCount%search.DefaultIndexInterval == 0 {
+			index.Add(fen, int(st.UncompressedSize))
+		}
+		// Write the record and its newline together so no frame splits it.
+		line = append(append(line[:0], record...), '\n')
+		if _, err := encoder.Write(line); err != nil {
+			return err
+		}
+		// Records arrive sorted, so the first FEN is the minimum and the
+		// last is the maximum.
+		if st.RecordCount == 0 {
+			st.MinFEN = fen
+		}
+		st.MaxFEN = fen
+		st.RecordCount++
+		st.UncompressedSize += int64(len(record) + 1)
+		return nil
+	}
+
 	// Stream sorted records from merge sort.
 	recordCh, errCh := collector.StreamSorted(ctx)
 
-	count := 0
+	var pending []byte
+	var pendingFEN string
 	for record := range recordCh {
-		if _, err := encoder.Write(record); err != nil {
-			return 0, err
+		fen := extractFEN(record)
+		if pending != nil && fen == pendingFEN {
+			if !isMirrored(pending) && !isMirrored(record) {
+				st.Duplicates++
+			}
+			pending = preferSource(pending, record)
+			continue
 		}
-		if _, err := encoder.Write([]byte("\n")); err != nil {
-			return 0, err
+		if pending != nil {
+			if err := write(unmarkRecord(pending), pendingFEN); err != nil {
+				return st, err
+			}
 		}
-		count++
+		pending, pendingFEN = record, fen
 	}
 
 	// Check for streaming errors.
 	if err := <-errCh; err != nil {
-		return 0, err
+		return st, err
+	}
+
+	if pending != nil {
+		if err := write(unmarkRecord(pending), pendingFEN); err != nil {
+			return st, err
+		}
 	}
 
-	return count, nil
+	// Flush the final frame and seek table so the checksum covers the whole
+	// file.
+	if err := encoder.Close(); err != nil {
+		return st, err
+	}
+	st.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+	if err := file.Close(); err != nil {
+		return st, err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return st, fmt.Errorf("renaming shard: %w", err)
+	}
+
+	if index != nil {
+		data, err := index.MarshalText()
+		if err != nil {
+			return st, err
+		}
+		if err := os.WriteFile(b.indexPath(shardID), data, 0644); err != nil {
+			return st, fmt.Errorf("writing index: %w", err)
+		}
+	}
+
+	return st, nil
+}
+
+// distributeBatchSize is the number of source lines handed to a worker at
+// a time during the sort phase.
+const distributeBatchSize = 4096
+
+// sourceBatch is a run of consecutive source lines. The reader fills lines,
+// a worker fills shardIDs and illegal, then closes done.
+type sourceBatch struct {
+	lines    [][]byte // Copies of the source lines, nil for empty lines.
+	shardIDs []int    // Shard for each line, or -1 if it is skipped.
+	illegal  int64    // Lines that failed FEN validation.
+	done     chan struct{}
+
+	// With WithStoreSymmetric, the mirrored record of each line and its
+	// shard, or nil and -1 if there is none.
+	mirrors        [][]byte
+	mirrorShardIDs []int
+}
+
+// distributeRecords reads source lines into the shard collectors, skipping
+// lines already consumed according to cp. With resume enabled, collectors are
+// spilled and cp is saved every checkpointInterval lines and at the end.
+//
+// The work is pipelined: one goroutine reads the source in batches, a pool
+// of workers extracts and validates FENs and computes shard IDs, and the
+// calling goroutine adds records to the collectors in source order. Only that
+// goroutine touches the collectors, so they need no locking and the result
+// is the same as a sequential pass.
+func (b *Builder) distributeRecords(ctx context.Context, reader io.Reader, collectors []*shardCollector, tracker *memoryTracker, cp *checkpoint, sampler *dictSampler, startTime time.Time) error {
+	b.reportProgress(Progress{Phase: "sort", RecordsRead: cp.RecordsRead, StartTime: startTime})
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel() // Runs first, unblocking the reader before the wait.
+
+	workers := max(b.workersCount, 1)
+	work := make(chan *sourceBatch, workers)
+	ordered := make(chan *sourceBatch, 2*workers)
+	readErr := make(chan error, 1)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(work)
+		defer close(ordered)
+		readErr <- b.readBatches(ctx, reader, cp.LinesConsumed, work, ordered)
+	}()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range work {
+				b.shardBatch(batch)
+				close(batch.done)
+			}
+		}()
+	}
+
+	linesConsumed := cp.LinesConsumed
+	recordsRead := cp.RecordsRead
+	illegal := cp.IllegalRecords
+	resumedRecords, sortStart := recordsRead, time.Now()
+	for batch := range ordered {
+		select {
+		case <-batch.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		for i, line := range batch.lines {
+			shardID := batch.shardIDs[i]
+			if shardID < 0 {
+				continue
+			}
+			if sampler != nil {
+				sampler.add(line)
+			}
+			if err := collectors[shardID].add(line); err != nil {
+				return fmt.Errorf("adding to shard %d: %w", shardID, err)
+			}
+			if mirror := batch.mirrors[i]; mirror != nil {
+				id := batch.mirrorShardIDs[i]
+				if err := collectors[id].add(mirror); err != nil {
+					return fmt.Errorf("adding to shard %d: %w", id, err)
+				}
+			}
+
+			recordsRead++
+			if recordsRead%100000 == 0 {
+				b.reportProgress(Progress{
+					Phase:          "sort",
+					RecordsRead:    recordsRead,
+					IllegalRecords: illegal,
+					MemoryBytes:    tracker.usage(),
+					StartTime:      startTime,
+					RecordsPerSec:  perSecond(recordsRead-resumedRecords, time.Since(sortStart)),
+				})
+			}
+		}
+		illegal += batch.illegal
+		linesConsumed += int64(len(batch.lines))
+
+		// Batches end on checkpoint boundaries, see readBatches.
+		if b.resume && b.checkpointInterval > 0 && linesConsumed%b.checkpointInterval == 0 {
+			cp.IllegalRecords = illegal
+			if err := b.checkpointSort(collectors, cp, linesConsumed, recordsRead, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := <-readErr; err != nil {
+		return err
+	}
+
+	cp.IllegalRecords = illegal
+	if b.resume {
+		return b.checkpointSort(collectors, cp, linesConsumed, recordsRead, true)
+	}
+	cp.LinesConsumed = linesConsumed
+	cp.RecordsRead = recordsRead
+	return nil
+}
+
+// readBatches splits the source into batches after skipping the first skip
+// lines, sending each batch to ordered and then to work. With resume enabled,
+// a batch also ends at every checkpoint interval so checkpoints fall exactly
+// on interval boundaries.
+func (b *Builder) readBatches(ctx context.Context, reader io.Reader, skip int64, work, ordered chan<- *sourceBatch) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024) // 10MB max line.
+
+	batch := &sourceBatch{done: make(chan struct{})}
+	send := func() error {
+		if len(batch.lines) == 0 {
+			return nil
+		}
+		for _, ch := range []chan<- *sourceBatch{ordered, work} {
+			select {
+			case ch <- batch:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		batch = &sourceBatch{done: make(chan struct{})}
+		return nil
+	}
+
+	checkpoints := b.resume && b.checkpointInterval > 0
+	var lines int64
+	for scanner.Scan() {
+		// Skip lines already distributed before the checkpoint.
+		lines++
+		if lines <= skip {
+			continue
+		}
+
+		var line []byte
+		if len(scanner.Bytes()) > 0 {
+			// Copy since the scanner reuses its buffer.
+			line = bytes.Clone(scanner.Bytes())
+		}
+		batch.lines = append(batch.lines, line)
+
+		if len(batch.lines) == distributeBatchSize || (checkpoints && lines%b.checkpointInterval == 0) {
+			if err := send(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading source: %w", err)
+	}
+	return send()
+}
+
+// shardBatch extracts and validates the FEN of each line in batch and
+// computes its shard, mirroring kept records with WithStoreSymmetric.
+func (b *Builder) shardBatch(batch *sourceBatch) {
+	batch.shardIDs = make([]int, len(batch.lines))
+	batch.mirrors = make([][]byte, len(batch.lines))
+	batch.mirrorShardIDs = make([]int, len(batch.lines))
+	for i, line := range batch.lines {
+		batch.shardIDs[i] = -1
+		batch.mirrorShardIDs[i] = -1
+		if len(line) == 0 {
+			continue
+		}
+		fenStr := extractFEN(line)
+		if fenStr == "" || !b.keepRecord(fenStr, &batch.illegal) {
+			continue
+		}
+		batch.shardIDs[i] = b.strategy.ShardID(fenStr, b.totalShards)
+
+		if !b.storeSymmetric {
+			continue
+		}
+		// Records that cannot be mirrored are still stored as they are.
+		if mirror, err := mirrorRecord(line); err == nil {
+			batch.mirrors[i] = mirror
+			batch.mirrorShardIDs[i] = b.strategy.ShardID(extractFEN(mirror), b.totalShards)
+		}
+	}
+}
+
+// keepRecord validates a record's FEN, counting failures in illegal.
+// It reports whether the record should be kept.
+func (b *Builder) keepRecord(fenStr string, illegal *int64) bool {
+	if err := fen.Validate(fenStr); err != nil {
+		*illegal++
+		return !b.skipIllegal
+	}
+	return true
+}
+
+// checkpointSort spills all collectors and saves the sort-phase progress.
+func (b *Builder) checkpointSort(collectors []*shardCollector, cp *checkpoint, linesConsumed, recordsRead int64, done bool) error {
+	if err := spillAll(collectors); err != nil {
+		return err
+	}
+	cp.LinesConsumed = linesConsumed
+	cp.RecordsRead = recordsRead
+	cp.SortDone = done
+	cp.captureSpills(collectors)
+	return cp.save(b.tempDir)
+}
+
+// shardPath returns the output path for a shard file.
+func (b *Builder) shardPath(shardID int) string {
+	return filepath.Join(b.outputDir, "shards", shardFilename(shardID))
+}
+
+// shardFilename returns the file name of a shard within the shards directory.
+func shardFilename(shardID int) string {
+	return fmt.Sprintf("%05d.zst", shardID)
+}
+
+// indexPath returns the output path for a shard's offset index.
+func (b *Builder) indexPath(shardID int) string {
+	return filepath.Join(b.outputDir, "shards", indexFilename(shardID))
+}
+
+// indexFilename returns the file name of a shard's offset index.
+func indexFilename(shardID int) string {
+	return fmt.Sprintf("%05d.idx", shardID)
 }
 
 func (b *Builder) reportProgress(p Progress) {
@@ -382,24 +897,58 @@ type shardCollector struct {
 	memTracker   *memoryTracker
 }
 
+const (
+	// sliceHeaderSize is the size of each []byte header in a collector's
+	// records slice.
+	sliceHeaderSize = 24
+
+	// memorySampleInterval is the accounted growth, in bytes, after which
+	// the tracker samples the real heap size again.
+	memorySampleInterval = 32 << 20
+)
+
 // memoryTracker tracks total memory usage across all collectors.
+//
+// Collectors account for the bytes they hold, including record capacity and
+// the records slice itself. That still misses memory the builder uses
+// elsewhere (scanner buffers, garbage awaiting collection, runtime
+// structures), so the tracker periodically samples the heap and treats the
+// difference as overhead that counts against the limit until the next
+// sample.
 type memoryTracker struct {
 	mu          sync.Mutex
-	totalBytes  int64
+	totalBytes  int64 // Bytes accounted by collectors.
+	overhead    int64 // Heap in use beyond totalBytes at the last sample.
+	sinceSample int64 // Accounted growth since the last sample.
+	backoff     int64 // Accounted growth left before overhead alone may force a GC.
 	maxBytes    int64
 	collectors  []*shardCollector
+	readHeap    func() int64 // Returns heap bytes in use; replaced in tests.
 }
 
 func newMemoryTracker(maxMB int, collectors []*shardCollector) *memoryTracker {
 	return &memoryTracker{
 		maxBytes:   int64(maxMB) * 1024 * 1024,
 		collectors: collectors,
+		readHeap:   heapInUse,
 	}
 }
 
+// heapInUse returns the bytes of allocated heap objects.
+func heapInUse() int64 {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return int64(ms.HeapAlloc)
+}
+
 func (m *memoryTracker) add(bytes int64) {
 	m.mu.Lock()
 	m.totalBytes += bytes
+	m.sinceSample += bytes
+	m.backoff = max(m.backoff-bytes, 0)
+	if m.sinceSample >= memorySampleInterval {
+		m.sampleLocked()
+	}
 	m.mu.Unlock()
 }
 
@@ -409,14 +958,60 @@ func (m *memoryTracker) remove(bytes int64) {
 	m.mu.Unlock()
 }
 
-func (m *memoryTracker) shouldSpill() bool {
+// sample measures the heap and updates the overhead estimate.
+func (m *memoryTracker) sample() {
+	m.mu.Lock()
+	m.sampleLocked()
+	m.mu.Unlock()
+}
+
+func (m *memoryTracker) sampleLocked() {
+	m.overhead = max(m.readHeap()-m.totalBytes, 0)
+	m.sinceSample = 0
+}
+
+// usage returns the estimated memory in use: accounted bytes plus the
+// overhead seen at the last heap sample.
+func (m *memoryTracker) usage() int64 {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return m.totalBytes > m.maxBytes
+	return m.totalBytes + m.overhead
+}
+
+// overheadBackoff returns the accounted growth between spill rounds forced
+// by overhead alone: a quarter of the limit, capped at the sample interval.
+func (m *memoryTracker) overheadBackoff() int64 {
+	return min(max(m.maxBytes/4, 1), memorySampleInterval)
+}
+
+func (m *memoryTracker) shouldSpill() bool {
+	return m.usage() > m.maxBytes
 }
 
 // spillUntilUnderLimit spills collectors until memory is under the limit.
 func (m *memoryTracker) spillUntilUnderLimit() error {
+	// If only the sampled overhead puts us over, it may be garbage the
+	// collector has yet to reclaim. Collect and measure again before
+	// spilling anything.
+	//
+	// When the overhead is real, every add would land here again, so after
+	// each such round the overhead alone is ignored until the collectors
+	// have grown by overheadBackoff.
+	m.mu.Lock()
+	overheadOnly := m.overhead > 0 && m.totalBytes <= m.maxBytes
+	if overheadOnly {
+		if m.backoff > 0 {
+			m.mu.Unlock()
+			return nil
+		}
+		m.backoff = m.overheadBackoff()
+	}
+	m.mu.Unlock()
+	if overheadOnly {
+		runtime.GC()
+		m.sample()
+	}
+
 	spillCount := 0
 	for m.shouldSpill() {
 		m.mu.Lock()
@@ -441,9 +1036,11 @@ func (m *memoryTracker) spillUntilUnderLimit() error {
 		spillCount++
 	}
 
-	// Hint to GC to release memory after significant spilling
+	// Hint to GC to release memory after significant spilling, then
+	// measure what is left.
 	if spillCount > 0 {
 		runtime.GC()
+		m.sample()
 	}
 	return nil
 }
@@ -451,19 +1048,27 @@ func (m *memoryTracker) spillUntilUnderLimit() error {
 func newShardCollector(shardID int, tempDir string, tracker *memoryTracker) *shardCollector {
 	return &shardCollector{
 		shardID:    shardID,
-		records:    make([][]byte, 0, 1000),
 		tempDir:    tempDir,
 		memTracker: tracker,
 	}
 }
 
+// Add adds a copy of record to the collector.
 func (c *shardCollector) Add(record []byte) error {
 	// Make a copy since the scanner reuses the buffer.
 	recordCopy := make([]byte, len(record))
 	copy(recordCopy, record)
+	return c.add(recordCopy)
+}
+
+// add adds record to the collector, which takes ownership of it.
+func (c *shardCollector) add(recordCopy []byte) error {
+	oldCap := cap(c.records)
 	c.records = append(c.records, recordCopy)
 
-	recordSize := int64(len(recordCopy) + 24) // 24 bytes slice header overhead estimate
+	// Charge the record's capacity plus any growth of the records slice.
+	// Collectors start empty, so idle shards cost nothing.
+	recordSize := int64(cap(recordCopy)) + int64(cap(c.records)-oldCap)*sliceHeaderSize
 	c.memoryBytes += recordSize
 	c.memTracker.add(recordSize)
 
@@ -2,12 +2,21 @@ package builder
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/discochess/stockpile/internal/search"
+	"github.com/discochess/stockpile/internal/seekable"
 )
 
 func TestExtractFEN(t *testing.T) {
@@ -179,6 +188,30 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
+func TestEstimateRemaining(t *testing.T) {
+	tests := []struct {
+		name        string
+		elapsed     time.Duration
+		done, total int64
+		want        time.Duration
+	}{
+		{"quarter done", time.Minute, 25, 100, 3 * time.Minute},
+		{"half done", 10 * time.Second, 1, 2, 10 * time.Second},
+		{"nothing done", time.Minute, 0, 100, 0},
+		{"complete", time.Minute, 100, 100, 0},
+		{"unknown total", time.Minute, 50, -1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := estimateRemaining(tt.elapsed, tt.done, tt.total)
+			if got != tt.want {
+				t.Errorf("estimateRemaining(%v, %d, %d) = %v, want %v", tt.elapsed, tt.done, tt.total, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestNewBuilder_Defaults(t *testing.T) {
 	b := NewBuilder()
 
@@ -191,6 +224,9 @@ func TestNewBuilder_Defaults(t *testing.T) {
 	if b.strategy == nil {
 		t.Error("strategy should not be nil")
 	}
+	if b.compressionLevel != zstd.SpeedBestCompression {
+		t.Errorf("compressionLevel = %v, want %v", b.compressionLevel, zstd.SpeedBestCompression)
+	}
 }
 
 func TestNewBuilder_WithOptions(t *testing.T) {
@@ -200,6 +236,7 @@ func TestNewBuilder_WithOptions(t *testing.T) {
 		WithTotalShards(100),
 		WithWorkers(8),
 		WithMaxMemoryMB(4096),
+		WithCompressionLevel(zstd.SpeedFastest),
 	)
 
 	if b.sourceURL != "http://example.com/data.jsonl" {
@@ -217,6 +254,9 @@ func TestNewBuilder_WithOptions(t *testing.T) {
 	if b.maxMemoryMB != 4096 {
 		t.Errorf("maxMemoryMB = %d", b.maxMemoryMB)
 	}
+	if b.compressionLevel != zstd.SpeedFastest {
+		t.Errorf("compressionLevel = %v", b.compressionLevel)
+	}
 }
 
 func TestBuildFromFile(t *testing.T) {
@@ -299,6 +339,340 @@ func TestBuildFromFile(t *testing.T) {
 	}
 }
 
+func TestBuildFromFile_MislabeledGzip(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Gzip data with a .jsonl extension must still be decompressed.
+	var data bytes.Buffer
+	gw := gzip.NewWriter(&data)
+	gw.Write([]byte(`{"fen":"8/8/8/8/8/8/8/8 w - - 0 1","evals":[{"pvs":[{"cp":0}],"knodes":1,"depth":1}]}` + "\n"))
+	gw.Close()
+
+	sourceFile := filepath.Join(tmpDir, "source.jsonl")
+	if err := os.WriteFile(sourceFile, data.Bytes(), 0644); err != nil {
+		t.Fatalf("writing source file: %v", err)
+	}
+
+	outputDir := filepath.Join(tmpDir, "output")
+	b := NewBuilder(
+		WithOutputDir(outputDir),
+		WithTotalShards(1),
+	)
+
+	if err := b.BuildFromFile(context.Background(), sourceFile, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+
+	m, err := ReadManifest(outputDir)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	if m.RecordCount != 1 {
+		t.Errorf("RecordCount = %d, want 1", m.RecordCount)
+	}
+}
+
+func TestBuildFromFile_Resume(t *testing.T) {
+	tmpDir := t.TempDir()
+	sourceFile := filepath.Join(tmpDir, "source.jsonl")
+	outputDir := filepath.Join(tmpDir, "output")
+
+	lines := []string{
+		`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[]}`,
+		`{"fen":"8/8/8/8/8/8/8/4K1k1 w - -","evals":[]}`,
+		`{"fen":"8/8/8/8/8/8/8/4Kk2 w - -","evals":[]}`,
+		`{"fen":"8/8/8/8/8/8/8/k3K3 w - -","evals":[]}`,
+		`{"fen":"8/8/8/8/8/8/8/1k2K3 w - -","evals":[]}`,
+	}
+
+	newBuilder := func() *Builder {
+		return NewBuilder(
+			WithOutputDir(outputDir),
+			WithTotalShards(4),
+			WithProgress(nil),
+			WithResume(true),
+			WithCheckpointInterval(2),
+		)
+	}
+
+	// First run fails on an oversized line after the first checkpoint.
+	var partial bytes.Buffer
+	for _, line := range lines[:3] {
+		partial.WriteString(line + "\n")
+	}
+	partial.Write(bytes.Repeat([]byte("x"), 11*1024*1024))
+	if err := os.WriteFile(sourceFile, partial.Bytes(), 0644); err != nil {
+		t.Fatalf("writing source file: %v", err)
+	}
+
+	if err := newBuilder().BuildFromFile(context.Background(), sourceFile, time.Time{}); err == nil {
+		t.Fatal("BuildFromFile() error = nil, want error")
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, ".tmp", checkpointFilename)); err != nil {
+		t.Fatalf("checkpoint not kept after failure: %v", err)
+	}
+
+	// Second run resumes from the checkpoint with the complete source.
+	var full bytes.Buffer
+	for _, line := range lines {
+		full.WriteString(line + "\n")
+	}
+	if err := os.WriteFile(sourceFile, full.Bytes(), 0644); err != nil {
+		t.Fatalf("writing source file: %v", err)
+	}
+
+	if err := newBuilder().BuildFromFile(context.Background(), sourceFile, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() resume error = %v", err)
+	}
+
+	m, err := ReadManifest(outputDir)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	if m.RecordCount != int64(len(lines)) {
+		t.Errorf("RecordCount = %d, want %d", m.RecordCount, len(lines))
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, ".tmp")); !os.IsNotExist(err) {
+		t.Error("temp directory not removed after successful build")
+	}
+}
+
+func TestBuildFromFile_Dedup(t *testing.T) {
+	tmpDir := t.TempDir()
+	sourceFile := filepath.Join(tmpDir, "source.jsonl")
+	outputDir := filepath.Join(tmpDir, "output")
+
+	source := `{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":1}],"knodes":10,"depth":20}]}
+{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":2}],"knodes":5,"depth":30}]}
+{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":3}],"knodes":50,"depth":30}]}
+{"fen":"8/8/8/8/8/8/8/4K1k1 w - -","evals":[{"pvs":[{"cp":4}],"knodes":1,"depth":1}]}
+`
+	if err := os.WriteFile(sourceFile, []byte(source), 0644); err != nil {
+		t.Fatalf("writing source file: %v", err)
+	}
+
+	var last Progress
+	b := NewBuilder(
+		WithOutputDir(outputDir),
+		WithTotalShards(1),
+		WithProgress(func(p Progress) { last = p }),
+	)
+	if err := b.BuildFromFile(context.Background(), sourceFile, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+
+	if last.Phase != "done" {
+		t.Fatalf("last phase = %q, want done", last.Phase)
+	}
+	if last.DuplicatesMerged != 2 {
+		t.Errorf("DuplicatesMerged = %d, want 2", last.DuplicatesMerged)
+	}
+	if last.RecordsWritten != 2 {
+		t.Errorf("RecordsWritten = %d, want 2", last.RecordsWritten)
+	}
+
+	m, err := ReadManifest(outputDir)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	if len(m.Shards) != 1 {
+		t.Fatalf("len(Shards) = %d, want 1", len(m.Shards))
+	}
+	si := m.Shards[0]
+	if si.RecordCount != 2 {
+		t.Errorf("Shards[0].RecordCount = %d, want 2", si.RecordCount)
+	}
+	if si.MinFEN != "8/8/8/8/8/8/8/4K1k1 w - -" || si.MaxFEN != "8/8/8/8/8/8/8/4K2k w - -" {
+		t.Errorf("Shards[0] range = [%q, %q]", si.MinFEN, si.MaxFEN)
+	}
+
+	compressed, err := os.ReadFile(filepath.Join(outputDir, "shards", "00000.zst"))
+	if err != nil {
+		t.Fatalf("reading shard: %v", err)
+	}
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		t.Fatalf("zstd.NewReader() error = %v", err)
+	}
+	defer decoder.Close()
+	data, err := decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		t.Fatalf("decoding shard: %v", err)
+	}
+	if sum := sha256.Sum256(compressed); si.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("Shards[0].SHA256 = %s, want %x", si.SHA256, sum)
+	}
+	if si.UncompressedSize != int64(len(data)) {
+		t.Errorf("Shards[0].UncompressedSize = %d, want %d", si.UncompressedSize, len(data))
+	}
+	if !bytes.Contains(data, []byte(`"cp":3`)) || bytes.Contains(data, []byte(`"cp":2`)) {
+		t.Errorf("shard kept the wrong duplicate:\n%s", data)
+	}
+}
+
+func TestBuildFromFile_SkipIllegal(t *testing.T) {
+	source := `{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":1}],"knodes":1,"depth":1}]}
+{"fen":"8/8/8/8/8/8/8/4K2K w - -","evals":[{"pvs":[{"cp":2}],"knodes":1,"depth":1}]}
+{"fen":"P7/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":3}],"knodes":1,"depth":1}]}
+`
+	for _, skip := range []bool{false, true} {
+		tmpDir := t.TempDir()
+		sourceFile := filepath.Join(tmpDir, "source.jsonl")
+		if err := os.WriteFile(sourceFile, []byte(source), 0644); err != nil {
+			t.Fatalf("writing source file: %v", err)
+		}
+
+		var last Progress
+		b := NewBuilder(
+			WithOutputDir(filepath.Join(tmpDir, "output")),
+			WithTotalShards(1),
+			WithSkipIllegal(skip),
+			WithProgress(func(p Progress) { last = p }),
+		)
+		if err := b.BuildFromFile(context.Background(), sourceFile, time.Time{}); err != nil {
+			t.Fatalf("BuildFromFile() error = %v", err)
+		}
+
+		if last.IllegalRecords != 2 {
+			t.Errorf("skip=%v: IllegalRecords = %d, want 2", skip, last.IllegalRecords)
+		}
+		want := int64(3)
+		if skip {
+			want = 1
+		}
+		if last.RecordsWritten != want {
+			t.Errorf("skip=%v: RecordsWritten = %d, want %d", skip, last.RecordsWritten, want)
+		}
+	}
+}
+
+func TestBuildFromFile_Index(t *testing.T) {
+	tmpDir := t.TempDir()
+	sourceFile := filepath.Join(tmpDir, "source.jsonl")
+	outputDir := filepath.Join(tmpDir, "output")
+
+	var source bytes.Buffer
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&source, `{"fen":"pos%04d","evals":[{"pvs":[{"cp":%d}],"knodes":1,"depth":1}]}`+"\n", i, i)
+	}
+	if err := os.WriteFile(sourceFile, source.Bytes(), 0644); err != nil {
+		t.Fatalf("writing source file: %v", err)
+	}
+
+	b := NewBuilder(
+		WithOutputDir(outputDir),
+		WithTotalShards(1),
+		WithProgress(nil),
+		WithBuildIndex(true),
+		WithFrameSize(1024),
+	)
+	if err := b.BuildFromFile(context.Background(), sourceFile, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+
+	m, err := ReadManifest(outputDir)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	if m.FrameSize != 1024 {
+		t.Errorf("FrameSize = %d, want 1024", m.FrameSize)
+	}
+
+	indexData, err := os.ReadFile(filepath.Join(outputDir, "shards", "00000.idx"))
+	if err != nil {
+		t.Fatalf("reading index: %v", err)
+	}
+	index, err := search.ParseIndex(indexData)
+	if err != nil {
+		t.Fatalf("ParseIndex() error = %v", err)
+	}
+	if want := (200 + search.DefaultIndexInterval - 1) / search.DefaultIndexInterval; index.Len() != want {
+		t.Errorf("index.Len() = %d, want %d", index.Len(), want)
+	}
+
+	compressed, err := os.ReadFile(filepath.Join(outputDir, "shards", "00000.zst"))
+	if err != nil {
+		t.Fatalf("reading shard: %v", err)
+	}
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		t.Fatalf("zstd.NewReader() error = %v", err)
+	}
+	defer decoder.Close()
+	data, err := decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		t.Fatalf("decoding shard: %v", err)
+	}
+
+	record, err := search.SearchIndexed(data, index, "pos0130")
+	if err != nil {
+		t.Fatalf("SearchIndexed() error = %v", err)
+	}
+	if record.FEN != "pos0130" {
+		t.Errorf("FEN = %q, want pos0130", record.FEN)
+	}
+
+	table, err := seekable.ReadTable(compressed)
+	if err != nil {
+		t.Fatalf("ReadTable() error = %v", err)
+	}
+	if table.NumFrames() < 2 {
+		t.Errorf("NumFrames() = %d, want several 1 KB frames", table.NumFrames())
+	}
+	record, err = search.SearchSeekable(compressed, index, "pos0130")
+	if err != nil {
+		t.Fatalf("SearchSeekable() error = %v", err)
+	}
+	if record.FEN != "pos0130" {
+		t.Errorf("SearchSeekable FEN = %q, want pos0130", record.FEN)
+	}
+}
+
+func TestManifestChecksums(t *testing.T) {
+	m := &Manifest{Shards: []ShardInfo{
+		{ID: 3, SHA256: "abc"},
+		{ID: 42, SHA256: "def"},
+		{ID: 7}, // Built before checksums were recorded.
+	}}
+
+	got := manifestChecksums(m)
+	want := map[string]string{"00003.zst": "abc", "00042.zst": "def"}
+	if len(got) != len(want) {
+		t.Fatalf("manifestChecksums() = %v, want %v", got, want)
+	}
+	for name, sum := range want {
+		if got[name] != sum {
+			t.Errorf("manifestChecksums()[%q] = %q, want %q", name, got[name], sum)
+		}
+	}
+}
+
+func TestPreferRecord(t *testing.T) {
+	shallow := []byte(`{"fen":"x","evals":[{"knodes":100,"depth":10}]}`)
+	deep := []byte(`{"fen":"x","evals":[{"knodes":1,"depth":40},{"knodes":9,"depth":12}]}`)
+	deepMoreNodes := []byte(`{"fen":"x","evals":[{"knodes":2,"depth":40}]}`)
+	invalid := []byte(`{"fen":"x","evals":`)
+
+	tests := []struct {
+		name string
+		a, b []byte
+		want []byte
+	}{
+		{"deeper wins", shallow, deep, deep},
+		{"order independent", deep, shallow, deep},
+		{"knodes tie-break", deep, deepMoreNodes, deepMoreNodes},
+		{"invalid loses", invalid, shallow, shallow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := preferRecord(tt.a, tt.b); !bytes.Equal(got, tt.want) {
+				t.Errorf("preferRecord() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestBuildFromFile_Cancellation(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "builder-test-*")
 	if err != nil {
@@ -480,3 +854,200 @@ func TestMemoryTracker_SpillsLargest(t *testing.T) {
 		t.Error("c2 should not be spilled")
 	}
 }
+
+// lichessRecord returns a record of realistic size (~250 bytes) for the
+// given index.
+func lichessRecord(i int) []byte {
+	return fmt.Appendf(nil, `{"fen":"r1bqkb1r/pppp1ppp/2n2n2/4p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - %d","evals":[{"pvs":[{"cp":31,"line":"e1g1 f8c5 d2d3 d7d6 c2c3 a7a6 a2a4 c8e6"},{"cp":24,"line":"d2d3 f8c5 c2c3 d7d6"}],"knodes":%d,"depth":36}]}`, i, 100000+i)
+}
+
+func TestMemoryTracker_RealisticSpill(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tracker := newMemoryTracker(0, nil)
+	tracker.maxBytes = 256 << 10
+	tracker.readHeap = func() int64 { return tracker.totalBytes } // No overhead.
+	collectors := make([]*shardCollector, 4)
+	for i := range collectors {
+		collectors[i] = newShardCollector(i, tmpDir, tracker)
+	}
+	tracker.collectors = collectors
+
+	const n = 5000
+	var raw int64
+	for i := 0; i < n; i++ {
+		record := lichessRecord(i)
+		raw += int64(len(record))
+		if err := collectors[i%len(collectors)].Add(record); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+		if tracker.totalBytes > tracker.maxBytes {
+			t.Fatalf("after record %d: accounted %d bytes, limit %d", i, tracker.totalBytes, tracker.maxBytes)
+		}
+	}
+	if raw < 4*tracker.maxBytes {
+		t.Fatalf("test input of %d bytes is too small to force spilling", raw)
+	}
+
+	var spills, total int
+	for _, c := range collectors {
+		spills += len(c.spilledFiles)
+		total += c.Count()
+
+		var inMemory int64
+		for _, r := range c.records {
+			inMemory += int64(cap(r))
+		}
+		inMemory += int64(cap(c.records)) * sliceHeaderSize
+		if c.memoryBytes != inMemory {
+			t.Errorf("collector %d accounts %d bytes, holds %d", c.shardID, c.memoryBytes, inMemory)
+		}
+	}
+	if spills == 0 {
+		t.Error("expected collectors to spill")
+	}
+	if total != n {
+		t.Errorf("collectors hold %d records, want %d", total, n)
+	}
+}
+
+func TestMemoryTracker_SpillsOnHeapOverhead(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tracker := newMemoryTracker(1, nil) // 1 MB.
+	c := newShardCollector(0, tmpDir, tracker)
+	tracker.collectors = []*shardCollector{c}
+
+	// Records alone stay far below the limit.
+	for i := 0; i < 10; i++ {
+		if err := c.Add(lichessRecord(i)); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if len(c.spilledFiles) != 0 {
+		t.Fatal("spilled before any overhead was measured")
+	}
+
+	// The heap shows memory the collectors do not account for.
+	tracker.readHeap = func() int64 { return tracker.totalBytes + 2<<20 }
+	tracker.sample()
+	if got := tracker.usage(); got < 2<<20 {
+		t.Errorf("usage() = %d, want the sampled overhead included", got)
+	}
+
+	if err := c.Add(lichessRecord(10)); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if len(c.spilledFiles) == 0 {
+		t.Error("expected a spill once the heap exceeds the limit")
+	}
+	if c.Count() != 11 {
+		t.Errorf("Count() = %d, want 11", c.Count())
+	}
+}
+
+func TestMemoryTracker_HeapOverheadBackoff(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// The heap always exceeds the 1 MB limit, however little is collected.
+	tracker := newMemoryTracker(1, nil)
+	var samples int
+	tracker.readHeap = func() int64 {
+		samples++
+		return tracker.totalBytes + 2<<20
+	}
+	tracker.sample()
+	c := newShardCollector(0, tmpDir, tracker)
+	tracker.collectors = []*shardCollector{c}
+
+	const n = 4000
+	for i := 0; i < n; i++ {
+		if err := c.Add(lichessRecord(i)); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if c.Count() != n {
+		t.Errorf("Count() = %d, want %d", c.Count(), n)
+	}
+
+	// Each round forced by the overhead samples twice: after the GC and
+	// after spilling. Rounds are spaced by the backoff, not run per record.
+	rounds := int(n*int64(len(lichessRecord(0)))/tracker.overheadBackoff()) + 2
+	if samples > 1+2*rounds {
+		t.Errorf("heap sampled %d times for %d records, want at most %d", samples, n, 1+2*rounds)
+	}
+	if len(c.spilledFiles) == 0 {
+		t.Error("expected the overhead to force spills")
+	}
+}
+
+// syntheticSource returns n source lines spread over a handful of legal
+// positions, with empty lines mixed in.
+func syntheticSource(n int) []byte {
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -",
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq -",
+		"r1bqkb1r/pppp1ppp/2n2n2/4p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq -",
+		"8/8/8/4k3/8/8/4K3/4R3 w - -",
+		"8/8/8/8/8/8/8/4K2k w - -",
+		"r3k2r/8/8/8/8/8/8/R3K2R w KQkq -",
+	}
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		if i%1000 == 999 {
+			buf.WriteByte('\n')
+			continue
+		}
+		fmt.Fprintf(&buf, `{"fen":"%s","evals":[{"pvs":[{"cp":%d,"line":"e2e4 e7e5"}],"knodes":%d,"depth":%d}]}`+"\n",
+			fens[i%len(fens)], i%100, i, 20+i%30)
+	}
+	return buf.Bytes()
+}
+
+// distribute runs the sort phase over source with the given worker count and
+// returns the collectors.
+func distribute(tb testing.TB, source []byte, workers int) []*shardCollector {
+	tb.Helper()
+	b := NewBuilder(WithTotalShards(64), WithWorkers(workers), WithProgress(nil), WithTempDir(tb.TempDir()))
+	collectors := make([]*shardCollector, b.totalShards)
+	tracker := newMemoryTracker(b.maxMemoryMB, collectors)
+	for i := range collectors {
+		collectors[i] = newShardCollector(i, b.tempDir, tracker)
+	}
+	cp := b.newCheckpoint("")
+	if err := b.distributeRecords(context.Background(), bytes.NewReader(source), collectors, tracker, cp, nil, time.Now()); err != nil {
+		tb.Fatalf("distributeRecords() error = %v", err)
+	}
+	return collectors
+}
+
+func TestDistributeRecords_Deterministic(t *testing.T) {
+	source := syntheticSource(20000)
+
+	want := distribute(t, source, 1)
+	got := distribute(t, source, 8)
+	for i := range want {
+		if len(got[i].records) != len(want[i].records) {
+			t.Fatalf("shard %d: %d records with 8 workers, %d with 1", i, len(got[i].records), len(want[i].records))
+		}
+		for j := range want[i].records {
+			if !bytes.Equal(got[i].records[j], want[i].records[j]) {
+				t.Fatalf("shard %d record %d differs between worker counts", i, j)
+			}
+		}
+	}
+}
+
+// BenchmarkDistributeRecords measures sort-phase throughput over a large
+// synthetic source with one worker and with several.
+func BenchmarkDistributeRecords(b *testing.B) {
+	source := syntheticSource(200000)
+	for _, workers := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(len(source)))
+			for i := 0; i < b.N; i++ {
+				distribute(b, source, workers)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	checkpointFilename = "checkpoint.json"
+	checkpointVersion  = 1
+
+	// DefaultCheckpointInterval is the number of source lines consumed
+	// between checkpoints when resume is enabled.
+	DefaultCheckpointInterval = 10_000_000
+
+	// checkpointShardInterval is the number of shards written between
+	// checkpoints during the shard phase.
+	checkpointShardInterval = 100
+)
+
+// checkpoint records build progress so an interrupted build can resume.
+// It is stored in the temp directory alongside the spill files it refers to.
+type checkpoint struct {
+	Version     int    `json:"version"`
+	SourcePath  string `json:"source_path"`
+	TotalShards int    `json:"total_shards"`
+	Strategy    string `json:"strategy"`
+
+	// CompressionLevel is the zstd level of the completed shards, so a
+	// resumed build does not mix levels.
+	CompressionLevel string `json:"compression_level"`
+
+	// LinesConsumed is the number of source lines fully distributed to
+	// spill files. Resuming skips this many lines of the source.
+	LinesConsumed int64 `json:"lines_consumed"`
+	RecordsRead   int64 `json:"records_read"`
+
+	// IllegalRecords counts records that failed fen.Validate so far.
+	IllegalRecords int64 `json:"illegal_records"`
+
+	// SortDone is set once the whole source has been spilled to disk.
+	SortDone bool `json:"sort_done"`
+
+	// Spills maps shard ID to the spill file names (relative to the temp
+	// directory) holding that shard's records.
+	Spills map[int]spillState `json:"spills,omitempty"`
+
+	// Shards records the shard files that have been completely written.
+	Shards map[int]completedShard `json:"shards,omitempty"`
+}
+
+// completedShard is a shard file written by an earlier run. Input is the
+// number of records collected for the shard, used to detect stale files.
+type completedShard struct {
+	Input int `json:"input"`
+	shardStats
+}
+
+// spillState is the persisted state of a shardCollector.
+type spillState struct {
+	Files []string `json:"files"`
+	Count int      `json:"count"`
+}
+
+// newCheckpoint creates an empty checkpoint for the given build.
+func (b *Builder) newCheckpoint(sourcePath string) *checkpoint {
+	return &checkpoint{
+		Version:          checkpointVersion,
+		SourcePath:       sourcePath,
+		TotalShards:      b.totalShards,
+		Strategy:         b.strategy.Name(),
+		CompressionLevel: b.compressionLevel.String(),
+		Shards:           make(map[int]completedShard),
+	}
+}
+
+// loadCheckpoint reads the checkpoint from the temp directory.
+// It returns nil if there is no checkpoint or it belongs to a different build.
+func (b *Builder) loadCheckpoint(sourcePath string) (*checkpoint, error) {
+	data, err := os.ReadFile(filepath.Join(b.tempDir, checkpointFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading checkpoint: %w", err)
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("parsing checkpoint: %w", err)
+	}
+
+	if cp.Version != checkpointVersion ||
+		cp.SourcePath != sourcePath ||
+		cp.TotalShards != b.totalShards ||
+		cp.Strategy != b.strategy.Name() ||
+		cp.CompressionLevel != b.compressionLevel.String() {
+		return nil, nil
+	}
+	if cp.Shards == nil {
+		cp.Shards = make(map[int]completedShard)
+	}
+
+	return &cp, nil
+}
+
+// save atomically writes the checkpoint to dir.
+func (cp *checkpoint) save(dir string) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("marshaling checkpoint: %w", err)
+	}
+
+	tmp := filepath.Join(dir, checkpointFilename+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("writing checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, checkpointFilename)); err != nil {
+		return fmt.Errorf("renaming checkpoint: %w", err)
+	}
+	return nil
+}
+
+// captureSpills records the spill state of every collector.
+// All collectors must have been fully spilled beforehand.
+func (cp *checkpoint) captureSpills(collectors []*shardCollector) {
+	cp.Spills = make(map[int]spillState)
+	for _, c := range collectors {
+		if len(c.spilledFiles) == 0 {
+			continue
+		}
+		files := make([]string, len(c.spilledFiles))
+		for i, path := range c.spilledFiles {
+			files[i] = filepath.Base(path)
+		}
+		cp.Spills[c.shardID] = spillState{Files: files, Count: c.spilledCount}
+	}
+}
+
+// restoreSpills loads the recorded spill state into the collectors.
+func (cp *checkpoint) restoreSpills(collectors []*shardCollector) {
+	for shardID, st := range cp.Spills {
+		if shardID < 0 || shardID >= len(collectors) {
+			continue
+		}
+		c := collectors[shardID]
+		c.spilledFiles = make([]string, len(st.Files))
+		for i, name := range st.Files {
+			c.spilledFiles[i] = filepath.Join(c.tempDir, name)
+		}
+		c.spilledCount = st.Count
+		c.spillCount = len(st.Files)
+	}
+}
+
+// spillAll flushes every collector's in-memory records to disk.
+func spillAll(collectors []*shardCollector) error {
+	for _, c := range collectors {
+		if err := c.spillToDisk(); err != nil {
+			return fmt.Errorf("spilling shard %d: %w", c.shardID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// CompactReport summarizes what Compact found in a database.
+type CompactReport struct {
+	ShardsScanned   int
+	ShardsRewritten int   // Shards that were out of order or had duplicates; 0 for a dry run.
+	Duplicates      int64 // Records sharing their FEN with a record that is kept.
+	OutOfOrder      int64 // Records whose FEN sorts before the previous record's.
+}
+
+// Compact re-sorts every shard of the database in the output directory by
+// FEN and collapses records with the same FEN into the deepest evaluation,
+// as a full build does. This repairs databases written by older builders
+// without rebuilding from source. Only shards that need it are rewritten,
+// and the manifest's record counts and per-shard info are updated to match.
+// With dryRun the shards are only scanned and nothing is written.
+//
+// As with Merge, the builder's strategy and shard count must match the
+// manifest, and rewritten shards use the manifest's compression settings.
+func (b *Builder) Compact(ctx context.Context, dryRun bool) (report CompactReport, err error) {
+	startTime := time.Now()
+	if b.resume {
+		return report, errors.New("compact does not support resume")
+	}
+
+	m, err := ReadManifest(b.outputDir)
+	if err != nil {
+		return report, err
+	}
+	if err := b.adoptManifest(m); err != nil {
+		return report, err
+	}
+
+	if !dryRun {
+		if b.tempDir == "" {
+			b.tempDir = filepath.Join(b.outputDir, ".tmp")
+		}
+		if err := os.MkdirAll(b.tempDir, 0755); err != nil {
+			return report, fmt.Errorf("creating temp directory: %w", err)
+		}
+		defer b.cleanupTempDir(&err)
+	}
+
+	b.reportProgress(Progress{Phase: "shard", ShardsTotal: len(m.Shards), StartTime: startTime})
+
+	// A fixed pool of workers compacts the shards fed to it; the first
+	// failure cancels the rest.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var rewritten []shardStats
+	var firstErr error
+	ids := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < max(b.workersCount, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for shardID := range ids {
+				scan, st, err := b.compactShard(ctx, shardID, dryRun)
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("compacting shard %d: %w", shardID, err)
+						cancel()
+					}
+					mu.Unlock()
+					continue
+				}
+				report.ShardsScanned++
+				report.Duplicates += scan.duplicates
+				report.OutOfOrder += scan.outOfOrder
+				if st != nil {
+					rewritten = append(rewritten, *st)
+				}
+				b.reportProgress(Progress{
+					Phase:            "shard",
+					DuplicatesMerged: report.Duplicates,
+					ShardsCreated:    report.ShardsScanned,
+					ShardsTotal:      len(m.Shards),
+					StartTime:        startTime,
+				})
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, si := range m.Shards {
+		select {
+		case ids <- si.ID:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(ids)
+	wg.Wait()
+	if firstErr != nil {
+		return report, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	report.ShardsRewritten = len(rewritten)
+
+	if len(rewritten) > 0 {
+		updateManifest(m, rewritten)
+		if err := WriteManifest(b.outputDir, m); err != nil {
+			return report, fmt.Errorf("writing manifest: %w", err)
+		}
+	}
+
+	b.reportProgress(Progress{
+		Phase:            "done",
+		RecordsWritten:   m.RecordCount,
+		DuplicatesMerged: report.Duplicates,
+		ShardsCreated:    m.ShardCount,
+		ShardsTotal:      m.TotalShards,
+		StartTime:        startTime,
+	})
+	return report, nil
+}
+
+// shardScan counts the problems Compact fixes in one shard.
+type shardScan struct {
+	duplicates int64
+	outOfOrder int64
+}
+
+// compactShard scans a shard and, unless dryRun or the shard is already
+// sorted and free of duplicates, rewrites it. The returned stats are nil if
+// the shard was not rewritten.
+func (b *Builder) compactShard(ctx context.Context, shardID int, dryRun bool) (shardScan, *shardStats, error) {
+	var scan shardScan
+	if err := ctx.Err(); err != nil {
+		return scan, nil, err
+	}
+	compressed, err := os.ReadFile(b.shardPath(shardID))
+	if err != nil {
+		return scan, nil, fmt.Errorf("reading shard: %w", err)
+	}
+	data, err := decodeShard(compressed, b.dictionary)
+	if err != nil {
+		return scan, nil, err
+	}
+
+	lines := shardLines(data)
+	fens := make([]string, len(lines))
+	for i, line := range lines {
+		fens[i] = extractFEN(line)
+		if i > 0 && fens[i] < fens[i-1] {
+			scan.outOfOrder++
+		}
+	}
+	slices.Sort(fens)
+	for i := 1; i < len(fens); i++ {
+		if fens[i] == fens[i-1] {
+			scan.duplicates++
+		}
+	}
+	if dryRun || (scan.duplicates == 0 && scan.outOfOrder == 0) {
+		return scan, nil, nil
+	}
+
+	c := newShardCollector(shardID, b.tempDir, nil)
+	c.memTracker = newMemoryTracker(b.maxMemoryMB, []*shardCollector{c})
+	for _, line := range lines {
+		if err := c.add(line); err != nil {
+			return scan, nil, err
+		}
+	}
+	st, err := b.rewriteShard(ctx, c)
+	if err != nil {
+		return scan, nil, err
+	}
+	return scan, &st, nil
+}
@@ -0,0 +1,159 @@
+package builder
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/discochess/stockpile/internal/shard/fnvshard"
+)
+
+func TestCompact(t *testing.T) {
+	const (
+		a = "8/8/8/8/8/8/8/1k2K3 w - -"
+		b = "8/8/8/8/8/8/8/4K2k w - -"
+		c = "8/8/8/8/8/8/8/k3K3 w - -"
+	)
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	source := filepath.Join(tmpDir, "source.jsonl")
+	writeSource(t, source, evalLine(a, 1))
+
+	out := filepath.Join(tmpDir, "out")
+	newBuilder := func() *Builder {
+		return NewBuilder(WithOutputDir(out), WithTotalShards(1), WithProgress(nil))
+	}
+	if err := newBuilder().BuildFromFile(ctx, source, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+
+	// Replace the shard with one an older builder might have written:
+	// unsorted, with a duplicate whose deeper copy comes last.
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd.NewWriter() error = %v", err)
+	}
+	shardPath := filepath.Join(out, "shards", "00000.zst")
+	stale := enc.EncodeAll([]byte(evalLine(a, 10)+evalLine(c, 20)+evalLine(b, 20)+evalLine(a, 30)), nil)
+	if err := os.WriteFile(shardPath, stale, 0644); err != nil {
+		t.Fatalf("writing shard: %v", err)
+	}
+
+	want := CompactReport{ShardsScanned: 1, Duplicates: 1, OutOfOrder: 2}
+	report, err := newBuilder().Compact(ctx, true)
+	if err != nil {
+		t.Fatalf("Compact(dry run) error = %v", err)
+	}
+	if report != want {
+		t.Errorf("Compact(dry run) = %+v, want %+v", report, want)
+	}
+	if data, _ := os.ReadFile(shardPath); string(data) != string(stale) {
+		t.Error("Compact(dry run) rewrote the shard")
+	}
+
+	want.ShardsRewritten = 1
+	report, err = newBuilder().Compact(ctx, false)
+	if err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if report != want {
+		t.Errorf("Compact() = %+v, want %+v", report, want)
+	}
+
+	compressed, err := os.ReadFile(shardPath)
+	if err != nil {
+		t.Fatalf("reading shard: %v", err)
+	}
+	data, err := decodeShard(compressed, nil)
+	if err != nil {
+		t.Fatalf("decodeShard() error = %v", err)
+	}
+	if got, want := string(data), evalLine(a, 30)+evalLine(b, 20)+evalLine(c, 20); got != want {
+		t.Errorf("compacted shard =\n%s\nwant\n%s", got, want)
+	}
+
+	m, err := ReadManifest(out)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	si := m.Shards[0]
+	if m.RecordCount != 3 || si.RecordCount != 3 || si.MinFEN != a || si.MaxFEN != c {
+		t.Errorf("manifest = %d records, shard %+v; want 3 records from %q to %q", m.RecordCount, si, a, c)
+	}
+	if sum := sha256.Sum256(compressed); si.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("Shards[0].SHA256 = %s, want %x", si.SHA256, sum)
+	}
+
+	// A compacted database is clean.
+	report, err = newBuilder().Compact(ctx, false)
+	if err != nil {
+		t.Fatalf("Compact() again error = %v", err)
+	}
+	if report != (CompactReport{ShardsScanned: 1}) {
+		t.Errorf("Compact() again = %+v, want nothing to fix", report)
+	}
+}
+
+func TestCompact_StopsAtFirstError(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "source.jsonl")
+	var lines []string
+	for i := range 60 {
+		lines = append(lines, evalLine(kingsFEN(i), 20))
+	}
+	writeSource(t, source, lines...)
+
+	out := filepath.Join(tmpDir, "out")
+	newBuilder := func() *Builder {
+		return NewBuilder(WithOutputDir(out), WithTotalShards(16), WithStrategy(fnvshard.New()), WithWorkers(1), WithProgress(nil))
+	}
+	if err := newBuilder().BuildFromFile(context.Background(), source, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+	m, err := ReadManifest(out)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	if len(m.Shards) < 2 {
+		t.Fatalf("built %d shards, want several", len(m.Shards))
+	}
+
+	// The first shard fed to the single worker is unreadable, so no other
+	// shard is compacted.
+	first := m.Shards[0].ID
+	if err := os.WriteFile(filepath.Join(out, "shards", fmt.Sprintf("%05d.zst", first)), []byte("not zstd"), 0644); err != nil {
+		t.Fatalf("writing shard: %v", err)
+	}
+	report, err := newBuilder().Compact(context.Background(), true)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("compacting shard %d", first)) {
+		t.Fatalf("Compact() error = %v, want shard %d to fail", err, first)
+	}
+	if report.ShardsScanned != 0 {
+		t.Errorf("Compact() scanned %d shards after the failure, want 0", report.ShardsScanned)
+	}
+}
+
+func TestCompact_MismatchedLayout(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "source.jsonl")
+	writeSource(t, source, evalLine(kingsFEN(0), 20))
+
+	out := filepath.Join(tmpDir, "out")
+	if err := NewBuilder(WithOutputDir(out), WithTotalShards(4), WithProgress(nil)).
+		BuildFromFile(context.Background(), source, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+
+	_, err := NewBuilder(WithOutputDir(out), WithTotalShards(8), WithProgress(nil)).Compact(context.Background(), true)
+	if err == nil || !strings.Contains(err.Error(), "shards") {
+		t.Errorf("Compact() error = %v, want shard count mismatch", err)
+	}
+}
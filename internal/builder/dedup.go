@@ -0,0 +1,60 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// evalQuality is the depth and node count of a record's deepest evaluation.
+type evalQuality struct {
+	depth  int
+	knodes int
+}
+
+// better reports whether q ranks above o: deeper first, then more nodes.
+func (q evalQuality) better(o evalQuality) bool {
+	if q.depth != o.depth {
+		return q.depth > o.depth
+	}
+	return q.knodes > o.knodes
+}
+
+// recordQuality extracts the deepest evaluation from a JSONL record.
+// Records that fail to parse rank below any valid record.
+func recordQuality(record []byte) evalQuality {
+	var r struct {
+		Evals []struct {
+			Depth  int `json:"depth"`
+			Knodes int `json:"knodes"`
+		} `json:"evals"`
+	}
+	if err := json.Unmarshal(record, &r); err != nil {
+		return evalQuality{depth: -1}
+	}
+
+	var best evalQuality
+	for _, e := range r.Evals {
+		q := evalQuality{depth: e.Depth, knodes: e.Knodes}
+		if q.better(best) {
+			best = q
+		}
+	}
+	return best
+}
+
+// preferRecord returns whichever of two records for the same FEN should be
+// kept. Exact ties are broken on the raw bytes so the result does not depend
+// on merge order.
+func preferRecord(a, b []byte) []byte {
+	qa, qb := recordQuality(a), recordQuality(b)
+	switch {
+	case qa.better(qb):
+		return a
+	case qb.better(qa):
+		return b
+	case bytes.Compare(a, b) <= 0:
+		return a
+	default:
+		return b
+	}
+}
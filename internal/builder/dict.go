@@ -0,0 +1,153 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/dict"
+	"github.com/klauspost/compress/zstd"
+)
+
+// dictionaryFilename is the trained zstd dictionary, stored next to the
+// manifest.
+const dictionaryFilename = "dictionary.zdict"
+
+const (
+	// dictMaxSamples caps how many records are kept for training.
+	dictMaxSamples = 1 << 14
+
+	// dictMaxSize is the largest dictionary trained, zstd's own default.
+	dictMaxSize = 112 << 10
+)
+
+// dictSampler keeps an evenly spaced sample of records for dictionary
+// training. It keeps every stride-th record; once full it drops every other
+// sample and doubles the stride, so the samples stay spread across the whole
+// input without knowing its length up front.
+type dictSampler struct {
+	samples [][]byte
+	stride  int64
+	seen    int64
+}
+
+func newDictSampler() *dictSampler {
+	return &dictSampler{stride: 1}
+}
+
+// add offers a record to the sampler. The record is copied if kept.
+func (s *dictSampler) add(record []byte) {
+	defer func() { s.seen++ }()
+	if s.seen%s.stride != 0 {
+		return
+	}
+	if len(s.samples) == dictMaxSamples {
+		for i := range len(s.samples) / 2 {
+			s.samples[i] = s.samples[2*i]
+		}
+		clear(s.samples[len(s.samples)/2:])
+		s.samples = s.samples[:len(s.samples)/2]
+		s.stride *= 2
+		if s.seen%s.stride != 0 {
+			return
+		}
+	}
+	s.samples = append(s.samples, bytes.Clone(record))
+}
+
+// dictTraining is the outcome of training a dictionary.
+type dictTraining struct {
+	dict []byte
+
+	// Compression ratios of held-out samples without and with the
+	// dictionary, compressed in frames like a shard.
+	plainRatio float64
+	dictRatio  float64
+}
+
+// trainDictionary trains a zstd dictionary on every other sample and
+// measures it on the rest.
+func trainDictionary(samples [][]byte, level zstd.EncoderLevel, frameSize int) (*dictTraining, error) {
+	var train, holdout [][]byte
+	for i, s := range samples {
+		if i%2 == 0 {
+			train = append(train, s)
+		} else {
+			holdout = append(holdout, s)
+		}
+	}
+	if len(train) == 0 || len(holdout) == 0 {
+		return nil, fmt.Errorf("training dictionary: only %d samples", len(samples))
+	}
+
+	// Derive the ID from the samples so rebuilds of the same input agree,
+	// while dictionaries of different inputs are unlikely to be confused.
+	id := crc32.NewIEEE()
+	for _, s := range train {
+		id.Write(s)
+	}
+	d, err := dict.BuildZstdDict(train, dict.Options{
+		MaxDictSize: dictMaxSize,
+		HashBytes:   6,
+		ZstdDictID:  32768 + id.Sum32()%(1<<31-32768), // IDs below 32768 are reserved.
+		ZstdLevel:   level,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("training dictionary: %w", err)
+	}
+
+	plain, err := compressionRatio(holdout, frameSize, zstd.WithEncoderLevel(level))
+	if err != nil {
+		return nil, err
+	}
+	withDict, err := compressionRatio(holdout, frameSize, zstd.WithEncoderLevel(level), zstd.WithEncoderDict(d))
+	if err != nil {
+		return nil, err
+	}
+	return &dictTraining{dict: d, plainRatio: plain, dictRatio: withDict}, nil
+}
+
+// compressionRatio compresses records in independent frames of about
+// frameSize bytes and returns the uncompressed to compressed size ratio.
+func compressionRatio(records [][]byte, frameSize int, opts ...zstd.EOption) (float64, error) {
+	enc, err := zstd.NewWriter(nil, append(opts, zstd.WithEncoderConcurrency(1))...)
+	if err != nil {
+		return 0, err
+	}
+	defer enc.Close()
+
+	var raw, compressed int
+	var frame, out []byte
+	flush := func() {
+		out = enc.EncodeAll(frame, out[:0])
+		raw += len(frame)
+		compressed += len(out)
+		frame = frame[:0]
+	}
+	for _, r := range records {
+		frame = append(frame, r...)
+		frame = append(frame, '\n')
+		if len(frame) >= frameSize {
+			flush()
+		}
+	}
+	if len(frame) > 0 {
+		flush()
+	}
+	return float64(raw) / float64(compressed), nil
+}
+
+// ReadDictionary returns the zstd dictionary the shards in dir were
+// compressed with, or nil if the manifest records none.
+func ReadDictionary(dir string, m *Manifest) ([]byte, error) {
+	if m.Dictionary == "" {
+		return nil, nil
+	}
+	d, err := os.ReadFile(filepath.Join(dir, m.Dictionary))
+	if err != nil {
+		return nil, fmt.Errorf("reading dictionary: %w", err)
+	}
+	return d, nil
+}
@@ -0,0 +1,128 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDictSampler_EvenlySpaced(t *testing.T) {
+	s := newDictSampler()
+	total := 3*dictMaxSamples + 7
+	for i := 0; i < total; i++ {
+		s.add([]byte(fmt.Sprint(i)))
+	}
+
+	if len(s.samples) > dictMaxSamples {
+		t.Fatalf("kept %d samples, want at most %d", len(s.samples), dictMaxSamples)
+	}
+	if s.stride != 4 {
+		t.Errorf("stride = %d, want 4", s.stride)
+	}
+	// Samples are every stride-th record from the start of the input to
+	// the end.
+	for i, sample := range s.samples {
+		if want := fmt.Sprint(int64(i) * s.stride); string(sample) != want {
+			t.Fatalf("samples[%d] = %s, want %s", i, sample, want)
+		}
+	}
+	if last := int64(len(s.samples)-1) * s.stride; last < int64(total)-s.stride {
+		t.Errorf("last sample is record %d of %d, want samples to span the input", last, total)
+	}
+}
+
+func TestBuildFromFile_Dictionary(t *testing.T) {
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+
+	var lines []string
+	for i := 0; i < 400; i++ {
+		lines = append(lines, string(lichessRecord(i))+"\n")
+	}
+	source := filepath.Join(tmpDir, "source.jsonl")
+	writeSource(t, source, lines...)
+
+	var last Progress
+	out := filepath.Join(tmpDir, "out")
+	b := NewBuilder(WithOutputDir(out), WithTotalShards(4), WithDictionary(true),
+		WithProgress(func(p Progress) { last = p }))
+	if err := b.BuildFromFile(ctx, source, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+	if last.PlainRatio <= 0 || last.DictRatio <= 0 {
+		t.Errorf("done progress ratios = %.2f, %.2f, want both measured", last.PlainRatio, last.DictRatio)
+	}
+
+	m, err := ReadManifest(out)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	if m.Dictionary != dictionaryFilename {
+		t.Fatalf("manifest Dictionary = %q, want %q", m.Dictionary, dictionaryFilename)
+	}
+	dict, err := ReadDictionary(out, m)
+	if err != nil {
+		t.Fatalf("ReadDictionary() error = %v", err)
+	}
+
+	// Shards only decode with the dictionary.
+	compressed, err := os.ReadFile(b.shardPath(m.Shards[0].ID))
+	if err != nil {
+		t.Fatalf("reading shard: %v", err)
+	}
+	data, err := decodeShard(compressed, dict)
+	if err != nil {
+		t.Fatalf("decodeShard() error = %v", err)
+	}
+	if int64(len(data)) != m.Shards[0].UncompressedSize {
+		t.Errorf("decoded %d bytes, want %d", len(data), m.Shards[0].UncompressedSize)
+	}
+	if _, err := decodeShard(compressed, nil); err == nil {
+		t.Error("decodeShard() without dictionary succeeded, want error")
+	}
+
+	// A merge keeps using the database's dictionary.
+	delta := filepath.Join(tmpDir, "delta.jsonl")
+	writeSource(t, delta, string(lichessRecord(5000))+"\n")
+	if err := NewBuilder(WithOutputDir(out), WithTotalShards(4), WithProgress(nil)).Merge(ctx, delta); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	merged, err := ReadManifest(out)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	if merged.Dictionary != dictionaryFilename {
+		t.Errorf("merged manifest Dictionary = %q, want %q", merged.Dictionary, dictionaryFilename)
+	}
+	for _, si := range merged.Shards {
+		compressed, err := os.ReadFile(b.shardPath(si.ID))
+		if err != nil {
+			t.Fatalf("reading shard: %v", err)
+		}
+		if _, err := decodeShard(compressed, dict); err != nil {
+			t.Errorf("shard %d: decodeShard() error = %v", si.ID, err)
+		}
+	}
+}
+
+func TestBuildFromFile_DictionaryTooFewRecords(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "source.jsonl")
+	writeSource(t, source, evalLine(kingsFEN(0), 20))
+
+	out := filepath.Join(tmpDir, "out")
+	b := NewBuilder(WithOutputDir(out), WithTotalShards(4), WithDictionary(true), WithProgress(nil))
+	if err := b.BuildFromFile(context.Background(), source, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+	m, err := ReadManifest(out)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	if m.Dictionary != "" {
+		t.Errorf("manifest Dictionary = %q, want none for a single record", m.Dictionary)
+	}
+}
@@ -2,12 +2,18 @@ package builder
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/rand/v2"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 // DefaultDialTimeout is the default timeout for establishing connections.
@@ -16,9 +22,23 @@ const DefaultDialTimeout = 30 * time.Second
 // DefaultResponseHeaderTimeout is the default timeout for receiving response headers.
 const DefaultResponseHeaderTimeout = 30 * time.Second
 
+// DefaultMaxRetries is the default number of times DownloadToFile retries
+// after a transient failure.
+const DefaultMaxRetries = 5
+
+// DefaultRetryBackoff is the default delay before the first retry.
+// Each further retry doubles it, up to maxRetryBackoff.
+const DefaultRetryBackoff = time.Second
+
+// maxRetryBackoff caps the delay between retries.
+const maxRetryBackoff = 5 * time.Minute
+
 // Downloader handles downloading files with resume support.
 type Downloader struct {
-	client *http.Client
+	client       *http.Client
+	maxRetries   int
+	retryBackoff time.Duration
+	limiter      *rate.Limiter
 }
 
 // DownloaderOption configures a Downloader.
@@ -40,6 +60,37 @@ func WithTimeout(timeout time.Duration) DownloaderOption {
 	}
 }
 
+// WithMaxRetries sets how many times DownloadToFile retries after a
+// transient failure. Zero disables retries.
+func WithMaxRetries(n int) DownloaderOption {
+	return func(d *Downloader) {
+		d.maxRetries = n
+	}
+}
+
+// WithRetryBackoff sets the delay before the first retry. The delay doubles
+// with each further retry, and each delay is randomized by up to half to
+// avoid synchronized retries.
+func WithRetryBackoff(base time.Duration) DownloaderOption {
+	return func(d *Downloader) {
+		d.retryBackoff = base
+	}
+}
+
+// WithRateLimit caps the download speed of DownloadToFile at bytesPerSec,
+// using a token bucket that allows bursts of up to one second of data.
+// Zero or a negative value means unlimited.
+func WithRateLimit(bytesPerSec int64) DownloaderOption {
+	return func(d *Downloader) {
+		if bytesPerSec <= 0 {
+			d.limiter = nil
+			return
+		}
+		burst := int(min(bytesPerSec, math.MaxInt32))
+		d.limiter = rate.NewLimiter(rate.Limit(bytesPerSec), burst)
+	}
+}
+
 // NewDownloader creates a new Downloader with sensible defaults.
 func NewDownloader(opts ...DownloaderOption) *Downloader {
 	d := &Downloader{
@@ -52,6 +103,8 @@ func NewDownloader(opts ...DownloaderOption) *Downloader {
 				ExpectContinueTimeout: 1 * time.Second,
 			},
 		},
+		maxRetries:   DefaultMaxRetries,
+		retryBackoff: DefaultRetryBackoff,
 	}
 	for _, opt := range opts {
 		opt(d)
@@ -59,6 +112,16 @@ func NewDownloader(opts ...DownloaderOption) *Downloader {
 	return d
 }
 
+// statusError is returned for an unexpected HTTP response status.
+type statusError struct {
+	status string
+	code   int
+}
+
+func (e *statusError) Error() string {
+	return "unexpected status: " + e.status
+}
+
 // Download downloads a URL to a file with resume support.
 // Returns the total size and a reader for the content.
 func (d *Downloader) Download(ctx context.Context, url string, destPath string) (io.ReadCloser, int64, error) {
@@ -68,30 +131,38 @@ func (d *Downloader) Download(ctx context.Context, url string, destPath string)
 		existingSize = info.Size()
 	}
 
+	body, totalSize, _, err := d.get(ctx, url, existingSize)
+	return body, totalSize, err
+}
+
+// get issues a GET for url starting at offset. It reports whether the
+// server honored the range; if not, the body starts at offset 0.
+func (d *Downloader) get(ctx context.Context, url string, offset int64) (io.ReadCloser, int64, bool, error) {
 	// Create request with range header for resume.
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, 0, fmt.Errorf("creating request: %w", err)
+		return nil, 0, false, fmt.Errorf("creating request: %w", err)
 	}
 
-	if existingSize > 0 {
-		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", existingSize))
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
 	}
 
 	resp, err := d.client.Do(req)
 	if err != nil {
-		return nil, 0, fmt.Errorf("downloading: %w", err)
+		return nil, 0, false, fmt.Errorf("downloading: %w", err)
 	}
 
 	// Check response status.
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
 		resp.Body.Close()
-		return nil, 0, fmt.Errorf("unexpected status: %s", resp.Status)
+		return nil, 0, false, &statusError{status: resp.Status, code: resp.StatusCode}
 	}
 
 	// Get total size.
 	var totalSize int64
-	if resp.StatusCode == http.StatusPartialContent {
+	partial := resp.StatusCode == http.StatusPartialContent
+	if partial {
 		// Parse Content-Range header.
 		contentRange := resp.Header.Get("Content-Range")
 		if contentRange != "" {
@@ -99,33 +170,57 @@ func (d *Downloader) Download(ctx context.Context, url string, destPath string)
 			var start, end int64
 			_, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &totalSize)
 			if err != nil {
-				totalSize = existingSize + resp.ContentLength
+				totalSize = offset + resp.ContentLength
 			}
 		}
 	} else {
 		totalSize = resp.ContentLength
-		existingSize = 0 // Server didn't support range, start over.
 	}
 
-	return resp.Body, totalSize, nil
+	return resp.Body, totalSize, partial, nil
 }
 
 // DownloadToFile downloads a URL directly to a file.
+// A partial file from an earlier attempt is resumed. Transient failures
+// (a truncated body, a network error, or a 5xx response) are retried with
+// exponential backoff, continuing from the bytes already written.
 func (d *Downloader) DownloadToFile(ctx context.Context, url string, destPath string, progress ProgressFunc) error {
-	body, totalSize, err := d.Download(ctx, url, destPath)
-	if err != nil {
-		return err
+	for attempt := 0; ; attempt++ {
+		err := d.downloadOnce(ctx, url, destPath, progress)
+		if err == nil || attempt >= d.maxRetries || !isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		timer := time.NewTimer(d.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
 	}
-	defer body.Close()
+}
 
+// downloadOnce makes a single attempt to download the rest of url into destPath.
+func (d *Downloader) downloadOnce(ctx context.Context, url string, destPath string, progress ProgressFunc) error {
 	// Check existing size for append mode.
 	var existingSize int64
-	var flags int
 	if info, err := os.Stat(destPath); err == nil {
 		existingSize = info.Size()
+	}
+
+	body, totalSize, partial, err := d.get(ctx, url, existingSize)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	// Append to a partial file only if the server resumed from its end.
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if partial {
 		flags = os.O_WRONLY | os.O_APPEND
 	} else {
-		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		existingSize = 0 // Server didn't support range, start over.
 	}
 
 	file, err := os.OpenFile(destPath, flags, 0644)
@@ -134,9 +229,14 @@ func (d *Downloader) DownloadToFile(ctx context.Context, url string, destPath st
 	}
 	defer file.Close()
 
-	// Copy with progress.
+	// Copy with progress. With a rate limit, reads are no larger than the
+	// limiter's burst so each one can be admitted.
 	buf := make([]byte, 32*1024)
+	if d.limiter != nil {
+		buf = buf[:min(len(buf), d.limiter.Burst())]
+	}
 	var downloaded int64 = existingSize
+	start := time.Now()
 
 	for {
 		select {
@@ -147,16 +247,24 @@ func (d *Downloader) DownloadToFile(ctx context.Context, url string, destPath st
 
 		n, err := body.Read(buf)
 		if n > 0 {
+			if err := d.throttle(ctx, n); err != nil {
+				return err
+			}
 			if _, writeErr := file.Write(buf[:n]); writeErr != nil {
 				return fmt.Errorf("writing file: %w", writeErr)
 			}
 			downloaded += int64(n)
 
 			if progress != nil {
+				// Rates cover this attempt only, not bytes resumed from disk.
+				elapsed := time.Since(start)
 				progress(Progress{
 					Phase:           "download",
 					BytesDownloaded: downloaded,
 					BytesTotal:      totalSize,
+					StartTime:       start,
+					BytesPerSec:     perSecond(downloaded-existingSize, elapsed),
+					ETA:             estimateRemaining(elapsed, downloaded-existingSize, totalSize-existingSize),
 				})
 			}
 		}
@@ -171,6 +279,61 @@ func (d *Downloader) DownloadToFile(ctx context.Context, url string, destPath st
 	return nil
 }
 
+// throttle blocks until the rate limiter admits n bytes or ctx is done.
+func (d *Downloader) throttle(ctx context.Context, n int) error {
+	if d.limiter == nil {
+		return nil
+	}
+	r := d.limiter.ReserveN(time.Now(), n)
+	if !r.OK() {
+		return fmt.Errorf("rate limit: read of %d bytes exceeds burst", n)
+	}
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// backoff returns the randomized delay before the given retry (0-based).
+func (d *Downloader) backoff(attempt int) time.Duration {
+	delay := d.retryBackoff
+	for i := 0; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxRetryBackoff)
+	if delay <= 0 {
+		return 0
+	}
+	// Randomize within [delay/2, delay].
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// isRetryable reports whether a download error is likely transient.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= 500
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
 // GetContentLength gets the content length of a URL without downloading.
 func (d *Downloader) GetContentLength(ctx context.Context, url string) (int64, error) {
 	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
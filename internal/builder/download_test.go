@@ -0,0 +1,223 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// rangeHandler serves content, honoring "bytes=N-" Range requests.
+func rangeHandler(content []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var start int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start); err == nil {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)-start))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(content[start:])
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Write(content)
+	}
+}
+
+func TestDownloadToFile_RetriesTruncatedBody(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+
+	var requests atomic.Int32
+	serve := rangeHandler(content)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			// Promise the whole body but send only half of it.
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write(content[:len(content)/2])
+			return
+		}
+		serve(w, r)
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "source")
+	d := NewDownloader(WithRetryBackoff(time.Millisecond))
+	if err := d.DownloadToFile(context.Background(), srv.URL, dest, nil); err != nil {
+		t.Fatalf("DownloadToFile() error = %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("reading download: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("downloaded %d bytes, want %d matching bytes", len(got), len(content))
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+func TestDownloadToFile_RetriesServerError(t *testing.T) {
+	content := []byte("evaluation data")
+
+	var requests atomic.Int32
+	serve := rangeHandler(content)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		serve(w, r)
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "source")
+	d := NewDownloader(WithRetryBackoff(time.Millisecond))
+	if err := d.DownloadToFile(context.Background(), srv.URL, dest, nil); err != nil {
+		t.Fatalf("DownloadToFile() error = %v", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("requests = %d, want 3", n)
+	}
+}
+
+func TestDownloadToFile_MaxRetries(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "source")
+	d := NewDownloader(WithMaxRetries(2), WithRetryBackoff(time.Millisecond))
+	if err := d.DownloadToFile(context.Background(), srv.URL, dest, nil); err == nil {
+		t.Fatal("DownloadToFile() error = nil, want error")
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("requests = %d, want 3 (1 attempt + 2 retries)", n)
+	}
+}
+
+func TestDownloadToFile_NoRetryOnClientError(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "source")
+	d := NewDownloader(WithRetryBackoff(time.Millisecond))
+	if err := d.DownloadToFile(context.Background(), srv.URL, dest, nil); err == nil {
+		t.Fatal("DownloadToFile() error = nil, want error")
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+}
+
+func TestDownloadToFile_CanceledDuringBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	dest := filepath.Join(t.TempDir(), "source")
+	d := NewDownloader(WithRetryBackoff(time.Hour))
+
+	start := time.Now()
+	err := d.DownloadToFile(ctx, srv.URL, dest, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("DownloadToFile() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("DownloadToFile() took %v, want prompt return on cancellation", elapsed)
+	}
+}
+
+func TestDownloadToFile_RestartsWhenRangeIgnored(t *testing.T) {
+	content := []byte("complete file contents")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Ignore Range and always send the full body.
+		w.Write(content)
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "source")
+	if err := os.WriteFile(dest, []byte("stale partial"), 0644); err != nil {
+		t.Fatalf("writing partial file: %v", err)
+	}
+
+	if err := NewDownloader().DownloadToFile(context.Background(), srv.URL, dest, nil); err != nil {
+		t.Fatalf("DownloadToFile() error = %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("reading download: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("file = %q, want %q", got, content)
+	}
+}
+
+func TestDownloadToFile_RateLimit(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), 64*1024)
+
+	srv := httptest.NewServer(rangeHandler(content))
+	defer srv.Close()
+
+	// The bucket starts full with one second of data, so the remaining
+	// 32 KiB takes about a second at 32 KiB/s.
+	const limit = 32 * 1024
+	var last Progress
+	progress := func(p Progress) { last = p }
+
+	dest := filepath.Join(t.TempDir(), "source")
+	d := NewDownloader(WithRateLimit(limit))
+
+	start := time.Now()
+	if err := d.DownloadToFile(context.Background(), srv.URL, dest, progress); err != nil {
+		t.Fatalf("DownloadToFile() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Errorf("DownloadToFile() took %v, want throttling to about 1s", elapsed)
+	}
+	if last.BytesDownloaded != int64(len(content)) {
+		t.Errorf("last progress BytesDownloaded = %d, want %d", last.BytesDownloaded, len(content))
+	}
+	if last.BytesPerSec <= 0 || last.BytesPerSec > 4*limit {
+		t.Errorf("last progress BytesPerSec = %.0f, want about %d", last.BytesPerSec, 2*limit)
+	}
+}
+
+func TestDownloadToFile_RateLimitCanceled(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), 64*1024)
+
+	srv := httptest.NewServer(rangeHandler(content))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	dest := filepath.Join(t.TempDir(), "source")
+	d := NewDownloader(WithRateLimit(1024))
+
+	err := d.DownloadToFile(ctx, srv.URL, dest, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("DownloadToFile() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
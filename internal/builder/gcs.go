@@ -3,6 +3,7 @@ package builder
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,17 +14,36 @@ import (
 	"google.golang.org/api/iterator"
 )
 
+// Compile-time check that GCSUploader implements RemoteShards.
+var _ RemoteShards = (*GCSUploader)(nil)
+
+// checksumMetadataKey is the object metadata key holding a shard's SHA-256.
+const checksumMetadataKey = "sha256"
+
 // GCSUploader uploads build output to Google Cloud Storage.
 type GCSUploader struct {
-	client *storage.Client
-	bucket *storage.BucketHandle
-	prefix string
+	client        *storage.Client
+	bucket        *storage.BucketHandle
+	prefix        string
+	skipUnchanged bool
+}
+
+// UploaderOption configures a GCSUploader.
+type UploaderOption func(*GCSUploader)
+
+// WithSkipUnchanged skips uploading shards whose remote copy already has the
+// checksum recorded for them in the local manifest. Uploaded shards carry
+// their checksum in object metadata, so the comparison costs one metadata
+// request per shard instead of a transfer. Shards without a manifest checksum
+// are always uploaded.
+func WithSkipUnchanged(skip bool) UploaderOption {
+	return func(u *GCSUploader) { u.skipUnchanged = skip }
 }
 
 // NewGCSUploader creates a new GCS uploader.
 // gcsPath should be in the format "gs://bucket/prefix".
-func NewGCSUploader(ctx context.Context, gcsPath string) (*GCSUploader, error) {
-	bucket, prefix, err := parseGCSPath(gcsPath)
+func NewGCSUploader(ctx context.Context, gcsPath string, opts ...UploaderOption) (*GCSUploader, error) {
+	bucket, prefix, err := ParseGCSPath(gcsPath)
 	if err != nil {
 		return nil, err
 	}
@@ -33,15 +53,19 @@ func NewGCSUploader(ctx context.Context, gcsPath string) (*GCSUploader, error) {
 		return nil, fmt.Errorf("creating GCS client: %w", err)
 	}
 
-	return &GCSUploader{
+	u := &GCSUploader{
 		client: client,
 		bucket: client.Bucket(bucket),
 		prefix: prefix,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u, nil
 }
 
-// parseGCSPath parses "gs://bucket/prefix" into bucket and prefix.
-func parseGCSPath(gcsPath string) (bucket, prefix string, err error) {
+// ParseGCSPath parses "gs://bucket/prefix" into bucket and prefix.
+func ParseGCSPath(gcsPath string) (bucket, prefix string, err error) {
 	if !strings.HasPrefix(gcsPath, "gs://") {
 		return "", "", fmt.Errorf("invalid GCS path: must start with gs://")
 	}
@@ -66,6 +90,8 @@ func parseGCSPath(gcsPath string) (bucket, prefix string, err error) {
 // Upload uploads the built shards and manifest from localDir to GCS.
 // It uploads new shards first (overwriting), then cleans up stale shards.
 // This "upload first, cleanup after" approach minimizes downtime.
+// With WithSkipUnchanged, shards already present remotely with the same
+// checksum are left in place.
 func (u *GCSUploader) Upload(ctx context.Context, localDir string, progress ProgressFunc) error {
 	shardsDir := filepath.Join(localDir, "shards")
 
@@ -75,11 +101,18 @@ func (u *GCSUploader) Upload(ctx context.Context, localDir string, progress Prog
 		return fmt.Errorf("reading shards directory: %w", err)
 	}
 
-	// Track uploaded shard names for cleanup.
-	uploadedShards := make(map[string]bool)
+	// Load shard checksums so they can be recorded on upload and compared
+	// against remote objects. Older manifests have none.
+	var checksums map[string]string
+	if m, err := ReadManifest(localDir); err == nil {
+		checksums = manifestChecksums(m)
+	}
+
+	// Track current shard names for cleanup.
+	currentShards := make(map[string]bool)
 
 	// Upload new shards (overwrites existing).
-	var uploaded int
+	var processed, uploaded, skipped int
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
@@ -87,79 +120,126 @@ func (u *GCSUploader) Upload(ctx context.Context, localDir string, progress Prog
 
 		localPath := filepath.Join(shardsDir, entry.Name())
 		gcsKey := u.prefix + "shards/" + entry.Name()
+		checksum := checksums[entry.Name()]
+
+		unchanged := false
+		if u.skipUnchanged && checksum != "" {
+			unchanged, err = u.hasChecksum(ctx, gcsKey, checksum)
+			if err != nil {
+				return fmt.Errorf("checking %s: %w", entry.Name(), err)
+			}
+		}
 
-		if err := u.uploadFile(ctx, localPath, gcsKey); err != nil {
-			return fmt.Errorf("uploading %s: %w", entry.Name(), err)
+		if unchanged {
+			skipped++
+		} else {
+			if err := u.uploadFile(ctx, localPath, gcsKey, checksum); err != nil {
+				return fmt.Errorf("uploading %s: %w", entry.Name(), err)
+			}
+			uploaded++
 		}
 
-		uploadedShards[entry.Name()] = true
-		uploaded++
-		if progress != nil && uploaded%100 == 0 {
+		currentShards[entry.Name()] = true
+		processed++
+		if progress != nil && processed%100 == 0 {
 			progress(Progress{
-				Phase:         "upload",
-				ShardsCreated: uploaded,
-				ShardsTotal:   len(entries),
+				Phase:          "upload",
+				ShardsCreated:  processed,
+				ShardsTotal:    len(entries),
+				ShardsUploaded: uploaded,
+				ShardsSkipped:  skipped,
 			})
 		}
 	}
 
+	// Upload the dictionary before the manifest that refers to it.
+	dictPath := filepath.Join(localDir, dictionaryFilename)
+	if _, err := os.Stat(dictPath); err == nil {
+		if err := u.uploadFile(ctx, dictPath, u.prefix+dictionaryFilename, ""); err != nil {
+			return fmt.Errorf("uploading dictionary: %w", err)
+		}
+	}
+
 	// Upload manifest.
 	manifestPath := filepath.Join(localDir, manifestFilename)
 	if _, err := os.Stat(manifestPath); err == nil {
 		gcsKey := u.prefix + manifestFilename
-		if err := u.uploadFile(ctx, manifestPath, gcsKey); err != nil {
+		if err := u.uploadFile(ctx, manifestPath, gcsKey, ""); err != nil {
 			return fmt.Errorf("uploading manifest: %w", err)
 		}
 	}
 
 	// Clean up stale shards (exist in GCS but not in new build).
-	if err := u.cleanStaleShards(ctx, uploadedShards); err != nil {
+	if _, err := PruneShards(ctx, u, currentShards, false); err != nil {
 		// Log but don't fail - stale shards are harmless.
 		fmt.Printf("[Upload] Warning: failed to clean stale shards: %v\n", err)
 	}
 
 	if progress != nil {
 		progress(Progress{
-			Phase:         "upload",
-			ShardsCreated: uploaded,
-			ShardsTotal:   len(entries),
+			Phase:          "upload",
+			ShardsCreated:  processed,
+			ShardsTotal:    len(entries),
+			ShardsUploaded: uploaded,
+			ShardsSkipped:  skipped,
 		})
 	}
 
 	return nil
 }
 
-// cleanStaleShards deletes shard files in GCS that aren't in the new build.
-func (u *GCSUploader) cleanStaleShards(ctx context.Context, currentShards map[string]bool) error {
+// manifestChecksums returns the recorded shard checksums keyed by shard
+// file name.
+func manifestChecksums(m *Manifest) map[string]string {
+	checksums := make(map[string]string, len(m.Shards))
+	for _, si := range m.Shards {
+		if si.SHA256 != "" {
+			checksums[shardFilename(si.ID)] = si.SHA256
+		}
+	}
+	return checksums
+}
+
+// hasChecksum reports whether the object at gcsKey exists and carries the
+// given checksum in its metadata.
+func (u *GCSUploader) hasChecksum(ctx context.Context, gcsKey, checksum string) (bool, error) {
+	attrs, err := u.bucket.Object(gcsKey).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return attrs.Metadata[checksumMetadataKey] == checksum, nil
+}
+
+// ListShards returns the names of the files under the shards/ prefix.
+func (u *GCSUploader) ListShards(ctx context.Context) ([]string, error) {
 	prefix := u.prefix + "shards/"
 	it := u.bucket.Objects(ctx, &storage.Query{Prefix: prefix})
 
+	var names []string
 	for {
 		attrs, err := it.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return fmt.Errorf("listing objects: %w", err)
-		}
-
-		// Extract shard filename from full path.
-		shardName := strings.TrimPrefix(attrs.Name, prefix)
-		if currentShards[shardName] {
-			continue // Keep this shard.
-		}
-
-		// Delete stale shard.
-		if err := u.bucket.Object(attrs.Name).Delete(ctx); err != nil {
-			return fmt.Errorf("deleting stale shard %s: %w", attrs.Name, err)
+			return nil, fmt.Errorf("listing objects: %w", err)
 		}
+		names = append(names, strings.TrimPrefix(attrs.Name, prefix))
 	}
+	return names, nil
+}
 
-	return nil
+// DeleteShard deletes the named file under the shards/ prefix.
+func (u *GCSUploader) DeleteShard(ctx context.Context, name string) error {
+	return u.bucket.Object(u.prefix + "shards/" + name).Delete(ctx)
 }
 
 // uploadFile uploads a single file to GCS.
-func (u *GCSUploader) uploadFile(ctx context.Context, localPath, gcsKey string) error {
+// A non-empty checksum is stored in the object's metadata.
+func (u *GCSUploader) uploadFile(ctx context.Context, localPath, gcsKey, checksum string) error {
 	file, err := os.Open(localPath)
 	if err != nil {
 		return err
@@ -168,6 +248,9 @@ func (u *GCSUploader) uploadFile(ctx context.Context, localPath, gcsKey string)
 
 	obj := u.bucket.Object(gcsKey)
 	writer := obj.NewWriter(ctx)
+	if checksum != "" {
+		writer.Metadata = map[string]string{checksumMetadataKey: checksum}
+	}
 
 	if _, err := io.Copy(writer, file); err != nil {
 		writer.Close()
@@ -10,14 +10,30 @@ import (
 
 // Manifest contains metadata about a built stockpile database.
 type Manifest struct {
-	Version      int       `json:"version"`
-	TotalShards  int       `json:"total_shards"`
-	Strategy     string    `json:"strategy"`
-	RecordCount  int64     `json:"record_count"`
-	ShardCount   int       `json:"shard_count"` // Non-empty shards
-	BuiltAt      time.Time `json:"built_at"`
-	SourceURL    string    `json:"source_url,omitempty"`
-	Compression  string    `json:"compression"`
+	Version          int         `json:"version"`
+	TotalShards      int         `json:"total_shards"`
+	Strategy         string      `json:"strategy"`
+	RecordCount      int64       `json:"record_count"`
+	ShardCount       int         `json:"shard_count"` // Non-empty shards
+	BuiltAt          time.Time   `json:"built_at"`
+	SourceURL        string      `json:"source_url,omitempty"`
+	Compression      string      `json:"compression"`
+	CompressionLevel string      `json:"compression_level,omitempty"` // e.g. "best"; see zstd.EncoderLevel.
+	FrameSize        int         `json:"frame_size,omitempty"`        // Uncompressed bytes per seekable zstd frame; 0 for single-frame shards.
+	Dictionary       string      `json:"dictionary,omitempty"`        // zstd dictionary file relative to the data directory; see ReadDictionary.
+	Shards           []ShardInfo `json:"shards,omitempty"`            // Non-empty shards, by ID.
+	Balance          *Balance    `json:"balance,omitempty"`           // Spread of Shards; see ComputeBalance.
+	Symmetric        bool        `json:"symmetric,omitempty"`         // Mirrored records were added; see WithStoreSymmetric.
+}
+
+// ShardInfo describes a single shard file.
+type ShardInfo struct {
+	ID               int    `json:"id"`
+	RecordCount      int    `json:"record_count"`
+	UncompressedSize int64  `json:"uncompressed_size"`
+	MinFEN           string `json:"min_fen"` // First FEN in the shard.
+	MaxFEN           string `json:"max_fen"` // Last FEN in the shard.
+	SHA256           string `json:"sha256"`  // Hex digest of the compressed shard file.
 }
 
 const manifestFilename = "manifest.json"
@@ -0,0 +1,298 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/discochess/stockpile/internal/codec/detect"
+)
+
+// Merge folds the records of a JSONL delta into the database already built
+// in the output directory. Only shards that receive delta records are read
+// and rewritten; as in a full build, the deepest evaluation of each FEN is
+// kept. The manifest's record counts and per-shard info are updated to match.
+// The first shard that fails to merge stops the rest; the shards rewritten
+// by then are still recorded in the manifest before the error is returned.
+//
+// The builder's strategy and shard count must match the manifest. Rewritten
+// shards use the manifest's compression level, frame size and dictionary so
+// the database stays uniform. Merge does not checkpoint, so WithResume is rejected.
+func (b *Builder) Merge(ctx context.Context, deltaPath string) (err error) {
+	startTime := time.Now()
+	if b.resume {
+		return errors.New("merge does not support resume")
+	}
+
+	m, err := ReadManifest(b.outputDir)
+	if err != nil {
+		return err
+	}
+	if err := b.adoptManifest(m); err != nil {
+		return err
+	}
+
+	if b.tempDir == "" {
+		b.tempDir = filepath.Join(b.outputDir, ".tmp")
+	}
+	if err := os.MkdirAll(b.tempDir, 0755); err != nil {
+		return fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer b.cleanupTempDir(&err)
+
+	file, err := os.Open(deltaPath)
+	if err != nil {
+		return fmt.Errorf("opening delta file: %w", err)
+	}
+	defer file.Close()
+	reader, _, err := detect.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("detecting delta compression: %w", err)
+	}
+	defer reader.Close()
+
+	// Distribute the delta exactly as a full build distributes its source.
+	collectors := make([]*shardCollector, b.totalShards)
+	tracker := newMemoryTracker(b.maxMemoryMB, collectors)
+	for i := range collectors {
+		collectors[i] = newShardCollector(i, b.tempDir, tracker)
+	}
+	cp := b.newCheckpoint(deltaPath)
+	if err := b.distributeRecords(ctx, reader, collectors, tracker, cp, nil, startTime); err != nil {
+		return err
+	}
+
+	var affected []*shardCollector
+	for _, c := range collectors {
+		if c.Count() > 0 {
+			affected = append(affected, c)
+		}
+	}
+	b.reportProgress(Progress{
+		Phase:       "shard",
+		RecordsRead: cp.RecordsRead,
+		ShardsTotal: len(affected),
+		StartTime:   startTime,
+	})
+
+	// A fixed pool of workers rewrites the affected shards; the first
+	// failure cancels the rest.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var rewritten []shardStats
+	var written int64
+	var firstErr error
+	shardStart := time.Now()
+	shards := make(chan *shardCollector)
+	var wg sync.WaitGroup
+	for i := 0; i < max(b.workersCount, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range shards {
+				st, err := b.mergeShard(ctx, c)
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("merging shard %d: %w", c.shardID, err)
+						cancel()
+					}
+					mu.Unlock()
+					continue
+				}
+				rewritten = append(rewritten, st)
+				written += int64(st.RecordCount)
+				elapsed := time.Since(shardStart)
+				b.reportProgress(Progress{
+					Phase:          "shard",
+					RecordsRead:    cp.RecordsRead,
+					RecordsWritten: written,
+					ShardsCreated:  len(rewritten),
+					ShardsTotal:    len(affected),
+					StartTime:      startTime,
+					RecordsPerSec:  perSecond(written, elapsed),
+					ETA:            estimateRemaining(elapsed, int64(len(rewritten)), int64(len(affected))),
+				})
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, c := range affected {
+		select {
+		case shards <- c:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(shards)
+	wg.Wait()
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+
+	// Each shard is replaced atomically, so the shards rewritten before a
+	// failure are complete; record them so the manifest still describes
+	// every shard on disk.
+	if firstErr != nil && len(rewritten) == 0 {
+		return firstErr
+	}
+	duplicates := updateManifest(m, rewritten)
+	if err := WriteManifest(b.outputDir, m); err != nil {
+		return errors.Join(firstErr, fmt.Errorf("writing manifest: %w", err))
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+
+	b.reportProgress(Progress{
+		Phase:            "done",
+		RecordsRead:      cp.RecordsRead,
+		RecordsWritten:   m.RecordCount,
+		DuplicatesMerged: duplicates,
+		IllegalRecords:   cp.IllegalRecords,
+		ShardsCreated:    m.ShardCount,
+		ShardsTotal:      m.TotalShards,
+		StartTime:        startTime,
+	})
+	return nil
+}
+
+// adoptManifest checks that the builder matches an existing database and
+// takes over its shard layout, compression settings and dictionary.
+func (b *Builder) adoptManifest(m *Manifest) error {
+	if m.Strategy != b.strategy.Name() {
+		return fmt.Errorf("database uses strategy %q, builder uses %q", m.Strategy, b.strategy.Name())
+	}
+	if m.TotalShards != b.totalShards {
+		return fmt.Errorf("database has %d shards, builder uses %d", m.TotalShards, b.totalShards)
+	}
+	if len(m.Shards) != m.ShardCount {
+		return fmt.Errorf("manifest lists %d of %d shards; rebuild the database to record per-shard info", len(m.Shards), m.ShardCount)
+	}
+	if m.Compression != "" && m.Compression != "zstd" {
+		return fmt.Errorf("cannot merge into %s-compressed shards", m.Compression)
+	}
+	if m.CompressionLevel != "" {
+		ok, level := zstd.EncoderLevelFromString(m.CompressionLevel)
+		if !ok {
+			return fmt.Errorf("unknown compression level in manifest: %s", m.CompressionLevel)
+		}
+		b.compressionLevel = level
+	}
+	if m.FrameSize > 0 {
+		b.frameSize = m.FrameSize
+	}
+	dict, err := ReadDictionary(b.outputDir, m)
+	if err != nil {
+		return err
+	}
+	b.dictionary = dict
+	return nil
+}
+
+// mergeShard adds the existing records of the collector's shard, if any, and
+// rewrites the shard.
+func (b *Builder) mergeShard(ctx context.Context, c *shardCollector) (shardStats, error) {
+	compressed, err := os.ReadFile(b.shardPath(c.shardID))
+	switch {
+	case err == nil:
+		data, err := decodeShard(compressed, b.dictionary)
+		if err != nil {
+			return shardStats{}, err
+		}
+		for _, line := range shardLines(data) {
+			if err := c.add(line); err != nil {
+				return shardStats{}, err
+			}
+		}
+	case !os.IsNotExist(err):
+		return shardStats{}, fmt.Errorf("reading shard: %w", err)
+	}
+	return b.rewriteShard(ctx, c)
+}
+
+// rewriteShard writes the collector's records over its shard. A stale
+// offset index is removed unless a new one is being written.
+func (b *Builder) rewriteShard(ctx context.Context, c *shardCollector) (shardStats, error) {
+	if !b.buildIndex {
+		if err := os.Remove(b.indexPath(c.shardID)); err != nil && !os.IsNotExist(err) {
+			return shardStats{}, fmt.Errorf("removing stale index: %w", err)
+		}
+	}
+	return b.writeShard(ctx, c.shardID, c)
+}
+
+// shardLines splits decompressed shard data into its non-empty lines.
+func shardLines(data []byte) [][]byte {
+	var lines [][]byte
+	for len(data) > 0 {
+		line := data
+		if n := bytes.IndexByte(data, '\n'); n >= 0 {
+			line, data = data[:n], data[n+1:]
+		} else {
+			data = nil
+		}
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// decodeShard decompresses a whole shard file, using dict if the shards
+// were compressed with one.
+func decodeShard(compressed, dict []byte) ([]byte, error) {
+	opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+	if dict != nil {
+		opts = append(opts, zstd.WithDecoderDicts(dict))
+	}
+	dec, err := zstd.NewReader(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+	data, err := dec.DecodeAll(compressed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing shard: %w", err)
+	}
+	return data, nil
+}
+
+// updateManifest replaces the shard info of rewritten shards and recomputes
+// the totals and balance. It returns the number of duplicate records collapsed.
+func updateManifest(m *Manifest, rewritten []shardStats) int64 {
+	byID := make(map[int]ShardInfo, len(m.Shards))
+	for _, s := range m.Shards {
+		byID[s.ID] = s
+	}
+	var duplicates int64
+	for _, st := range rewritten {
+		byID[st.ID] = st.ShardInfo
+		duplicates += int64(st.Duplicates)
+	}
+
+	m.Shards = m.Shards[:0]
+	m.RecordCount = 0
+	for _, s := range byID {
+		m.Shards = append(m.Shards, s)
+		m.RecordCount += int64(s.RecordCount)
+	}
+	sort.Slice(m.Shards, func(i, j int) bool { return m.Shards[i].ID < m.Shards[j].ID })
+	m.ShardCount = len(m.Shards)
+	m.Balance = ComputeBalance(m.Shards)
+	m.BuiltAt = time.Now()
+	return duplicates
+}
@@ -0,0 +1,211 @@
+package builder
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/discochess/stockpile/internal/shard/fnvshard"
+)
+
+// evalLine returns a source record for fen at the given depth.
+func evalLine(fen string, depth int) string {
+	return fmt.Sprintf(`{"fen":"%s","evals":[{"pvs":[{"cp":%d,"line":"e2e4"}],"knodes":1,"depth":%d}]}`, fen, depth, depth) + "\n"
+}
+
+// kingsFEN returns a distinct legal position for i.
+func kingsFEN(i int) string {
+	squares := []string{"4K2k", "4K1k1", "4Kk2", "k3K3", "1k2K3", "2k1K3"}
+	return fmt.Sprintf("8/8/8/8/8/8/8/%s w - - %d", squares[i%len(squares)], i/len(squares))
+}
+
+func writeSource(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+
+	var base []string
+	for i := 0; i < 60; i++ {
+		base = append(base, evalLine(kingsFEN(i), 20))
+	}
+	delta := []string{
+		evalLine(kingsFEN(3), 30),  // Deeper: replaces the base record.
+		evalLine(kingsFEN(4), 10),  // Shallower: loses to the base record.
+		evalLine(kingsFEN(100), 5), // New position.
+		evalLine(kingsFEN(101), 5), // New position.
+	}
+
+	baseFile := filepath.Join(tmpDir, "base.jsonl")
+	deltaFile := filepath.Join(tmpDir, "delta.jsonl")
+	allFile := filepath.Join(tmpDir, "all.jsonl")
+	writeSource(t, baseFile, base...)
+	writeSource(t, deltaFile, delta...)
+	writeSource(t, allFile, append(append([]string{}, base...), delta...)...)
+
+	newBuilder := func(dir string) *Builder {
+		return NewBuilder(WithOutputDir(dir), WithTotalShards(16), WithProgress(nil))
+	}
+
+	merged := filepath.Join(tmpDir, "merged")
+	if err := newBuilder(merged).BuildFromFile(ctx, baseFile, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+	before, err := ReadManifest(merged)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	if err := newBuilder(merged).Merge(ctx, deltaFile); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	after, err := ReadManifest(merged)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+
+	if after.RecordCount != before.RecordCount+2 {
+		t.Errorf("RecordCount = %d, want %d", after.RecordCount, before.RecordCount+2)
+	}
+
+	// Merging the delta must give the same shards as building everything.
+	full := filepath.Join(tmpDir, "full")
+	if err := newBuilder(full).BuildFromFile(ctx, allFile, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+	want, err := ReadManifest(full)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	if after.RecordCount != want.RecordCount || after.ShardCount != want.ShardCount {
+		t.Errorf("merged %d records in %d shards, full build %d in %d",
+			after.RecordCount, after.ShardCount, want.RecordCount, want.ShardCount)
+	}
+	if len(after.Shards) != len(want.Shards) {
+		t.Fatalf("merged manifest lists %d shards, full build %d", len(after.Shards), len(want.Shards))
+	}
+	for i := range want.Shards {
+		if after.Shards[i] != want.Shards[i] {
+			t.Errorf("shard info = %+v, full build %+v", after.Shards[i], want.Shards[i])
+		}
+	}
+	if !reflect.DeepEqual(after.Balance, want.Balance) {
+		t.Errorf("merged balance = %+v, full build %+v", after.Balance, want.Balance)
+	}
+
+	// Only shards touched by the delta are rewritten.
+	rewritten := 0
+	for i := range before.Shards {
+		if before.Shards[i].SHA256 != after.Shards[i].SHA256 {
+			rewritten++
+		}
+	}
+	if rewritten == 0 || rewritten > len(delta) {
+		t.Errorf("%d shards rewritten for a delta of %d records", rewritten, len(delta))
+	}
+}
+
+func TestMerge_MismatchedLayout(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "source.jsonl")
+	writeSource(t, source, evalLine(kingsFEN(0), 20))
+
+	out := filepath.Join(tmpDir, "out")
+	if err := NewBuilder(WithOutputDir(out), WithTotalShards(4), WithProgress(nil)).
+		BuildFromFile(context.Background(), source, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+
+	err := NewBuilder(WithOutputDir(out), WithTotalShards(8), WithProgress(nil)).Merge(context.Background(), source)
+	if err == nil || !strings.Contains(err.Error(), "shards") {
+		t.Errorf("Merge() error = %v, want shard count mismatch", err)
+	}
+}
+
+func TestMerge_StopsAtFirstError(t *testing.T) {
+	tmpDir := t.TempDir()
+	strategy := fnvshard.New()
+	var base, delta []string
+	for i := range 60 {
+		base = append(base, evalLine(kingsFEN(i), 20))
+	}
+	affected := make(map[int]bool)
+	for i := 100; i < 140; i++ {
+		delta = append(delta, evalLine(kingsFEN(i), 20))
+		affected[strategy.ShardID(kingsFEN(i), 16)] = true
+	}
+	baseFile := filepath.Join(tmpDir, "base.jsonl")
+	deltaFile := filepath.Join(tmpDir, "delta.jsonl")
+	writeSource(t, baseFile, base...)
+	writeSource(t, deltaFile, delta...)
+
+	out := filepath.Join(tmpDir, "out")
+	newBuilder := func() *Builder {
+		return NewBuilder(WithOutputDir(out), WithTotalShards(16), WithStrategy(strategy), WithWorkers(1), WithProgress(nil))
+	}
+	if err := newBuilder().BuildFromFile(context.Background(), baseFile, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+	before, err := ReadManifest(out)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+
+	// Shards are fed to the single worker in ID order. Corrupt an affected
+	// shard with affected shards on both sides of it.
+	var ids []int
+	for _, s := range before.Shards {
+		if affected[s.ID] {
+			ids = append(ids, s.ID)
+		}
+	}
+	if len(ids) < 3 {
+		t.Fatalf("delta touches %d built shards, want at least 3", len(ids))
+	}
+	bad := ids[len(ids)/2]
+	if err := os.WriteFile(filepath.Join(out, "shards", fmt.Sprintf("%05d.zst", bad)), []byte("not zstd"), 0644); err != nil {
+		t.Fatalf("writing shard: %v", err)
+	}
+
+	err = newBuilder().Merge(context.Background(), deltaFile)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("merging shard %d", bad)) {
+		t.Fatalf("Merge() error = %v, want shard %d to fail", err, bad)
+	}
+
+	after, err := ReadManifest(out)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	beforeSHA := make(map[int]string)
+	for _, s := range before.Shards {
+		beforeSHA[s.ID] = s.SHA256
+	}
+	for _, s := range after.Shards {
+		if s.ID == bad {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(out, "shards", fmt.Sprintf("%05d.zst", s.ID)))
+		if err != nil {
+			t.Fatalf("reading shard %d: %v", s.ID, err)
+		}
+		sum := sha256.Sum256(data)
+		if got := hex.EncodeToString(sum[:]); got != s.SHA256 {
+			t.Errorf("shard %d: manifest SHA256 does not match the file", s.ID)
+		}
+		rewritten := s.SHA256 != beforeSHA[s.ID]
+		if want := affected[s.ID] && s.ID < bad; rewritten != want {
+			t.Errorf("shard %d rewritten = %v, want %v", s.ID, rewritten, want)
+		}
+	}
+}
@@ -10,15 +10,31 @@ import (
 
 // Progress tracks build progress.
 type Progress struct {
-	Phase           string
-	BytesDownloaded int64
-	BytesTotal      int64
-	RecordsRead     int64
-	RecordsWritten  int64
-	ShardsCreated   int
-	ShardsTotal     int
-	StartTime       time.Time
-	Error           error
+	Phase            string
+	BytesDownloaded  int64
+	BytesTotal       int64
+	RecordsRead      int64
+	RecordsWritten   int64
+	DuplicatesMerged int64 // Same-FEN records dropped in favor of a deeper eval.
+	IllegalRecords   int64 // Records failing fen.Validate, see WithSkipIllegal.
+	MemoryBytes      int64 // Estimated memory in use while sorting, compared against WithMaxMemoryMB.
+	ShardsCreated    int
+	ShardsTotal      int
+	ShardsUploaded   int // Shards transferred during upload.
+	ShardsSkipped    int // Shards left in place because they were unchanged.
+	StartTime        time.Time
+	Error            error
+
+	// Throughput and estimated time left in the current phase, measured from
+	// when the phase started. Zero when not yet known.
+	BytesPerSec   float64       // Download speed.
+	RecordsPerSec float64       // Records read while sorting, written while sharding.
+	ETA           time.Duration // Download and shard phases only.
+
+	// Compression ratios of held-out source records without and with the
+	// trained dictionary, see WithDictionary. Zero when none was trained.
+	PlainRatio float64
+	DictRatio  float64
 }
 
 // ProgressFunc is called periodically with progress updates.
@@ -56,6 +72,24 @@ func (pr *progressReader) Read(p []byte) (int, error) {
 	return n, err
 }
 
+// perSecond returns the rate of n events over elapsed.
+func perSecond(n int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(n) / elapsed.Seconds()
+}
+
+// estimateRemaining extrapolates how long the rest of a phase will take from
+// done of total units completed in elapsed. It returns zero if the total is
+// unknown or nothing has completed yet.
+func estimateRemaining(elapsed time.Duration, done, total int64) time.Duration {
+	if done <= 0 || total <= done {
+		return 0
+	}
+	return time.Duration(float64(elapsed) * float64(total-done) / float64(done))
+}
+
 // FormatBytes formats bytes as human-readable string.
 func FormatBytes(bytes int64) string {
 	const unit = 1024
@@ -91,16 +125,51 @@ func DefaultProgressFunc(p Progress) {
 		}
 		fmt.Printf("\r[Download] %s / %s (%.1f%%)",
 			FormatBytes(p.BytesDownloaded), FormatBytes(p.BytesTotal), pct)
+		if p.BytesPerSec > 0 {
+			fmt.Printf(", %s/s", FormatBytes(int64(p.BytesPerSec)))
+		}
+		printETA(p.ETA)
 	case "sort":
 		fmt.Printf("\r[Sort] %d records processed", p.RecordsRead)
+		if p.RecordsPerSec > 0 {
+			fmt.Printf(" (%.0f/s)", p.RecordsPerSec)
+		}
+		if p.MemoryBytes > 0 {
+			fmt.Printf(", %s in memory", FormatBytes(p.MemoryBytes))
+		}
 	case "shard":
 		fmt.Printf("\r[Shard] %d / %d shards created, %d records",
 			p.ShardsCreated, p.ShardsTotal, p.RecordsWritten)
+		if p.RecordsPerSec > 0 {
+			fmt.Printf(" (%.0f/s)", p.RecordsPerSec)
+		}
+		printETA(p.ETA)
+	case "upload":
+		fmt.Printf("\r[Upload] %d / %d shards (%d uploaded, %d unchanged)",
+			p.ShardsCreated, p.ShardsTotal, p.ShardsUploaded, p.ShardsSkipped)
 	case "done":
 		elapsed := time.Since(p.StartTime)
 		fmt.Printf("\n[Done] %d records in %d shards (%s)\n",
 			p.RecordsWritten, p.ShardsCreated, FormatDuration(elapsed))
+		if p.DuplicatesMerged > 0 {
+			fmt.Printf("[Done] %d duplicate records merged\n", p.DuplicatesMerged)
+		}
+		if p.IllegalRecords > 0 {
+			fmt.Printf("[Done] %d records failed FEN validation\n", p.IllegalRecords)
+		}
+		if p.PlainRatio > 0 {
+			fmt.Printf("[Done] dictionary improves compression from %.2fx to %.2fx (%+.1f%%) on held-out records\n",
+				p.PlainRatio, p.DictRatio, (p.DictRatio/p.PlainRatio-1)*100)
+		}
 	case "error":
 		fmt.Printf("\n[Error] %v\n", p.Error)
 	}
 }
+
+// printETA appends an ETA to the current progress line. The padding clears
+// leftovers from a longer previous estimate.
+func printETA(eta time.Duration) {
+	if eta > 0 {
+		fmt.Printf(", ETA %-8s", FormatDuration(eta))
+	}
+}
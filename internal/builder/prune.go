@@ -0,0 +1,61 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// RemoteShards lists and deletes the files under a remote build's shards/
+// prefix. GCSUploader and S3Shards implement it.
+type RemoteShards interface {
+	// ListShards returns the names of the files under the shards/ prefix.
+	ListShards(ctx context.Context) ([]string, error)
+
+	// DeleteShard deletes the named file under the shards/ prefix.
+	DeleteShard(ctx context.Context, name string) error
+}
+
+// ManifestShardFiles returns the shard file names a build described by m
+// expects: each listed shard and its optional offset index.
+// It fails for older manifests that do not list their shards.
+func ManifestShardFiles(m *Manifest) (map[string]bool, error) {
+	if len(m.Shards) == 0 && m.ShardCount > 0 {
+		return nil, fmt.Errorf("manifest does not list its shards; rebuild to add them")
+	}
+	files := make(map[string]bool, 2*len(m.Shards))
+	for _, si := range m.Shards {
+		files[shardFilename(si.ID)] = true
+		files[indexFilename(si.ID)] = true
+	}
+	return files, nil
+}
+
+// PruneShards deletes remote shard files that are not in keep and returns
+// their names in sorted order. With dryRun, the stale files are only
+// reported. Deletion stops at the first error; the names deleted so far are
+// returned with it.
+func PruneShards(ctx context.Context, r RemoteShards, keep map[string]bool, dryRun bool) ([]string, error) {
+	names, err := r.ListShards(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing shards: %w", err)
+	}
+
+	var stale []string
+	for _, name := range names {
+		if !keep[name] {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+
+	if dryRun {
+		return stale, nil
+	}
+	for i, name := range stale {
+		if err := r.DeleteShard(ctx, name); err != nil {
+			return stale[:i], fmt.Errorf("deleting stale shard %s: %w", name, err)
+		}
+	}
+	return stale, nil
+}
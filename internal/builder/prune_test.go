@@ -0,0 +1,104 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// fakeRemote is an in-memory RemoteShards.
+type fakeRemote struct {
+	files     map[string]bool
+	deleteErr error
+}
+
+func (f *fakeRemote) ListShards(ctx context.Context) ([]string, error) {
+	var names []string
+	for name := range f.files {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (f *fakeRemote) DeleteShard(ctx context.Context, name string) error {
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	delete(f.files, name)
+	return nil
+}
+
+func TestPruneShards(t *testing.T) {
+	m := &Manifest{ShardCount: 2, Shards: []ShardInfo{{ID: 1}, {ID: 7}}}
+	keep, err := ManifestShardFiles(m)
+	if err != nil {
+		t.Fatalf("ManifestShardFiles: %v", err)
+	}
+
+	newRemote := func() *fakeRemote {
+		return &fakeRemote{files: map[string]bool{
+			"00001.zst": true, "00001.idx": true, "00007.zst": true,
+			"00003.zst": true, "00009.idx": true,
+		}}
+	}
+	want := []string{"00003.zst", "00009.idx"}
+
+	r := newRemote()
+	got, err := PruneShards(context.Background(), r, keep, true)
+	if err != nil {
+		t.Fatalf("PruneShards dry run: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dry run stale = %v, want %v", got, want)
+	}
+	if len(r.files) != 5 {
+		t.Errorf("dry run deleted files: %d left, want 5", len(r.files))
+	}
+
+	got, err = PruneShards(context.Background(), r, keep, false)
+	if err != nil {
+		t.Fatalf("PruneShards: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("deleted = %v, want %v", got, want)
+	}
+	if len(r.files) != 3 || r.files["00003.zst"] || r.files["00009.idx"] {
+		t.Errorf("remaining files = %v", r.files)
+	}
+
+	r = newRemote()
+	r.deleteErr = errors.New("denied")
+	if _, err := PruneShards(context.Background(), r, keep, false); !errors.Is(err, r.deleteErr) {
+		t.Errorf("PruneShards error = %v, want %v", err, r.deleteErr)
+	}
+}
+
+func TestManifestShardFiles_Unlisted(t *testing.T) {
+	if _, err := ManifestShardFiles(&Manifest{ShardCount: 3}); err == nil {
+		t.Error("ManifestShardFiles() with unlisted shards succeeded, want error")
+	}
+}
+
+func TestParseS3Path(t *testing.T) {
+	tests := []struct {
+		path, bucket, prefix string
+		wantErr              bool
+	}{
+		{"s3://bucket", "bucket", "", false},
+		{"s3://bucket/data/", "bucket", "data/", false},
+		{"s3://bucket/a/b", "bucket", "a/b/", false},
+		{"s3://", "", "", true},
+		{"gs://bucket", "", "", true},
+	}
+	for _, tt := range tests {
+		bucket, prefix, err := ParseS3Path(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseS3Path(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if bucket != tt.bucket || prefix != tt.prefix {
+			t.Errorf("ParseS3Path(%q) = %q, %q, want %q, %q", tt.path, bucket, prefix, tt.bucket, tt.prefix)
+		}
+	}
+}
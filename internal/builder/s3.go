@@ -0,0 +1,90 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Compile-time check that S3Shards implements RemoteShards.
+var _ RemoteShards = (*S3Shards)(nil)
+
+// S3Shards manages the shard objects of a build stored in S3.
+type S3Shards struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Shards creates an S3Shards for a build at s3Path, in the format
+// "s3://bucket/prefix". Credentials, region and endpoint come from the
+// default AWS configuration chain.
+func NewS3Shards(ctx context.Context, s3Path string) (*S3Shards, error) {
+	bucket, prefix, err := ParseS3Path(s3Path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+
+	return &S3Shards{
+		client: s3.NewFromConfig(cfg),
+		bucket: bucket,
+		prefix: prefix,
+	}, nil
+}
+
+// ParseS3Path parses "s3://bucket/prefix" into bucket and prefix.
+func ParseS3Path(s3Path string) (bucket, prefix string, err error) {
+	if !strings.HasPrefix(s3Path, "s3://") {
+		return "", "", fmt.Errorf("invalid S3 path: must start with s3://")
+	}
+
+	bucket, prefix, _ = strings.Cut(strings.TrimPrefix(s3Path, "s3://"), "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("invalid S3 path: missing bucket name")
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return bucket, prefix, nil
+}
+
+// ListShards returns the names of the files under the shards/ prefix.
+func (s *S3Shards) ListShards(ctx context.Context) ([]string, error) {
+	prefix := s.prefix + "shards/"
+	p := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	var names []string
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing objects: %w", err)
+		}
+		for _, obj := range page.Contents {
+			names = append(names, strings.TrimPrefix(aws.ToString(obj.Key), prefix))
+		}
+	}
+	return names, nil
+}
+
+// DeleteShard deletes the named file under the shards/ prefix.
+func (s *S3Shards) DeleteShard(ctx context.Context, name string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + "shards/" + name),
+	})
+	return err
+}
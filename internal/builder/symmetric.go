@@ -0,0 +1,93 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/discochess/stockpile/internal/fen"
+)
+
+// mirrorMark prefixes records synthesized by WithStoreSymmetric while they
+// pass through the shard collectors. It lets writeShard keep a source record
+// over a mirrored one for the same FEN, and is stripped before writing.
+const mirrorMark = 0x00
+
+// mirroredRecord has the fields of a source record that mirroring rewrites.
+// Other fields are dropped.
+type mirroredRecord struct {
+	FEN   string `json:"fen"`
+	Evals []struct {
+		PVs []struct {
+			CP   *int   `json:"cp,omitempty"`
+			Mate *int   `json:"mate,omitempty"`
+			Line string `json:"line"`
+		} `json:"pvs"`
+		Knodes int `json:"knodes"`
+		Depth  int `json:"depth"`
+	} `json:"evals"`
+}
+
+// mirrorRecord returns the record for the color-swapped position of a source
+// record, see fen.Mirror, prefixed with mirrorMark. Scores are relative to
+// the side to move, which is swapped along with the colors, so they are kept
+// as-is; only the moves of each line are mirrored along with the board.
+func mirrorRecord(record []byte) ([]byte, error) {
+	var r mirroredRecord
+	if err := json.Unmarshal(record, &r); err != nil {
+		return nil, err
+	}
+	mirrored, err := fen.Mirror(r.FEN)
+	if err != nil {
+		return nil, err
+	}
+	r.FEN = mirrored
+
+	for i := range r.Evals {
+		for j := range r.Evals[i].PVs {
+			pv := &r.Evals[i].PVs[j]
+			pv.Line = mirrorLine(pv.Line)
+		}
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{mirrorMark}, data...), nil
+}
+
+// mirrorLine mirrors the ranks of every square in a line of UCI moves, so
+// that e2e4 becomes e7e5. Promotion pieces are left as-is.
+func mirrorLine(line string) string {
+	b := []byte(line)
+	for i, ch := range b {
+		if ch >= '1' && ch <= '8' && i > 0 && b[i-1] >= 'a' && b[i-1] <= 'h' {
+			b[i] = '1' + '8' - ch
+		}
+	}
+	return string(b)
+}
+
+// isMirrored reports whether a collected record was synthesized by
+// mirrorRecord.
+func isMirrored(record []byte) bool {
+	return len(record) > 0 && record[0] == mirrorMark
+}
+
+// preferSource is preferRecord, except that a source record always wins
+// over a mirrored one: a mirror only fills in for a missing position.
+func preferSource(a, b []byte) []byte {
+	switch ma, mb := isMirrored(a), isMirrored(b); {
+	case ma && !mb:
+		return b
+	case mb && !ma:
+		return a
+	default:
+		return preferRecord(a, b)
+	}
+}
+
+// unmarkRecord strips mirrorMark from a record that carries it.
+func unmarkRecord(record []byte) []byte {
+	return bytes.TrimPrefix(record, []byte{mirrorMark})
+}
@@ -0,0 +1,130 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestMirrorRecord(t *testing.T) {
+	tests := []struct {
+		name   string
+		record string
+		want   string
+	}{
+		{
+			name:   "centipawns",
+			record: `{"fen":"8/8/8/8/8/8/4P3/4K2k w - -","evals":[{"pvs":[{"cp":150,"line":"e2e4 h1g2"},{"cp":-20,"line":"e1d1"}],"knodes":3,"depth":20}]}`,
+			want:   `{"fen":"4k2K/4p3/8/8/8/8/8/8 b - -","evals":[{"pvs":[{"cp":150,"line":"e7e5 h8g7"},{"cp":-20,"line":"e8d8"}],"knodes":3,"depth":20}]}`,
+		},
+		{
+			name:   "mate and promotion",
+			record: `{"fen":"8/4P3/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"mate":2,"line":"e7e8q h1g2"}],"knodes":1,"depth":30},{"pvs":[{"mate":3,"line":"e7e8r"}],"knodes":1,"depth":10}]}`,
+			want:   `{"fen":"4k2K/8/8/8/8/8/4p3/8 b - -","evals":[{"pvs":[{"mate":2,"line":"e2e1q h8g7"}],"knodes":1,"depth":30},{"pvs":[{"mate":3,"line":"e2e1r"}],"knodes":1,"depth":10}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mirrorRecord([]byte(tt.record))
+			if err != nil {
+				t.Fatalf("mirrorRecord() error = %v", err)
+			}
+			if !isMirrored(got) {
+				t.Errorf("mirrorRecord() = %q, want it marked", got)
+			}
+			if got := unmarkRecord(got); string(got) != tt.want {
+				t.Errorf("mirrorRecord() = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := mirrorRecord([]byte(`{"fen":"not a fen","evals":[]}`)); err == nil {
+		t.Error("mirrorRecord() with an invalid FEN succeeded")
+	}
+}
+
+func TestPreferSource(t *testing.T) {
+	source := []byte(`{"fen":"x","evals":[{"pvs":[],"knodes":1,"depth":1}]}`)
+	mirror := append([]byte{mirrorMark}, `{"fen":"x","evals":[{"pvs":[],"knodes":9,"depth":40}]}`...)
+
+	if got := preferSource(source, mirror); !bytes.Equal(got, source) {
+		t.Errorf("preferSource(source, mirror) = %s, want the source", got)
+	}
+	if got := preferSource(mirror, source); !bytes.Equal(got, source) {
+		t.Errorf("preferSource(mirror, source) = %s, want the source", got)
+	}
+}
+
+func TestBuildFromFile_StoreSymmetric(t *testing.T) {
+	// The first position has no mirror in the source. The other two mirror
+	// each other, so neither gets a synthesized record.
+	source := `{"fen":"8/8/8/8/8/8/4P3/4K2k w - -","evals":[{"pvs":[{"cp":150,"line":"e2e4"}],"knodes":3,"depth":20}]}
+{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":10,"line":"e1e2"}],"knodes":1,"depth":10}]}
+{"fen":"4k2K/8/8/8/8/8/8/8 b - -","evals":[{"pvs":[{"cp":-99,"line":"e8e7"}],"knodes":1,"depth":5}]}
+`
+	tmpDir := t.TempDir()
+	sourceFile := filepath.Join(tmpDir, "source.jsonl")
+	outputDir := filepath.Join(tmpDir, "output")
+	if err := os.WriteFile(sourceFile, []byte(source), 0644); err != nil {
+		t.Fatalf("writing source file: %v", err)
+	}
+
+	var last Progress
+	b := NewBuilder(
+		WithOutputDir(outputDir),
+		WithTotalShards(1),
+		WithStoreSymmetric(true),
+		WithProgress(func(p Progress) { last = p }),
+	)
+	if err := b.BuildFromFile(context.Background(), sourceFile, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+
+	if last.RecordsRead != 3 || last.RecordsWritten != 4 || last.DuplicatesMerged != 0 {
+		t.Errorf("records read/written/merged = %d/%d/%d, want 3/4/0",
+			last.RecordsRead, last.RecordsWritten, last.DuplicatesMerged)
+	}
+	m, err := ReadManifest(outputDir)
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	if !m.Symmetric {
+		t.Error("Manifest.Symmetric = false, want true")
+	}
+
+	compressed, err := os.ReadFile(filepath.Join(outputDir, "shards", "00000.zst"))
+	if err != nil {
+		t.Fatalf("reading shard: %v", err)
+	}
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		t.Fatalf("zstd.NewReader() error = %v", err)
+	}
+	defer decoder.Close()
+	data, err := decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		t.Fatalf("decoding shard: %v", err)
+	}
+
+	want := []string{
+		`{"fen":"4k2K/4p3/8/8/8/8/8/8 b - -","evals":[{"pvs":[{"cp":150,"line":"e7e5"}],"knodes":3,"depth":20}]}`,
+		`{"fen":"4k2K/8/8/8/8/8/8/8 b - -","evals":[{"pvs":[{"cp":-99,"line":"e8e7"}],"knodes":1,"depth":5}]}`,
+		`{"fen":"8/8/8/8/8/8/4P3/4K2k w - -","evals":[{"pvs":[{"cp":150,"line":"e2e4"}],"knodes":3,"depth":20}]}`,
+		`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":10,"line":"e1e2"}],"knodes":1,"depth":10}]}`,
+	}
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	if len(lines) != len(want) {
+		t.Fatalf("shard has %d records, want %d:\n%s", len(lines), len(want), data)
+	}
+	for i, line := range lines {
+		if string(line) != want[i] {
+			t.Errorf("record %d = %s\nwant %s", i, line, want[i])
+		}
+	}
+}
@@ -1,7 +1,12 @@
 // Package codec provides compression and decompression for shard data.
 package codec
 
-import "io"
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
 
 // Codec provides compression and decompression functionality.
 type Codec interface {
@@ -13,3 +18,44 @@ type Codec interface {
 	// Returns empty string for no compression.
 	Extension() string
 }
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]func() Codec)
+)
+
+// Register makes a codec available by name, as recorded in a manifest's
+// compression field. Codec packages call it from init, so a codec is only
+// available to ByName once its package is imported.
+// Register panics if name is already registered.
+func Register(name string, factory func() Codec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[name]; dup {
+		panic("codec: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// ByName returns a new instance of the codec registered under name.
+func ByName(name string) (Codec, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown codec %q (available: %v)", name, Names())
+	}
+	return factory(), nil
+}
+
+// Names returns the sorted names of all registered codecs.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,50 @@
+package codec_test
+
+import (
+	"testing"
+
+	"github.com/discochess/stockpile/internal/codec"
+	"github.com/discochess/stockpile/internal/codec/gzipcodec"
+	"github.com/discochess/stockpile/internal/codec/lz4codec"
+	"github.com/discochess/stockpile/internal/codec/noopcodec"
+	"github.com/discochess/stockpile/internal/codec/zstdcodec"
+)
+
+func TestByName(t *testing.T) {
+	tests := []struct {
+		name string
+		want codec.Codec
+	}{
+		{"zstd", zstdcodec.New()},
+		{"gzip", gzipcodec.New()},
+		{"lz4", lz4codec.New()},
+		{"none", noopcodec.New()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := codec.ByName(tt.name)
+			if err != nil {
+				t.Fatalf("ByName() error = %v", err)
+			}
+			if got.Extension() != tt.want.Extension() {
+				t.Errorf("ByName(%q).Extension() = %q, want %q", tt.name, got.Extension(), tt.want.Extension())
+			}
+		})
+	}
+}
+
+func TestByName_Unknown(t *testing.T) {
+	if _, err := codec.ByName("brotli"); err == nil {
+		t.Error("ByName() error = nil, want error for unknown codec")
+	}
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() did not panic for duplicate name")
+		}
+	}()
+	codec.Register("zstd", func() codec.Codec { return zstdcodec.New() })
+}
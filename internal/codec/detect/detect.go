@@ -0,0 +1,79 @@
+// Package detect identifies the compression format of a stream from its
+// magic bytes and wraps it with the matching decompressor.
+package detect
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Format identifies a compression format.
+type Format string
+
+// Supported formats.
+const (
+	None  Format = "none"
+	Zstd  Format = "zstd"
+	Gzip  Format = "gzip"
+	Bzip2 Format = "bzip2"
+)
+
+var (
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+)
+
+// Sniff returns the format indicated by the leading bytes of header.
+// Unrecognized or short headers are reported as None.
+func Sniff(header []byte) Format {
+	switch {
+	case bytes.HasPrefix(header, zstdMagic):
+		return Zstd
+	case bytes.HasPrefix(header, gzipMagic):
+		return Gzip
+	case bytes.HasPrefix(header, bzip2Magic):
+		return Bzip2
+	default:
+		return None
+	}
+}
+
+// NewReader sniffs the compression format of r and returns a reader that
+// yields the decompressed stream. Uncompressed input is passed through.
+// The file extension is deliberately ignored so mislabeled files still work.
+func NewReader(r io.Reader) (io.ReadCloser, Format, error) {
+	br := bufio.NewReader(r)
+
+	header, err := br.Peek(len(zstdMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, None, fmt.Errorf("reading header: %w", err)
+	}
+
+	format := Sniff(header)
+	switch format {
+	case Zstd:
+		decoder, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, format, fmt.Errorf("creating zstd decoder: %w", err)
+		}
+		return decoder.IOReadCloser(), format, nil
+	case Gzip:
+		decoder, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, format, fmt.Errorf("creating gzip decoder: %w", err)
+		}
+		return decoder, format, nil
+	case Bzip2:
+		return io.NopCloser(bzip2.NewReader(br)), format, nil
+	default:
+		return io.NopCloser(br), format, nil
+	}
+}
@@ -0,0 +1,84 @@
+package detect
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   Format
+	}{
+		{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}, Zstd},
+		{"gzip", []byte{0x1f, 0x8b, 0x08}, Gzip},
+		{"bzip2", []byte("BZh91AY"), Bzip2},
+		{"jsonl", []byte(`{"fen"`), None},
+		{"pgn", []byte(`[Event`), None},
+		{"empty", nil, None},
+		{"short", []byte{0x28}, None},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sniff(tt.header); got != tt.want {
+				t.Errorf("Sniff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	original := []byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[]}` + "\n")
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(original)
+	gw.Close()
+
+	var zs bytes.Buffer
+	zw, err := zstd.NewWriter(&zs)
+	if err != nil {
+		t.Fatalf("zstd.NewWriter() error = %v", err)
+	}
+	zw.Write(original)
+	zw.Close()
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  Format
+	}{
+		{"plain", original, None},
+		{"gzip", gz.Bytes(), Gzip},
+		{"zstd", zs.Bytes(), Zstd},
+		{"empty", nil, None},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, format, err := NewReader(bytes.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("NewReader() error = %v", err)
+			}
+			defer r.Close()
+
+			if format != tt.want {
+				t.Errorf("format = %q, want %q", format, tt.want)
+			}
+
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if tt.input != nil && !bytes.Equal(got, original) {
+				t.Errorf("decompressed = %q, want %q", got, original)
+			}
+		})
+	}
+}
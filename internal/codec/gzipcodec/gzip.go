@@ -11,6 +11,10 @@ import (
 // Compile-time check that Codec implements codec.Codec.
 var _ codec.Codec = (*Codec)(nil)
 
+func init() {
+	codec.Register("gzip", func() codec.Codec { return New() })
+}
+
 // Codec implements gzip compression.
 type Codec struct{}
 
@@ -1,4 +1,6 @@
-// Package noopcodec provides a no-op codec (no compression).
+// Package noopcodec provides a no-op codec (no compression). Shards are
+// stored as plain JSONL without a file extension, which suits tests,
+// already-compressed inputs and benchmarks of the search path alone.
 package noopcodec
 
 import (
@@ -10,6 +12,10 @@ import (
 // Compile-time check that Codec implements codec.Codec.
 var _ codec.Codec = (*Codec)(nil)
 
+func init() {
+	codec.Register("none", func() codec.Codec { return New() })
+}
+
 // Codec implements no compression.
 type Codec struct{}
 
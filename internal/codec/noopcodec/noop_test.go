@@ -0,0 +1,52 @@
+package noopcodec
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestCodec_Extension(t *testing.T) {
+	c := New()
+	if got := c.Extension(); got != "" {
+		t.Errorf("Extension() = %q, want empty", got)
+	}
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	c := New()
+	original := []byte(`{"fen":"8/8/8/8/8/8/8/K6k w - -","evals":[]}` + "\n")
+
+	var stored bytes.Buffer
+	writer, err := c.Writer(&stored)
+	if err != nil {
+		t.Fatalf("Writer() error = %v", err)
+	}
+	if _, err := writer.Write(original); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Stored bytes are the input, unchanged.
+	if !bytes.Equal(stored.Bytes(), original) {
+		t.Errorf("stored %q, want %q", stored.Bytes(), original)
+	}
+
+	reader, err := c.Reader(&stored)
+	if err != nil {
+		t.Fatalf("Reader() error = %v", err)
+	}
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if err := reader.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if !bytes.Equal(got, original) {
+		t.Errorf("Round-trip failed: got %q, want %q", got, original)
+	}
+}
@@ -2,7 +2,9 @@
 package zstdcodec
 
 import (
+	"errors"
 	"io"
+	"sync"
 
 	"github.com/klauspost/compress/zstd"
 
@@ -12,29 +14,148 @@ import (
 // Compile-time check that Codec implements codec.Codec.
 var _ codec.Codec = (*Codec)(nil)
 
+func init() {
+	codec.Register("zstd", func() codec.Codec { return New() })
+}
+
+// errClosed is returned when reading from or writing to a closed stream.
+var errClosed = errors.New("zstdcodec: stream closed")
+
 // Codec implements zstd compression.
-type Codec struct{}
+// Decoders and encoders are pooled and reused across calls; each Reader or
+// Writer owns its instance until Close, so a Codec is safe for concurrent use.
+type Codec struct {
+	level    zstd.EncoderLevel
+	dict     []byte
+	decoders sync.Pool // *zstd.Decoder
+	encoders sync.Pool // *zstd.Encoder
+}
 
-// New returns a new zstd codec.
+// New returns a new zstd codec that compresses at the default level.
 func New() *Codec {
-	return &Codec{}
+	return NewWithLevel(zstd.SpeedDefault)
+}
+
+// NewWithLevel returns a new zstd codec that compresses at the given level.
+// Higher levels produce smaller output at the cost of compression speed;
+// decompression speed is largely unaffected.
+func NewWithLevel(level zstd.EncoderLevel) *Codec {
+	return &Codec{level: level}
+}
+
+// NewWithDict returns a new zstd codec that compresses at the default level
+// using a trained dictionary. Data written with a dictionary can only be
+// read by a codec with the same dictionary; an invalid dictionary is
+// reported by the first Reader or Writer call.
+func NewWithDict(dict []byte) *Codec {
+	return &Codec{level: zstd.SpeedDefault, dict: dict}
+}
+
+// Level returns the encoder level used by Writer.
+func (c *Codec) Level() zstd.EncoderLevel {
+	return c.level
 }
 
 // Reader wraps r to decompress zstd data.
+// Closing the returned reader returns its decoder to the pool.
 func (c *Codec) Reader(r io.Reader) (io.ReadCloser, error) {
-	decoder, err := zstd.NewReader(r)
+	if dec, ok := c.decoders.Get().(*zstd.Decoder); ok {
+		if err := dec.Reset(r); err != nil {
+			dec.Close()
+			return nil, err
+		}
+		return &pooledReader{codec: c, dec: dec}, nil
+	}
+
+	// Single-threaded decoders decode synchronously and start no goroutines,
+	// so they are safe to drop from the pool without an explicit Close.
+	opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+	if c.dict != nil {
+		opts = append(opts, zstd.WithDecoderDicts(c.dict))
+	}
+	dec, err := zstd.NewReader(r, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return decoder.IOReadCloser(), nil
+	return &pooledReader{codec: c, dec: dec}, nil
 }
 
 // Writer wraps w to compress data with zstd.
+// Closing the returned writer flushes the stream and returns its encoder
+// to the pool.
 func (c *Codec) Writer(w io.Writer) (io.WriteCloser, error) {
-	return zstd.NewWriter(w)
+	if enc, ok := c.encoders.Get().(*zstd.Encoder); ok {
+		enc.Reset(w)
+		return &pooledWriter{codec: c, enc: enc}, nil
+	}
+
+	opts := []zstd.EOption{
+		zstd.WithEncoderConcurrency(1),
+		zstd.WithEncoderLevel(c.level),
+	}
+	if c.dict != nil {
+		opts = append(opts, zstd.WithEncoderDict(c.dict))
+	}
+	enc, err := zstd.NewWriter(w, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &pooledWriter{codec: c, enc: enc}, nil
 }
 
 // Extension returns "zst".
 func (c *Codec) Extension() string {
 	return "zst"
 }
+
+// pooledReader is a decompressing reader backed by a pooled decoder.
+type pooledReader struct {
+	codec *Codec
+	dec   *zstd.Decoder
+}
+
+func (r *pooledReader) Read(p []byte) (int, error) {
+	if r.dec == nil {
+		return 0, errClosed
+	}
+	return r.dec.Read(p)
+}
+
+func (r *pooledReader) Close() error {
+	if r.dec == nil {
+		return nil
+	}
+	// Drop the reference to the source before pooling.
+	if err := r.dec.Reset(nil); err == nil {
+		r.codec.decoders.Put(r.dec)
+	}
+	r.dec = nil
+	return nil
+}
+
+// pooledWriter is a compressing writer backed by a pooled encoder.
+type pooledWriter struct {
+	codec *Codec
+	enc   *zstd.Encoder
+}
+
+func (w *pooledWriter) Write(p []byte) (int, error) {
+	if w.enc == nil {
+		return 0, errClosed
+	}
+	return w.enc.Write(p)
+}
+
+func (w *pooledWriter) Close() error {
+	if w.enc == nil {
+		return nil
+	}
+	err := w.enc.Close()
+	if err == nil {
+		// Drop the reference to the destination before pooling.
+		w.enc.Reset(nil)
+		w.codec.encoders.Put(w.enc)
+	}
+	w.enc = nil
+	return err
+}
@@ -2,8 +2,13 @@ package zstdcodec
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"sync"
 	"testing"
+
+	"github.com/klauspost/compress/dict"
+	"github.com/klauspost/compress/zstd"
 )
 
 func TestCodec_Extension(t *testing.T) {
@@ -48,6 +53,95 @@ func TestCodec_RoundTrip(t *testing.T) {
 	}
 }
 
+func TestNewWithLevel(t *testing.T) {
+	if got := New().Level(); got != zstd.SpeedDefault {
+		t.Errorf("New().Level() = %v, want %v", got, zstd.SpeedDefault)
+	}
+
+	original := bytes.Repeat([]byte("ABCDEFGHIJ"), 10000)
+	for _, level := range []zstd.EncoderLevel{zstd.SpeedFastest, zstd.SpeedBestCompression} {
+		t.Run(level.String(), func(t *testing.T) {
+			c := NewWithLevel(level)
+			if got := c.Level(); got != level {
+				t.Errorf("Level() = %v, want %v", got, level)
+			}
+
+			var compressed bytes.Buffer
+			writer, err := c.Writer(&compressed)
+			if err != nil {
+				t.Fatalf("Writer() error = %v", err)
+			}
+			if _, err := writer.Write(original); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			reader, err := c.Reader(&compressed)
+			if err != nil {
+				t.Fatalf("Reader() error = %v", err)
+			}
+			defer reader.Close()
+			decompressed, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if !bytes.Equal(decompressed, original) {
+				t.Error("round-trip mismatch")
+			}
+		})
+	}
+}
+
+func TestNewWithDict(t *testing.T) {
+	var samples [][]byte
+	for i := 0; i < 500; i++ {
+		samples = append(samples, fmt.Appendf(nil, `{"fen":"8/8/8/8/8/8/8/4K2k w - - %d","evals":[{"pvs":[{"cp":%d,"line":"e1d1"}],"knodes":%d,"depth":30}]}`, i, i%97, i*31))
+	}
+	d, err := dict.BuildZstdDict(samples, dict.Options{MaxDictSize: 8 << 10, HashBytes: 6, ZstdLevel: zstd.SpeedFastest})
+	if err != nil {
+		t.Fatalf("BuildZstdDict() error = %v", err)
+	}
+
+	c := NewWithDict(d)
+	original := samples[42]
+	var compressed bytes.Buffer
+	writer, err := c.Writer(&compressed)
+	if err != nil {
+		t.Fatalf("Writer() error = %v", err)
+	}
+	if _, err := writer.Write(original); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	reader, err := c.Reader(bytes.NewReader(compressed.Bytes()))
+	if err != nil {
+		t.Fatalf("Reader() error = %v", err)
+	}
+	defer reader.Close()
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if !bytes.Equal(decompressed, original) {
+		t.Error("round-trip mismatch")
+	}
+
+	// Without the dictionary the data cannot be read.
+	plain, err := New().Reader(bytes.NewReader(compressed.Bytes()))
+	if err != nil {
+		t.Fatalf("Reader() error = %v", err)
+	}
+	defer plain.Close()
+	if _, err := io.ReadAll(plain); err == nil {
+		t.Error("ReadAll() without dictionary succeeded, want error")
+	}
+}
+
 func TestCodec_RoundTrip_LargeData(t *testing.T) {
 	c := New()
 	original := bytes.Repeat([]byte("ABCDEFGHIJ"), 10000) // 100KB of repetitive data
@@ -114,3 +208,108 @@ func TestCodec_RoundTrip_EmptyData(t *testing.T) {
 		t.Errorf("Round-trip failed for empty data: got %q", decompressed)
 	}
 }
+
+func TestCodec_ConcurrentReuse(t *testing.T) {
+	c := New()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				original := bytes.Repeat([]byte(fmt.Sprintf("goroutine %d iteration %d\n", g, i)), 100)
+
+				var compressed bytes.Buffer
+				w, err := c.Writer(&compressed)
+				if err != nil {
+					errs <- err
+					return
+				}
+				w.Write(original)
+				if err := w.Close(); err != nil {
+					errs <- err
+					return
+				}
+
+				r, err := c.Reader(bytes.NewReader(compressed.Bytes()))
+				if err != nil {
+					errs <- err
+					return
+				}
+				got, err := io.ReadAll(r)
+				r.Close()
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !bytes.Equal(got, original) {
+					errs <- fmt.Errorf("goroutine %d iteration %d: round-trip mismatch", g, i)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestCodec_UseAfterClose(t *testing.T) {
+	c := New()
+
+	var compressed bytes.Buffer
+	w, err := c.Writer(&compressed)
+	if err != nil {
+		t.Fatalf("Writer() error = %v", err)
+	}
+	w.Close()
+	if err := w.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Error("Write() after Close() error = nil, want error")
+	}
+
+	r, err := c.Reader(&compressed)
+	if err != nil {
+		t.Fatalf("Reader() error = %v", err)
+	}
+	r.Close()
+	if _, err := r.Read(make([]byte, 1)); err == nil {
+		t.Error("Read() after Close() error = nil, want error")
+	}
+}
+
+func BenchmarkCodec_Reader(b *testing.B) {
+	c := New()
+	original := bytes.Repeat([]byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[]}`+"\n"), 1000)
+
+	var compressed bytes.Buffer
+	w, err := c.Writer(&compressed)
+	if err != nil {
+		b.Fatalf("Writer() error = %v", err)
+	}
+	w.Write(original)
+	w.Close()
+	data := compressed.Bytes()
+
+	b.SetBytes(int64(len(original)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r, err := c.Reader(bytes.NewReader(data))
+		if err != nil {
+			b.Fatalf("Reader() error = %v", err)
+		}
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			b.Fatalf("Copy() error = %v", err)
+		}
+		r.Close()
+	}
+}
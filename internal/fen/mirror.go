@@ -0,0 +1,73 @@
+package fen
+
+import "strings"
+
+// Mirror returns the color-swapped equivalent of a position: the board is
+// flipped vertically, piece colors are swapped, the side to move is
+// toggled, and castling rights and the en passant square are mirrored.
+// Because stored evaluations are relative to the side to move, a position
+// and its mirror have the same scores, so one record can serve both.
+//
+// Move counters, if present, are kept as-is. Returns ErrInvalidFEN if the
+// FEN is malformed.
+func Mirror(fen string) (string, error) {
+	if _, err := Normalize(fen); err != nil {
+		return "", err
+	}
+	parts := strings.Fields(fen)
+
+	ranks := strings.Split(parts[0], "/")
+	for i, j := 0, len(ranks)-1; i < j; i, j = i+1, j-1 {
+		ranks[i], ranks[j] = ranks[j], ranks[i]
+	}
+	parts[0] = swapCase(strings.Join(ranks, "/"))
+
+	if parts[1] == "w" {
+		parts[1] = "b"
+	} else {
+		parts[1] = "w"
+	}
+
+	if parts[2] != "-" {
+		if !isValidCastling(parts[2]) {
+			return "", ErrInvalidFEN
+		}
+		var rights strings.Builder
+		swapped := swapCase(parts[2])
+		for _, right := range "KQkq" {
+			if strings.ContainsRune(swapped, right) {
+				rights.WriteRune(right)
+			}
+		}
+		parts[2] = rights.String()
+	}
+
+	if ep := parts[3]; ep != "-" {
+		switch {
+		case len(ep) != 2 || ep[0] < 'a' || ep[0] > 'h':
+			return "", ErrInvalidFEN
+		case ep[1] == '3':
+			parts[3] = ep[:1] + "6"
+		case ep[1] == '6':
+			parts[3] = ep[:1] + "3"
+		default:
+			return "", ErrInvalidFEN
+		}
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// swapCase swaps upper- and lowercase ASCII letters, leaving others as-is.
+func swapCase(s string) string {
+	b := []byte(s)
+	for i, ch := range b {
+		switch {
+		case ch >= 'a' && ch <= 'z':
+			b[i] = ch - 'a' + 'A'
+		case ch >= 'A' && ch <= 'Z':
+			b[i] = ch - 'A' + 'a'
+		}
+	}
+	return string(b)
+}
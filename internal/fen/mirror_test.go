@@ -0,0 +1,91 @@
+package fen
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want string
+	}{
+		{
+			name: "starting position",
+			fen:  "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -",
+			want: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq -",
+		},
+		{
+			name: "en passant",
+			fen:  "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3",
+			want: "rnbqkbnr/pppp1ppp/8/4p3/8/8/PPPPPPPP/RNBQKBNR w KQkq e6",
+		},
+		{
+			name: "asymmetric castling",
+			fen:  "r3k3/8/8/8/8/8/8/4K2R w Kq -",
+			want: "4k2r/8/8/8/8/8/8/R3K3 b Qk -",
+		},
+		{
+			name: "endgame with counters",
+			fen:  "8/5k2/8/3P4/8/8/1K6/8 w - - 3 50",
+			want: "8/1k6/8/8/3p4/8/5K2/8 b - - 3 50",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Mirror(tt.fen)
+			if err != nil {
+				t.Fatalf("Mirror() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Mirror() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMirror_Involution(t *testing.T) {
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6",
+		"r1bqkb1r/pppp1ppp/2n2n2/4p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 4 4",
+		"r3k2r/8/8/8/8/8/8/R3K2R b Kq -",
+		"8/8/8/8/8/8/8/4K2k w - -",
+	}
+	for _, fen := range fens {
+		if err := Validate(fen); err != nil {
+			t.Fatalf("Validate(%q) error = %v", fen, err)
+		}
+		once, err := Mirror(fen)
+		if err != nil {
+			t.Fatalf("Mirror(%q) error = %v", fen, err)
+		}
+		if err := Validate(once); err != nil {
+			t.Errorf("Mirror(%q) = %q is not legal: %v", fen, once, err)
+		}
+		twice, err := Mirror(once)
+		if err != nil {
+			t.Fatalf("Mirror(%q) error = %v", once, err)
+		}
+		if twice != fen {
+			t.Errorf("Mirror(Mirror(%q)) = %q", fen, twice)
+		}
+	}
+}
+
+func TestMirror_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq -",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkz -",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e4",
+	}
+	for _, fen := range tests {
+		if _, err := Mirror(fen); !errors.Is(err, ErrInvalidFEN) {
+			t.Errorf("Mirror(%q) error = %v, want ErrInvalidFEN", fen, err)
+		}
+	}
+}
@@ -22,6 +22,49 @@ type Material struct {
 	BlackBishops int
 	BlackRooks   int
 	BlackQueens  int
+
+	// Kings are counted only when parsed with WithKings.
+	WhiteKings int
+	BlackKings int
+}
+
+// MaterialOption configures ParseMaterial.
+type MaterialOption func(*materialConfig)
+
+type materialConfig struct {
+	countKings bool
+}
+
+// WithKings makes ParseMaterial count kings, so callers can reject
+// positions without exactly one king per side.
+func WithKings() MaterialOption {
+	return func(c *materialConfig) {
+		c.countKings = true
+	}
+}
+
+// Signature packs the piece counts into a stable integer, suitable as a
+// sharding or grouping key. Each side uses 16 bits, White in the low half:
+//   - Bits 0-3:   min(Pawns, 15)
+//   - Bits 4-6:   min(Knights, 7)
+//   - Bits 7-9:   min(Bishops, 7)
+//   - Bits 10-12: min(Rooks, 7)
+//   - Bits 13-15: min(Queens, 7)
+//
+// Kings are not included, so the signature is the same whether or not
+// WithKings was used. Counts beyond a field's capacity saturate.
+func (m Material) Signature() uint32 {
+	return sideSignature(m.WhitePawns, m.WhiteKnights, m.WhiteBishops, m.WhiteRooks, m.WhiteQueens) |
+		sideSignature(m.BlackPawns, m.BlackKnights, m.BlackBishops, m.BlackRooks, m.BlackQueens)<<16
+}
+
+// sideSignature packs one side's piece counts into 16 bits.
+func sideSignature(pawns, knights, bishops, rooks, queens int) uint32 {
+	return uint32(min(pawns, 15)) |
+		uint32(min(knights, 7))<<4 |
+		uint32(min(bishops, 7))<<7 |
+		uint32(min(rooks, 7))<<10 |
+		uint32(min(queens, 7))<<13
 }
 
 // Normalize returns a normalized FEN string suitable for lookups.
@@ -47,8 +90,37 @@ func Normalize(fen string) (string, error) {
 	return strings.Join(parts[:4], " "), nil
 }
 
-// ParseMaterial extracts material counts from a FEN string.
-func ParseMaterial(fen string) (Material, error) {
+// NormalizeStrict is like Normalize but also clears the en passant square
+// to "-" unless a pawn of the side to move stands ready to capture on it.
+// This is the "legal en passant" convention used by the Lichess database,
+// so FENs from sources that always set the square after a double push,
+// such as many GUIs, match stored positions.
+func NormalizeStrict(fen string) (string, error) {
+	normalized, err := Normalize(fen)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Fields(normalized)
+	if parts[3] == "-" {
+		return normalized, nil
+	}
+	if !isValidEnPassant(parts[3], parts[1]) {
+		return "", ErrInvalidFEN
+	}
+	if !canCaptureEnPassant(parseBoard(parts[0]), parts[3], parts[1]) {
+		parts[3] = "-"
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// ParseMaterial extracts material counts from a FEN string. Kings are
+// ignored unless WithKings is given.
+func ParseMaterial(fen string, opts ...MaterialOption) (Material, error) {
+	var cfg materialConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	parts := strings.Fields(fen)
 	if len(parts) == 0 {
 		return Material{}, ErrInvalidFEN
@@ -77,8 +149,14 @@ func ParseMaterial(fen string) (Material, error) {
 			m.BlackRooks++
 		case 'q':
 			m.BlackQueens++
-		case 'K', 'k':
-			// Kings are always present, don't count
+		case 'K':
+			if cfg.countKings {
+				m.WhiteKings++
+			}
+		case 'k':
+			if cfg.countKings {
+				m.BlackKings++
+			}
 		case '/', '1', '2', '3', '4', '5', '6', '7', '8':
 			// Valid FEN characters, ignore
 		default:
@@ -128,3 +206,24 @@ func isValidPiecePlacement(placement string) bool {
 
 	return true
 }
+
+// board holds piece letters indexed by [rank][file], with rank 0 being
+// White's back rank and 0 marking an empty square.
+type board [8][8]byte
+
+// parseBoard expands a piece placement validated by isValidPiecePlacement.
+func parseBoard(placement string) board {
+	var b board
+	for i, rank := range strings.Split(placement, "/") {
+		r, f := 7-i, 0
+		for j := 0; j < len(rank); j++ {
+			if ch := rank[j]; ch >= '1' && ch <= '8' {
+				f += int(ch - '0')
+			} else {
+				b[r][f] = ch
+				f++
+			}
+		}
+	}
+	return b
+}
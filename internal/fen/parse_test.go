@@ -72,6 +72,69 @@ func TestNormalize(t *testing.T) {
 	}
 }
 
+func TestNormalizeStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "e3 without capturing pawn",
+			input: "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+			want:  "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq -",
+		},
+		{
+			name:  "e3 with black pawn on d4",
+			input: "rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 3",
+			want:  "rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3",
+		},
+		{
+			name:  "e3 with white pawn beside, not black",
+			input: "rnbqkbnr/pppppppp/8/8/3PP3/8/PPP2PPP/RNBQKBNR b KQkq e3",
+			want:  "rnbqkbnr/pppppppp/8/8/3PP3/8/PPP2PPP/RNBQKBNR b KQkq -",
+		},
+		{
+			name:  "e6 without capturing pawn",
+			input: "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2",
+			want:  "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq -",
+		},
+		{
+			name:  "e6 with white pawn on f5",
+			input: "rnbqkbnr/pppp1ppp/8/4pP2/8/8/PPPPP1PP/RNBQKBNR w KQkq e6 0 3",
+			want:  "rnbqkbnr/pppp1ppp/8/4pP2/8/8/PPPPP1PP/RNBQKBNR w KQkq e6",
+		},
+		{
+			name:  "a6 on the edge with white pawn on b5",
+			input: "rnbqkbnr/1ppppppp/8/pP6/8/8/P1PPPPPP/RNBQKBNR w KQkq a6",
+			want:  "rnbqkbnr/1ppppppp/8/pP6/8/8/P1PPPPPP/RNBQKBNR w KQkq a6",
+		},
+		{
+			name:  "no en passant square",
+			input: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			want:  "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -",
+		},
+		{
+			name:    "en passant on wrong rank",
+			input:   "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e6",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeStrict(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NormalizeStrict() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("NormalizeStrict() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseMaterial(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -135,6 +198,104 @@ func TestParseMaterial(t *testing.T) {
 	}
 }
 
+func TestParseMaterial_WithKings(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantWhite int
+		wantBlack int
+	}{
+		{"starting position", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -", 1, 1},
+		{"missing black king", "8/8/8/8/8/8/8/4K3 w - -", 1, 0},
+		{"two white kings", "8/8/8/8/8/8/8/K3K2k w - -", 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMaterial(tt.input, WithKings())
+			if err != nil {
+				t.Fatalf("ParseMaterial() error = %v", err)
+			}
+			if got.WhiteKings != tt.wantWhite || got.BlackKings != tt.wantBlack {
+				t.Errorf("kings = %d/%d, want %d/%d", got.WhiteKings, got.BlackKings, tt.wantWhite, tt.wantBlack)
+			}
+
+			// Without the option, kings stay uncounted.
+			plain, err := ParseMaterial(tt.input)
+			if err != nil {
+				t.Fatalf("ParseMaterial() error = %v", err)
+			}
+			if plain.WhiteKings != 0 || plain.BlackKings != 0 {
+				t.Errorf("kings counted without WithKings: %+v", plain)
+			}
+		})
+	}
+}
+
+func TestMaterial_Signature(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  uint32
+	}{
+		{
+			name:  "starting position",
+			input: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			// 8 pawns, 2 knights, 2 bishops, 2 rooks, 1 queen per side.
+			want: 0x29282928,
+		},
+		{
+			name:  "bare kings",
+			input: "8/8/8/8/8/8/8/4K2k w - -",
+			want:  0,
+		},
+		{
+			name:  "underpromotions",
+			input: "4k3/8/8/8/8/8/8/NNNBBB1K w - -",
+			want:  3<<4 | 3<<7,
+		},
+		{
+			name:  "nine queens",
+			input: "QQQQQQQQ/Q7/8/8/8/8/8/4K2k w - -",
+			want:  7 << 13,
+		},
+		{
+			name:  "black promotions",
+			input: "4K2k/8/8/8/8/8/pppppppp/qqqrrrnn b - -",
+			want:  (8 | 2<<4 | 3<<10 | 3<<13) << 16,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseMaterial(tt.input)
+			if err != nil {
+				t.Fatalf("ParseMaterial() error = %v", err)
+			}
+			if got := m.Signature(); got != tt.want {
+				t.Errorf("Signature() = %#x, want %#x", got, tt.want)
+			}
+
+			withKings, err := ParseMaterial(tt.input, WithKings())
+			if err != nil {
+				t.Fatalf("ParseMaterial() error = %v", err)
+			}
+			if got := withKings.Signature(); got != tt.want {
+				t.Errorf("Signature() with kings = %#x, want %#x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaterial_SignatureDistinguishesMinors(t *testing.T) {
+	// Bishop pair and knight pair must not collide.
+	bishops := Material{WhiteBishops: 2}
+	knights := Material{WhiteKnights: 2}
+	if bishops.Signature() == knights.Signature() {
+		t.Errorf("Signature() collides for bishops and knights: %#x", bishops.Signature())
+	}
+}
+
 func TestSideToMove(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -0,0 +1,91 @@
+package fen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validate checks that a FEN describes a legal-looking position. Beyond
+// what Normalize accepts, it requires:
+//   - exactly one king per side
+//   - at most 8 pawns per side, and no pawns on the first or last rank
+//   - castling rights backed by a king and rook on their home squares
+//   - an en passant square that a pawn just passed over
+//
+// It does not detect positions that are unreachable for other reasons,
+// such as the side not to move being in check. Errors wrap ErrInvalidFEN.
+// Validate is slower than Normalize; call it only where strictness matters.
+func Validate(fen string) error {
+	normalized, err := Normalize(fen)
+	if err != nil {
+		return err
+	}
+	parts := strings.Fields(normalized)
+	b := parseBoard(parts[0])
+
+	var counts [128]int
+	for r := range b {
+		for f, piece := range b[r] {
+			counts[piece]++
+			if (piece == 'P' || piece == 'p') && (r == 0 || r == 7) {
+				return invalid("pawn on %c%d", 'a'+f, r+1)
+			}
+		}
+	}
+	if counts['K'] != 1 || counts['k'] != 1 {
+		return invalid("%d white and %d black kings", counts['K'], counts['k'])
+	}
+	if counts['P'] > 8 || counts['p'] > 8 {
+		return invalid("%d white and %d black pawns", counts['P'], counts['p'])
+	}
+
+	if !isValidCastling(parts[2]) {
+		return invalid("castling field %q", parts[2])
+	}
+	for _, right := range parts[2] {
+		if right == '-' {
+			break
+		}
+		if !hasCastlingPieces(b, right) {
+			return invalid("castling right %c without king and rook on home squares", right)
+		}
+	}
+
+	if ep := parts[3]; ep != "-" {
+		if !isValidEnPassant(ep, parts[1]) {
+			return invalid("en passant square %q", ep)
+		}
+		f := int(ep[0] - 'a')
+		// The pushed pawn passed over rank 6 (or 3) from rank 7 (or 2).
+		epRank, pawnRank, fromRank, pawn := 5, 4, 6, byte('p')
+		if parts[1] == "b" {
+			epRank, pawnRank, fromRank, pawn = 2, 3, 1, 'P'
+		}
+		if b[pawnRank][f] != pawn || b[epRank][f] != 0 || b[fromRank][f] != 0 {
+			return invalid("en passant square %s without a just-pushed pawn", ep)
+		}
+	}
+
+	return nil
+}
+
+// hasCastlingPieces reports whether the king and rook for a castling right
+// are on their home squares.
+func hasCastlingPieces(b board, right rune) bool {
+	switch right {
+	case 'K':
+		return b[0][4] == 'K' && b[0][7] == 'R'
+	case 'Q':
+		return b[0][4] == 'K' && b[0][0] == 'R'
+	case 'k':
+		return b[7][4] == 'k' && b[7][7] == 'r'
+	case 'q':
+		return b[7][4] == 'k' && b[7][0] == 'r'
+	}
+	return false
+}
+
+// invalid returns an ErrInvalidFEN wrapping a formatted reason.
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidFEN, fmt.Sprintf(format, args...))
+}
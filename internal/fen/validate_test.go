@@ -0,0 +1,60 @@
+package fen
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fen     string
+		wantErr bool
+	}{
+		{"starting position", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", false},
+		{"after e4", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3", false},
+		{"after e4 e5", "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6", false},
+		{"bare kings", "8/8/8/8/8/8/8/4K2k w - -", false},
+		{"partial castling", "r3k3/8/8/8/8/8/8/4K2R w Kq -", false},
+
+		{"malformed", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq -", true},
+		{"no white king", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQ1BNR w kq -", true},
+		{"two black kings", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNk w - -", true},
+		{"nine white pawns", "rnbqkbnr/pppppppp/8/8/8/P7/PPPPPPPP/RNBQKBNR w KQkq -", true},
+		{"pawn on first rank", "4k3/8/8/8/8/8/8/P3K3 w - -", true},
+		{"pawn on last rank", "p3k3/8/8/8/8/8/8/4K3 w - -", true},
+		{"bad castling letters", "r3k2r/8/8/8/8/8/8/R3K2R w KX -", true},
+		{"castling without rook", "r3k2r/8/8/8/8/8/8/R3K3 w K -", true},
+		{"castling with moved king", "r3k2r/8/8/8/8/8/8/R2K3R w Q -", true},
+		{"black castling without rook", "r3k3/8/8/8/8/8/8/R3K2R b k -", true},
+		{"en passant wrong rank", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e4", true},
+		{"en passant without pawn", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq e3", true},
+		{"en passant origin occupied", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPPPPPP/RNBQKBNR b KQkq e3", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.fen)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidFEN) {
+					t.Errorf("Validate() error = %v, want ErrInvalidFEN", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_NormalizeIsLenient(t *testing.T) {
+	// Normalize stays cheap and accepts what Validate rejects.
+	fen := "8/8/8/8/8/8/8/8 w - -"
+	if _, err := Normalize(fen); err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if err := Validate(fen); err == nil {
+		t.Error("Validate() accepted a position with no kings")
+	}
+}
@@ -0,0 +1,99 @@
+package fen
+
+import "strings"
+
+// Offsets into polyglotRandom.
+const (
+	zobristCastle    = 768
+	zobristEnPassant = 772
+	zobristTurn      = 780
+)
+
+// polyglotPieces orders pieces by Polyglot "kind": black pawn = 0,
+// white pawn = 1, black knight = 2, and so on up to white king = 11.
+const polyglotPieces = "pPnNbBrRqQkK"
+
+// Zobrist returns the Polyglot Zobrist hash of a position, as used to key
+// Polyglot opening books. Halfmove and fullmove counters are ignored, so
+// the hash is stable for a normalized FEN.
+//
+// Following Polyglot, the en passant file is hashed only if a pawn of the
+// side to move could capture on it. Returns ErrInvalidFEN if the FEN is
+// malformed, including bad castling or en passant fields.
+func Zobrist(fen string) (uint64, error) {
+	normalized, err := Normalize(fen)
+	if err != nil {
+		return 0, err
+	}
+	parts := strings.Fields(normalized)
+	if !isValidCastling(parts[2]) || !isValidEnPassant(parts[3], parts[1]) {
+		return 0, ErrInvalidFEN
+	}
+
+	b := parseBoard(parts[0])
+	var h uint64
+	for r := range b {
+		for f, piece := range b[r] {
+			if piece != 0 {
+				kind := strings.IndexByte(polyglotPieces, piece)
+				h ^= polyglotRandom[64*kind+8*r+f]
+			}
+		}
+	}
+
+	for i, right := range "KQkq" {
+		if strings.ContainsRune(parts[2], right) {
+			h ^= polyglotRandom[zobristCastle+i]
+		}
+	}
+
+	if ep := parts[3]; ep != "-" && canCaptureEnPassant(b, ep, parts[1]) {
+		h ^= polyglotRandom[zobristEnPassant+int(ep[0]-'a')]
+	}
+
+	if parts[1] == "w" {
+		h ^= polyglotRandom[zobristTurn]
+	}
+	return h, nil
+}
+
+// isValidCastling reports whether s is "-" or distinct letters from "KQkq".
+func isValidCastling(s string) bool {
+	if s == "-" {
+		return true
+	}
+	if s == "" || len(s) > 4 {
+		return false
+	}
+	for i, ch := range s {
+		if !strings.ContainsRune("KQkq", ch) || strings.ContainsRune(s[:i], ch) {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidEnPassant reports whether s is "-" or a square the side to move
+// could capture on: rank 6 for White, rank 3 for Black.
+func isValidEnPassant(s, side string) bool {
+	if s == "-" {
+		return true
+	}
+	rank := byte('6')
+	if side == "b" {
+		rank = '3'
+	}
+	return len(s) == 2 && s[0] >= 'a' && s[0] <= 'h' && s[1] == rank
+}
+
+// canCaptureEnPassant reports whether a pawn of the side to move stands
+// beside the pawn that passed over ep, a square accepted by
+// isValidEnPassant. Pins are not considered.
+func canCaptureEnPassant(b board, ep, side string) bool {
+	f := int(ep[0] - 'a')
+	r, pawn := 4, byte('P')
+	if side == "b" {
+		r, pawn = 3, 'p'
+	}
+	return (f > 0 && b[r][f-1] == pawn) || (f < 7 && b[r][f+1] == pawn)
+}
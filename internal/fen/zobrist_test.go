@@ -0,0 +1,83 @@
+package fen
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestZobrist(t *testing.T) {
+	// Test positions from the Polyglot book format specification.
+	tests := []struct {
+		name string
+		fen  string
+		want uint64
+	}{
+		{"starting position", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 0x463b96181691fc9c},
+		{"e4", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", 0x823c9b50fd114196},
+		{"e4 d5", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", 0x0756b94461c50fb0},
+		{"e4 d5 e5", "rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 2", 0x662fafb965db29d4},
+		{"e4 d5 e5 f5", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", 0x22a48b5a8e47ff78},
+		{"e4 d5 e5 f5 Ke2", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR b kq - 0 3", 0x652a607ca3f242c1},
+		{"e4 d5 e5 f5 Ke2 Kf7", "rnbq1bnr/ppp1pkpp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR w - - 0 4", 0x00fdd303c946bdd9},
+		{"a4 b5 h4 b4 c4", "rnbqkbnr/p1pppppp/8/8/PpP4P/8/1P1PPPP1/RNBQKBNR b KQkq c3 0 3", 0x3c8123ea7b067637},
+		{"a4 b5 h4 b4 c4 bxc3 Ra3", "rnbqkbnr/p1pppppp/8/8/P6P/R1p5/1P1PPPP1/1NBQKBNR b Kkq - 0 4", 0x5c3f9b829b279560},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Zobrist(tt.fen)
+			if err != nil {
+				t.Fatalf("Zobrist() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Zobrist() = %#016x, want %#016x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZobrist_IgnoresCounters(t *testing.T) {
+	full, err := Zobrist("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	if err != nil {
+		t.Fatalf("Zobrist() error = %v", err)
+	}
+	normalized, err := Zobrist("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -")
+	if err != nil {
+		t.Fatalf("Zobrist() error = %v", err)
+	}
+	if full != normalized {
+		t.Errorf("Zobrist() = %#016x with counters, %#016x without", full, normalized)
+	}
+}
+
+func TestZobrist_UncapturableEnPassant(t *testing.T) {
+	// No black pawn can take on e3, so the square must not affect the hash.
+	with, err := Zobrist("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3")
+	if err != nil {
+		t.Fatalf("Zobrist() error = %v", err)
+	}
+	without, err := Zobrist("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq -")
+	if err != nil {
+		t.Fatalf("Zobrist() error = %v", err)
+	}
+	if with != without {
+		t.Errorf("Zobrist() = %#016x with e3, %#016x without", with, without)
+	}
+}
+
+func TestZobrist_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq -",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq -",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkx -",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KKqk -",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e3",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq z6",
+	}
+	for _, fen := range tests {
+		if _, err := Zobrist(fen); !errors.Is(err, ErrInvalidFEN) {
+			t.Errorf("Zobrist(%q) error = %v, want ErrInvalidFEN", fen, err)
+		}
+	}
+}
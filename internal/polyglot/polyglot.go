@@ -0,0 +1,138 @@
+// Package polyglot writes opening books in the Polyglot .bin format.
+//
+// A book is a sequence of 16-byte big-endian entries sorted by position
+// key. Keys are Polyglot Zobrist hashes, see fen.Zobrist.
+package polyglot
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/discochess/stockpile/internal/fen"
+)
+
+// ErrInvalidMove indicates a move is not valid UCI notation for the position.
+var ErrInvalidMove = errors.New("invalid UCI move")
+
+// Entry is a single book move.
+type Entry struct {
+	Key    uint64 // Zobrist hash of the position.
+	Move   uint16 // Encoded move, see EncodeMove.
+	Weight uint16 // Relative likelihood among moves for the same key.
+	Learn  uint32 // Unused by most engines; written as given.
+}
+
+// entrySize is the encoded size of an Entry in bytes.
+const entrySize = 16
+
+// promotions maps UCI promotion letters to Polyglot piece codes.
+var promotions = map[byte]uint16{'n': 1, 'b': 2, 'r': 3, 'q': 4}
+
+// EncodeMove converts a UCI move, e.g. "e2e4" or "e7e8q", to Polyglot's
+// 16-bit move encoding. The position is needed because Polyglot writes
+// castling as the king capturing its own rook ("e1h1" rather than "e1g1").
+func EncodeMove(fenStr, uci string) (uint16, error) {
+	if len(uci) != 4 && len(uci) != 5 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMove, uci)
+	}
+	fromFile, fromRank, ok1 := square(uci[0:2])
+	toFile, toRank, ok2 := square(uci[2:4])
+	if !ok1 || !ok2 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMove, uci)
+	}
+
+	var promo uint16
+	if len(uci) == 5 {
+		p, ok := promotions[uci[4]]
+		if !ok {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidMove, uci)
+		}
+		promo = p
+	}
+
+	piece, err := pieceAt(fenStr, fromFile, fromRank)
+	if err != nil {
+		return 0, err
+	}
+	if (piece == 'K' || piece == 'k') && fromFile == 4 && fromRank == toRank {
+		switch toFile {
+		case 6:
+			toFile = 7
+		case 2:
+			toFile = 0
+		}
+	}
+
+	return uint16(toFile) | uint16(toRank)<<3 | uint16(fromFile)<<6 | uint16(fromRank)<<9 | promo<<12, nil
+}
+
+// square parses a square name such as "e4" into 0-based file and rank.
+func square(s string) (file, rank int, ok bool) {
+	if s[0] < 'a' || s[0] > 'h' || s[1] < '1' || s[1] > '8' {
+		return 0, 0, false
+	}
+	return int(s[0] - 'a'), int(s[1] - '1'), true
+}
+
+// pieceAt returns the FEN letter of the piece on a square, or 0 if empty.
+func pieceAt(fenStr string, file, rank int) (byte, error) {
+	normalized, err := fen.Normalize(fenStr)
+	if err != nil {
+		return 0, err
+	}
+	placement, _, _ := strings.Cut(normalized, " ")
+	row := strings.Split(placement, "/")[7-rank]
+	f := 0
+	for i := 0; i < len(row); i++ {
+		ch := row[i]
+		if ch >= '1' && ch <= '8' {
+			f += int(ch - '0')
+			continue
+		}
+		if f == file {
+			return ch, nil
+		}
+		f++
+	}
+	return 0, nil
+}
+
+// Weight derives a book weight from an evaluation's depth and the move's
+// win probability for the side to move. Deeper, stronger moves weigh more.
+// The result is at least 1 so every move remains playable.
+func Weight(depth int, winProbability float64) uint16 {
+	w := math.Round(float64(depth) * winProbability * 100)
+	return uint16(min(max(w, 1), math.MaxUint16))
+}
+
+// WriteBook sorts entries by key, heaviest move first, and writes them to w.
+func WriteBook(w io.Writer, entries []Entry) error {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Key != entries[j].Key {
+			return entries[i].Key < entries[j].Key
+		}
+		return entries[i].Weight > entries[j].Weight
+	})
+
+	bw := bufio.NewWriter(w)
+	var buf [entrySize]byte
+	for _, e := range entries {
+		binary.BigEndian.PutUint64(buf[0:8], e.Key)
+		binary.BigEndian.PutUint16(buf[8:10], e.Move)
+		binary.BigEndian.PutUint16(buf[10:12], e.Weight)
+		binary.BigEndian.PutUint32(buf[12:16], e.Learn)
+		if _, err := bw.Write(buf[:]); err != nil {
+			return fmt.Errorf("writing book entry: %w", err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("writing book entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package polyglot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+const startFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -"
+
+func TestEncodeMove(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		uci  string
+		want uint16
+	}{
+		{"pawn push", startFEN, "e2e4", 4 | 3<<3 | 4<<6 | 1<<9},
+		{"knight", startFEN, "g1f3", 5 | 2<<3 | 6<<6 | 0<<9},
+		{"white short castle", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq -", "e1g1", 7 | 0<<3 | 4<<6 | 0<<9},
+		{"black long castle", "r3k2r/8/8/8/8/8/8/R3K2R b KQkq -", "e8c8", 0 | 7<<3 | 4<<6 | 7<<9},
+		{"rook not castling", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq -", "a1c1", 2 | 0<<3 | 0<<6 | 0<<9},
+		{"promotion", "8/4P3/8/8/8/8/8/k6K w - -", "e7e8q", 4 | 7<<3 | 4<<6 | 6<<9 | 4<<12},
+		{"underpromotion", "8/4P3/8/8/8/8/8/k6K w - -", "e7e8n", 4 | 7<<3 | 4<<6 | 6<<9 | 1<<12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeMove(tt.fen, tt.uci)
+			if err != nil {
+				t.Fatalf("EncodeMove() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EncodeMove(%q) = %#04x, want %#04x", tt.uci, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeMove_Invalid(t *testing.T) {
+	for _, uci := range []string{"", "e2", "e2e9", "i2e4", "e7e8k", "e2e4qq"} {
+		if _, err := EncodeMove(startFEN, uci); !errors.Is(err, ErrInvalidMove) {
+			t.Errorf("EncodeMove(%q) error = %v, want ErrInvalidMove", uci, err)
+		}
+	}
+}
+
+func TestWeight(t *testing.T) {
+	if got := Weight(30, 0.5); got != 1500 {
+		t.Errorf("Weight(30, 0.5) = %d, want 1500", got)
+	}
+	if got := Weight(30, 0); got != 1 {
+		t.Errorf("Weight(30, 0) = %d, want 1", got)
+	}
+	if got := Weight(1000, 1); got != 65535 {
+		t.Errorf("Weight(1000, 1) = %d, want 65535", got)
+	}
+	if Weight(40, 0.6) <= Weight(20, 0.6) {
+		t.Error("deeper evaluation should weigh more")
+	}
+}
+
+func TestWriteBook(t *testing.T) {
+	entries := []Entry{
+		{Key: 3, Move: 0x0101, Weight: 10},
+		{Key: 1, Move: 0x0202, Weight: 5, Learn: 7},
+		{Key: 3, Move: 0x0303, Weight: 20},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBook(&buf, entries); err != nil {
+		t.Fatalf("WriteBook() error = %v", err)
+	}
+	data := buf.Bytes()
+	if len(data) != 3*entrySize {
+		t.Fatalf("wrote %d bytes, want %d", len(data), 3*entrySize)
+	}
+
+	want := []Entry{
+		{Key: 1, Move: 0x0202, Weight: 5, Learn: 7},
+		{Key: 3, Move: 0x0303, Weight: 20},
+		{Key: 3, Move: 0x0101, Weight: 10},
+	}
+	for i, w := range want {
+		b := data[i*entrySize:]
+		got := Entry{
+			Key:    binary.BigEndian.Uint64(b[0:8]),
+			Move:   binary.BigEndian.Uint16(b[8:10]),
+			Weight: binary.BigEndian.Uint16(b[10:12]),
+			Learn:  binary.BigEndian.Uint32(b[12:16]),
+		}
+		if got != w {
+			t.Errorf("entry %d = %+v, want %+v", i, got, w)
+		}
+	}
+}
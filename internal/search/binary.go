@@ -12,6 +12,11 @@ import (
 // ErrNotFound indicates the position was not found in the shard.
 var ErrNotFound = errors.New("position not found")
 
+// ErrCorruptShard indicates the shard data holds a malformed line, such as
+// one without a FEN or a matching record that is not valid JSON. Errors
+// wrapping it name the offending line.
+var ErrCorruptShard = errors.New("stockpile: corrupt shard")
+
 // EvalRecord represents a single evaluation record in the shard data.
 // Matches the Lichess evaluation database format.
 type EvalRecord struct {
@@ -28,35 +33,111 @@ type EvalRecord struct {
 }
 
 // Search searches for a FEN in sorted JSONL shard data.
-// Returns the evaluation record if found, or ErrNotFound.
+// Returns the evaluation record if found, ErrNotFound if not, or an error
+// wrapping ErrCorruptShard if a line the search depends on is malformed.
 func Search(data []byte, targetFEN string) (*EvalRecord, error) {
 	lines := splitLines(data)
-	if len(lines) == 0 {
+	idx, ok, err := find(len(lines), sliceLine(lines), targetFEN)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
 		return nil, ErrNotFound
 	}
 
-	// Binary search for the target FEN.
-	idx := sort.Search(len(lines), func(i int) bool {
-		fen := extractFEN(lines[i])
+	// Parse the full record.
+	return parseRecord(lines[idx], fmt.Sprintf("line %d", idx+1))
+}
+
+// Exists reports whether a FEN is present in sorted JSONL shard data.
+// Unlike Search, it does not parse the matching record. Returns an error
+// wrapping ErrCorruptShard if a line the search depends on is malformed.
+func Exists(data []byte, targetFEN string) (bool, error) {
+	lines := splitLines(data)
+	_, ok, err := find(len(lines), sliceLine(lines), targetFEN)
+	if err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+// Line returns the raw JSONL record for a FEN in sorted shard data,
+// without parsing it. The returned slice aliases data.
+func Line(data []byte, targetFEN string) ([]byte, bool) {
+	lines := splitLines(data)
+	idx, ok, err := find(len(lines), sliceLine(lines), targetFEN)
+	if !ok || err != nil {
+		return nil, false
+	}
+	return lines[idx], true
+}
+
+// SearchNearest returns up to k records of sorted JSONL shard data whose
+// FENs are nearest the target in sort order: about half sort just before
+// it and half just after, or more on one side near either end of the data.
+// A record matching the target exactly is included, in the middle. Records
+// are returned in sorted order. Lines that fail to parse are skipped, and
+// corrupt data that defeats the search yields no records.
+func SearchNearest(data []byte, targetFEN string, k int) []*EvalRecord {
+	if k <= 0 {
+		return nil
+	}
+	lines := splitLines(data)
+	idx, _, err := find(len(lines), sliceLine(lines), targetFEN)
+	if err != nil {
+		return nil
+	}
+
+	start := max(min(idx-k/2, len(lines)-k), 0)
+	end := min(start+k, len(lines))
+	records := make([]*EvalRecord, 0, end-start)
+	for i := start; i < end; i++ {
+		record, err := parseRecord(lines[i], fmt.Sprintf("line %d", i+1))
+		if err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// find binary-searches n sorted lines, the i-th returned by line, for the
+// target FEN. Returns the line index and whether it is an exact match. A
+// probed line without a FEN cannot be ordered, so it fails the search with
+// an error wrapping ErrCorruptShard rather than steering it the wrong way.
+func find(n int, line func(i int) []byte, targetFEN string) (int, bool, error) {
+	corrupt := -1
+	idx := sort.Search(n, func(i int) bool {
+		fen := extractFEN(line(i))
+		if fen == "" && corrupt < 0 {
+			corrupt = i
+		}
 		return fen >= targetFEN
 	})
+	if corrupt >= 0 {
+		return 0, false, fmt.Errorf("%w: line %d: missing fen", ErrCorruptShard, corrupt+1)
+	}
 
-	if idx >= len(lines) {
-		return nil, ErrNotFound
+	if idx >= n {
+		return idx, false, nil
 	}
 
 	// Verify exact match.
-	fen := extractFEN(lines[idx])
-	if fen != targetFEN {
-		return nil, ErrNotFound
-	}
+	return idx, extractFEN(line(idx)) == targetFEN, nil
+}
 
-	// Parse the full record.
+// sliceLine returns a line accessor for find over split lines.
+func sliceLine(lines [][]byte) func(i int) []byte {
+	return func(i int) []byte { return lines[i] }
+}
+
+// parseRecord decodes a matched line, reporting a parse failure as
+// corruption at the given location.
+func parseRecord(line []byte, where string) (*EvalRecord, error) {
 	var record EvalRecord
-	if err := json.Unmarshal(lines[idx], &record); err != nil {
-		return nil, fmt.Errorf("parsing eval record: %w", err)
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, fmt.Errorf("%w: %s: parsing eval record: %w", ErrCorruptShard, where, err)
 	}
-
 	return &record, nil
 }
 
@@ -100,4 +181,3 @@ func extractFEN(line []byte) string {
 
 	return string(line[start : start+end])
 }
-
@@ -1,6 +1,9 @@
 package search
 
 import (
+	"errors"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -76,6 +79,99 @@ func TestSearch_EmptyData(t *testing.T) {
 	}
 }
 
+func TestExists(t *testing.T) {
+	data := []byte(`{"fen":"8/8/8/4k3/8/8/4K3/4R3 w - -","evals":[]}
+{"fen":"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -","evals":[]}
+`)
+
+	tests := []struct {
+		name string
+		fen  string
+		want bool
+	}{
+		{"first line", "8/8/8/4k3/8/8/4K3/4R3 w - -", true},
+		{"last line", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -", true},
+		{"before first", "1/8/8/8/8/8/8/8 w - -", false},
+		{"between lines", "8/8/8/8/8/8/8/4K2k w - -", false},
+		{"after last", "z", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Exists(data, tt.fen)
+			if err != nil || got != tt.want {
+				t.Errorf("Exists() = %v, %v; want %v, nil", got, err, tt.want)
+			}
+		})
+	}
+
+	if got, err := Exists(nil, "8/8/8/4k3/8/8/4K3/4R3 w - -"); got || err != nil {
+		t.Errorf("Exists() on empty data = %v, %v; want false, nil", got, err)
+	}
+
+	corrupt := []byte(`{"fen":"a","evals":[]}
+{"evals":[]}
+{"fen":"c","evals":[]}
+`)
+	if _, err := Exists(corrupt, "b"); !errors.Is(err, ErrCorruptShard) {
+		t.Errorf("Exists() on corrupt data error = %v, want ErrCorruptShard", err)
+	}
+}
+
+func TestLine(t *testing.T) {
+	data := []byte(`{"fen":"8/8/8/4k3/8/8/4K3/4R3 w - -","evals":[]}
+{"fen":"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -","evals":[{"depth":30}]}
+`)
+
+	line, ok := Line(data, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -")
+	if !ok {
+		t.Fatal("Line() ok = false, want true")
+	}
+	want := `{"fen":"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -","evals":[{"depth":30}]}`
+	if string(line) != want {
+		t.Errorf("Line() = %s, want %s", line, want)
+	}
+
+	if _, ok := Line(data, "8/8/8/8/8/8/8/4K2k w - -"); ok {
+		t.Error("Line() for missing FEN ok = true, want false")
+	}
+}
+
+func TestSearchNearest(t *testing.T) {
+	data := shardOf([]string{"b", "d", "f", "h", "j"})
+
+	tests := []struct {
+		name   string
+		target string
+		k      int
+		want   []string
+	}{
+		{"between lines", "e", 2, []string{"d", "f"}},
+		{"between lines, odd k", "e", 3, []string{"d", "f", "h"}},
+		{"exact match centered", "f", 3, []string{"d", "f", "h"}},
+		{"before first", "a", 2, []string{"b", "d"}},
+		{"after last", "z", 3, []string{"f", "h", "j"}},
+		{"k exceeds records", "e", 10, []string{"b", "d", "f", "h", "j"}},
+		{"zero k", "e", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, r := range SearchNearest(data, tt.target, tt.k) {
+				got = append(got, r.FEN)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SearchNearest(%q, %d) = %q, want %q", tt.target, tt.k, got, tt.want)
+			}
+		})
+	}
+
+	if got := SearchNearest(nil, "e", 2); len(got) != 0 {
+		t.Errorf("SearchNearest() on empty data = %v, want none", got)
+	}
+}
+
 func TestExtractFEN(t *testing.T) {
 	tests := []struct {
 		name string
@@ -157,8 +253,8 @@ func TestSplitLines(t *testing.T) {
 	}
 }
 
-func BenchmarkSearch(b *testing.B) {
-	// Generate sorted JSONL data with 1000 entries.
+// benchShard returns sorted JSONL data with 1000 entries.
+func benchShard() []byte {
 	var data []byte
 	for i := 0; i < 1000; i++ {
 		// Create FENs that sort lexicographically.
@@ -166,7 +262,11 @@ func BenchmarkSearch(b *testing.B) {
 		line := `{"fen":"` + fen + `","evals":[{"pvs":[{"cp":0,"line":"e4"}],"knodes":1000,"depth":20}]}` + "\n"
 		data = append(data, line...)
 	}
+	return data
+}
 
+func BenchmarkSearch(b *testing.B) {
+	data := benchShard()
 	targetFEN := "positionMN" // Somewhere in the middle.
 
 	b.ResetTimer()
@@ -183,3 +283,46 @@ func BenchmarkExtractFEN(b *testing.B) {
 		extractFEN(line)
 	}
 }
+
+func TestSearch_CorruptLine(t *testing.T) {
+	good := func(fen string) string {
+		return `{"fen":"` + fen + `","evals":[]}` + "\n"
+	}
+
+	tests := []struct {
+		name string
+		data string
+		fen  string
+	}{
+		{
+			name: "missing fen on probed line",
+			data: good("a") + good("b") + `{"evals":[]}` + "\n" + good("d") + good("e"),
+			fen:  "e",
+		},
+		{
+			name: "garbled fen field",
+			data: good("a") + good("b") + `{"fen:"c","evals":[]}` + "\n" + good("d") + good("e"),
+			fen:  "a",
+		},
+		{
+			name: "matched line is not valid JSON",
+			data: good("a") + `{"fen":"b","evals":[` + "\n" + good("c"),
+			fen:  "b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Search([]byte(tt.data), tt.fen)
+			if !errors.Is(err, ErrCorruptShard) {
+				t.Fatalf("Search(%q) error = %v, want ErrCorruptShard", tt.fen, err)
+			}
+			if errors.Is(err, ErrNotFound) {
+				t.Errorf("Search(%q) error = %v, should not match ErrNotFound", tt.fen, err)
+			}
+			if !strings.Contains(err.Error(), "line ") {
+				t.Errorf("Search(%q) error = %q, want the line number", tt.fen, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// DefaultIndexInterval is the number of records between index entries.
+const DefaultIndexInterval = 64
+
+// Index is a sparse offset index over sorted JSONL shard data.
+// Each entry holds the FEN of a record and the byte offset at which that
+// record starts in the decompressed shard.
+type Index struct {
+	fens    []string
+	offsets []int
+}
+
+// Add appends an entry. Entries must be added in FEN order.
+func (x *Index) Add(fen string, offset int) {
+	x.fens = append(x.fens, fen)
+	x.offsets = append(x.offsets, offset)
+}
+
+// Len returns the number of entries in the index.
+func (x *Index) Len() int {
+	return len(x.fens)
+}
+
+// MarshalText encodes the index as one "offset<TAB>fen" line per entry.
+func (x *Index) MarshalText() ([]byte, error) {
+	var buf bytes.Buffer
+	for i, fen := range x.fens {
+		buf.WriteString(strconv.Itoa(x.offsets[i]))
+		buf.WriteByte('\t')
+		buf.WriteString(fen)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseIndex decodes an index produced by MarshalText.
+func ParseIndex(data []byte) (*Index, error) {
+	x := &Index{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		offset, fen, ok := bytes.Cut(scanner.Bytes(), []byte{'\t'})
+		if !ok {
+			return nil, fmt.Errorf("index line %d: missing separator", n)
+		}
+		off, err := strconv.Atoi(string(offset))
+		if err != nil {
+			return nil, fmt.Errorf("index line %d: invalid offset: %w", n, err)
+		}
+		x.Add(string(fen), off)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading index: %w", err)
+	}
+	return x, nil
+}
+
+// BuildIndex creates an index over data with an entry every interval records.
+func BuildIndex(data []byte, interval int) *Index {
+	if interval <= 0 {
+		interval = DefaultIndexInterval
+	}
+	x := &Index{}
+	for offset, n := 0, 0; offset < len(data); n++ {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			end = len(data) - offset
+		}
+		if end > 0 && n%interval == 0 {
+			x.Add(extractFEN(data[offset:offset+end]), offset)
+		}
+		offset += end + 1
+	}
+	return x
+}
+
+// SearchIndexed searches for a FEN in sorted JSONL shard data using index to
+// jump to the block that may contain it, then scans only that block.
+// Returns the evaluation record if found, or ErrNotFound.
+func SearchIndexed(data []byte, index *Index, targetFEN string) (*EvalRecord, error) {
+	if index == nil || index.Len() == 0 {
+		return Search(data, targetFEN)
+	}
+
+	start, end, ok := index.blockRange(targetFEN, len(data))
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if start < 0 || start > end || end > len(data) {
+		return nil, fmt.Errorf("index offset out of range")
+	}
+
+	return scanBlock(data[start:end], start, targetFEN)
+}
+
+// blockRange returns the byte range [start, end) of the index block that may
+// contain targetFEN, where size is the length of the indexed data.
+// ok is false if targetFEN sorts before the first entry.
+func (x *Index) blockRange(targetFEN string, size int) (start, end int, ok bool) {
+	// Find the last entry whose FEN is <= target.
+	i := sort.SearchStrings(x.fens, targetFEN)
+	if i == x.Len() || x.fens[i] != targetFEN {
+		i--
+	}
+	if i < 0 {
+		return 0, 0, false
+	}
+
+	start, end = x.offsets[i], size
+	if i+1 < x.Len() {
+		end = x.offsets[i+1]
+	}
+	return start, end, true
+}
+
+// scanBlock scans a block of whole, sorted JSONL lines for targetFEN.
+// base is the offset of the block within the shard, used in errors.
+func scanBlock(block []byte, base int, targetFEN string) (*EvalRecord, error) {
+	for offset := base; len(block) > 0; {
+		line := block
+		if n := bytes.IndexByte(block, '\n'); n >= 0 {
+			line, block = block[:n], block[n+1:]
+		} else {
+			block = nil
+		}
+		lineOffset := offset
+		offset += len(line) + 1
+		if len(line) == 0 {
+			continue
+		}
+
+		fen := extractFEN(line)
+		if fen == "" {
+			return nil, fmt.Errorf("%w: offset %d: missing fen", ErrCorruptShard, lineOffset)
+		}
+		if fen > targetFEN {
+			break
+		}
+		if fen == targetFEN {
+			return parseRecord(line, fmt.Sprintf("offset %d", lineOffset))
+		}
+	}
+
+	return nil, ErrNotFound
+}
@@ -0,0 +1,155 @@
+package search
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/discochess/stockpile/internal/seekable"
+)
+
+// sortedShard returns n sorted JSONL records with FENs "pos0000".."posNNNN".
+func sortedShard(n int) []byte {
+	var data []byte
+	for i := 0; i < n; i++ {
+		line := fmt.Sprintf(`{"fen":"pos%04d","evals":[{"pvs":[{"cp":%d,"line":"e4"}],"knodes":1,"depth":20}]}`+"\n", i, i)
+		data = append(data, line...)
+	}
+	return data
+}
+
+func TestSearchIndexed(t *testing.T) {
+	data := sortedShard(200)
+
+	for _, interval := range []int{1, 7, 64, 1000} {
+		index := BuildIndex(data, interval)
+
+		for _, fen := range []string{"pos0000", "pos0006", "pos0007", "pos0063", "pos0064", "pos0199"} {
+			record, err := SearchIndexed(data, index, fen)
+			if err != nil {
+				t.Errorf("interval %d: SearchIndexed(%q) error = %v", interval, fen, err)
+				continue
+			}
+			if record.FEN != fen {
+				t.Errorf("interval %d: SearchIndexed(%q).FEN = %q", interval, fen, record.FEN)
+			}
+		}
+
+		for _, fen := range []string{"a", "pos0063x", "pos0200", "z"} {
+			if _, err := SearchIndexed(data, index, fen); !errors.Is(err, ErrNotFound) {
+				t.Errorf("interval %d: SearchIndexed(%q) error = %v, want ErrNotFound", interval, fen, err)
+			}
+		}
+	}
+}
+
+func TestSearchIndexed_NilIndex(t *testing.T) {
+	data := sortedShard(10)
+
+	record, err := SearchIndexed(data, nil, "pos0005")
+	if err != nil {
+		t.Fatalf("SearchIndexed() error = %v", err)
+	}
+	if record.FEN != "pos0005" {
+		t.Errorf("FEN = %q, want pos0005", record.FEN)
+	}
+}
+
+func TestIndex_MarshalRoundTrip(t *testing.T) {
+	index := BuildIndex(sortedShard(100), 10)
+
+	text, err := index.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText() error = %v", err)
+	}
+
+	parsed, err := ParseIndex(text)
+	if err != nil {
+		t.Fatalf("ParseIndex() error = %v", err)
+	}
+
+	if parsed.Len() != 10 {
+		t.Fatalf("Len() = %d, want 10", parsed.Len())
+	}
+	for i := range index.fens {
+		if parsed.fens[i] != index.fens[i] || parsed.offsets[i] != index.offsets[i] {
+			t.Errorf("entry %d = (%q, %d), want (%q, %d)", i,
+				parsed.fens[i], parsed.offsets[i], index.fens[i], index.offsets[i])
+		}
+	}
+}
+
+func TestParseIndex_Invalid(t *testing.T) {
+	for _, input := range []string{"no-separator\n", "abc\tpos0001\n"} {
+		if _, err := ParseIndex([]byte(input)); err == nil {
+			t.Errorf("ParseIndex(%q) error = nil, want error", input)
+		}
+	}
+}
+
+func BenchmarkSearchIndexed(b *testing.B) {
+	data := sortedShard(50000)
+	index := BuildIndex(data, DefaultIndexInterval)
+	targetFEN := "pos25000"
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = SearchIndexed(data, index, targetFEN)
+	}
+}
+
+func TestSearchSeekable(t *testing.T) {
+	data := sortedShard(500)
+	index := BuildIndex(data, 16)
+
+	var buf bytes.Buffer
+	w, err := seekable.NewWriter(&buf, 1024, zstd.SpeedDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if _, err := w.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	compressed := buf.Bytes()
+
+	for _, x := range []*Index{index, nil} {
+		for _, fen := range []string{"pos0000", "pos0015", "pos0016", "pos0250", "pos0499"} {
+			record, err := SearchSeekable(compressed, x, fen)
+			if err != nil {
+				t.Errorf("SearchSeekable(%q) error = %v", fen, err)
+				continue
+			}
+			if record.FEN != fen {
+				t.Errorf("SearchSeekable(%q).FEN = %q", fen, record.FEN)
+			}
+		}
+		for _, fen := range []string{"a", "pos0250x", "pos0500"} {
+			if _, err := SearchSeekable(compressed, x, fen); !errors.Is(err, ErrNotFound) {
+				t.Errorf("SearchSeekable(%q) error = %v, want ErrNotFound", fen, err)
+			}
+		}
+	}
+}
+
+func TestSearchIndexed_CorruptLine(t *testing.T) {
+	data := sortedShard(10)
+	index := BuildIndex(data, 4)
+	// Blank out the FEN key of pos0005 without changing any offsets.
+	data = bytes.Replace(data, []byte(`{"fen":"pos0005"`), []byte(`{"xxx":"pos0005"`), 1)
+
+	if _, err := SearchIndexed(data, index, "pos0006"); !errors.Is(err, ErrCorruptShard) {
+		t.Errorf("SearchIndexed() error = %v, want ErrCorruptShard", err)
+	}
+	// Blocks without the bad line are unaffected.
+	if _, err := SearchIndexed(data, index, "pos0009"); err != nil {
+		t.Errorf("SearchIndexed() error = %v", err)
+	}
+}
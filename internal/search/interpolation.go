@@ -0,0 +1,136 @@
+package search
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// interpolationCutoff is the region size, in bytes, below which
+// SearchInterpolation stops probing and binary-searches the remaining lines.
+const interpolationCutoff = 4096
+
+// SearchInterpolation searches for a FEN in sorted JSONL shard data using
+// interpolation search. It returns the same results as Search.
+//
+// Instead of splitting the shard into lines and halving the range each step,
+// it estimates where the target falls between the first and last FEN of the
+// current byte range and probes the line at that offset. When FENs are spread
+// evenly, as in large shards, this converges in far fewer probes than binary
+// search and skips the pass over the whole shard that Search needs to find
+// line boundaries. A probe that fails to halve the range is followed by a
+// bisection step, so skewed data degrades to binary search rather than a
+// linear scan. Ranges below a few kilobytes are finished with binary search.
+//
+// Search remains the default. Interpolation helps most on large shards whose
+// FENs are spread evenly, such as those of hash-based strategies; when FENs
+// are tightly clustered its estimates are poor and it does little better
+// than binary search.
+func SearchInterpolation(data []byte, targetFEN string) (*EvalRecord, error) {
+	line, offset, err := interpolate(data, targetFEN)
+	if err != nil {
+		return nil, err
+	}
+	return parseRecord(line, fmt.Sprintf("offset %d", offset))
+}
+
+// interpolate returns the line for targetFEN and its offset in data.
+// data[lo:hi] always holds whole lines: lo is at the start of a line and hi
+// just past the end of one.
+func interpolate(data []byte, targetFEN string) ([]byte, int, error) {
+	lo, hi := 0, len(data)
+	bisect := false
+	for hi-lo > interpolationCutoff {
+		// Skip blank lines at either end of the range.
+		lo += len(data[lo:hi]) - len(bytes.TrimLeft(data[lo:hi], "\n"))
+		region := data[lo:hi]
+		lastStart := lineStart(region, len(bytes.TrimRight(region, "\n"))-1)
+		loFEN := extractFEN(lineAt(region, 0))
+		hiFEN := extractFEN(lineAt(region, lastStart))
+		switch {
+		case loFEN == "":
+			return nil, 0, fmt.Errorf("%w: offset %d: missing fen", ErrCorruptShard, lo)
+		case hiFEN == "":
+			return nil, 0, fmt.Errorf("%w: offset %d: missing fen", ErrCorruptShard, lo+lastStart)
+		}
+		if targetFEN < loFEN || targetFEN > hiFEN {
+			return nil, 0, ErrNotFound
+		}
+
+		probe := len(region) / 2
+		if !bisect {
+			probe = int(fraction(loFEN, hiFEN, targetFEN) * float64(len(region)-1))
+		}
+		start := lineStart(region, probe)
+		line := lineAt(region, start)
+
+		size := hi - lo
+		switch fen := extractFEN(line); {
+		case fen == "" && len(line) > 0:
+			return nil, 0, fmt.Errorf("%w: offset %d: missing fen", ErrCorruptShard, lo+start)
+		case fen == targetFEN:
+			return line, lo + start, nil
+		case fen < targetFEN:
+			lo += start + len(line) + 1
+		default:
+			hi = lo + start
+		}
+		lo = min(lo, hi)
+		bisect = !bisect && hi-lo > size/2
+	}
+
+	rest := data[lo:hi]
+	lines := splitLines(rest)
+	idx, ok, err := find(len(lines), sliceLine(lines), targetFEN)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !ok {
+		return nil, 0, ErrNotFound
+	}
+	// Lines alias rest, so the capacity difference is the line's offset.
+	return lines[idx], lo + cap(rest) - cap(lines[idx]), nil
+}
+
+// lineStart returns the offset of the start of the line containing data[i].
+func lineStart(data []byte, i int) int {
+	return bytes.LastIndexByte(data[:max(i, 0)], '\n') + 1
+}
+
+// lineAt returns the line starting at offset start, without its newline.
+func lineAt(data []byte, start int) []byte {
+	line := data[start:]
+	if n := bytes.IndexByte(line, '\n'); n >= 0 {
+		line = line[:n]
+	}
+	return line
+}
+
+// fraction estimates how far target lies between lo and hi, in [0, 1].
+// It compares the eight bytes following the common prefix of lo and hi as
+// big-endian integers.
+func fraction(lo, hi, target string) float64 {
+	n := 0
+	for n < len(lo) && n < len(hi) && lo[n] == hi[n] {
+		n++
+	}
+	l, h, t := keyAt(lo, n), keyAt(hi, n), keyAt(target, n)
+	switch {
+	case h <= l:
+		return 0.5
+	case t <= l:
+		return 0
+	case t >= h:
+		return 1
+	}
+	return (t - l) / (h - l)
+}
+
+// keyAt returns the eight bytes of s from offset n, zero-padded, as a number.
+func keyAt(s string, n int) float64 {
+	var buf [8]byte
+	if n < len(s) {
+		copy(buf[:], s[n:])
+	}
+	return float64(binary.BigEndian.Uint64(buf[:]))
+}
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// shardOf returns sorted JSONL records for the given FENs.
+func shardOf(fens []string) []byte {
+	sort.Strings(fens)
+	var data []byte
+	for _, fen := range fens {
+		data = fmt.Appendf(data, `{"fen":%q,"evals":[{"pvs":[{"cp":1,"line":"e4"}],"knodes":1,"depth":20}]}`+"\n", fen)
+	}
+	return data
+}
+
+func TestSearchInterpolation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	uniform := make([]string, 3000)
+	for i := range uniform {
+		uniform[i] = fmt.Sprintf("%016x w - -", rng.Uint64())
+	}
+
+	// Most FENs share a long prefix and a few outliers stretch the range,
+	// so interpolation estimates are poor.
+	var skewed []string
+	for i := 0; i < 3000; i++ {
+		skewed = append(skewed, fmt.Sprintf("8/8/8/8/8/8/8/4K2k w - - %05d", i))
+	}
+	skewed = append(skewed, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -", "~")
+
+	tests := map[string][]string{
+		"uniform": uniform,
+		"skewed":  skewed,
+		"small":   {"a", "b", "c"},
+	}
+
+	for name, fens := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := shardOf(fens)
+			for _, fen := range fens {
+				record, err := SearchInterpolation(data, fen)
+				if err != nil {
+					t.Fatalf("SearchInterpolation(%q) error = %v", fen, err)
+				}
+				if record.FEN != fen {
+					t.Fatalf("SearchInterpolation(%q).FEN = %q", fen, record.FEN)
+				}
+			}
+			for _, fen := range []string{"", "0", fens[len(fens)/2] + "x", "\x7f"} {
+				if _, err := SearchInterpolation(data, fen); !errors.Is(err, ErrNotFound) {
+					t.Errorf("SearchInterpolation(%q) error = %v, want ErrNotFound", fen, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchInterpolation_Empty(t *testing.T) {
+	if _, err := SearchInterpolation(nil, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SearchInterpolation(nil) error = %v, want ErrNotFound", err)
+	}
+}
+
+func BenchmarkSearchInterpolation(b *testing.B) {
+	data := benchShard()
+	targetFEN := "positionMN" // Same target as BenchmarkSearch.
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = SearchInterpolation(data, targetFEN)
+	}
+}
+
+// largeShard returns 50000 sorted records with FENs "pos00000".."pos49999".
+func largeShard() []byte {
+	fens := make([]string, 50000)
+	for i := range fens {
+		fens[i] = fmt.Sprintf("pos%05d", i)
+	}
+	return shardOf(fens)
+}
+
+// BenchmarkSearch_Large and BenchmarkSearchInterpolation_Large compare the
+// two on a shard large enough for interpolation to pay off.
+func BenchmarkSearch_Large(b *testing.B) {
+	data := largeShard()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Search(data, "pos31337")
+	}
+}
+
+func BenchmarkSearchInterpolation_Large(b *testing.B) {
+	data := largeShard()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = SearchInterpolation(data, "pos31337")
+	}
+}
+
+func TestSearchInterpolation_CorruptLine(t *testing.T) {
+	fens := make([]string, 2000)
+	for i := range fens {
+		fens[i] = fmt.Sprintf("pos%05d", i)
+	}
+	data := shardOf(fens)
+	data = bytes.Replace(data, []byte(`{"fen":"pos01999"`), []byte(`{"xxx":"pos01999"`), 1)
+
+	if _, err := SearchInterpolation(data, "pos01000"); !errors.Is(err, ErrCorruptShard) {
+		t.Errorf("SearchInterpolation() error = %v, want ErrCorruptShard", err)
+	}
+}
@@ -0,0 +1,69 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// LineIndex records where each non-empty line of shard data starts and
+// ends. Searches of data that decompresses identically every time, such as
+// repeated reads of one shard file, can reuse it instead of splitting the
+// data into lines again.
+type LineIndex struct {
+	size   int // Length of the indexed data.
+	starts []int
+	ends   []int
+}
+
+// NewLineIndex indexes the lines of data.
+func NewLineIndex(data []byte) *LineIndex {
+	n := bytes.Count(data, []byte{'\n'}) + 1
+	x := &LineIndex{
+		size:   len(data),
+		starts: make([]int, 0, n),
+		ends:   make([]int, 0, n),
+	}
+	for offset := 0; offset < len(data); {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			end = len(data)
+		} else {
+			end += offset
+		}
+		if end > offset {
+			x.starts = append(x.starts, offset)
+			x.ends = append(x.ends, end)
+		}
+		offset = end + 1
+	}
+	return x
+}
+
+// Len returns the number of lines indexed.
+func (x *LineIndex) Len() int {
+	return len(x.starts)
+}
+
+// line returns the i-th line of data.
+func (x *LineIndex) line(data []byte, i int) []byte {
+	return data[x.starts[i]:x.ends[i]]
+}
+
+// SearchLineIndex is like Search, but finds the lines of data through x,
+// built by NewLineIndex from the same data, instead of splitting it. If x is
+// nil or was built from data of another length, it falls back to Search.
+func SearchLineIndex(data []byte, x *LineIndex, targetFEN string) (*EvalRecord, error) {
+	if x == nil || x.size != len(data) {
+		return Search(data, targetFEN)
+	}
+
+	line := func(i int) []byte { return x.line(data, i) }
+	idx, ok, err := find(x.Len(), line, targetFEN)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return parseRecord(line(idx), fmt.Sprintf("line %d", idx+1))
+}
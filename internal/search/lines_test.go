@@ -0,0 +1,77 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNewLineIndex(t *testing.T) {
+	data := []byte("a\n\nbb\nccc")
+	x := NewLineIndex(data)
+
+	want := []string{"a", "bb", "ccc"}
+	if x.Len() != len(want) {
+		t.Fatalf("Len() = %d, want %d", x.Len(), len(want))
+	}
+	for i, w := range want {
+		if got := string(x.line(data, i)); got != w {
+			t.Errorf("line(%d) = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestSearchLineIndex(t *testing.T) {
+	fens := make([]string, 500)
+	for i := range fens {
+		fens[i] = fmt.Sprintf("pos%04d", i*2)
+	}
+	data := shardOf(fens)
+	x := NewLineIndex(data)
+
+	for _, fen := range []string{"pos0000", "pos0500", "pos0998"} {
+		record, err := SearchLineIndex(data, x, fen)
+		if err != nil {
+			t.Fatalf("SearchLineIndex(%q) error = %v", fen, err)
+		}
+		if record.FEN != fen {
+			t.Errorf("SearchLineIndex(%q).FEN = %q", fen, record.FEN)
+		}
+	}
+	for _, fen := range []string{"pos0001", "aaa", "zzz"} {
+		if _, err := SearchLineIndex(data, x, fen); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SearchLineIndex(%q) error = %v, want ErrNotFound", fen, err)
+		}
+	}
+}
+
+func TestSearchLineIndex_Fallback(t *testing.T) {
+	data := shardOf([]string{"a", "b", "c"})
+	stale := NewLineIndex(data[:len(data)/2])
+
+	for _, x := range []*LineIndex{nil, stale} {
+		record, err := SearchLineIndex(data, x, "c")
+		if err != nil || record.FEN != "c" {
+			t.Errorf("SearchLineIndex() = %v, %v; want record c", record, err)
+		}
+	}
+}
+
+func TestSearchLineIndex_Corrupt(t *testing.T) {
+	data := []byte(`{"fen":"a","evals":[]}` + "\n" + `{"evals":[]}` + "\n" + `{"fen":"c","evals":[]}` + "\n")
+	if _, err := SearchLineIndex(data, NewLineIndex(data), "b"); !errors.Is(err, ErrCorruptShard) {
+		t.Errorf("SearchLineIndex() error = %v, want ErrCorruptShard", err)
+	}
+}
+
+// BenchmarkSearchLineIndex_Large compares against BenchmarkSearch_Large:
+// the same search without splitting the shard into lines first.
+func BenchmarkSearchLineIndex_Large(b *testing.B) {
+	data := largeShard()
+	x := NewLineIndex(data)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = SearchLineIndex(data, x, "pos31337")
+	}
+}
@@ -0,0 +1,64 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/discochess/stockpile/internal/seekable"
+)
+
+// SearchSeekable searches for a FEN in a compressed shard written in the
+// seekable zstd format. It uses index to find the block that may contain the
+// FEN and decompresses only the frames covering that block, rather than the
+// whole shard.
+//
+// Without an index, or if the shard has no seek table, the whole shard is
+// decompressed and searched.
+func SearchSeekable(compressed []byte, index *Index, targetFEN string) (*EvalRecord, error) {
+	table, err := seekable.ReadTable(compressed)
+	if err != nil || index == nil || index.Len() == 0 {
+		data, err := decompressAll(compressed)
+		if err != nil {
+			return nil, err
+		}
+		return SearchIndexed(data, index, targetFEN)
+	}
+
+	return SearchFrames(bytes.NewReader(compressed), table, index, targetFEN)
+}
+
+// SearchFrames searches for a FEN in a seekable shard read through r, given
+// its parsed seek table and a non-empty index. Only the compressed frames
+// covering the block that may contain the FEN are read and decompressed, so
+// a lookup touches a few frames rather than the whole shard.
+func SearchFrames(r io.ReaderAt, table *seekable.Table, index *Index, targetFEN string) (*EvalRecord, error) {
+	start, end, ok := index.blockRange(targetFEN, int(table.Size()))
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if start < 0 || start > end || int64(end) > table.Size() {
+		return nil, fmt.Errorf("index offset out of range")
+	}
+
+	block, err := table.ReadRangeAt(r, int64(start), int64(end))
+	if err != nil {
+		return nil, err
+	}
+	return scanBlock(block, start, targetFEN)
+}
+
+func decompressAll(compressed []byte) ([]byte, error) {
+	dec, err := zstd.NewReader(bytes.NewReader(compressed), zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+	data, err := io.ReadAll(dec)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing shard: %w", err)
+	}
+	return data, nil
+}
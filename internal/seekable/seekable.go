@@ -0,0 +1,272 @@
+// Package seekable reads and writes the zstd seekable format: a sequence of
+// independently compressed zstd frames followed by a seek table stored in a
+// skippable frame.
+//
+// Because the seek table lives in a skippable frame, a seekable stream is
+// still a valid zstd stream and decompresses in full with any decoder. Readers
+// that understand the table can instead decompress only the frames covering a
+// byte range of the original data.
+package seekable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultFrameSize is the default uncompressed size of each frame.
+const DefaultFrameSize = 256 << 10
+
+const (
+	skippableMagic = 0x184D2A5E
+	seekableMagic  = 0x8F92EAB1
+
+	frameHeaderSize = 8 // Skippable frame magic and size.
+	footerSize      = 9 // Number of frames, descriptor, seekable magic.
+	entrySize       = 8 // Compressed and decompressed size, no checksum.
+
+	checksumFlag = 1 << 7
+)
+
+// ErrNotSeekable indicates the data has no seek table.
+var ErrNotSeekable = errors.New("seekable: missing seek table")
+
+// Writer compresses data into fixed-size independent zstd frames and writes
+// the seek table on Close.
+//
+// A frame is cut only between Write calls, once it holds at least the
+// configured frame size, so a single Write never spans two frames. Callers
+// that write one record per call therefore get frames that start and end on
+// record boundaries.
+type Writer struct {
+	w         io.Writer
+	enc       *zstd.Encoder
+	frameSize int
+	buf       []byte
+	out       []byte
+	entries   []entry
+	closed    bool
+}
+
+type entry struct {
+	compressed   uint32
+	decompressed uint32
+}
+
+// NewWriter returns a Writer that compresses frames of roughly frameSize
+// uncompressed bytes at the given level. A frameSize <= 0 selects
+// DefaultFrameSize. Extra encoder options, such as a dictionary, apply to
+// every frame.
+func NewWriter(w io.Writer, frameSize int, level zstd.EncoderLevel, opts ...zstd.EOption) (*Writer, error) {
+	if frameSize <= 0 {
+		frameSize = DefaultFrameSize
+	}
+	opts = append([]zstd.EOption{
+		zstd.WithEncoderConcurrency(1),
+		zstd.WithEncoderLevel(level),
+	}, opts...)
+	enc, err := zstd.NewWriter(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, enc: enc, frameSize: frameSize}, nil
+}
+
+// Write buffers p into the current frame, flushing the frame first if it is
+// already full.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("seekable: write to closed writer")
+	}
+	if len(w.buf) >= w.frameSize {
+		if err := w.flush(); err != nil {
+			return 0, err
+		}
+	}
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+// Close flushes the final frame and writes the seek table. It does not close
+// the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	defer w.enc.Close()
+
+	if err := w.flush(); err != nil {
+		return err
+	}
+	_, err := w.w.Write(appendSeekTable(nil, w.entries))
+	return err
+}
+
+func (w *Writer) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	if len(w.buf) > 1<<32-1 {
+		return fmt.Errorf("seekable: frame of %d bytes is too large", len(w.buf))
+	}
+	w.out = w.enc.EncodeAll(w.buf, w.out[:0])
+	if _, err := w.w.Write(w.out); err != nil {
+		return err
+	}
+	w.entries = append(w.entries, entry{
+		compressed:   uint32(len(w.out)),
+		decompressed: uint32(len(w.buf)),
+	})
+	w.buf = w.buf[:0]
+	return nil
+}
+
+func appendSeekTable(dst []byte, entries []entry) []byte {
+	size := len(entries)*entrySize + footerSize
+	dst = binary.LittleEndian.AppendUint32(dst, skippableMagic)
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(size))
+	for _, e := range entries {
+		dst = binary.LittleEndian.AppendUint32(dst, e.compressed)
+		dst = binary.LittleEndian.AppendUint32(dst, e.decompressed)
+	}
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(entries)))
+	dst = append(dst, 0) // Descriptor: no checksums.
+	return binary.LittleEndian.AppendUint32(dst, seekableMagic)
+}
+
+// Table is a parsed seek table. It maps offsets in the decompressed data to
+// the frames that hold them.
+type Table struct {
+	// Cumulative frame boundaries; frame i spans compressed bytes
+	// [cOffsets[i], cOffsets[i+1]) and decompressed bytes
+	// [dOffsets[i], dOffsets[i+1]).
+	cOffsets []int64
+	dOffsets []int64
+}
+
+// ReadTable parses the seek table at the end of data.
+// Returns ErrNotSeekable if data does not end with one.
+func ReadTable(data []byte) (*Table, error) {
+	return ReadTableAt(bytes.NewReader(data), int64(len(data)))
+}
+
+// ReadTableAt parses the seek table at the end of the size bytes of r,
+// reading only the table itself. Returns ErrNotSeekable if r does not end
+// with one.
+func ReadTableAt(r io.ReaderAt, size int64) (*Table, error) {
+	if size < frameHeaderSize+footerSize {
+		return nil, ErrNotSeekable
+	}
+	footer := make([]byte, footerSize)
+	if _, err := r.ReadAt(footer, size-footerSize); err != nil {
+		return nil, fmt.Errorf("seekable: reading footer: %w", err)
+	}
+	if binary.LittleEndian.Uint32(footer[5:]) != seekableMagic {
+		return nil, ErrNotSeekable
+	}
+	n := int64(binary.LittleEndian.Uint32(footer))
+	descriptor := footer[4]
+	entryLen := int64(entrySize)
+	if descriptor&checksumFlag != 0 {
+		entryLen += 4
+	}
+
+	tableSize := n*entryLen + footerSize
+	start := size - tableSize - frameHeaderSize
+	if start < 0 {
+		return nil, fmt.Errorf("seekable: seek table of %d frames exceeds data", n)
+	}
+	raw := make([]byte, frameHeaderSize+tableSize-footerSize)
+	if _, err := r.ReadAt(raw, start); err != nil {
+		return nil, fmt.Errorf("seekable: reading seek table: %w", err)
+	}
+	if binary.LittleEndian.Uint32(raw) != skippableMagic ||
+		int64(binary.LittleEndian.Uint32(raw[4:])) != tableSize {
+		return nil, fmt.Errorf("seekable: malformed seek table header")
+	}
+
+	t := &Table{
+		cOffsets: make([]int64, n+1),
+		dOffsets: make([]int64, n+1),
+	}
+	entries := raw[frameHeaderSize:]
+	for i := int64(0); i < n; i++ {
+		e := entries[i*entryLen:]
+		t.cOffsets[i+1] = t.cOffsets[i] + int64(binary.LittleEndian.Uint32(e))
+		t.dOffsets[i+1] = t.dOffsets[i] + int64(binary.LittleEndian.Uint32(e[4:]))
+	}
+	if t.cOffsets[n] != start {
+		return nil, fmt.Errorf("seekable: frames cover %d bytes, want %d", t.cOffsets[n], start)
+	}
+	return t, nil
+}
+
+// NumFrames returns the number of frames in the table.
+func (t *Table) NumFrames() int {
+	return len(t.cOffsets) - 1
+}
+
+// Size returns the total decompressed size.
+func (t *Table) Size() int64 {
+	return t.dOffsets[len(t.dOffsets)-1]
+}
+
+// frameAt returns the index of the frame containing decompressed offset off.
+func (t *Table) frameAt(off int64) int {
+	return sort.Search(t.NumFrames(), func(i int) bool { return t.dOffsets[i+1] > off })
+}
+
+// ReadRange decompresses the frames of data covering decompressed bytes
+// [start, end) and returns exactly that range. Only the overlapping frames
+// are decompressed.
+func (t *Table) ReadRange(data []byte, start, end int64) ([]byte, error) {
+	return t.ReadRangeAt(bytes.NewReader(data), start, end)
+}
+
+// ReadRangeAt is like ReadRange but reads from r, so only the compressed
+// bytes of the overlapping frames are read.
+func (t *Table) ReadRangeAt(r io.ReaderAt, start, end int64) ([]byte, error) {
+	if start < 0 || start > end || end > t.Size() {
+		return nil, fmt.Errorf("seekable: range [%d, %d) out of bounds", start, end)
+	}
+	if start == end {
+		return nil, nil
+	}
+
+	first, last := t.frameAt(start), t.frameAt(end-1)
+	compressed := make([]byte, t.cOffsets[last+1]-t.cOffsets[first])
+	if _, err := r.ReadAt(compressed, t.cOffsets[first]); err != nil {
+		return nil, fmt.Errorf("seekable: reading frames %d-%d: %w", first, last, err)
+	}
+	dec, err := decoder()
+	if err != nil {
+		return nil, err
+	}
+	var out []byte
+	for i := first; i <= last; i++ {
+		frame := compressed[t.cOffsets[i]-t.cOffsets[first] : t.cOffsets[i+1]-t.cOffsets[first]]
+		if out, err = dec.DecodeAll(frame, out); err != nil {
+			return nil, fmt.Errorf("seekable: decoding frame %d: %w", i, err)
+		}
+	}
+
+	base := t.dOffsets[first]
+	if int64(len(out)) != t.dOffsets[last+1]-base {
+		return nil, fmt.Errorf("seekable: frames %d-%d decoded to %d bytes, want %d",
+			first, last, len(out), t.dOffsets[last+1]-base)
+	}
+	return out[start-base : end-base], nil
+}
+
+// decoder returns a shared decoder. DecodeAll is safe for concurrent use and
+// starts no background goroutines.
+var decoder = sync.OnceValues(func() (*zstd.Decoder, error) {
+	return zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+})
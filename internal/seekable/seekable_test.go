@@ -0,0 +1,143 @@
+package seekable
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compress writes n numbered lines, one per Write, and returns the
+// uncompressed and compressed data.
+func compress(t *testing.T, n, frameSize int) (plain, compressed []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, frameSize, zstd.SpeedDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		line := fmt.Sprintf("line %05d\n", i)
+		plain = append(plain, line...)
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return plain, buf.Bytes()
+}
+
+func TestWriter_DecodesAsPlainZstd(t *testing.T) {
+	plain, compressed := compress(t, 1000, 1024)
+
+	dec, err := zstd.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dec.Close()
+	got, err := io.ReadAll(dec)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Errorf("decoded %d bytes, want %d", len(got), len(plain))
+	}
+}
+
+func TestTable_ReadRange(t *testing.T) {
+	plain, compressed := compress(t, 1000, 1024)
+
+	table, err := ReadTable(compressed)
+	if err != nil {
+		t.Fatalf("ReadTable() error = %v", err)
+	}
+	if table.Size() != int64(len(plain)) {
+		t.Errorf("Size() = %d, want %d", table.Size(), len(plain))
+	}
+	// Lines are 11 bytes, so a frame is cut after 94 lines (1034 bytes).
+	if got, want := table.NumFrames(), 11; got != want {
+		t.Errorf("NumFrames() = %d, want %d", got, want)
+	}
+
+	for _, r := range [][2]int64{{0, 11}, {1030, 1045}, {500, 5000}, {0, int64(len(plain))}, {int64(len(plain)) - 1, int64(len(plain))}} {
+		got, err := table.ReadRange(compressed, r[0], r[1])
+		if err != nil {
+			t.Errorf("ReadRange(%d, %d) error = %v", r[0], r[1], err)
+			continue
+		}
+		if !bytes.Equal(got, plain[r[0]:r[1]]) {
+			t.Errorf("ReadRange(%d, %d) = %q, want %q", r[0], r[1], got, plain[r[0]:r[1]])
+		}
+	}
+
+	if _, err := table.ReadRange(compressed, 0, int64(len(plain))+1); err == nil {
+		t.Error("ReadRange() past end: expected error")
+	}
+}
+
+func TestWriter_FramesEndOnWriteBoundaries(t *testing.T) {
+	_, compressed := compress(t, 1000, 1000)
+
+	table, err := ReadTable(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < table.NumFrames(); i++ {
+		if size := table.dOffsets[i+1] - table.dOffsets[i]; size%11 != 0 {
+			t.Errorf("frame %d holds %d bytes, not a whole number of lines", i, size)
+		}
+	}
+}
+
+func TestReadTable_NotSeekable(t *testing.T) {
+	enc, _ := zstd.NewWriter(nil)
+	data := enc.EncodeAll([]byte("plain zstd stream"), nil)
+	enc.Close()
+
+	if _, err := ReadTable(data); !errors.Is(err, ErrNotSeekable) {
+		t.Errorf("ReadTable() error = %v, want ErrNotSeekable", err)
+	}
+}
+
+// countingReaderAt counts the bytes read through it.
+type countingReaderAt struct {
+	r    io.ReaderAt
+	read int64
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.read += int64(n)
+	return n, err
+}
+
+func TestTable_ReadRangeAt_ReadsOnlyNeededFrames(t *testing.T) {
+	plain, compressed := compress(t, 1000, 1024)
+
+	r := &countingReaderAt{r: bytes.NewReader(compressed)}
+	table, err := ReadTableAt(r, int64(len(compressed)))
+	if err != nil {
+		t.Fatalf("ReadTableAt() error = %v", err)
+	}
+	tableBytes := r.read
+	if tableBytes >= int64(len(compressed))/2 {
+		t.Errorf("ReadTableAt() read %d of %d bytes", tableBytes, len(compressed))
+	}
+
+	got, err := table.ReadRangeAt(r, 1030, 1045)
+	if err != nil {
+		t.Fatalf("ReadRangeAt() error = %v", err)
+	}
+	if !bytes.Equal(got, plain[1030:1045]) {
+		t.Errorf("ReadRangeAt() = %q, want %q", got, plain[1030:1045])
+	}
+	// The range straddles frames 0 and 1 only.
+	if want := table.cOffsets[2]; r.read-tableBytes != want {
+		t.Errorf("ReadRangeAt() read %d bytes, want %d", r.read-tableBytes, want)
+	}
+}
@@ -15,6 +15,10 @@ type Strategy struct{}
 // Ensure Strategy implements shard.Strategy.
 var _ shard.Strategy = (*Strategy)(nil)
 
+func init() {
+	shard.Register("fnv32", func() shard.Strategy { return New() })
+}
+
 // New creates a new FNV-based sharding strategy.
 func New() *Strategy {
 	return &Strategy{}
@@ -11,18 +11,47 @@ import (
 )
 
 // Strategy implements material-based sharding.
-type Strategy struct{}
+type Strategy struct {
+	sideSplit bool
+}
 
 // Ensure Strategy implements shard.Strategy.
 var _ shard.Strategy = (*Strategy)(nil)
 
+func init() {
+	shard.Register("material", func() shard.Strategy { return New() })
+	shard.Register("material-side", func() shard.Strategy { return New(WithSideSplit()) })
+}
+
+// Option configures a Strategy.
+type Option func(*Strategy)
+
+// WithSideSplit keeps white-to-move and black-to-move positions in separate
+// shards whatever the shard count: white gets the lower half of the shard
+// IDs and black the upper half. Without it, the side-to-move bit is lost in
+// the modulo reduction unless totalShards exceeds 2^18, so at the default
+// shard count both colors share shards. The strategy is then named
+// "material-side".
+func WithSideSplit() Option {
+	return func(s *Strategy) {
+		s.sideSplit = true
+	}
+}
+
 // New creates a new material-based sharding strategy.
-func New() *Strategy {
-	return &Strategy{}
+func New(opts ...Option) *Strategy {
+	s := &Strategy{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Name returns the strategy name.
 func (s *Strategy) Name() string {
+	if s.sideSplit {
+		return "material-side"
+	}
 	return "material"
 }
 
@@ -38,6 +67,8 @@ func (s *Strategy) Name() string {
 // - Bit 18:     Side to move (0=white, 1=black)
 //
 // This produces up to 524,288 unique values, which is then reduced modulo totalShards.
+// With WithSideSplit, bits 0-17 are instead reduced modulo totalShards/2 and
+// black-to-move positions are offset by totalShards/2.
 func (s *Strategy) ShardID(fenStr string, totalShards int) int {
 	mat, err := fen.ParseMaterial(fenStr)
 	if err != nil {
@@ -46,23 +77,35 @@ func (s *Strategy) ShardID(fenStr string, totalShards int) int {
 	}
 
 	side, _ := fen.SideToMove(fenStr)
+	sig := mat.Signature()
 
 	var id uint32
 
 	// Encode queens (3 bits each)
-	id |= uint32(min(mat.WhiteQueens, 7)) << 0
-	id |= uint32(min(mat.BlackQueens, 7)) << 3
+	id |= uint32(sigField(sig, 13)) << 0
+	id |= uint32(sigField(sig, 16+13)) << 3
 
 	// Encode rooks (3 bits each)
-	id |= uint32(min(mat.WhiteRooks, 7)) << 6
-	id |= uint32(min(mat.BlackRooks, 7)) << 9
+	id |= uint32(sigField(sig, 10)) << 6
+	id |= uint32(sigField(sig, 16+10)) << 9
 
 	// Encode minor pieces combined (3 bits each)
-	whiteMinors := mat.WhiteBishops + mat.WhiteKnights
-	blackMinors := mat.BlackBishops + mat.BlackKnights
+	whiteMinors := sigField(sig, 4) + sigField(sig, 7)
+	blackMinors := sigField(sig, 16+4) + sigField(sig, 16+7)
 	id |= uint32(min(whiteMinors, 7)) << 12
 	id |= uint32(min(blackMinors, 7)) << 15
 
+	if s.sideSplit {
+		half := uint32(totalShards / 2)
+		if half == 0 {
+			return 0 // A single shard cannot be split.
+		}
+		if side == "b" {
+			return int(half + id%half)
+		}
+		return int(id % half)
+	}
+
 	// Encode side to move (1 bit)
 	if side == "b" {
 		id |= 1 << 18
@@ -71,6 +114,11 @@ func (s *Strategy) ShardID(fenStr string, totalShards int) int {
 	return int(id % uint32(totalShards))
 }
 
+// sigField extracts a 3-bit piece count from a fen.Material signature.
+func sigField(sig uint32, shift int) int {
+	return int(sig>>shift) & 0x7
+}
+
 // hashFallback computes a simple hash for invalid or unparseable FENs.
 func hashFallback(s string, totalShards int) int {
 	var h uint32 = 2166136261 // FNV offset basis
@@ -152,3 +152,55 @@ func BenchmarkStrategy_ShardID_Endgame(b *testing.B) {
 		s.ShardID(fen, totalShards)
 	}
 }
+
+func TestStrategy_ShardID_Stable(t *testing.T) {
+	// Shard IDs are persisted in built databases and must never change.
+	s := New()
+	tests := []struct {
+		fen  string
+		want int
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 148617},
+		{"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", 410761},
+		{"8/8/8/4k3/8/8/4K3/4R3 w - - 0 1", 64},
+		{"QQQQQQQQ/QQQQQQQQ/8/8/8/8/8/4K2k b - -", 262151},
+		{"1NNNNNNN/BBBBBBBB/8/8/8/8/qqqqrrrr/4K2k w - -", 30752},
+	}
+
+	for _, tt := range tests {
+		if got := s.ShardID(tt.fen, 1<<20); got != tt.want {
+			t.Errorf("ShardID(%q) = %d, want %d", tt.fen, got, tt.want)
+		}
+	}
+}
+
+func TestStrategy_SideSplit(t *testing.T) {
+	s := New(WithSideSplit())
+	if got := s.Name(); got != "material-side" {
+		t.Errorf("Name() = %q, want %q", got, "material-side")
+	}
+
+	boards := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR",
+		"8/8/8/4k3/8/8/4K3/4R3",
+		"r1bqkb1r/pppp1ppp/2n2n2/4p3/2B1P3/5N2/PPPP1PPP/RNBQK2R",
+		"8/8/8/8/8/8/8/4K2k",
+	}
+	for _, totalShards := range []int{2, 3, 1024, 32768, 1 << 20} {
+		half := totalShards / 2
+		for _, board := range boards {
+			white := s.ShardID(board+" w - - 0 1", totalShards)
+			black := s.ShardID(board+" b - - 0 1", totalShards)
+			if white < 0 || white >= half {
+				t.Errorf("ShardID(%s w, %d) = %d, want in [0, %d)", board, totalShards, white, half)
+			}
+			if black < half || black >= 2*half {
+				t.Errorf("ShardID(%s b, %d) = %d, want in [%d, %d)", board, totalShards, black, half, 2*half)
+			}
+		}
+	}
+
+	if id := s.ShardID("8/8/8/8/8/8/8/4K2k b - -", 1); id != 0 {
+		t.Errorf("ShardID() with 1 shard = %d, want 0", id)
+	}
+}
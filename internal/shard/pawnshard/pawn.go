@@ -0,0 +1,104 @@
+// Package pawnshard implements pawn-structure sharding for chess positions.
+//
+// Pawn-structure sharding groups positions by the squares occupied by pawns
+// of both colors. Pawns never move backwards, so the pawn skeleton changes
+// rarely and transpositions that reach the same structure through different
+// move orders land in the same shard.
+package pawnshard
+
+import (
+	"strings"
+
+	"github.com/discochess/stockpile/internal/shard"
+)
+
+// Strategy implements pawn-structure sharding.
+type Strategy struct{}
+
+// Ensure Strategy implements shard.Strategy.
+var _ shard.Strategy = (*Strategy)(nil)
+
+func init() {
+	shard.Register("pawn", func() shard.Strategy { return New() })
+}
+
+// New creates a new pawn-structure sharding strategy.
+func New() *Strategy {
+	return &Strategy{}
+}
+
+// Name returns the strategy name.
+func (s *Strategy) Name() string {
+	return "pawn"
+}
+
+// ShardID computes a shard ID from the pawn skeleton.
+//
+// The white and black pawns are each encoded as a 64-bit board with one bit
+// per square (a8 = bit 0, h1 = bit 63). The two boards are hashed together
+// with FNV-1a and reduced modulo totalShards. Pieces other than pawns, side
+// to move, castling rights and en passant are ignored.
+func (s *Strategy) ShardID(fenStr string, totalShards int) int {
+	white, black, ok := pawnBoards(fenStr)
+	if !ok {
+		// Fall back to a hash-based approach for invalid FENs
+		return hashFallback(fenStr, totalShards)
+	}
+
+	h := uint64(14695981039346656037) // FNV-1a 64 offset basis
+	for _, board := range [2]uint64{white, black} {
+		for i := 0; i < 8; i++ {
+			h ^= (board >> (8 * i)) & 0xff
+			h *= 1099511628211 // FNV-1a 64 prime
+		}
+	}
+	return int(h % uint64(totalShards))
+}
+
+// pawnBoards returns the white and black pawn bitboards for a FEN.
+// It reports false if the piece placement field is malformed.
+func pawnBoards(fenStr string) (white, black uint64, ok bool) {
+	placement, _, _ := strings.Cut(strings.TrimSpace(fenStr), " ")
+	ranks := strings.Split(placement, "/")
+	if len(ranks) != 8 {
+		return 0, 0, false
+	}
+
+	for r, rank := range ranks {
+		file := 0
+		for _, ch := range rank {
+			switch ch {
+			case '1', '2', '3', '4', '5', '6', '7', '8':
+				file += int(ch - '0')
+				continue
+			case 'P':
+				if file < 8 {
+					white |= 1 << (r*8 + file)
+				}
+			case 'p':
+				if file < 8 {
+					black |= 1 << (r*8 + file)
+				}
+			case 'N', 'B', 'R', 'Q', 'K', 'n', 'b', 'r', 'q', 'k':
+			default:
+				return 0, 0, false
+			}
+			file++
+		}
+		if file != 8 {
+			return 0, 0, false
+		}
+	}
+
+	return white, black, true
+}
+
+// hashFallback computes a simple hash for invalid or unparseable FENs.
+func hashFallback(s string, totalShards int) int {
+	var h uint32 = 2166136261 // FNV offset basis
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= 16777619 // FNV prime
+	}
+	return int(h % uint32(totalShards))
+}
@@ -0,0 +1,119 @@
+package pawnshard
+
+import (
+	"testing"
+)
+
+func TestStrategy_Name(t *testing.T) {
+	s := New()
+	if got := s.Name(); got != "pawn" {
+		t.Errorf("Name() = %q, want %q", got, "pawn")
+	}
+}
+
+func TestStrategy_ShardID(t *testing.T) {
+	s := New()
+	totalShards := 32768
+
+	tests := []struct {
+		name string
+		fen  string
+	}{
+		{
+			name: "starting position",
+			fen:  "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		},
+		{
+			name: "endgame K+R vs K",
+			fen:  "8/8/8/4k3/8/8/4K3/4R3 w - - 0 1",
+		},
+		{
+			name: "complex middlegame",
+			fen:  "r1bqkb1r/pppp1ppp/2n2n2/4p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 4 4",
+		},
+		{
+			name: "pawn endgame",
+			fen:  "8/5pk1/6p1/8/8/6P1/5PK1/8 b - - 0 40",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := s.ShardID(tt.fen, totalShards)
+			if id < 0 || id >= totalShards {
+				t.Errorf("ShardID() = %d, want 0 <= id < %d", id, totalShards)
+			}
+		})
+	}
+}
+
+func TestStrategy_ShardID_Consistency(t *testing.T) {
+	s := New()
+	totalShards := 32768
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+	// Same FEN should always produce same shard ID.
+	id1 := s.ShardID(fen, totalShards)
+	id2 := s.ShardID(fen, totalShards)
+
+	if id1 != id2 {
+		t.Errorf("ShardID() not consistent: got %d and %d", id1, id2)
+	}
+}
+
+func TestStrategy_ShardID_SamePawnsCluster(t *testing.T) {
+	s := New()
+	totalShards := 32768
+
+	// Same pawn structure with pieces elsewhere and the other side to move,
+	// as reached by a transposition.
+	fen1 := "r1bqkb1r/pppp1ppp/2n2n2/4p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 4 4"
+	fen2 := "r1bqk2r/pppp1ppp/2n2n2/2b1p3/4P3/3B1N2/PPPP1PPP/RNBQ1RK1 b kq - 5 5"
+
+	id1 := s.ShardID(fen1, totalShards)
+	id2 := s.ShardID(fen2, totalShards)
+
+	if id1 != id2 {
+		t.Errorf("Same pawn structure should cluster: got shard %d and %d", id1, id2)
+	}
+}
+
+func TestStrategy_ShardID_DifferentPawns(t *testing.T) {
+	s := New()
+	totalShards := 1 << 20
+
+	fen1 := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	fen2 := "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+
+	if s.ShardID(fen1, totalShards) == s.ShardID(fen2, totalShards) {
+		t.Error("Different pawn structures should not share a shard")
+	}
+}
+
+func TestStrategy_ShardID_InvalidFEN(t *testing.T) {
+	s := New()
+	totalShards := 32768
+
+	// Invalid FENs should still return a valid shard ID (via fallback hash).
+	for _, fen := range []string{
+		"not a valid fen",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq - 0 1",
+		"rnbqkbnr/ppppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+	} {
+		id := s.ShardID(fen, totalShards)
+		if id < 0 || id >= totalShards {
+			t.Errorf("ShardID(%q) = %d, want 0 <= id < %d", fen, id, totalShards)
+		}
+	}
+}
+
+func BenchmarkStrategy_ShardID(b *testing.B) {
+	s := New()
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	totalShards := 32768
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.ShardID(fen, totalShards)
+	}
+}
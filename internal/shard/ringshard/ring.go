@@ -0,0 +1,68 @@
+// Package ringshard implements consistent-hash sharding for chess positions.
+//
+// Unlike modulo-based strategies, changing the shard count moves only a
+// small fraction of positions: growing from N to N+1 shards relocates about
+// 1/(N+1) of them, all into the new shard. This makes it practical to grow a
+// database by rebuilding only the shards whose contents changed, instead of
+// every shard. Like fnvshard, it provides uniform distribution but no
+// locality benefits.
+package ringshard
+
+import (
+	"github.com/discochess/stockpile/internal/fen"
+	"github.com/discochess/stockpile/internal/shard"
+)
+
+// Strategy implements consistent-hash sharding using jump consistent hashing
+// (Lamping and Veach, "A Fast, Minimal Memory, Consistent Hash Algorithm").
+type Strategy struct{}
+
+// Ensure Strategy implements shard.Strategy.
+var _ shard.Strategy = (*Strategy)(nil)
+
+func init() {
+	shard.Register("ring", func() shard.Strategy { return New() })
+}
+
+// New creates a new consistent-hash sharding strategy.
+func New() *Strategy {
+	return &Strategy{}
+}
+
+// Name returns the strategy name.
+func (s *Strategy) Name() string {
+	return "ring"
+}
+
+// ShardID computes a shard ID by jump-hashing the FNV-1a hash of the
+// normalized FEN string.
+func (s *Strategy) ShardID(fenStr string, totalShards int) int {
+	// Normalize FEN to ensure consistent hashing for equivalent positions.
+	normalized, err := fen.Normalize(fenStr)
+	if err != nil {
+		// Fall back to hashing the raw FEN for invalid inputs.
+		normalized = fenStr
+	}
+	return jumpHash(fnv1a64(normalized), totalShards)
+}
+
+// jumpHash maps key to a bucket in [0, buckets).
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// fnv1a64 computes the FNV-1a 64-bit hash of a string.
+func fnv1a64(s string) uint64 {
+	var h uint64 = 14695981039346656037 // FNV offset basis
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= 1099511628211 // FNV prime
+	}
+	return h
+}
@@ -0,0 +1,115 @@
+package ringshard
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStrategy_Name(t *testing.T) {
+	s := New()
+	if got := s.Name(); got != "ring" {
+		t.Errorf("Name() = %q, want %q", got, "ring")
+	}
+}
+
+func TestStrategy_ShardID(t *testing.T) {
+	s := New()
+
+	for _, totalShards := range []int{1, 2, 7, 32768} {
+		for _, fen := range sampleFENs(1000) {
+			id := s.ShardID(fen, totalShards)
+			if id < 0 || id >= totalShards {
+				t.Fatalf("ShardID(%q, %d) = %d, out of range", fen, totalShards, id)
+			}
+		}
+	}
+}
+
+func TestStrategy_ShardID_Consistency(t *testing.T) {
+	s := New()
+	totalShards := 32768
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+	// Same FEN should always produce same shard ID.
+	id1 := s.ShardID(fen, totalShards)
+	id2 := s.ShardID(fen, totalShards)
+
+	if id1 != id2 {
+		t.Errorf("ShardID() not consistent: got %d and %d", id1, id2)
+	}
+}
+
+func TestStrategy_ShardID_IgnoresMoveCounters(t *testing.T) {
+	s := New()
+	totalShards := 32768
+
+	fen1 := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	fen2 := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 10 20"
+
+	if s.ShardID(fen1, totalShards) != s.ShardID(fen2, totalShards) {
+		t.Error("FENs differing only in move counters should map to the same shard")
+	}
+}
+
+func TestStrategy_ShardID_Resharding(t *testing.T) {
+	s := New()
+	fens := sampleFENs(20000)
+
+	for _, n := range []int{10, 100, 1000} {
+		t.Run(fmt.Sprintf("%d_to_%d", n, n+1), func(t *testing.T) {
+			var moved int
+			for _, fen := range fens {
+				before := s.ShardID(fen, n)
+				after := s.ShardID(fen, n+1)
+				if before == after {
+					continue
+				}
+				moved++
+				// Positions only ever move into the new shard.
+				if after != n {
+					t.Fatalf("%q moved from shard %d to %d, want %d", fen, before, after, n)
+				}
+			}
+
+			// Expect about 1/(n+1) of positions to move; allow generous slack
+			// for sampling noise.
+			want := float64(len(fens)) / float64(n+1)
+			if got := float64(moved); got < want/2 || got > want*2 {
+				t.Errorf("moved %d of %d positions, want about %.0f", moved, len(fens), want)
+			}
+		})
+	}
+}
+
+func TestStrategy_ShardID_InvalidFEN(t *testing.T) {
+	s := New()
+	totalShards := 32768
+
+	// Invalid FEN should still return a valid shard ID.
+	id := s.ShardID("not a valid fen", totalShards)
+	if id < 0 || id >= totalShards {
+		t.Errorf("ShardID() for invalid FEN = %d, want 0 <= id < %d", id, totalShards)
+	}
+}
+
+// sampleFENs returns n distinct, valid FENs.
+func sampleFENs(n int) []string {
+	fens := make([]string, n)
+	for i := range fens {
+		// Put the index in the en passant field, which Normalize keeps but
+		// does not validate, so every FEN normalizes differently.
+		fens[i] = fmt.Sprintf("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq %d 0 1", i)
+	}
+	return fens
+}
+
+func BenchmarkStrategy_ShardID(b *testing.B) {
+	s := New()
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	totalShards := 32768
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.ShardID(fen, totalShards)
+	}
+}
@@ -2,6 +2,12 @@
 // chess positions across multiple shard files.
 package shard
 
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
 // Strategy defines a sharding algorithm that maps FEN positions to shard IDs.
 type Strategy interface {
 	// Name returns a human-readable name for this strategy.
@@ -15,3 +21,45 @@ type Strategy interface {
 	// differ only in halfmove/fullmove counters should map to the same shard).
 	ShardID(fen string, totalShards int) int
 }
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]func() Strategy)
+)
+
+// Register makes a strategy available by name, as given to the CLI
+// --strategy flag and recorded in a manifest. Strategy packages call it from
+// init, so a strategy is only available to New once its package is imported.
+// The name should match the strategy's Name.
+// Register panics if name is already registered.
+func Register(name string, factory func() Strategy) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[name]; dup {
+		panic("shard: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// New returns a new instance of the strategy registered under name.
+func New(name string) (Strategy, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q (available: %v)", name, Names())
+	}
+	return factory(), nil
+}
+
+// Names returns the sorted names of all registered strategies.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
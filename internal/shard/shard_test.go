@@ -0,0 +1,66 @@
+package shard_test
+
+import (
+	"testing"
+
+	"github.com/discochess/stockpile/internal/shard"
+	_ "github.com/discochess/stockpile/internal/shard/fnvshard"
+	_ "github.com/discochess/stockpile/internal/shard/materialshard"
+	_ "github.com/discochess/stockpile/internal/shard/pawnshard"
+	_ "github.com/discochess/stockpile/internal/shard/ringshard"
+)
+
+func TestNew(t *testing.T) {
+	for _, name := range []string{"material", "material-side", "fnv32", "pawn", "ring"} {
+		t.Run(name, func(t *testing.T) {
+			s, err := shard.New(name)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := s.Name(); got != name {
+				t.Errorf("New(%q).Name() = %q", name, got)
+			}
+		})
+	}
+}
+
+func TestNew_Unknown(t *testing.T) {
+	if _, err := shard.New("zobrist"); err == nil {
+		t.Error("New() error = nil, want error for unknown strategy")
+	}
+}
+
+func TestRegister_Custom(t *testing.T) {
+	shard.Register("test-constant", func() shard.Strategy { return constantStrategy{} })
+
+	s, err := shard.New("test-constant")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := s.ShardID("8/8/8/8/8/8/8/8 w - - 0 1", 16); got != 0 {
+		t.Errorf("ShardID() = %d, want 0", got)
+	}
+
+	var found bool
+	for _, name := range shard.Names() {
+		found = found || name == "test-constant"
+	}
+	if !found {
+		t.Errorf("Names() = %v, missing %q", shard.Names(), "test-constant")
+	}
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() did not panic for duplicate name")
+		}
+	}()
+	shard.Register("material", func() shard.Strategy { return constantStrategy{} })
+}
+
+// constantStrategy maps every position to shard 0.
+type constantStrategy struct{}
+
+func (constantStrategy) Name() string            { return "test-constant" }
+func (constantStrategy) ShardID(string, int) int { return 0 }
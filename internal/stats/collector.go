@@ -9,12 +9,26 @@ const (
 	MetricMisses       = "stockpile_misses_total"
 	MetricShardFetches = "stockpile_shard_fetches_total"
 
+	// Client per-shard counters, labeled with LabelShard. They are only
+	// reported to a LabeledCollector, and only if the client enables them.
+	MetricShardHits   = "stockpile_shard_hits_total"
+	MetricShardMisses = "stockpile_shard_misses_total"
+
+	// Client latency histograms, in seconds.
+	MetricLookupLatency     = "stockpile_lookup_latency_seconds"
+	MetricShardFetchLatency = "stockpile_shard_fetch_latency_seconds"
+	MetricSearchLatency     = "stockpile_search_latency_seconds"
+
 	// Cache metrics.
 	MetricCacheHits   = "stockpile_cache_hits_total"
 	MetricCacheMisses = "stockpile_cache_misses_total"
 	MetricCacheSize   = "stockpile_cache_size"
+	MetricCacheBytes  = "stockpile_cache_bytes"
 )
 
+// LabelShard is the label of per-shard metrics. Its value is the shard ID.
+const LabelShard = "shard"
+
 // Collector defines the interface for collecting metrics.
 type Collector interface {
 	// IncCounter increments a counter metric by delta.
@@ -26,3 +40,21 @@ type Collector interface {
 	// ObserveHistogram records a value in a histogram metric.
 	ObserveHistogram(name string, value float64)
 }
+
+// LabeledCollector is implemented by collectors that can break a counter
+// down by a label, such as a count per shard.
+type LabeledCollector interface {
+	Collector
+
+	// IncCounterLabeled increments by delta the counter metric name for
+	// which label has the given value.
+	IncCounterLabeled(name, label, value string, delta int64)
+}
+
+// IncCounterLabeled increments a labeled counter if c is a LabeledCollector
+// and does nothing otherwise.
+func IncCounterLabeled(c Collector, name, label, value string, delta int64) {
+	if lc, ok := c.(LabeledCollector); ok {
+		lc.IncCounterLabeled(name, label, value, delta)
+	}
+}
@@ -0,0 +1,30 @@
+package stats
+
+import "sort"
+
+// Gini returns the Gini coefficient of values: 0 when every value is equal,
+// approaching 1 as the total concentrates in a single value. values is not
+// modified.
+func Gini(values []int64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]int64, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	n := float64(len(sorted))
+	var sum, cumulativeSum float64
+	for i, v := range sorted {
+		sum += float64(v)
+		cumulativeSum += float64(i+1) * float64(v)
+	}
+
+	if sum == 0 {
+		return 0
+	}
+
+	// Gini coefficient formula.
+	return (2*cumulativeSum)/(n*sum) - (n+1)/n
+}
@@ -0,0 +1,70 @@
+package stats
+
+import "sync"
+
+// InMemory is a collector that keeps metrics in memory so they can be read
+// back, e.g. by tests asserting what a client reported. It keeps the sum of
+// each counter, the latest value of each gauge and the number of
+// observations of each histogram. It is safe for concurrent use.
+type InMemory struct {
+	mu         sync.Mutex
+	counters   map[string]int64
+	gauges     map[string]int64
+	histograms map[string]int64
+}
+
+// Compile-time check that InMemory implements Collector.
+var _ Collector = (*InMemory)(nil)
+
+// NewInMemory creates a new in-memory collector.
+func NewInMemory() *InMemory {
+	return &InMemory{
+		counters:   make(map[string]int64),
+		gauges:     make(map[string]int64),
+		histograms: make(map[string]int64),
+	}
+}
+
+// IncCounter adds delta to a counter.
+func (m *InMemory) IncCounter(name string, delta int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name] += delta
+}
+
+// SetGauge records the latest value of a gauge.
+func (m *InMemory) SetGauge(name string, value int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[name] = value
+}
+
+// ObserveHistogram counts an observation of a histogram. The value itself
+// is not kept.
+func (m *InMemory) ObserveHistogram(name string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.histograms[name]++
+}
+
+// Counter returns the sum of a counter's increments, or 0 if it was never
+// incremented.
+func (m *InMemory) Counter(name string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[name]
+}
+
+// Gauge returns the latest value of a gauge, or 0 if it was never set.
+func (m *InMemory) Gauge(name string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.gauges[name]
+}
+
+// HistogramCount returns the number of observations of a histogram.
+func (m *InMemory) HistogramCount(name string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.histograms[name]
+}
@@ -12,8 +12,8 @@ type Collector struct {
 	logger *zap.Logger
 }
 
-// Compile-time check that Collector implements stats.Collector.
-var _ stats.Collector = (*Collector)(nil)
+// Compile-time check that Collector implements stats.LabeledCollector.
+var _ stats.LabeledCollector = (*Collector)(nil)
 
 // New creates a new logger-based collector.
 // If logger is nil, a no-op logger is used.
@@ -32,6 +32,15 @@ func (c *Collector) IncCounter(name string, delta int64) {
 	)
 }
 
+// IncCounterLabeled logs a labeled counter increment.
+func (c *Collector) IncCounterLabeled(name, label, value string, delta int64) {
+	c.logger.Debug("counter",
+		zap.String("metric", name),
+		zap.String(label, value),
+		zap.Int64("delta", delta),
+	)
+}
+
 // SetGauge logs a gauge value.
 func (c *Collector) SetGauge(name string, value int64) {
 	c.logger.Debug("gauge",
@@ -0,0 +1,141 @@
+// Package otel provides an OpenTelemetry-based stats collector.
+package otel
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"github.com/discochess/stockpile/internal/stats"
+)
+
+// meterName is the instrumentation scope used when no meter is provided.
+const meterName = "github.com/discochess/stockpile"
+
+// Collector implements stats.Collector using OpenTelemetry instruments.
+// Metric names map directly to instrument names.
+type Collector struct {
+	meter metric.Meter
+
+	mu         sync.RWMutex
+	counters   map[string]metric.Int64Counter
+	gauges     map[string]metric.Int64Gauge
+	histograms map[string]metric.Float64Histogram
+}
+
+// Compile-time check that Collector implements stats.LabeledCollector.
+var _ stats.LabeledCollector = (*Collector)(nil)
+
+// New creates a new OpenTelemetry collector.
+// If meter is nil, a meter from the global MeterProvider is used.
+func New(meter metric.Meter) *Collector {
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter(meterName)
+	}
+	return &Collector{
+		meter:      meter,
+		counters:   make(map[string]metric.Int64Counter),
+		gauges:     make(map[string]metric.Int64Gauge),
+		histograms: make(map[string]metric.Float64Histogram),
+	}
+}
+
+// IncCounter increments a counter metric.
+func (c *Collector) IncCounter(name string, delta int64) {
+	counter := c.getOrCreateCounter(name)
+	counter.Add(context.Background(), delta)
+}
+
+// IncCounterLabeled increments a counter metric, recording the label as an
+// attribute of the measurement.
+func (c *Collector) IncCounterLabeled(name, label, value string, delta int64) {
+	counter := c.getOrCreateCounter(name)
+	counter.Add(context.Background(), delta, metric.WithAttributes(attribute.String(label, value)))
+}
+
+// SetGauge sets a gauge metric.
+func (c *Collector) SetGauge(name string, value int64) {
+	gauge := c.getOrCreateGauge(name)
+	gauge.Record(context.Background(), value)
+}
+
+// ObserveHistogram records a value in a histogram.
+func (c *Collector) ObserveHistogram(name string, value float64) {
+	histogram := c.getOrCreateHistogram(name)
+	histogram.Record(context.Background(), value)
+}
+
+func (c *Collector) getOrCreateCounter(name string) metric.Int64Counter {
+	c.mu.RLock()
+	counter, ok := c.counters[name]
+	c.mu.RUnlock()
+	if ok {
+		return counter
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Double-check after acquiring write lock.
+	if counter, ok = c.counters[name]; ok {
+		return counter
+	}
+
+	counter, err := c.meter.Int64Counter(name)
+	if err != nil {
+		// Fallback: drop measurements rather than fail the caller.
+		counter = noop.Int64Counter{}
+	}
+	c.counters[name] = counter
+	return counter
+}
+
+func (c *Collector) getOrCreateGauge(name string) metric.Int64Gauge {
+	c.mu.RLock()
+	gauge, ok := c.gauges[name]
+	c.mu.RUnlock()
+	if ok {
+		return gauge
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gauge, ok = c.gauges[name]; ok {
+		return gauge
+	}
+
+	gauge, err := c.meter.Int64Gauge(name)
+	if err != nil {
+		gauge = noop.Int64Gauge{}
+	}
+	c.gauges[name] = gauge
+	return gauge
+}
+
+func (c *Collector) getOrCreateHistogram(name string) metric.Float64Histogram {
+	c.mu.RLock()
+	histogram, ok := c.histograms[name]
+	c.mu.RUnlock()
+	if ok {
+		return histogram
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if histogram, ok = c.histograms[name]; ok {
+		return histogram
+	}
+
+	histogram, err := c.meter.Float64Histogram(name)
+	if err != nil {
+		histogram = noop.Float64Histogram{}
+	}
+	c.histograms[name] = histogram
+	return histogram
+}
@@ -0,0 +1,154 @@
+package otel
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/discochess/stockpile/internal/stats"
+)
+
+// newTestCollector returns a collector backed by an in-memory reader.
+func newTestCollector() (*Collector, *sdkmetric.ManualReader) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	return New(provider.Meter("test")), reader
+}
+
+// collect gathers all metrics from reader, keyed by name.
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func TestNew_GlobalMeter(t *testing.T) {
+	c := New(nil)
+	if c.meter == nil {
+		t.Error("meter should not be nil")
+	}
+	// Recording through the global (no-op by default) provider must not panic.
+	c.IncCounter(stats.MetricLookups, 1)
+}
+
+func TestCollector_IncCounter(t *testing.T) {
+	c, reader := newTestCollector()
+
+	c.IncCounter(stats.MetricLookups, 5)
+	c.IncCounter(stats.MetricLookups, 3)
+	c.IncCounter(stats.MetricHits, 2)
+
+	metrics := collect(t, reader)
+	for name, want := range map[string]int64{stats.MetricLookups: 8, stats.MetricHits: 2} {
+		sum, ok := metrics[name].(metricdata.Sum[int64])
+		if !ok {
+			t.Errorf("%s: got %T, want metricdata.Sum[int64]", name, metrics[name])
+			continue
+		}
+		if !sum.IsMonotonic {
+			t.Errorf("%s: counter should be monotonic", name)
+		}
+		if len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != want {
+			t.Errorf("%s: data points = %+v, want value %d", name, sum.DataPoints, want)
+		}
+	}
+}
+
+func TestCollector_IncCounterLabeled(t *testing.T) {
+	c, reader := newTestCollector()
+
+	c.IncCounterLabeled(stats.MetricShardHits, stats.LabelShard, "3", 2)
+	c.IncCounterLabeled(stats.MetricShardHits, stats.LabelShard, "3", 1)
+	c.IncCounterLabeled(stats.MetricShardHits, stats.LabelShard, "7", 1)
+
+	sum, ok := collect(t, reader)[stats.MetricShardHits].(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("got %T, want metricdata.Sum[int64]", collect(t, reader)[stats.MetricShardHits])
+	}
+	got := make(map[string]int64)
+	for _, dp := range sum.DataPoints {
+		shard, _ := dp.Attributes.Value(stats.LabelShard)
+		got[shard.AsString()] = dp.Value
+	}
+	if len(got) != 2 || got["3"] != 3 || got["7"] != 1 {
+		t.Errorf("per-shard values = %v, want map[3:3 7:1]", got)
+	}
+}
+
+func TestCollector_SetGauge(t *testing.T) {
+	c, reader := newTestCollector()
+
+	c.SetGauge(stats.MetricCacheSize, 10)
+	c.SetGauge(stats.MetricCacheSize, 42)
+
+	gauge, ok := collect(t, reader)[stats.MetricCacheSize].(metricdata.Gauge[int64])
+	if !ok {
+		t.Fatal("gauge not found")
+	}
+	if len(gauge.DataPoints) != 1 || gauge.DataPoints[0].Value != 42 {
+		t.Errorf("data points = %+v, want value 42", gauge.DataPoints)
+	}
+}
+
+func TestCollector_ObserveHistogram(t *testing.T) {
+	c, reader := newTestCollector()
+
+	c.ObserveHistogram("test_histogram", 0.5)
+	c.ObserveHistogram("test_histogram", 1.5)
+
+	hist, ok := collect(t, reader)["test_histogram"].(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatal("histogram not found")
+	}
+	if len(hist.DataPoints) != 1 {
+		t.Fatalf("data points = %d, want 1", len(hist.DataPoints))
+	}
+	if dp := hist.DataPoints[0]; dp.Count != 2 || dp.Sum != 2 {
+		t.Errorf("count = %d, sum = %v, want 2 and 2", dp.Count, dp.Sum)
+	}
+}
+
+func TestCollector_ReuseInstruments(t *testing.T) {
+	c, _ := newTestCollector()
+
+	c.IncCounter("reuse", 1)
+	c.IncCounter("reuse", 1)
+
+	if len(c.counters) != 1 {
+		t.Errorf("counters = %d, want 1", len(c.counters))
+	}
+}
+
+func TestCollector_ConcurrentAccess(t *testing.T) {
+	c, reader := newTestCollector()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.IncCounter("concurrent_counter", 1)
+				c.ObserveHistogram("concurrent_histogram", float64(j))
+			}
+		}()
+	}
+	wg.Wait()
+
+	sum, ok := collect(t, reader)["concurrent_counter"].(metricdata.Sum[int64])
+	if !ok || len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != 1000 {
+		t.Errorf("concurrent_counter = %+v, want 1000", sum)
+	}
+}
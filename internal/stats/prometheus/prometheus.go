@@ -2,13 +2,20 @@
 package prometheus
 
 import (
+	"net/http"
 	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/discochess/stockpile/internal/stats"
 )
 
+// LatencyBuckets are histogram buckets for lookup latencies in seconds,
+// doubling from 10µs to about 1.3s. Cached lookups take microseconds, so
+// prometheus.DefBuckets would put nearly all of them in the first bucket.
+var LatencyBuckets = prometheus.ExponentialBuckets(10e-6, 2, 18)
+
 // Collector implements stats.Collector using Prometheus metrics.
 type Collector struct {
 	registry prometheus.Registerer
@@ -17,13 +24,18 @@ type Collector struct {
 	counters   map[string]prometheus.Counter
 	gauges     map[string]prometheus.Gauge
 	histograms map[string]prometheus.Histogram
+	buckets    map[string][]float64
+	labeled    map[string]*prometheus.CounterVec
 }
 
-// Compile-time check that Collector implements stats.Collector.
-var _ stats.Collector = (*Collector)(nil)
+// Compile-time check that Collector implements stats.LabeledCollector.
+var _ stats.LabeledCollector = (*Collector)(nil)
 
 // New creates a new Prometheus collector.
 // If registry is nil, prometheus.DefaultRegisterer is used.
+//
+// The client's latency histograms use LatencyBuckets; other histograms use
+// prometheus.DefBuckets unless configured with SetHistogramBuckets.
 func New(registry prometheus.Registerer) *Collector {
 	if registry == nil {
 		registry = prometheus.DefaultRegisterer
@@ -33,15 +45,37 @@ func New(registry prometheus.Registerer) *Collector {
 		counters:   make(map[string]prometheus.Counter),
 		gauges:     make(map[string]prometheus.Gauge),
 		histograms: make(map[string]prometheus.Histogram),
+		labeled:    make(map[string]*prometheus.CounterVec),
+		buckets: map[string][]float64{
+			stats.MetricLookupLatency:     LatencyBuckets,
+			stats.MetricShardFetchLatency: LatencyBuckets,
+			stats.MetricSearchLatency:     LatencyBuckets,
+		},
 	}
 }
 
+// SetHistogramBuckets sets the buckets of the named histogram. It must be
+// called before the first observation of name; buckets of a histogram
+// already created are not changed.
+func (c *Collector) SetHistogramBuckets(name string, buckets []float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buckets[name] = buckets
+}
+
 // IncCounter increments a counter metric.
 func (c *Collector) IncCounter(name string, delta int64) {
 	counter := c.getOrCreateCounter(name)
 	counter.Add(float64(delta))
 }
 
+// IncCounterLabeled increments a counter metric for one value of a label.
+// Each labeled metric is a CounterVec with label as its only label.
+func (c *Collector) IncCounterLabeled(name, label, value string, delta int64) {
+	vec := c.getOrCreateCounterVec(name, label)
+	vec.WithLabelValues(value).Add(float64(delta))
+}
+
 // SetGauge sets a gauge metric.
 func (c *Collector) SetGauge(name string, value int64) {
 	gauge := c.getOrCreateGauge(name)
@@ -88,6 +122,37 @@ func (c *Collector) getOrCreateCounter(name string) prometheus.Counter {
 	return counter
 }
 
+func (c *Collector) getOrCreateCounterVec(name, label string) *prometheus.CounterVec {
+	c.mu.RLock()
+	vec, ok := c.labeled[name]
+	c.mu.RUnlock()
+	if ok {
+		return vec
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if vec, ok = c.labeled[name]; ok {
+		return vec
+	}
+
+	vec = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: name,
+		Help: name,
+	}, []string{label})
+	if err := c.registry.Register(vec); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			if existing, ok := are.ExistingCollector.(*prometheus.CounterVec); ok {
+				c.labeled[name] = existing
+				return existing
+			}
+		}
+	}
+	c.labeled[name] = vec
+	return vec
+}
+
 func (c *Collector) getOrCreateGauge(name string) prometheus.Gauge {
 	c.mu.RLock()
 	gauge, ok := c.gauges[name]
@@ -134,10 +199,14 @@ func (c *Collector) getOrCreateHistogram(name string) prometheus.Histogram {
 		return histogram
 	}
 
+	buckets, ok := c.buckets[name]
+	if !ok {
+		buckets = prometheus.DefBuckets
+	}
 	histogram = prometheus.NewHistogram(prometheus.HistogramOpts{
 		Name:    name,
 		Help:    name,
-		Buckets: prometheus.DefBuckets,
+		Buckets: buckets,
 	})
 	if err := c.registry.Register(histogram); err != nil {
 		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
@@ -150,3 +219,17 @@ func (c *Collector) getOrCreateHistogram(name string) prometheus.Histogram {
 	c.histograms[name] = histogram
 	return histogram
 }
+
+// Handler returns an HTTP handler that serves the metrics of the collector's
+// registry in the Prometheus exposition format, for mounting at /metrics.
+// The registry must also be a prometheus.Gatherer, as a *prometheus.Registry
+// is; otherwise the handler responds with an error.
+func (c *Collector) Handler() http.Handler {
+	gatherer, ok := c.registry.(prometheus.Gatherer)
+	if !ok {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "metrics registry cannot be gathered", http.StatusInternalServerError)
+		})
+	}
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+}
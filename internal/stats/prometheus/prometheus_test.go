@@ -1,9 +1,16 @@
 package prometheus
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/discochess/stockpile/internal/stats"
 )
 
 func TestNew_DefaultRegistry(t *testing.T) {
@@ -244,3 +251,108 @@ func TestCollector_AlreadyRegistered(t *testing.T) {
 		}
 	}
 }
+
+func TestCollector_Handler(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c := New(reg)
+	c.IncCounter("handler_counter", 7)
+
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	if !strings.Contains(string(body), "handler_counter 7") {
+		t.Errorf("exposition missing handler_counter 7:\n%s", body)
+	}
+}
+
+func TestCollector_Handler_NotGatherer(t *testing.T) {
+	c := New(prometheus.WrapRegistererWithPrefix("x_", prometheus.NewRegistry()))
+
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
+
+// histogramBounds returns the bucket upper bounds of the named histogram.
+func histogramBounds(t *testing.T, reg *prometheus.Registry, name string) []float64 {
+	t.Helper()
+	metrics, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, m := range metrics {
+		if m.GetName() == name {
+			var bounds []float64
+			for _, b := range m.GetMetric()[0].GetHistogram().GetBucket() {
+				bounds = append(bounds, b.GetUpperBound())
+			}
+			return bounds
+		}
+	}
+	t.Fatalf("histogram %s not found in registry", name)
+	return nil
+}
+
+func TestCollector_HistogramBuckets(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c := New(reg)
+	c.SetHistogramBuckets("custom_histogram", []float64{1, 2, 3})
+
+	c.ObserveHistogram(stats.MetricLookupLatency, 50e-6)
+	c.ObserveHistogram("custom_histogram", 2)
+	c.ObserveHistogram("default_histogram", 0.5)
+
+	tests := []struct {
+		name string
+		want []float64
+	}{
+		{stats.MetricLookupLatency, LatencyBuckets},
+		{"custom_histogram", []float64{1, 2, 3}},
+		{"default_histogram", prometheus.DefBuckets},
+	}
+	for _, tt := range tests {
+		if got := histogramBounds(t, reg, tt.name); !slices.Equal(got, tt.want) {
+			t.Errorf("%s buckets = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCollector_IncCounterLabeled(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c := New(reg)
+
+	c.IncCounterLabeled(stats.MetricShardHits, stats.LabelShard, "3", 2)
+	c.IncCounterLabeled(stats.MetricShardHits, stats.LabelShard, "3", 1)
+	c.IncCounterLabeled(stats.MetricShardHits, stats.LabelShard, "7", 1)
+
+	metrics, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	got := make(map[string]float64)
+	for _, m := range metrics {
+		if m.GetName() != stats.MetricShardHits {
+			continue
+		}
+		for _, metric := range m.GetMetric() {
+			for _, l := range metric.GetLabel() {
+				if l.GetName() == stats.LabelShard {
+					got[l.GetValue()] = metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	if len(got) != 2 || got["3"] != 3 || got["7"] != 1 {
+		t.Errorf("per-shard values = %v, want map[3:3 7:1]", got)
+	}
+}
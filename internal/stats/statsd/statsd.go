@@ -0,0 +1,140 @@
+// Package statsd provides a stats collector that sends metrics to a statsd
+// server over UDP, using github.com/cactus/go-statsd-client.
+package statsd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cactus/go-statsd-client/statsd"
+
+	"github.com/discochess/stockpile/internal/stats"
+)
+
+const (
+	// DefaultMaxPacketSize is the default payload limit of a datagram. It
+	// keeps packets within a 1500-byte Ethernet MTU.
+	DefaultMaxPacketSize = 1432
+
+	// DefaultFlushInterval is the default longest time a metric waits in
+	// the buffer before it is sent.
+	DefaultFlushInterval = 100 * time.Millisecond
+)
+
+// Collector implements stats.Collector on a buffered statsd client, which
+// sends several metrics per datagram, whenever the next one would not fit
+// or the flush interval elapses. Metrics are sent in statsd's plain-text
+// line format:
+//
+//	<prefix>.<name>:<value>|<type>[|@<sample rate>]
+//
+// Counters are sent as "c", gauges as "g" and histogram observations as "ms"
+// timings; the library's histograms are latencies in seconds, so their
+// values are converted to milliseconds.
+//
+// Send errors are dropped, as is usual for statsd. Close sends what is left.
+type Collector struct {
+	client     statsd.Statter
+	sampleRate float32
+}
+
+// Compile-time check that Collector implements stats.Collector.
+var _ stats.Collector = (*Collector)(nil)
+
+// config holds the settings applied by Options.
+type config struct {
+	client     statsd.ClientConfig
+	sampleRate float32
+}
+
+// Option configures a Collector.
+type Option func(*config)
+
+// WithPrefix prepends prefix and a dot to every metric name.
+func WithPrefix(prefix string) Option {
+	return func(c *config) {
+		c.client.Prefix = prefix
+	}
+}
+
+// WithSampleRate sends only the given fraction of counter increments and
+// histogram observations, tagged with the rate so that the server scales
+// them back up. Gauges are always sent. Default is 1, which sends every
+// sample; values outside (0, 1] are treated as 1.
+func WithSampleRate(rate float64) Option {
+	return func(c *config) {
+		if rate <= 0 || rate > 1 {
+			rate = 1
+		}
+		c.sampleRate = float32(rate)
+	}
+}
+
+// WithMaxPacketSize bounds the payload of each datagram. Default is
+// DefaultMaxPacketSize. A single line longer than the limit is sent alone.
+func WithMaxPacketSize(n int) Option {
+	return func(c *config) {
+		c.client.FlushBytes = n
+	}
+}
+
+// WithFlushInterval sets how often buffered metrics are sent.
+// Default is DefaultFlushInterval; values below 1 leave it unchanged.
+func WithFlushInterval(d time.Duration) Option {
+	return func(c *config) {
+		if d > 0 {
+			c.client.FlushInterval = d
+		}
+	}
+}
+
+// New creates a collector sending to the statsd server at addr, e.g.
+// "localhost:8125". Call Close to send buffered metrics and stop it.
+func New(addr string, opts ...Option) (*Collector, error) {
+	cfg := config{
+		client: statsd.ClientConfig{
+			Address:       addr,
+			UseBuffered:   true,
+			FlushInterval: DefaultFlushInterval,
+			FlushBytes:    DefaultMaxPacketSize,
+		},
+		sampleRate: 1,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	client, err := statsd.NewClientWithConfig(&cfg.client)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to statsd at %s: %w", addr, err)
+	}
+	return &Collector{client: client, sampleRate: cfg.sampleRate}, nil
+}
+
+// IncCounter sends a counter increment.
+func (c *Collector) IncCounter(name string, delta int64) {
+	c.client.Inc(name, delta, c.sampleRate)
+}
+
+// SetGauge sends a gauge value.
+func (c *Collector) SetGauge(name string, value int64) {
+	if value < 0 {
+		// A signed value would adjust the gauge rather than set it.
+		c.client.Gauge(name, 0, 1)
+		c.client.GaugeDelta(name, value, 1)
+		return
+	}
+	c.client.Gauge(name, value, 1)
+}
+
+// ObserveHistogram sends a histogram observation, in seconds, as a timing
+// in milliseconds.
+func (c *Collector) ObserveHistogram(name string, value float64) {
+	c.client.TimingDuration(name, time.Duration(value*float64(time.Second)), c.sampleRate)
+}
+
+// Close sends the buffered metrics and closes the connection. Metrics
+// recorded afterwards are dropped.
+func (c *Collector) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,198 @@
+package statsd
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cactus/go-statsd-client/statsd"
+)
+
+// listen returns a fake statsd server and a function returning the next
+// datagram it receives.
+func listen(t *testing.T) (net.PacketConn, func() string) {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	return pc, func() string {
+		t.Helper()
+		buf := make([]byte, 64*1024)
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom() error = %v", err)
+		}
+		return string(buf[:n])
+	}
+}
+
+func TestCollector_IncCounter(t *testing.T) {
+	pc, read := listen(t)
+	c, err := New(pc.LocalAddr().String(), WithPrefix("stockpile"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	c.IncCounter("stockpile_lookups_total", 3)
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if got, want := read(), "stockpile.stockpile_lookups_total:3|c"; got != want {
+		t.Errorf("datagram = %q, want %q", got, want)
+	}
+}
+
+func TestCollector_Batches(t *testing.T) {
+	pc, read := listen(t)
+	c, err := New(pc.LocalAddr().String(), WithFlushInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	c.IncCounter("hits", 1)
+	c.SetGauge("size", 7)
+	c.SetGauge("delta", -2)
+	c.ObserveHistogram("latency", 0.0025)
+	c.Close()
+
+	want := strings.Join([]string{"hits:1|c", "size:7|g", "delta:0|g", "delta:-2|g", "latency:2.5|ms"}, "\n")
+	if got := read(); got != want {
+		t.Errorf("datagram = %q, want %q", got, want)
+	}
+}
+
+func TestCollector_MaxPacketSize(t *testing.T) {
+	pc, read := listen(t)
+	c, err := New(pc.LocalAddr().String(), WithMaxPacketSize(20), WithFlushInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	c.IncCounter("aaaaaaaa", 1) // 12 bytes.
+	c.IncCounter("bbbbbbbb", 1) // Would make 25 bytes.
+	c.Close()
+
+	for _, want := range []string{"aaaaaaaa:1|c", "bbbbbbbb:1|c"} {
+		if got := read(); got != want {
+			t.Errorf("datagram = %q, want %q", got, want)
+		}
+	}
+}
+
+// counterLine returns a counter line of exactly n bytes, for n >= 5.
+func counterLine(n int, name byte) string {
+	return strings.Repeat(string(name), n-4) + ":1|c"
+}
+
+func TestCollector_DefaultMaxPacketSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string // Datagrams, in order.
+	}{
+		{
+			name:  "lines fit the packet",
+			lines: []string{counterLine(700, 'a'), counterLine(DefaultMaxPacketSize-700-10, 'b')},
+			want:  []string{counterLine(700, 'a') + "\n" + counterLine(DefaultMaxPacketSize-700-10, 'b')},
+		},
+		{
+			name:  "one byte over splits",
+			lines: []string{counterLine(700, 'a'), counterLine(DefaultMaxPacketSize-700, 'b')},
+			want:  []string{counterLine(700, 'a'), counterLine(DefaultMaxPacketSize-700, 'b')},
+		},
+		{
+			name:  "line at the limit",
+			lines: []string{counterLine(DefaultMaxPacketSize, 'a'), counterLine(10, 'b')},
+			want:  []string{counterLine(DefaultMaxPacketSize, 'a'), counterLine(10, 'b')},
+		},
+		{
+			name:  "longer line sent alone",
+			lines: []string{counterLine(10, 'a'), counterLine(DefaultMaxPacketSize+100, 'b'), counterLine(10, 'c')},
+			want:  []string{counterLine(10, 'a'), counterLine(DefaultMaxPacketSize+100, 'b'), counterLine(10, 'c')},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc, read := listen(t)
+			c, err := New(pc.LocalAddr().String(), WithFlushInterval(time.Hour))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for _, line := range tt.lines {
+				name, _, _ := strings.Cut(line, ":")
+				c.IncCounter(name, 1)
+			}
+			c.Close()
+
+			for i, want := range tt.want {
+				if got := read(); got != want {
+					t.Errorf("datagram %d = %.20q (%d bytes), want %.20q (%d bytes)", i, got, len(got), want, len(want))
+				}
+			}
+		})
+	}
+}
+
+func TestCollector_PacketsWithinLimit(t *testing.T) {
+	pc, read := listen(t)
+	c, err := New(pc.LocalAddr().String(), WithFlushInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// Lines of every length from 5 to 300 bytes cross the packet boundary
+	// at many offsets.
+	var lines []string
+	for n := 5; n <= 300; n++ {
+		line := counterLine(n, byte('a'+n%26))
+		lines = append(lines, line)
+		name, _, _ := strings.Cut(line, ":")
+		c.IncCounter(name, 1)
+	}
+	c.Close()
+
+	var got []string
+	for len(got) < len(lines) {
+		datagram := read()
+		if len(datagram) > DefaultMaxPacketSize {
+			t.Errorf("datagram of %d bytes exceeds %d", len(datagram), DefaultMaxPacketSize)
+		}
+		got = append(got, strings.Split(datagram, "\n")...)
+	}
+	if strings.Join(got, "\n") != strings.Join(lines, "\n") {
+		t.Errorf("datagrams split or reordered the lines")
+	}
+}
+
+func TestCollector_SampleRate(t *testing.T) {
+	pc, read := listen(t)
+	c, err := New(pc.LocalAddr().String(), WithSampleRate(0.5), WithFlushInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	samples := []bool{false, true}
+	c.client.(*statsd.Client).SetSamplerFunc(func(rate float32) bool {
+		if rate >= 1 {
+			return true
+		}
+		v := samples[0]
+		samples = samples[1:]
+		return v
+	})
+
+	c.IncCounter("dropped", 1)
+	c.IncCounter("kept", 1)
+	c.SetGauge("gauge", 1) // Never sampled.
+	c.Close()
+
+	if got, want := read(), "kept:1|c|@0.500000\ngauge:1|g"; got != want {
+		t.Errorf("datagram = %q, want %q", got, want)
+	}
+}
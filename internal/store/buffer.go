@@ -0,0 +1,51 @@
+package store
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Releaser is implemented by stores whose ReadShard returns buffers drawn
+// from the shared pool.
+//
+// Ownership contract: the slice returned by ReadShard belongs to the caller.
+// A caller that is finished with it, and holds no references to it or to any
+// sub-slice of it, may hand it back with ReleaseShard so a later read can
+// reuse the memory. After ReleaseShard the contents may be overwritten at any
+// time. Callers that retain shard data, such as caches, must never release
+// it; releasing is always optional, and unreleased buffers are simply
+// garbage collected.
+type Releaser interface {
+	// ReleaseShard returns data obtained from ReadShard to the pool.
+	ReleaseShard(data []byte)
+}
+
+// maxPooledSize bounds the buffers kept in the pool so that one unusually
+// large shard does not pin its memory indefinitely.
+const maxPooledSize = 64 << 20
+
+var bufPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// ReadAll reads r until EOF into a buffer from the shared pool. The result
+// may be handed back with Release; see Releaser for the ownership contract.
+func ReadAll(r io.Reader) ([]byte, error) {
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	if _, err := buf.ReadFrom(r); err != nil {
+		bufPool.Put(buf)
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Release returns a buffer obtained from ReadAll to the shared pool. The
+// caller must not use data, or any slice of it, afterwards.
+func Release(data []byte) {
+	if data == nil || cap(data) > maxPooledSize {
+		return
+	}
+	bufPool.Put(bytes.NewBuffer(data[:0]))
+}
@@ -10,6 +10,12 @@ type Backend interface {
 	// Set stores a shard in the cache.
 	Set(shardID int, data []byte)
 
+	// Remove evicts a shard from the cache, if present.
+	Remove(shardID int)
+
+	// Clear evicts every shard and resets the hit and miss counts.
+	Clear()
+
 	// Stats returns cache statistics.
 	Stats() Stats
 }
@@ -18,7 +24,17 @@ type Backend interface {
 type Stats struct {
 	Hits   int64
 	Misses int64
-	Size   int // Current number of entries
+	Size   int   // Current number of entries
+	Bytes  int64 // Current size of cached data, if the backend tracks it
+
+	// NegativeHits counts reads answered from the negative cache without
+	// consulting the backend. They are not included in Hits or Misses.
+	NegativeHits int64
+
+	// Expired counts reads whose cached copy was older than the TTL and
+	// was refreshed from the underlying store. They are not included in
+	// Hits or Misses.
+	Expired int64
 }
 
 // HitRate returns the cache hit rate as a percentage.
@@ -2,6 +2,10 @@ package cachedstore
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/discochess/stockpile/internal/store"
 )
@@ -13,35 +17,186 @@ var _ store.Store = (*Store)(nil)
 type Store struct {
 	underlying store.Store
 	backend    Backend
+
+	// mu guards negative and cachedAt.
+	mu sync.Mutex
+
+	// Negative cache of shards the underlying store reported missing,
+	// mapped to when the entry expires.
+	negativeTTL  time.Duration
+	negative     map[int]time.Time
+	negativeHits atomic.Int64
+
+	// Time each shard was cached, for expiring entries after ttl.
+	ttl      time.Duration
+	cachedAt map[int]time.Time
+	expired  atomic.Int64
+
+	now func() time.Time
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithNegativeCache remembers shards that the underlying store reports as
+// store.ErrNotFound for ttl, so repeated reads of a missing shard return
+// immediately instead of going to the backend. The TTL bounds how long a
+// shard created after the miss stays hidden; keep it short.
+// A ttl of zero or less disables negative caching (the default).
+func WithNegativeCache(ttl time.Duration) Option {
+	return func(s *Store) {
+		s.negativeTTL = ttl
+	}
+}
+
+// WithTTL expires cached shards d after they were read from the underlying
+// store. A read of an expired shard is served from the underlying store and
+// re-cached, so long-running processes pick up rebuilt shards without a
+// restart. The backend's own eviction still applies, so capacity and TTL
+// both bound what is cached.
+// A d of zero or less disables expiry (the default).
+func WithTTL(d time.Duration) Option {
+	return func(s *Store) {
+		s.ttl = d
+	}
 }
 
 // New creates a new cached store wrapping the given store.
-func New(underlying store.Store, backend Backend) *Store {
-	return &Store{
+func New(underlying store.Store, backend Backend, opts ...Option) *Store {
+	s := &Store{
 		underlying: underlying,
 		backend:    backend,
+		negative:   make(map[int]time.Time),
+		cachedAt:   make(map[int]time.Time),
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
-// ReadShard reads a shard, checking the cache first.
+// ReadShard reads a shard, checking the cache first. A cancelled ctx fails
+// the read even if the shard is cached.
 func (s *Store) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
-	// Check cache first.
-	if data, ok := s.backend.Get(shardID); ok {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Check for a recent not-found result.
+	if s.isKnownMissing(shardID) {
+		s.negativeHits.Add(1)
+		markHit(ctx)
+		return nil, store.ErrNotFound
+	}
+
+	// Check cache first, unless the cached copy is too old.
+	if s.isExpired(shardID) {
+		s.expired.Add(1)
+	} else if data, ok := s.backend.Get(shardID); ok {
+		markHit(ctx)
 		return data, nil
 	}
 
 	// Cache miss - read from underlying store.
 	data, err := s.underlying.ReadShard(ctx, shardID)
 	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			s.rememberMissing(shardID)
+		}
 		return nil, err
 	}
 
 	// Cache the result.
 	s.backend.Set(shardID, data)
+	s.markCached(shardID)
 
 	return data, nil
 }
 
+// markHit records a cache hit in the ReadInfo of ctx, if any.
+func markHit(ctx context.Context) {
+	if info := store.ReadInfoFromContext(ctx); info != nil {
+		info.CacheHit = true
+	}
+}
+
+// isExpired reports whether shardID was cached more than ttl ago.
+func (s *Store) isExpired(shardID int) bool {
+	if s.ttl <= 0 {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	at, ok := s.cachedAt[shardID]
+	return ok && !s.now().Before(at.Add(s.ttl))
+}
+
+// markCached records that shardID was just cached.
+func (s *Store) markCached(shardID int) {
+	if s.ttl <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cachedAt[shardID] = s.now()
+}
+
+// isKnownMissing reports whether shardID has an unexpired negative entry.
+func (s *Store) isKnownMissing(shardID int) bool {
+	if s.negativeTTL <= 0 {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expires, ok := s.negative[shardID]
+	if !ok {
+		return false
+	}
+	if s.now().Before(expires) {
+		return true
+	}
+	delete(s.negative, shardID)
+	return false
+}
+
+// rememberMissing records a negative entry for shardID.
+func (s *Store) rememberMissing(shardID int) {
+	if s.negativeTTL <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.negative[shardID] = s.now().Add(s.negativeTTL)
+}
+
+// Invalidate evicts a shard from the cache, including a negative entry, so
+// that the next read goes to the underlying store. A read already in flight
+// may cache its result again.
+func (s *Store) Invalidate(shardID int) {
+	s.backend.Remove(shardID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.negative, shardID)
+	delete(s.cachedAt, shardID)
+}
+
+// Clear evicts every shard from the cache, including negative entries, and
+// resets the statistics reported by Stats, for example after the
+// underlying shards were rebuilt. Reads already in flight may cache their
+// results again.
+func (s *Store) Clear() {
+	s.backend.Clear()
+	s.negativeHits.Store(0)
+	s.expired.Store(0)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.negative)
+	clear(s.cachedAt)
+}
+
 // Close closes the underlying store.
 func (s *Store) Close() error {
 	return s.underlying.Close()
@@ -49,5 +204,8 @@ func (s *Store) Close() error {
 
 // Stats returns cache statistics.
 func (s *Store) Stats() Stats {
-	return s.backend.Stats()
+	st := s.backend.Stats()
+	st.NegativeHits = s.negativeHits.Load()
+	st.Expired = s.expired.Load()
+	return st
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/discochess/stockpile/internal/store"
 )
@@ -32,6 +33,15 @@ func (b *fakeBackend) Set(shardID int, data []byte) {
 	b.data[shardID] = data
 }
 
+func (b *fakeBackend) Remove(shardID int) {
+	delete(b.data, shardID)
+}
+
+func (b *fakeBackend) Clear() {
+	clear(b.data)
+	b.hits, b.misses = 0, 0
+}
+
 func (b *fakeBackend) Stats() Stats {
 	return Stats{Hits: b.hits, Misses: b.misses, Size: len(b.data)}
 }
@@ -147,3 +157,238 @@ func TestStats_HitRate(t *testing.T) {
 		})
 	}
 }
+
+// countingStore counts reads of a fakeStore.
+type countingStore struct {
+	*fakeStore
+	reads int
+}
+
+func (s *countingStore) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
+	s.reads++
+	return s.fakeStore.ReadShard(ctx, shardID)
+}
+
+func TestStore_NegativeCache(t *testing.T) {
+	underlying := &countingStore{fakeStore: newFakeStore()}
+	s := New(underlying, newFakeBackend(), WithNegativeCache(time.Minute))
+
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// First read goes to the underlying store and records a tombstone.
+	for i := 0; i < 3; i++ {
+		if _, err := s.ReadShard(ctx, 7); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("ReadShard() error = %v, want ErrNotFound", err)
+		}
+	}
+	if underlying.reads != 1 {
+		t.Errorf("underlying reads = %d, want 1", underlying.reads)
+	}
+	if got := s.Stats().NegativeHits; got != 2 {
+		t.Errorf("Stats().NegativeHits = %d, want 2", got)
+	}
+
+	// Once the TTL expires, a shard created in the meantime is found.
+	underlying.data[7] = []byte("new shard")
+	now = now.Add(time.Minute)
+
+	data, err := s.ReadShard(ctx, 7)
+	if err != nil {
+		t.Fatalf("ReadShard() after TTL error = %v", err)
+	}
+	if string(data) != "new shard" {
+		t.Errorf("ReadShard() = %q, want %q", data, "new shard")
+	}
+	if underlying.reads != 2 {
+		t.Errorf("underlying reads = %d, want 2", underlying.reads)
+	}
+}
+
+func TestStore_NegativeCacheDisabled(t *testing.T) {
+	underlying := &countingStore{fakeStore: newFakeStore()}
+	s := New(underlying, newFakeBackend())
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.ReadShard(ctx, 7); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("ReadShard() error = %v, want ErrNotFound", err)
+		}
+	}
+	if underlying.reads != 3 {
+		t.Errorf("underlying reads = %d, want 3", underlying.reads)
+	}
+	if got := s.Stats().NegativeHits; got != 0 {
+		t.Errorf("Stats().NegativeHits = %d, want 0", got)
+	}
+}
+
+func TestStore_NegativeCacheIgnoresOtherErrors(t *testing.T) {
+	failing := &errStore{err: errors.New("connection reset")}
+	s := New(failing, newFakeBackend(), WithNegativeCache(time.Minute))
+	ctx := context.Background()
+
+	s.ReadShard(ctx, 1)
+	s.ReadShard(ctx, 1)
+	if failing.reads != 2 {
+		t.Errorf("underlying reads = %d, want 2 (transient errors are not cached)", failing.reads)
+	}
+}
+
+// errStore fails every read with err.
+type errStore struct {
+	err   error
+	reads int
+}
+
+func (s *errStore) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
+	s.reads++
+	return nil, s.err
+}
+
+func (s *errStore) Close() error {
+	return nil
+}
+
+func TestStore_TTL(t *testing.T) {
+	underlying := &countingStore{fakeStore: newFakeStore()}
+	underlying.data[1] = []byte("v1")
+	s := New(underlying, newFakeBackend(), WithTTL(time.Hour))
+
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	read := func() string {
+		t.Helper()
+		data, err := s.ReadShard(ctx, 1)
+		if err != nil {
+			t.Fatalf("ReadShard() error = %v", err)
+		}
+		return string(data)
+	}
+
+	if got := read(); got != "v1" {
+		t.Errorf("ReadShard() = %q, want %q", got, "v1")
+	}
+
+	// The shard is rebuilt; the cached copy is served until it expires.
+	underlying.data[1] = []byte("v2")
+	now = now.Add(59 * time.Minute)
+	if got := read(); got != "v1" {
+		t.Errorf("ReadShard() before expiry = %q, want %q", got, "v1")
+	}
+
+	now = now.Add(time.Minute)
+	if got := read(); got != "v2" {
+		t.Errorf("ReadShard() after expiry = %q, want %q", got, "v2")
+	}
+	if underlying.reads != 2 {
+		t.Errorf("underlying reads = %d, want 2", underlying.reads)
+	}
+	if got := s.Stats().Expired; got != 1 {
+		t.Errorf("Stats().Expired = %d, want 1", got)
+	}
+
+	// The refreshed copy gets a new TTL.
+	if got := read(); got != "v2" {
+		t.Errorf("ReadShard() = %q, want %q", got, "v2")
+	}
+	if underlying.reads != 2 {
+		t.Errorf("underlying reads = %d, want 2", underlying.reads)
+	}
+}
+
+func TestStore_TTLDisabled(t *testing.T) {
+	underlying := &countingStore{fakeStore: newFakeStore()}
+	underlying.data[1] = []byte("v1")
+	s := New(underlying, newFakeBackend())
+
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	s.ReadShard(ctx, 1)
+	now = now.Add(24 * 365 * time.Hour)
+	s.ReadShard(ctx, 1)
+
+	if underlying.reads != 1 {
+		t.Errorf("underlying reads = %d, want 1", underlying.reads)
+	}
+}
+
+func TestStore_Invalidate(t *testing.T) {
+	underlying := &countingStore{fakeStore: newFakeStore()}
+	underlying.data[1] = []byte("v1")
+	underlying.data[2] = []byte("other")
+	s := New(underlying, newFakeBackend(), WithNegativeCache(time.Minute))
+	ctx := context.Background()
+
+	s.ReadShard(ctx, 1)
+	s.ReadShard(ctx, 2)
+	s.ReadShard(ctx, 3) // Remembered as missing.
+
+	underlying.data[1] = []byte("v2")
+	underlying.data[3] = []byte("created")
+	s.Invalidate(1)
+	s.Invalidate(3)
+
+	for id, want := range map[int]string{1: "v2", 2: "other", 3: "created"} {
+		data, err := s.ReadShard(ctx, id)
+		if err != nil || string(data) != want {
+			t.Errorf("ReadShard(%d) = %q, %v; want %q", id, data, err, want)
+		}
+	}
+	if underlying.reads != 5 {
+		t.Errorf("underlying reads = %d, want 5", underlying.reads)
+	}
+}
+
+func TestStore_Clear(t *testing.T) {
+	underlying := &countingStore{fakeStore: newFakeStore()}
+	underlying.data[1] = []byte("v1")
+	s := New(underlying, newFakeBackend(), WithNegativeCache(time.Minute))
+	ctx := context.Background()
+
+	s.ReadShard(ctx, 1)
+	s.ReadShard(ctx, 1)
+	s.ReadShard(ctx, 7)
+	s.ReadShard(ctx, 7) // Negative hit.
+
+	underlying.data[1] = []byte("v2")
+	underlying.data[7] = []byte("created")
+	s.Clear()
+
+	if st := s.Stats(); st != (Stats{}) {
+		t.Errorf("Stats() after Clear = %+v, want zero", st)
+	}
+	for id, want := range map[int]string{1: "v2", 7: "created"} {
+		data, err := s.ReadShard(ctx, id)
+		if err != nil || string(data) != want {
+			t.Errorf("ReadShard(%d) = %q, %v; want %q", id, data, err, want)
+		}
+	}
+}
+
+func TestStore_ReadInfo(t *testing.T) {
+	underlying := newFakeStore()
+	underlying.data[1] = []byte("data")
+	s := New(underlying, newFakeBackend(), WithNegativeCache(time.Minute))
+
+	for _, tt := range []struct {
+		shardID int
+		want    bool
+	}{
+		{1, false}, // Read from the underlying store.
+		{1, true},  // Cached.
+		{2, false}, // Missing.
+		{2, true},  // Negatively cached.
+	} {
+		info := &store.ReadInfo{}
+		s.ReadShard(store.WithReadInfo(context.Background(), info), tt.shardID)
+		if info.CacheHit != tt.want {
+			t.Errorf("ReadShard(%d) CacheHit = %v, want %v", tt.shardID, info.CacheHit, tt.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Package lfu implements an LFU cache eviction strategy.
+package lfu
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/discochess/stockpile/internal/store/cachedstore/cachestrategy"
+)
+
+// Compile-time check that Strategy implements cachestrategy.Strategy.
+var _ cachestrategy.Strategy = (*Strategy)(nil)
+
+// Strategy implements LFU eviction: when full, adding a key evicts the
+// least frequently used entry, and the least recently used among those on a
+// tie. Unlike LRU, shards that are hit constantly, such as the opening
+// shards, survive a burst of one-off lookups. A Strategy is safe for
+// concurrent use.
+type Strategy struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[int]*list.Element
+	freqs    map[int]*list.List // Use count -> entries, front most recently used.
+	minFreq  int
+}
+
+// entry is a cached key and value with its use count.
+type entry struct {
+	key   int
+	value []byte
+	freq  int
+}
+
+// New creates a new LFU strategy with the given capacity.
+func New(capacity int) (*Strategy, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("lfu: capacity must be positive, got %d", capacity)
+	}
+	return &Strategy{
+		capacity: capacity,
+		items:    make(map[int]*list.Element),
+		freqs:    make(map[int]*list.List),
+	}, nil
+}
+
+// Get retrieves a value by key and counts a use of it.
+func (s *Strategy) Get(key int) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	return s.touch(el).Value.(*entry).value, true
+}
+
+// Add adds a value to the cache. Replacing the value of a cached key counts
+// as a use of it. It reports whether an entry was evicted to make room.
+func (s *Strategy) Add(key int, value []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		s.touch(el).Value.(*entry).value = value
+		return false
+	}
+
+	var evicted bool
+	if len(s.items) >= s.capacity {
+		s.removeElement(s.freqs[s.minFreq].Back())
+		evicted = true
+	}
+
+	e := &entry{key: key, value: value, freq: 1}
+	s.items[key] = s.bucket(1).PushFront(e)
+	s.minFreq = 1
+	return evicted
+}
+
+// Remove removes a key from the cache, reporting whether it was present.
+func (s *Strategy) Remove(key int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.items[key]
+	if ok {
+		s.removeElement(el)
+	}
+	return ok
+}
+
+// Purge removes every item from the cache.
+func (s *Strategy) Purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.items)
+	clear(s.freqs)
+	s.minFreq = 0
+}
+
+// Len returns the number of items in the cache.
+func (s *Strategy) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// touch moves el to the next use count and returns its new element.
+// s.mu must be held.
+func (s *Strategy) touch(el *list.Element) *list.Element {
+	e := el.Value.(*entry)
+	s.unlink(el)
+	if _, ok := s.freqs[e.freq]; !ok && e.freq == s.minFreq {
+		s.minFreq++
+	}
+	e.freq++
+	el = s.bucket(e.freq).PushFront(e)
+	s.items[e.key] = el
+	return el
+}
+
+// removeElement removes el from the cache. s.mu must be held.
+func (s *Strategy) removeElement(el *list.Element) {
+	s.unlink(el)
+	delete(s.items, el.Value.(*entry).key)
+	if _, ok := s.freqs[s.minFreq]; !ok {
+		s.minFreq = 0
+		for freq := range s.freqs {
+			if s.minFreq == 0 || freq < s.minFreq {
+				s.minFreq = freq
+			}
+		}
+	}
+}
+
+// unlink removes el from its use count list, dropping the list if it is
+// left empty. s.mu must be held.
+func (s *Strategy) unlink(el *list.Element) {
+	freq := el.Value.(*entry).freq
+	l := s.freqs[freq]
+	l.Remove(el)
+	if l.Len() == 0 {
+		delete(s.freqs, freq)
+	}
+}
+
+// bucket returns the list of entries used freq times, creating it if
+// needed. s.mu must be held.
+func (s *Strategy) bucket(freq int) *list.List {
+	l, ok := s.freqs[freq]
+	if !ok {
+		l = list.New()
+		s.freqs[freq] = l
+	}
+	return l
+}
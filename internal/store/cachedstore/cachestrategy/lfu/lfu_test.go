@@ -0,0 +1,130 @@
+package lfu
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestNew_InvalidCapacity(t *testing.T) {
+	for _, capacity := range []int{0, -1} {
+		if _, err := New(capacity); err == nil {
+			t.Errorf("New(%d) error = nil, want error", capacity)
+		}
+	}
+}
+
+func TestStrategy_GetAdd(t *testing.T) {
+	s, err := New(2)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, ok := s.Get(1); ok {
+		t.Error("Get() should return false for missing key")
+	}
+
+	s.Add(1, []byte("hello"))
+	s.Add(1, []byte("world"))
+	data, ok := s.Get(1)
+	if !ok || string(data) != "world" {
+		t.Errorf("Get() = %q, %v, want %q, true", data, ok, "world")
+	}
+	if got := s.Len(); got != 1 {
+		t.Errorf("Len() = %d, want 1", got)
+	}
+}
+
+func TestStrategy_EvictsLeastFrequentlyUsed(t *testing.T) {
+	s, err := New(3)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	s.Add(1, nil)
+	s.Add(2, nil)
+	s.Add(3, nil)
+	s.Get(1)
+	s.Get(1)
+	s.Get(3) // 2 is now the least frequently used.
+
+	if evicted := s.Add(4, nil); !evicted {
+		t.Error("Add() = false, want eviction")
+	}
+	if _, ok := s.Get(2); ok {
+		t.Error("Get(2) should return false after eviction")
+	}
+
+	// 4 and 3 have been used once and twice; a tie among the least used
+	// goes to the least recently used.
+	s.Get(4)
+	s.Add(5, nil)
+	if _, ok := s.Get(3); ok {
+		t.Error("Get(3) should return false after eviction")
+	}
+	for _, key := range []int{1, 4, 5} {
+		if _, ok := s.Get(key); !ok {
+			t.Errorf("Get(%d) should return true", key)
+		}
+	}
+}
+
+func TestStrategy_RemovePurge(t *testing.T) {
+	s, err := New(2)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	s.Add(1, nil)
+	s.Add(2, nil)
+	s.Get(2)
+	if !s.Remove(1) {
+		t.Error("Remove(1) = false, want true")
+	}
+	if s.Remove(1) {
+		t.Error("Remove(1) again = true, want false")
+	}
+
+	// Removing the only least used entry must not break later evictions.
+	s.Add(3, nil)
+	s.Get(3)
+	s.Get(3)
+	s.Add(4, nil)
+	if _, ok := s.Get(2); ok {
+		t.Error("Get(2) should return false after eviction")
+	}
+
+	s.Purge()
+	if got := s.Len(); got != 0 {
+		t.Errorf("Len() after Purge() = %d, want 0", got)
+	}
+	s.Add(5, nil)
+	if _, ok := s.Get(5); !ok {
+		t.Error("Get(5) after Purge() should return true")
+	}
+}
+
+func TestStrategy_Concurrent(t *testing.T) {
+	s, err := New(10)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 1000 {
+				key := (i*1000 + j) % 25
+				if _, ok := s.Get(key); !ok {
+					s.Add(key, nil)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := s.Len(); got > 10 {
+		t.Errorf("Len() = %d, want at most 10", got)
+	}
+}
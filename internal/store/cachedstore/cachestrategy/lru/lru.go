@@ -33,6 +33,16 @@ func (s *Strategy) Add(key int, value []byte) bool {
 	return s.cache.Add(key, value)
 }
 
+// Remove removes a key from the cache, reporting whether it was present.
+func (s *Strategy) Remove(key int) bool {
+	return s.cache.Remove(key)
+}
+
+// Purge removes every item from the cache.
+func (s *Strategy) Purge() {
+	s.cache.Purge()
+}
+
 // Len returns the number of items in the cache.
 func (s *Strategy) Len() int {
 	return s.cache.Len()
@@ -0,0 +1,128 @@
+// Package sizelru implements an LRU cache eviction strategy bounded by the
+// total size of cached values rather than the number of entries.
+package sizelru
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/discochess/stockpile/internal/store/cachedstore/cachestrategy"
+)
+
+// Compile-time checks that Strategy implements cachestrategy.Strategy and
+// cachestrategy.Sizer.
+var (
+	_ cachestrategy.Strategy = (*Strategy)(nil)
+	_ cachestrategy.Sizer    = (*Strategy)(nil)
+)
+
+// Strategy implements LRU eviction with a byte budget.
+// Adding a value evicts least recently used entries until the total size of
+// all values fits within the budget. A Strategy is safe for concurrent use.
+type Strategy struct {
+	mu       sync.Mutex
+	maxBytes int64
+	bytes    int64
+	ll       *list.List // Front is most recently used.
+	items    map[int]*list.Element
+}
+
+// entry is a cached key and value.
+type entry struct {
+	key   int
+	value []byte
+}
+
+// New creates a new size-bounded LRU strategy holding at most maxBytes of
+// values.
+func New(maxBytes int64) (*Strategy, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("sizelru: max bytes must be positive, got %d", maxBytes)
+	}
+	return &Strategy{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		items:    make(map[int]*list.Element),
+	}, nil
+}
+
+// Get retrieves a value by key and marks it as recently used.
+func (s *Strategy) Get(key int) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	s.ll.MoveToFront(el)
+	return el.Value.(*entry).value, true
+}
+
+// Add adds a value to the cache, replacing any value with the same key.
+// It reports whether any other entry was evicted to make room.
+// A value larger than the whole budget is not cached.
+func (s *Strategy) Add(key int, value []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		s.removeElement(el)
+	}
+
+	size := int64(len(value))
+	if size > s.maxBytes {
+		return false
+	}
+
+	var evicted bool
+	for s.bytes+size > s.maxBytes {
+		s.removeElement(s.ll.Back())
+		evicted = true
+	}
+
+	s.items[key] = s.ll.PushFront(&entry{key: key, value: value})
+	s.bytes += size
+	return evicted
+}
+
+// Remove removes a key from the cache, reporting whether it was present.
+func (s *Strategy) Remove(key int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.items[key]
+	if ok {
+		s.removeElement(el)
+	}
+	return ok
+}
+
+// Purge removes every item from the cache.
+func (s *Strategy) Purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ll.Init()
+	clear(s.items)
+	s.bytes = 0
+}
+
+// Len returns the number of items in the cache.
+func (s *Strategy) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ll.Len()
+}
+
+// Bytes returns the total size of all cached values.
+func (s *Strategy) Bytes() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bytes
+}
+
+// removeElement removes el from the cache. s.mu must be held.
+func (s *Strategy) removeElement(el *list.Element) {
+	e := s.ll.Remove(el).(*entry)
+	delete(s.items, e.key)
+	s.bytes -= int64(len(e.value))
+}
@@ -0,0 +1,182 @@
+package sizelru
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestNew_InvalidBudget(t *testing.T) {
+	for _, maxBytes := range []int64{0, -1} {
+		if _, err := New(maxBytes); err == nil {
+			t.Errorf("New(%d) error = nil, want error", maxBytes)
+		}
+	}
+}
+
+func TestStrategy_GetAdd(t *testing.T) {
+	s, err := New(100)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, ok := s.Get(1); ok {
+		t.Error("Get() should return false for missing key")
+	}
+
+	s.Add(1, []byte("hello"))
+	data, ok := s.Get(1)
+	if !ok || string(data) != "hello" {
+		t.Errorf("Get() = %q, %v, want %q, true", data, ok, "hello")
+	}
+	if got := s.Bytes(); got != 5 {
+		t.Errorf("Bytes() = %d, want 5", got)
+	}
+}
+
+func TestStrategy_EvictsLeastRecentlyUsed(t *testing.T) {
+	s, err := New(30)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	s.Add(1, make([]byte, 10))
+	s.Add(2, make([]byte, 10))
+	s.Add(3, make([]byte, 10))
+	s.Get(1) // Make 2 the least recently used.
+
+	if evicted := s.Add(4, make([]byte, 10)); !evicted {
+		t.Error("Add() = false, want eviction")
+	}
+	if _, ok := s.Get(2); ok {
+		t.Error("Get(2) should return false after eviction")
+	}
+	for _, key := range []int{1, 3, 4} {
+		if _, ok := s.Get(key); !ok {
+			t.Errorf("Get(%d) should return true", key)
+		}
+	}
+	if got := s.Bytes(); got != 30 {
+		t.Errorf("Bytes() = %d, want 30", got)
+	}
+}
+
+func TestStrategy_LargeInsertEvictsSeveral(t *testing.T) {
+	s, err := New(100)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for key := 1; key <= 4; key++ {
+		s.Add(key, make([]byte, 20))
+	}
+
+	// 80 bytes used; a 70-byte value exceeds the remaining 20, so the three
+	// least recently used entries must go.
+	if evicted := s.Add(5, make([]byte, 70)); !evicted {
+		t.Error("Add() = false, want eviction")
+	}
+	if got := s.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+	if _, ok := s.Get(4); !ok {
+		t.Error("Get(4) should return true")
+	}
+	if got := s.Bytes(); got != 90 {
+		t.Errorf("Bytes() = %d, want 90", got)
+	}
+}
+
+func TestStrategy_ValueLargerThanBudget(t *testing.T) {
+	s, err := New(50)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	s.Add(1, make([]byte, 10))
+
+	// A value that can never fit is not cached and does not flush the cache.
+	if evicted := s.Add(2, make([]byte, 51)); evicted {
+		t.Error("Add() = true, want no eviction")
+	}
+	if _, ok := s.Get(2); ok {
+		t.Error("Get(2) should return false for oversized value")
+	}
+	if _, ok := s.Get(1); !ok {
+		t.Error("Get(1) should return true")
+	}
+}
+
+func TestStrategy_ReplaceUpdatesSize(t *testing.T) {
+	s, err := New(100)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	s.Add(1, make([]byte, 40))
+	s.Add(1, []byte("new"))
+
+	if got := s.Bytes(); got != 3 {
+		t.Errorf("Bytes() = %d, want 3", got)
+	}
+	if got := s.Len(); got != 1 {
+		t.Errorf("Len() = %d, want 1", got)
+	}
+	if data, _ := s.Get(1); !bytes.Equal(data, []byte("new")) {
+		t.Errorf("Get(1) = %q, want %q", data, "new")
+	}
+}
+
+func TestStrategy_RemovePurge(t *testing.T) {
+	s, err := New(100)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	s.Add(1, make([]byte, 10))
+	s.Add(2, make([]byte, 20))
+	if !s.Remove(1) || s.Remove(1) {
+		t.Error("Remove(1) twice should report true, then false")
+	}
+	if got := s.Bytes(); got != 20 {
+		t.Errorf("Bytes() after Remove = %d, want 20", got)
+	}
+
+	s.Purge()
+	if s.Len() != 0 || s.Bytes() != 0 {
+		t.Errorf("after Purge: Len() = %d, Bytes() = %d, want 0, 0", s.Len(), s.Bytes())
+	}
+	// The budget is whole again.
+	s.Add(3, make([]byte, 100))
+	if _, ok := s.Get(3); !ok {
+		t.Error("Get(3) after Purge and Add = false, want true")
+	}
+}
+
+func TestStrategy_Concurrent(t *testing.T) {
+	s, err := New(1000)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := (g*1000 + i) % 50
+				s.Add(key, make([]byte, 30))
+				s.Get(key)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if got := s.Bytes(); got > 1000 {
+		t.Errorf("Bytes() = %d, exceeds budget", got)
+	}
+	if got, want := s.Bytes(), int64(s.Len()*30); got != want {
+		t.Errorf("Bytes() = %d, want %d for %d entries", got, want, s.Len())
+	}
+}
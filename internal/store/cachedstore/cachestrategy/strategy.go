@@ -6,4 +6,17 @@ type Strategy interface {
 	Get(key int) ([]byte, bool)
 	Add(key int, value []byte) bool
 	Len() int
+
+	// Remove removes a key, reporting whether it was present.
+	Remove(key int) bool
+
+	// Purge removes every key.
+	Purge()
+}
+
+// Sizer is implemented by strategies that track the total size of their
+// values, such as size-bounded strategies.
+type Sizer interface {
+	// Bytes returns the total size of all cached values.
+	Bytes() int64
 }
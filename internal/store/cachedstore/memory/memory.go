@@ -23,6 +23,10 @@ type Backend struct {
 
 // New creates a new memory backend with the given eviction strategy.
 // The collector is optional; if nil, a no-op collector is used.
+//
+// To bound memory use rather than entry count, use a strategy that
+// implements cachestrategy.Sizer, such as sizelru; its size is then
+// reported in Stats and as a gauge.
 func New(strategy cachestrategy.Strategy, collector stats.Collector) *Backend {
 	if collector == nil {
 		collector = stats.NewNoop()
@@ -49,16 +53,42 @@ func (b *Backend) Get(shardID int) ([]byte, bool) {
 // Set stores shard data in the cache.
 func (b *Backend) Set(shardID int, data []byte) {
 	b.strategy.Add(shardID, data)
+	b.reportSize()
+}
+
+// Remove evicts shard data from the cache.
+func (b *Backend) Remove(shardID int) {
+	b.strategy.Remove(shardID)
+	b.reportSize()
+}
+
+// Clear evicts all shard data and resets the hit and miss counts.
+func (b *Backend) Clear() {
+	b.strategy.Purge()
+	b.hits.Store(0)
+	b.misses.Store(0)
+	b.reportSize()
+}
+
+// reportSize updates the cache size gauges.
+func (b *Backend) reportSize() {
 	b.collector.SetGauge(stats.MetricCacheSize, int64(b.strategy.Len()))
+	if sizer, ok := b.strategy.(cachestrategy.Sizer); ok {
+		b.collector.SetGauge(stats.MetricCacheBytes, sizer.Bytes())
+	}
 }
 
 // Stats returns current cache statistics.
 func (b *Backend) Stats() cachedstore.Stats {
-	return cachedstore.Stats{
+	st := cachedstore.Stats{
 		Hits:   b.hits.Load(),
 		Misses: b.misses.Load(),
 		Size:   b.strategy.Len(),
 	}
+	if sizer, ok := b.strategy.(cachestrategy.Sizer); ok {
+		st.Bytes = sizer.Bytes()
+	}
+	return st
 }
 
 // Len returns the number of items in the cache.
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/discochess/stockpile/internal/store/cachedstore/cachestrategy/lru"
+	"github.com/discochess/stockpile/internal/store/cachedstore/cachestrategy/sizelru"
 )
 
 func TestBackend_GetSet(t *testing.T) {
@@ -77,6 +78,25 @@ func TestBackend_LRUEviction(t *testing.T) {
 	}
 }
 
+func TestBackend_SizeBounded(t *testing.T) {
+	strategy, err := sizelru.New(10)
+	if err != nil {
+		t.Fatalf("sizelru.New() error = %v", err)
+	}
+	b := New(strategy, nil)
+
+	b.Set(1, []byte("one"))
+	b.Set(2, []byte("two"))
+	b.Set(3, []byte("three")) // 11 bytes total; should evict 1.
+
+	if _, ok := b.Get(1); ok {
+		t.Error("Get(1) should return false after eviction")
+	}
+	if got := b.Stats().Bytes; got != 8 {
+		t.Errorf("Stats().Bytes = %d, want 8", got)
+	}
+}
+
 func TestLRU_InvalidCapacity(t *testing.T) {
 	_, err := lru.New(0)
 	if err == nil {
@@ -108,6 +128,16 @@ func (s *fakeStrategy) Len() int {
 	return len(s.data)
 }
 
+func (s *fakeStrategy) Remove(key int) bool {
+	_, ok := s.data[key]
+	delete(s.data, key)
+	return ok
+}
+
+func (s *fakeStrategy) Purge() {
+	clear(s.data)
+}
+
 func TestBackend_InjectableStrategy(t *testing.T) {
 	strategy := &fakeStrategy{data: make(map[int][]byte)}
 	b := New(strategy, nil)
@@ -118,3 +148,32 @@ func TestBackend_InjectableStrategy(t *testing.T) {
 		t.Error("injectable strategy should work")
 	}
 }
+
+func TestBackend_RemoveClear(t *testing.T) {
+	strategy, err := sizelru.New(100)
+	if err != nil {
+		t.Fatalf("sizelru.New() error = %v", err)
+	}
+	b := New(strategy, nil)
+
+	b.Set(1, []byte("one"))
+	b.Set(2, []byte("two"))
+	b.Get(1)
+	b.Get(3)
+
+	b.Remove(1)
+	if _, ok := b.Get(1); ok {
+		t.Error("Get(1) after Remove found the shard")
+	}
+	if st := b.Stats(); st.Size != 1 || st.Bytes != 3 {
+		t.Errorf("Stats() after Remove = %+v, want 1 shard of 3 bytes", st)
+	}
+
+	b.Clear()
+	if st := b.Stats(); st.Size != 0 || st.Bytes != 0 || st.Hits != 0 || st.Misses != 0 {
+		t.Errorf("Stats() after Clear = %+v, want zero", st)
+	}
+	if _, ok := b.Get(2); ok {
+		t.Error("Get(2) after Clear found the shard")
+	}
+}
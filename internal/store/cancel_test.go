@@ -0,0 +1,157 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/discochess/stockpile/internal/codec/zstdcodec"
+	"github.com/discochess/stockpile/internal/stats"
+	"github.com/discochess/stockpile/internal/store"
+	"github.com/discochess/stockpile/internal/store/cachedstore"
+	"github.com/discochess/stockpile/internal/store/cachedstore/cachestrategy/lru"
+	"github.com/discochess/stockpile/internal/store/cachedstore/memory"
+	"github.com/discochess/stockpile/internal/store/diskstore"
+	"github.com/discochess/stockpile/internal/store/gcsstore"
+	"github.com/discochess/stockpile/internal/store/httpstore"
+	"github.com/discochess/stockpile/internal/store/memstore"
+	"github.com/discochess/stockpile/internal/store/s3store"
+	"github.com/discochess/stockpile/internal/store/tieredstore"
+)
+
+var cancelShard = []byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[]}` + "\n")
+
+// cancelBackends returns every store backend, each holding cancelShard as
+// shard 0. Remote backends whose protocol is not served locally are marked
+// offline: they can only be exercised with a cancelled context.
+func cancelBackends(t *testing.T) []struct {
+	name    string
+	st      store.Store
+	offline bool
+} {
+	t.Helper()
+	ctx := context.Background()
+	c := zstdcodec.New()
+
+	dir := t.TempDir()
+	disk, err := diskstore.New(dir, c)
+	if err != nil {
+		t.Fatalf("diskstore.New() error = %v", err)
+	}
+	if err := disk.WriteShard(ctx, 0, cancelShard); err != nil {
+		t.Fatalf("WriteShard() error = %v", err)
+	}
+	mmap, err := diskstore.NewMmap(dir, c)
+	if err != nil {
+		t.Fatalf("diskstore.NewMmap() error = %v", err)
+	}
+
+	mem := memstore.New()
+	mem.SetShard(0, cancelShard)
+
+	var compressed bytes.Buffer
+	w, err := c.Writer(&compressed)
+	if err != nil {
+		t.Fatalf("Writer() error = %v", err)
+	}
+	w.Write(cancelShard)
+	w.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(compressed.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+	hs, err := httpstore.New(srv.URL, c)
+	if err != nil {
+		t.Fatalf("httpstore.New() error = %v", err)
+	}
+
+	strategy, err := lru.New(4)
+	if err != nil {
+		t.Fatalf("lru.New() error = %v", err)
+	}
+	cached := cachedstore.New(mem, memory.New(strategy, stats.NewNoop()))
+	if _, err := cached.ReadShard(ctx, 0); err != nil { // Warm the cache.
+		t.Fatalf("warming cache: %v", err)
+	}
+
+	// Keep the cloud clients offline: no credentials lookup, no real host.
+	t.Setenv("STORAGE_EMULATOR_HOST", srv.Listener.Addr().String())
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	gcs, err := gcsstore.New(ctx, "bucket", c)
+	if err != nil {
+		t.Fatalf("gcsstore.New() error = %v", err)
+	}
+	s3, err := s3store.New(ctx, "bucket", c, s3store.WithEndpoint(srv.URL))
+	if err != nil {
+		t.Fatalf("s3store.New() error = %v", err)
+	}
+
+	backends := []struct {
+		name    string
+		st      store.Store
+		offline bool
+	}{
+		{"disk", disk, false},
+		{"mmap", mmap, false},
+		{"mem", mem, false},
+		{"http", hs, false},
+		{"cached", cached, false},
+		{"tiered", tieredstore.New([]store.Store{memstore.New(), mem}), false},
+		{"gcs", gcs, true},
+		{"s3", s3, true},
+	}
+	for _, b := range backends {
+		t.Cleanup(func() { b.st.Close() })
+	}
+	return backends
+}
+
+func TestReadShard_Canceled(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, b := range cancelBackends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			if !b.offline {
+				data, err := b.st.ReadShard(context.Background(), 0)
+				if err != nil || !bytes.Equal(data, cancelShard) {
+					t.Fatalf("ReadShard() = %q, %v; want the shard", data, err)
+				}
+			}
+			data, err := b.st.ReadShard(canceled, 0)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("ReadShard() with cancelled context = %q, %v; want context.Canceled", data, err)
+			}
+		})
+	}
+}
+
+// cancelingReader cancels its context after the first read.
+type cancelingReader struct {
+	r      io.Reader
+	cancel context.CancelFunc
+}
+
+func (r *cancelingReader) Read(p []byte) (int, error) {
+	defer r.cancel()
+	return r.r.Read(p[:min(len(p), 4)])
+}
+
+func TestContextReader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := store.ContextReader(ctx, &cancelingReader{r: bytes.NewReader(cancelShard), cancel: cancel})
+
+	data, err := io.ReadAll(r)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadAll() error = %v, want context.Canceled", err)
+	}
+	if !bytes.Equal(data, cancelShard[:4]) {
+		t.Errorf("ReadAll() read %q before cancellation, want %q", data, cancelShard[:4])
+	}
+}
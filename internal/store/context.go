@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"io"
+)
+
+// ContextReader returns a reader that fails with ctx.Err() once ctx is
+// done. Stores wrap their decompressors with it so that a cancelled read
+// stops between chunks instead of decompressing the rest of the shard.
+func ContextReader(ctx context.Context, r io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, r: r}
+}
+
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// ReadInfo collects details of a shard read that do not show in its result,
+// for tracing. Stores that know a detail fill it in; see WithReadInfo.
+type ReadInfo struct {
+	// CacheHit reports that the read was served by a cache without going
+	// to the store behind it.
+	CacheHit bool
+}
+
+type readInfoKey struct{}
+
+// WithReadInfo returns a context that makes stores record details of the
+// reads made with it in info. Stores write info without locking, so the
+// context must not be used for concurrent reads.
+func WithReadInfo(ctx context.Context, info *ReadInfo) context.Context {
+	return context.WithValue(ctx, readInfoKey{}, info)
+}
+
+// ReadInfoFromContext returns the ReadInfo attached to ctx by WithReadInfo,
+// or nil if there is none.
+func ReadInfoFromContext(ctx context.Context) *ReadInfo {
+	info, _ := ctx.Value(readInfoKey{}).(*ReadInfo)
+	return info
+}
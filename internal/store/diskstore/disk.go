@@ -4,27 +4,64 @@ package diskstore
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/discochess/stockpile/internal/codec"
+	"github.com/discochess/stockpile/internal/search"
+	"github.com/discochess/stockpile/internal/seekable"
 	"github.com/discochess/stockpile/internal/store"
 )
 
-// Compile-time check that Store implements store.Store.
-var _ store.Store = (*Store)(nil)
+// Compile-time checks that Store implements store.Store, store.Writer,
+// store.Lister and store.Releaser.
+var (
+	_ store.Store    = (*Store)(nil)
+	_ store.Writer   = (*Store)(nil)
+	_ store.Lister   = (*Store)(nil)
+	_ store.Releaser = (*Store)(nil)
+)
 
 // Store is a disk-based filesystem storage backend.
 type Store struct {
-	root  string
-	codec codec.Codec
+	root          string
+	codec         codec.Codec
+	maxShardBytes int64
+	frameSeeking  bool
+}
+
+// Option configures a Store, or the Store underlying an MmapStore or
+// PersistentStore.
+type Option func(*Store)
+
+// WithMaxShardBytes fails reads of shards that decompress to more than n
+// bytes with an error wrapping store.ErrShardTooLarge, instead of reading
+// them into memory whole. A limit of zero or less, the default, disables
+// the check.
+func WithMaxShardBytes(n int64) Option {
+	return func(s *Store) {
+		s.maxShardBytes = n
+	}
+}
+
+// WithFrameSeeking enables SearchShard for shards written in the seekable
+// zstd format with an offset index (.idx) next to them, so that a lookup
+// reads and decompresses only the frames that may hold its position. The
+// frames are decoded without a dictionary, so it must only be enabled for
+// plain zstd shards. Default is disabled.
+func WithFrameSeeking(enabled bool) Option {
+	return func(s *Store) {
+		s.frameSeeking = enabled
+	}
 }
 
 // New creates a new disk store rooted at the given directory.
 // The directory must exist. The codec handles compression/decompression.
-func New(root string, codec codec.Codec) (*Store, error) {
+func New(root string, codec codec.Codec, opts ...Option) (*Store, error) {
 	info, err := os.Stat(root)
 	if err != nil {
 		return nil, fmt.Errorf("stat root directory: %w", err)
@@ -33,13 +70,18 @@ func New(root string, codec codec.Codec) (*Store, error) {
 		return nil, fmt.Errorf("%s is not a directory", root)
 	}
 
-	return &Store{
+	s := &Store{
 		root:  root,
 		codec: codec,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
 }
 
-// ReadShard reads and decompresses the content of the given shard.
+// ReadShard reads and decompresses the content of the given shard into a
+// pooled buffer, which the caller may return with ReleaseShard.
 func (s *Store) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
 	// Check for cancellation before starting I/O.
 	select {
@@ -65,7 +107,7 @@ func (s *Store) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
 	}
 	defer reader.Close()
 
-	data, err := io.ReadAll(reader)
+	data, err := s.readAll(ctx, reader)
 	if err != nil {
 		return nil, fmt.Errorf("decompressing shard: %w", err)
 	}
@@ -73,6 +115,157 @@ func (s *Store) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
 	return data, nil
 }
 
+// SearchShard looks up a FEN in the given shard by reading only the frames
+// that may hold it; see search.SearchFrames. If frame seeking is disabled,
+// or the shard is missing or has no seek table or offset index, ok is false
+// and the caller should read the whole shard with ReadShard instead.
+func (s *Store) SearchShard(ctx context.Context, shardID int, fen string) (record *search.EvalRecord, ok bool, err error) {
+	if !s.frameSeeking {
+		return nil, false, nil
+	}
+	select {
+	case <-ctx.Done():
+		return nil, true, ctx.Err()
+	default:
+	}
+
+	f, err := os.Open(s.shardPath(shardID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, true, fmt.Errorf("opening shard: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, true, fmt.Errorf("stat shard: %w", err)
+	}
+
+	seek, err := s.readSeekIndex(shardID, f, info.Size())
+	if err != nil {
+		return nil, true, err
+	}
+	if seek == nil {
+		return nil, false, nil
+	}
+	record, err = search.SearchFrames(f, seek.table, seek.index, fen)
+	return record, true, err
+}
+
+// seekIndex holds what SearchShard needs to read a shard frame by frame.
+type seekIndex struct {
+	table *seekable.Table
+	index *search.Index
+}
+
+// readSeekIndex reads the offset index of a shard and the seek table of
+// its data, the size bytes of r. It returns nil if either is missing.
+func (s *Store) readSeekIndex(shardID int, r io.ReaderAt, size int64) (*seekIndex, error) {
+	text, err := os.ReadFile(s.indexPath(shardID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading shard index: %w", err)
+	}
+	index, err := search.ParseIndex(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing shard index: %w", err)
+	}
+	if index.Len() == 0 {
+		return nil, nil
+	}
+
+	table, err := seekable.ReadTableAt(r, size)
+	if err != nil {
+		if errors.Is(err, seekable.ErrNotSeekable) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading seek table: %w", err)
+	}
+	return &seekIndex{table: table, index: index}, nil
+}
+
+// ReleaseShard returns data from ReadShard to the shared buffer pool.
+// See store.Releaser for the ownership contract.
+func (s *Store) ReleaseShard(data []byte) {
+	store.Release(data)
+}
+
+// WriteShard compresses data with the codec and writes it as the given shard.
+// The file is written to a temporary path and renamed into place so readers
+// never observe a partially written shard.
+func (s *Store) WriteShard(ctx context.Context, shardID int, data []byte) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	path := s.shardPath(shardID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating shards directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+s.shardName(shardID)+"-*")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath) // No-op after a successful rename.
+
+	compressor, err := s.codec.Writer(f)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("creating compressor: %w", err)
+	}
+	if _, err := compressor.Write(data); err != nil {
+		compressor.Close()
+		f.Close()
+		return fmt.Errorf("compressing shard: %w", err)
+	}
+	if err := compressor.Close(); err != nil {
+		f.Close()
+		return fmt.Errorf("finalizing compression: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("renaming shard: %w", err)
+	}
+
+	return nil
+}
+
+// ListShards returns the IDs of the shard files in the shards directory.
+// A missing shards directory holds no shards.
+func (s *Store) ListShards(ctx context.Context) ([]int, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	entries, err := os.ReadDir(filepath.Join(s.root, "shards"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading shards directory: %w", err)
+	}
+	var ids []int
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if id, ok := store.ParseShardName(entry.Name(), s.codec.Extension()); ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
 // Close releases any resources held by the store.
 func (s *Store) Close() error {
 	return nil
@@ -83,6 +276,11 @@ func (s *Store) shardPath(shardID int) string {
 	return filepath.Join(s.root, "shards", s.shardName(shardID))
 }
 
+// indexPath returns the filesystem path for a shard's offset index.
+func (s *Store) indexPath(shardID int) string {
+	return filepath.Join(s.root, "shards", fmt.Sprintf("%05d.idx", shardID))
+}
+
 // shardName returns the filename for a shard ID.
 func (s *Store) shardName(shardID int) string {
 	name := fmt.Sprintf("%05d", shardID)
@@ -91,3 +289,9 @@ func (s *Store) shardName(shardID int) string {
 	}
 	return name
 }
+
+// readAll reads a shard's decompressor, stopping early if ctx is cancelled
+// or the shard exceeds the size limit.
+func (s *Store) readAll(ctx context.Context, r io.Reader) ([]byte, error) {
+	return store.ReadAll(store.LimitReader(store.ContextReader(ctx, r), s.maxShardBytes))
+}
@@ -1,13 +1,21 @@
 package diskstore
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
+	"github.com/klauspost/compress/zstd"
+
 	"github.com/discochess/stockpile/internal/codec/noopcodec"
+	"github.com/discochess/stockpile/internal/codec/zstdcodec"
+	"github.com/discochess/stockpile/internal/search"
+	"github.com/discochess/stockpile/internal/seekable"
 	"github.com/discochess/stockpile/internal/store"
 )
 
@@ -83,3 +91,269 @@ func TestNew_NotDirectory(t *testing.T) {
 		t.Error("New() with file (not directory) should return error")
 	}
 }
+
+func TestStore_WriteShard(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, zstdcodec.New())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	data := []byte("shard data")
+
+	if err := s.WriteShard(ctx, 7, data); err != nil {
+		t.Fatalf("WriteShard() error = %v", err)
+	}
+
+	got, err := s.ReadShard(ctx, 7)
+	if err != nil {
+		t.Fatalf("ReadShard() error = %v", err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("ReadShard() = %q, want %q", got, data)
+	}
+
+	// Overwriting replaces the shard and leaves no temp files behind.
+	if err := s.WriteShard(ctx, 7, []byte("new data")); err != nil {
+		t.Fatalf("WriteShard() error = %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "shards"))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("shards directory has %d entries, want 1", len(entries))
+	}
+}
+
+func TestStore_ListShards(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, zstdcodec.New())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+
+	// No shards directory yet.
+	ids, err := s.ListShards(ctx)
+	if err != nil || len(ids) != 0 {
+		t.Fatalf("ListShards() = %v, %v; want none", ids, err)
+	}
+
+	for _, id := range []int{12, 3, 40} {
+		if err := s.WriteShard(ctx, id, []byte("shard data")); err != nil {
+			t.Fatalf("WriteShard() error = %v", err)
+		}
+	}
+	// Neither an index nor a shard for another codec is listed.
+	for _, name := range []string{"00003.idx", "00005.gz"} {
+		if err := os.WriteFile(filepath.Join(dir, "shards", name), nil, 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	ids, err = s.ListShards(ctx)
+	if err != nil {
+		t.Fatalf("ListShards() error = %v", err)
+	}
+	if want := []int{3, 12, 40}; !slices.Equal(ids, want) {
+		t.Errorf("ListShards() = %v, want %v", ids, want)
+	}
+}
+
+// BenchmarkStore_ReadShard compares reading a shard with and without handing
+// the buffer back via ReleaseShard. With release, steady-state reads reuse
+// pooled buffers instead of allocating the decompressed shard each time.
+func BenchmarkStore_ReadShard(b *testing.B) {
+	s, err := New(b.TempDir(), zstdcodec.New())
+	if err != nil {
+		b.Fatalf("New() error = %v", err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	data := bytes.Repeat([]byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[]}`+"\n"), 10000)
+	if err := s.WriteShard(ctx, 1, data); err != nil {
+		b.Fatalf("WriteShard() error = %v", err)
+	}
+
+	for _, release := range []bool{false, true} {
+		name := "NoRelease"
+		if release {
+			name = "Release"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				got, err := s.ReadShard(ctx, 1)
+				if err != nil {
+					b.Fatalf("ReadShard() error = %v", err)
+				}
+				if release {
+					s.ReleaseShard(got)
+				}
+			}
+		})
+	}
+}
+
+// sortedRecords returns n sorted JSONL records with FENs "pos00000".."posNNNNN".
+func sortedRecords(n int) []byte {
+	var data []byte
+	for i := range n {
+		data = fmt.Appendf(data, `{"fen":"pos%05d","evals":[{"pvs":[{"cp":%d,"line":"e2e4"}],"knodes":1,"depth":20}]}`+"\n", i, i)
+	}
+	return data
+}
+
+// writeSeekableShard writes data as shard id in the seekable zstd format
+// with 4KB frames, as the builder does, and its offset index if index is
+// set.
+func writeSeekableShard(t testing.TB, dir string, id int, data []byte, index bool) {
+	t.Helper()
+	shardsDir := filepath.Join(dir, "shards")
+	if err := os.MkdirAll(shardsDir, 0755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	var buf bytes.Buffer
+	w, err := seekable.NewWriter(&buf, 4<<10, zstd.SpeedDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if _, err := w.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(shardsDir, fmt.Sprintf("%05d.zst", id)), buf.Bytes(), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if !index {
+		return
+	}
+	text, err := search.BuildIndex(data, search.DefaultIndexInterval).MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(shardsDir, fmt.Sprintf("%05d.idx", id)), text, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+// shardSearcher is the SearchShard method shared by Store and
+// PersistentStore.
+type shardSearcher interface {
+	SearchShard(ctx context.Context, shardID int, fen string) (*search.EvalRecord, bool, error)
+}
+
+// testSearchShard checks SearchShard against shard 1, seekable and
+// indexed, shard 2, seekable without an index, and shard 3, indexed but
+// written as a single plain zstd frame.
+func testSearchShard(t *testing.T, s shardSearcher) {
+	t.Helper()
+	ctx := context.Background()
+	for _, fen := range []string{"pos00000", "pos00063", "pos00064", "pos01234", "pos01999"} {
+		record, ok, err := s.SearchShard(ctx, 1, fen)
+		if err != nil || !ok {
+			t.Errorf("SearchShard(1, %q) = _, %v, %v, want ok", fen, ok, err)
+			continue
+		}
+		if record.FEN != fen {
+			t.Errorf("SearchShard(1, %q).FEN = %q", fen, record.FEN)
+		}
+	}
+	if _, ok, err := s.SearchShard(ctx, 1, "pos01234x"); !ok || !errors.Is(err, search.ErrNotFound) {
+		t.Errorf("SearchShard(1, missing) = _, %v, %v, want ok and ErrNotFound", ok, err)
+	}
+	for _, id := range []int{2, 3, 4} {
+		if _, ok, err := s.SearchShard(ctx, id, "pos00001"); ok || err != nil {
+			t.Errorf("SearchShard(%d) = _, %v, %v, want fallback", id, ok, err)
+		}
+	}
+}
+
+// writeSearchShards writes the shards testSearchShard expects.
+func writeSearchShards(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	data := sortedRecords(2000)
+	writeSeekableShard(t, dir, 1, data, true)
+	writeSeekableShard(t, dir, 2, data, false)
+
+	plain, err := New(dir, zstdcodec.New())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := plain.WriteShard(context.Background(), 3, data); err != nil {
+		t.Fatalf("WriteShard() error = %v", err)
+	}
+	text, err := search.BuildIndex(data, search.DefaultIndexInterval).MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "shards", "00003.idx"), text, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return dir
+}
+
+func TestStore_SearchShard(t *testing.T) {
+	dir := writeSearchShards(t)
+
+	s, err := New(dir, zstdcodec.New(), WithFrameSeeking(true))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	testSearchShard(t, s)
+
+	disabled, err := New(dir, zstdcodec.New())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, ok, err := disabled.SearchShard(context.Background(), 1, "pos00001"); ok || err != nil {
+		t.Errorf("SearchShard() without frame seeking = _, %v, %v, want fallback", ok, err)
+	}
+}
+
+// BenchmarkLookup_Seekable compares looking up one position by reading and
+// searching its whole shard with reading only the frames that may hold it.
+func BenchmarkLookup_Seekable(b *testing.B) {
+	dir := b.TempDir()
+	data := sortedRecords(50000)
+	writeSeekableShard(b, dir, 1, data, true)
+	s, err := New(dir, zstdcodec.New(), WithFrameSeeking(true))
+	if err != nil {
+		b.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	const fen = "pos31415"
+
+	b.Run("WholeShard", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			got, err := s.ReadShard(ctx, 1)
+			if err != nil {
+				b.Fatalf("ReadShard() error = %v", err)
+			}
+			if _, err := search.Search(got, fen); err != nil {
+				b.Fatalf("Search() error = %v", err)
+			}
+			s.ReleaseShard(got)
+		}
+	})
+	b.Run("Frames", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, _, err := s.SearchShard(ctx, 1, fen); err != nil {
+				b.Fatalf("SearchShard() error = %v", err)
+			}
+		}
+	})
+}
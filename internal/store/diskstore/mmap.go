@@ -0,0 +1,175 @@
+package diskstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/discochess/stockpile/internal/codec"
+	"github.com/discochess/stockpile/internal/store"
+)
+
+// Compile-time checks that MmapStore implements store.Store, store.Lister
+// and store.Releaser.
+var (
+	_ store.Store    = (*MmapStore)(nil)
+	_ store.Lister   = (*MmapStore)(nil)
+	_ store.Releaser = (*MmapStore)(nil)
+)
+
+// ErrClosed is returned by the ReadShard methods of MmapStore and
+// PersistentStore after Close has been called.
+var ErrClosed = errors.New("diskstore: store is closed")
+
+// MmapStore is a disk-based storage backend that memory-maps compressed
+// shard files and keeps the mappings open for the lifetime of the store.
+//
+// Compared to Store, repeated cold reads of the same shard skip the open and
+// read syscalls and let the kernel page cache serve the compressed bytes
+// directly. The cost is one open file descriptor and one mapping per shard
+// touched, held until Close; with tens of thousands of shards this can
+// exceed the process file-descriptor limit, so raise it (ulimit -n) or use
+// Store when only a small fraction of lookups hit disk.
+//
+// Each read still decompresses the whole shard, into a pooled buffer that the
+// caller may return with ReleaseShard so steady-state reads allocate little.
+type MmapStore struct {
+	base *Store
+
+	// mu guards closed and the lifetime of the mappings; mapMu guards the
+	// mapping table itself, since readers cannot upgrade mu to add entries.
+	mu      sync.RWMutex
+	closed  bool
+	mapMu   sync.Mutex
+	mapping map[int]*mappedFile
+}
+
+// mappedFile is an open shard file and its read-only memory mapping.
+type mappedFile struct {
+	file *os.File
+	data []byte
+}
+
+// NewMmap creates a new memory-mapping disk store rooted at the given
+// directory. The directory must exist. The codec handles decompression.
+func NewMmap(root string, codec codec.Codec, opts ...Option) (*MmapStore, error) {
+	base, err := New(root, codec, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MmapStore{
+		base:    base,
+		mapping: make(map[int]*mappedFile),
+	}, nil
+}
+
+// ReadShard decompresses the content of the given shard from its mapping,
+// mapping the shard file on first access.
+func (s *MmapStore) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
+	// Check for cancellation before starting I/O.
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	// Hold the read lock while decompressing so Close cannot unmap the
+	// bytes underneath us.
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	mf, err := s.mapped(shardID)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := s.base.codec.Reader(bytes.NewReader(mf.data))
+	if err != nil {
+		return nil, fmt.Errorf("creating decompressor: %w", err)
+	}
+	defer reader.Close()
+
+	data, err := s.base.readAll(ctx, reader)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing shard: %w", err)
+	}
+	return data, nil
+}
+
+// ReleaseShard returns data from ReadShard to the shared buffer pool.
+// See store.Releaser for the ownership contract.
+func (s *MmapStore) ReleaseShard(data []byte) {
+	store.Release(data)
+}
+
+// ListShards returns the IDs of the shard files in the shards directory.
+func (s *MmapStore) ListShards(ctx context.Context) ([]int, error) {
+	return s.base.ListShards(ctx)
+}
+
+// mapped returns the mapping for a shard, creating it if needed.
+// The caller must hold s.mu for reading.
+func (s *MmapStore) mapped(shardID int) (*mappedFile, error) {
+	s.mapMu.Lock()
+	defer s.mapMu.Unlock()
+
+	if mf, ok := s.mapping[shardID]; ok {
+		return mf, nil
+	}
+
+	f, err := os.Open(s.base.shardPath(shardID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, store.ErrNotFound
+		}
+		return nil, fmt.Errorf("opening shard: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("stat shard: %w", err)
+	}
+
+	data, err := mapFile(f, int(info.Size()))
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("mapping shard: %w", err)
+	}
+
+	mf := &mappedFile{file: f, data: data}
+	s.mapping[shardID] = mf
+	return mf, nil
+}
+
+// Close unmaps all shards and closes their file handles.
+func (s *MmapStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var errs []error
+	for id, mf := range s.mapping {
+		if err := unmapFile(mf.data); err != nil {
+			errs = append(errs, fmt.Errorf("unmapping shard %d: %w", id, err))
+		}
+		if err := mf.file.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("closing shard %d: %w", id, err))
+		}
+	}
+	s.mapping = nil
+
+	return errors.Join(errs...)
+}
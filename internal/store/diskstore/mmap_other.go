@@ -0,0 +1,23 @@
+//go:build !unix
+
+package diskstore
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads f fully into memory on platforms without mmap support.
+// The file handle is still kept open so behavior matches the unix build.
+func mapFile(f *os.File, size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// unmapFile is a no-op; the buffer is reclaimed by the garbage collector.
+func unmapFile(data []byte) error {
+	return nil
+}
@@ -0,0 +1,85 @@
+package diskstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/discochess/stockpile/internal/codec/noopcodec"
+	"github.com/discochess/stockpile/internal/codec/zstdcodec"
+	"github.com/discochess/stockpile/internal/store"
+)
+
+func TestMmapStore_ReadShard(t *testing.T) {
+	dir := t.TempDir()
+	codec := zstdcodec.New()
+
+	shardsDir := filepath.Join(dir, "shards")
+	if err := os.MkdirAll(shardsDir, 0755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	data := []byte("shard data")
+	var compressed bytes.Buffer
+	w, err := codec.Writer(&compressed)
+	if err != nil {
+		t.Fatalf("Writer() error = %v", err)
+	}
+	w.Write(data)
+	w.Close()
+
+	shardPath := filepath.Join(shardsDir, "00001."+codec.Extension())
+	if err := os.WriteFile(shardPath, compressed.Bytes(), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	s, err := NewMmap(dir, codec)
+	if err != nil {
+		t.Fatalf("NewMmap() error = %v", err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+
+	// Read twice to exercise the cached mapping and buffer reuse.
+	for i := 0; i < 2; i++ {
+		got, err := s.ReadShard(ctx, 1)
+		if err != nil {
+			t.Fatalf("ReadShard() error = %v", err)
+		}
+		if string(got) != string(data) {
+			t.Errorf("ReadShard() = %q, want %q", got, data)
+		}
+	}
+}
+
+func TestMmapStore_ReadShardNotFound(t *testing.T) {
+	s, err := NewMmap(t.TempDir(), noopcodec.New())
+	if err != nil {
+		t.Fatalf("NewMmap() error = %v", err)
+	}
+	defer s.Close()
+
+	_, err = s.ReadShard(context.Background(), 99999)
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ReadShard() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMmapStore_ReadShardAfterClose(t *testing.T) {
+	s, err := NewMmap(t.TempDir(), noopcodec.New())
+	if err != nil {
+		t.Fatalf("NewMmap() error = %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	_, err = s.ReadShard(context.Background(), 1)
+	if !errors.Is(err, ErrClosed) {
+		t.Errorf("ReadShard() error = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package diskstore
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps size bytes of f read-only into memory.
+func mapFile(f *os.File, size int) ([]byte, error) {
+	if size == 0 {
+		// Zero-length mappings are rejected by mmap(2).
+		return []byte{}, nil
+	}
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+// unmapFile releases a mapping created by mapFile.
+func unmapFile(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,302 @@
+package diskstore
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/discochess/stockpile/internal/codec"
+	"github.com/discochess/stockpile/internal/search"
+	"github.com/discochess/stockpile/internal/store"
+)
+
+// Compile-time checks that PersistentStore implements store.Store,
+// store.Lister and store.Releaser.
+var (
+	_ store.Store    = (*PersistentStore)(nil)
+	_ store.Lister   = (*PersistentStore)(nil)
+	_ store.Releaser = (*PersistentStore)(nil)
+)
+
+// DefaultMaxOpenFiles is the default number of shard files a PersistentStore
+// keeps open.
+const DefaultMaxOpenFiles = 256
+
+// PersistentStore is a disk-based storage backend that keeps shard files
+// open between reads, so repeated reads of a shard skip the open and close
+// syscalls. Files are opened on first access; once more than the configured
+// number are open, the least recently read is closed.
+//
+// With WithLineIndex, the store also indexes the lines of each shard it
+// keeps open, so that lookups can search it without splitting it again; see
+// ReadShardIndexed.
+//
+// A shard file replaced while open, for example by a rebuild, keeps being
+// read from the old file until it is evicted or the store is closed.
+type PersistentStore struct {
+	base      *Store
+	maxOpen   int
+	lineIndex bool
+
+	mu     sync.Mutex
+	closed bool
+	files  map[int]*list.Element // Values are *openFile.
+	lru    *list.List            // Most recently read first.
+}
+
+// openFile is an open shard file and the reads using it.
+type openFile struct {
+	id    int
+	file  *os.File
+	size  int64
+	lines *search.LineIndex // Guarded by PersistentStore.mu.
+
+	// Seek table and offset index for SearchShard, once loaded; seek is
+	// nil if the shard has none. Guarded by PersistentStore.mu.
+	seek       *seekIndex
+	seekLoaded bool
+
+	refs    int  // Reads in progress; guarded by PersistentStore.mu.
+	evicted bool // Closed once refs drops to zero.
+}
+
+// PersistentOption configures a PersistentStore.
+type PersistentOption func(*PersistentStore)
+
+// WithMaxOpenFiles bounds the number of shard files kept open.
+// Default is DefaultMaxOpenFiles; values below 1 are treated as 1.
+func WithMaxOpenFiles(n int) PersistentOption {
+	return func(s *PersistentStore) {
+		s.maxOpen = max(n, 1)
+	}
+}
+
+// WithLineIndex enables indexing the lines of open shards for
+// ReadShardIndexed. The index of a shard costs two ints per record and is
+// dropped when its file is closed.
+func WithLineIndex(enabled bool) PersistentOption {
+	return func(s *PersistentStore) {
+		s.lineIndex = enabled
+	}
+}
+
+// WithStoreOptions applies Store options, such as WithMaxShardBytes, to
+// the reads of a PersistentStore.
+func WithStoreOptions(opts ...Option) PersistentOption {
+	return func(s *PersistentStore) {
+		for _, opt := range opts {
+			opt(s.base)
+		}
+	}
+}
+
+// NewPersistent creates a new disk store rooted at the given directory that
+// keeps shard files open. The directory must exist. The codec handles
+// decompression.
+func NewPersistent(root string, codec codec.Codec, opts ...PersistentOption) (*PersistentStore, error) {
+	base, err := New(root, codec)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &PersistentStore{
+		base:    base,
+		maxOpen: DefaultMaxOpenFiles,
+		files:   make(map[int]*list.Element),
+		lru:     list.New(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// ReadShard reads and decompresses the content of the given shard into a
+// pooled buffer, which the caller may return with ReleaseShard.
+func (s *PersistentStore) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
+	data, _, err := s.read(ctx, shardID, false)
+	return data, err
+}
+
+// ReadShardIndexed is like ReadShard, but also returns the line index of
+// the shard data if the store was created with WithLineIndex. The index is
+// built on the first read of the shard and reused while its file stays open.
+func (s *PersistentStore) ReadShardIndexed(ctx context.Context, shardID int) ([]byte, *search.LineIndex, error) {
+	return s.read(ctx, shardID, s.lineIndex)
+}
+
+func (s *PersistentStore) read(ctx context.Context, shardID int, index bool) ([]byte, *search.LineIndex, error) {
+	// Check for cancellation before starting I/O.
+	select {
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	default:
+	}
+
+	of, err := s.acquire(shardID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer s.release(of)
+
+	reader, err := s.base.codec.Reader(io.NewSectionReader(of.file, 0, of.size))
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating decompressor: %w", err)
+	}
+	defer reader.Close()
+
+	data, err := s.base.readAll(ctx, reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decompressing shard: %w", err)
+	}
+	if !index {
+		return data, nil, nil
+	}
+
+	s.mu.Lock()
+	lines := of.lines
+	s.mu.Unlock()
+	if lines == nil {
+		lines = search.NewLineIndex(data)
+		s.mu.Lock()
+		of.lines = lines
+		s.mu.Unlock()
+	}
+	return data, lines, nil
+}
+
+// SearchShard is like Store.SearchShard, but reads through the open shard
+// file and keeps its seek table and offset index while the file stays
+// open. Frame seeking is enabled with WithStoreOptions(WithFrameSeeking(true)).
+func (s *PersistentStore) SearchShard(ctx context.Context, shardID int, fen string) (record *search.EvalRecord, ok bool, err error) {
+	if !s.base.frameSeeking {
+		return nil, false, nil
+	}
+	select {
+	case <-ctx.Done():
+		return nil, true, ctx.Err()
+	default:
+	}
+
+	of, err := s.acquire(shardID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, false, nil
+		}
+		return nil, true, err
+	}
+	defer s.release(of)
+
+	s.mu.Lock()
+	seek, loaded := of.seek, of.seekLoaded
+	s.mu.Unlock()
+	if !loaded {
+		if seek, err = s.base.readSeekIndex(shardID, of.file, of.size); err != nil {
+			return nil, true, err
+		}
+		s.mu.Lock()
+		of.seek, of.seekLoaded = seek, true
+		s.mu.Unlock()
+	}
+	if seek == nil {
+		return nil, false, nil
+	}
+	record, err = search.SearchFrames(of.file, seek.table, seek.index, fen)
+	return record, true, err
+}
+
+// ReleaseShard returns data from ReadShard to the shared buffer pool.
+// See store.Releaser for the ownership contract.
+func (s *PersistentStore) ReleaseShard(data []byte) {
+	store.Release(data)
+}
+
+// ListShards returns the IDs of the shard files in the shards directory.
+func (s *PersistentStore) ListShards(ctx context.Context) ([]int, error) {
+	return s.base.ListShards(ctx)
+}
+
+// acquire returns the open file of a shard, opening it if needed, and holds
+// it open until release.
+func (s *PersistentStore) acquire(shardID int) (*openFile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+	if el, ok := s.files[shardID]; ok {
+		s.lru.MoveToFront(el)
+		of := el.Value.(*openFile)
+		of.refs++
+		return of, nil
+	}
+
+	f, err := os.Open(s.base.shardPath(shardID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, store.ErrNotFound
+		}
+		return nil, fmt.Errorf("opening shard: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("stat shard: %w", err)
+	}
+
+	of := &openFile{id: shardID, file: f, size: info.Size(), refs: 1}
+	s.files[shardID] = s.lru.PushFront(of)
+	for s.lru.Len() > s.maxOpen {
+		s.evict(s.lru.Back())
+	}
+	return of, nil
+}
+
+// release ends a read started by acquire.
+func (s *PersistentStore) release(of *openFile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	of.refs--
+	if of.evicted && of.refs == 0 {
+		of.file.Close()
+	}
+}
+
+// evict stops tracking an open file, closing it unless reads are still
+// using it. The caller must hold s.mu.
+func (s *PersistentStore) evict(el *list.Element) error {
+	of := s.lru.Remove(el).(*openFile)
+	delete(s.files, of.id)
+	of.evicted = true
+	if of.refs == 0 {
+		return of.file.Close()
+	}
+	return nil
+}
+
+// Close closes all shard files. Files still being read are closed when
+// their reads finish.
+func (s *PersistentStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var errs []error
+	for s.lru.Len() > 0 {
+		id := s.lru.Back().Value.(*openFile).id
+		if err := s.evict(s.lru.Back()); err != nil {
+			errs = append(errs, fmt.Errorf("closing shard %d: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
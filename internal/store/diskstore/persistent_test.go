@@ -0,0 +1,210 @@
+package diskstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/discochess/stockpile/internal/codec/noopcodec"
+	"github.com/discochess/stockpile/internal/codec/zstdcodec"
+	"github.com/discochess/stockpile/internal/store"
+)
+
+// writeShards writes shards 0..n-1 to a new directory, each holding its ID.
+func writeShards(t testing.TB, n int) string {
+	t.Helper()
+	dir := t.TempDir()
+	s, err := New(dir, zstdcodec.New())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for id := range n {
+		if err := s.WriteShard(context.Background(), id, fmt.Appendf(nil, "shard %d\n", id)); err != nil {
+			t.Fatalf("WriteShard() error = %v", err)
+		}
+	}
+	return dir
+}
+
+func TestPersistentStore_ReadShard(t *testing.T) {
+	s, err := NewPersistent(writeShards(t, 3), zstdcodec.New(), WithMaxOpenFiles(2))
+	if err != nil {
+		t.Fatalf("NewPersistent() error = %v", err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	for _, id := range []int{0, 1, 0, 2, 1, 1} {
+		got, err := s.ReadShard(ctx, id)
+		if err != nil {
+			t.Fatalf("ReadShard(%d) error = %v", id, err)
+		}
+		if want := fmt.Sprintf("shard %d\n", id); string(got) != want {
+			t.Errorf("ReadShard(%d) = %q, want %q", id, got, want)
+		}
+		s.ReleaseShard(got)
+	}
+
+	// Shard 0 was least recently read when shard 2 was opened.
+	if s.lru.Len() != 2 {
+		t.Errorf("%d files open, want 2", s.lru.Len())
+	}
+	if _, ok := s.files[0]; ok {
+		t.Error("shard 0 still open, want it evicted")
+	}
+}
+
+func TestPersistentStore_ReadShardNotFound(t *testing.T) {
+	s, err := NewPersistent(t.TempDir(), noopcodec.New())
+	if err != nil {
+		t.Fatalf("NewPersistent() error = %v", err)
+	}
+	defer s.Close()
+
+	if _, err := s.ReadShard(context.Background(), 99999); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ReadShard() error = %v, want ErrNotFound", err)
+	}
+	if s.lru.Len() != 0 {
+		t.Errorf("%d files open after a missing shard, want 0", s.lru.Len())
+	}
+}
+
+func TestPersistentStore_ReadShardAfterClose(t *testing.T) {
+	s, err := NewPersistent(writeShards(t, 1), zstdcodec.New())
+	if err != nil {
+		t.Fatalf("NewPersistent() error = %v", err)
+	}
+	if _, err := s.ReadShard(context.Background(), 0); err != nil {
+		t.Fatalf("ReadShard() error = %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if _, err := s.ReadShard(context.Background(), 0); !errors.Is(err, ErrClosed) {
+		t.Errorf("ReadShard() error = %v, want ErrClosed", err)
+	}
+}
+
+func TestPersistentStore_EvictWhileReading(t *testing.T) {
+	s, err := NewPersistent(writeShards(t, 2), zstdcodec.New(), WithMaxOpenFiles(1))
+	if err != nil {
+		t.Fatalf("NewPersistent() error = %v", err)
+	}
+	defer s.Close()
+
+	// Hold shard 0 as a read in progress would, then evict it.
+	of, err := s.acquire(0)
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	if _, err := s.ReadShard(context.Background(), 1); err != nil {
+		t.Fatalf("ReadShard() error = %v", err)
+	}
+	if !of.evicted {
+		t.Fatal("shard 0 not evicted")
+	}
+
+	// The evicted file stays usable until the read releases it.
+	buf := make([]byte, 1)
+	if _, err := of.file.ReadAt(buf, 0); err != nil {
+		t.Errorf("ReadAt() on evicted file in use error = %v", err)
+	}
+	s.release(of)
+	if _, err := of.file.ReadAt(buf, 0); err == nil {
+		t.Error("ReadAt() after release succeeded, want the file closed")
+	}
+}
+
+func TestPersistentStore_ReadShardIndexed(t *testing.T) {
+	dir := writeShards(t, 1)
+	for _, index := range []bool{false, true} {
+		s, err := NewPersistent(dir, zstdcodec.New(), WithLineIndex(index))
+		if err != nil {
+			t.Fatalf("NewPersistent() error = %v", err)
+		}
+
+		data, first, err := s.ReadShardIndexed(context.Background(), 0)
+		if err != nil {
+			t.Fatalf("ReadShardIndexed() error = %v", err)
+		}
+		if !bytes.Equal(data, []byte("shard 0\n")) {
+			t.Errorf("ReadShardIndexed() data = %q", data)
+		}
+		_, second, err := s.ReadShardIndexed(context.Background(), 0)
+		if err != nil {
+			t.Fatalf("ReadShardIndexed() error = %v", err)
+		}
+
+		if !index {
+			if first != nil || second != nil {
+				t.Error("ReadShardIndexed() returned an index without WithLineIndex")
+			}
+		} else if first == nil || first != second || first.Len() != 1 {
+			t.Errorf("ReadShardIndexed() indexes = %p, %p; want one reused index of 1 line", first, second)
+		}
+		s.Close()
+	}
+}
+
+func TestPersistentStore_SearchShard(t *testing.T) {
+	s, err := NewPersistent(writeSearchShards(t), zstdcodec.New(), WithStoreOptions(WithFrameSeeking(true)))
+	if err != nil {
+		t.Fatalf("NewPersistent() error = %v", err)
+	}
+	defer s.Close()
+
+	testSearchShard(t, s)
+	// The second pass reuses the seek tables loaded by the first.
+	testSearchShard(t, s)
+}
+
+// BenchmarkReadShard_Warm compares repeated reads of one shard through each
+// disk store.
+func BenchmarkReadShard_Warm(b *testing.B) {
+	dir := b.TempDir()
+	disk, err := New(dir, zstdcodec.New())
+	if err != nil {
+		b.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	data := bytes.Repeat([]byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[]}`+"\n"), 10000)
+	if err := disk.WriteShard(ctx, 1, data); err != nil {
+		b.Fatalf("WriteShard() error = %v", err)
+	}
+	mmap, err := NewMmap(dir, zstdcodec.New())
+	if err != nil {
+		b.Fatalf("NewMmap() error = %v", err)
+	}
+	persistent, err := NewPersistent(dir, zstdcodec.New())
+	if err != nil {
+		b.Fatalf("NewPersistent() error = %v", err)
+	}
+
+	for _, bc := range []struct {
+		name string
+		st   interface {
+			store.Store
+			store.Releaser
+		}
+	}{
+		{"Disk", disk},
+		{"Mmap", mmap},
+		{"Persistent", persistent},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				got, err := bc.st.ReadShard(ctx, 1)
+				if err != nil {
+					b.Fatalf("ReadShard() error = %v", err)
+				}
+				bc.st.ReleaseShard(got)
+			}
+		})
+		bc.st.Close()
+	}
+}
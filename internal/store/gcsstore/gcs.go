@@ -4,17 +4,24 @@ package gcsstore
 import (
 	"context"
 	"fmt"
-	"io"
+	"sort"
 	"strings"
 
 	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
 
 	"github.com/discochess/stockpile/internal/codec"
 	"github.com/discochess/stockpile/internal/store"
 )
 
-// Compile-time check that Store implements store.Store.
-var _ store.Store = (*Store)(nil)
+// Compile-time checks that Store implements store.Store, store.Writer,
+// store.Lister and store.Releaser.
+var (
+	_ store.Store    = (*Store)(nil)
+	_ store.Writer   = (*Store)(nil)
+	_ store.Lister   = (*Store)(nil)
+	_ store.Releaser = (*Store)(nil)
+)
 
 // Store is a Google Cloud Storage backend.
 type Store struct {
@@ -22,6 +29,8 @@ type Store struct {
 	bucket *storage.BucketHandle
 	prefix string
 	codec  codec.Codec
+
+	maxShardBytes int64
 }
 
 // New creates a new GCS store.
@@ -59,7 +68,16 @@ func WithPrefix(prefix string) Option {
 	}
 }
 
-// ReadShard reads and decompresses the content of the given shard.
+// WithMaxShardBytes fails reads of objects that decompress to more than n
+// bytes; see store.LimitReader. Default is no limit.
+func WithMaxShardBytes(n int64) Option {
+	return func(s *Store) {
+		s.maxShardBytes = n
+	}
+}
+
+// ReadShard reads and decompresses the content of the given shard into a
+// pooled buffer, which the caller may return with ReleaseShard.
 func (s *Store) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
 	// Check for cancellation before starting.
 	select {
@@ -86,7 +104,7 @@ func (s *Store) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
 	}
 	defer decompressor.Close()
 
-	data, err := io.ReadAll(decompressor)
+	data, err := store.ReadAll(store.LimitReader(store.ContextReader(ctx, decompressor), s.maxShardBytes))
 	if err != nil {
 		return nil, fmt.Errorf("decompressing shard: %w", err)
 	}
@@ -94,6 +112,60 @@ func (s *Store) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
 	return data, nil
 }
 
+// ReleaseShard returns data from ReadShard to the shared buffer pool.
+// See store.Releaser for the ownership contract.
+func (s *Store) ReleaseShard(data []byte) {
+	store.Release(data)
+}
+
+// WriteShard compresses data with the codec and uploads it as the given shard.
+func (s *Store) WriteShard(ctx context.Context, shardID int, data []byte) error {
+	// Cancelling ctx aborts the upload, so a failed write leaves no object.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer := s.bucket.Object(s.shardKey(shardID)).NewWriter(ctx)
+
+	compressor, err := s.codec.Writer(writer)
+	if err != nil {
+		return fmt.Errorf("creating compressor: %w", err)
+	}
+	if _, err := compressor.Write(data); err != nil {
+		return fmt.Errorf("compressing shard: %w", err)
+	}
+	if err := compressor.Close(); err != nil {
+		return fmt.Errorf("finalizing compression: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("writing shard: %w", err)
+	}
+
+	return nil
+}
+
+// ListShards returns the IDs of the shard objects under the shards/ prefix.
+func (s *Store) ListShards(ctx context.Context) ([]int, error) {
+	prefix := s.prefix + "shards/"
+	it := s.bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+
+	var ids []int
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("listing shards: %w", err)
+		}
+		if id, ok := store.ParseShardName(strings.TrimPrefix(attrs.Name, prefix), s.codec.Extension()); ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
 // Close releases resources.
 func (s *Store) Close() error {
 	return s.client.Close()
@@ -9,6 +9,7 @@ import (
 	"cloud.google.com/go/storage"
 	"github.com/klauspost/compress/zstd"
 
+	"github.com/discochess/stockpile/internal/codec/noopcodec"
 	"github.com/discochess/stockpile/internal/codec/zstdcodec"
 	"github.com/discochess/stockpile/internal/store"
 )
@@ -136,6 +137,13 @@ func TestStore_shardName(t *testing.T) {
 	}
 }
 
+func TestStore_shardName_NoExtension(t *testing.T) {
+	s := &Store{codec: noopcodec.New()}
+	if got := s.shardName(42); got != "00042" {
+		t.Errorf("shardName(42) = %q, want %q", got, "00042")
+	}
+}
+
 // TestStore_ReadShard_NotFound tests that ErrNotFound is returned for missing objects.
 // This is a unit test that doesn't require actual GCS access.
 func TestStore_ErrNotFound_Mapping(t *testing.T) {
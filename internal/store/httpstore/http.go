@@ -0,0 +1,152 @@
+// Package httpstore implements a read-only HTTP(S) backend, for shards
+// served by a web server or CDN.
+package httpstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/discochess/stockpile/internal/codec"
+	"github.com/discochess/stockpile/internal/store"
+)
+
+// Compile-time check that Store implements store.Store.
+var _ store.Store = (*Store)(nil)
+
+// Store is a read-only HTTP(S) backend.
+// Requests carry no cache directives, so responses may be served from any
+// HTTP cache in between, such as a CDN.
+type Store struct {
+	client  *http.Client
+	baseURL string
+	prefix  string
+	codec   codec.Codec
+
+	maxShardBytes int64
+}
+
+// New creates a new HTTP store that reads shards from
+// baseURL/[prefix/]shards/<id>.<ext>.
+// The codec handles decompression.
+func New(baseURL string, c codec.Codec, opts ...Option) (*Store, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+
+	s := &Store{
+		client:  http.DefaultClient,
+		baseURL: strings.TrimSuffix(baseURL, "/") + "/",
+		codec:   c,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithHTTPClient sets the HTTP client used for requests.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Store) {
+		s.client = client
+	}
+}
+
+// WithPrefix sets a path prefix, relative to the base URL, for all shards.
+func WithPrefix(prefix string) Option {
+	return func(s *Store) {
+		s.prefix = strings.Trim(prefix, "/")
+		if s.prefix != "" {
+			s.prefix += "/"
+		}
+	}
+}
+
+// WithMaxShardBytes fails reads of shards that decompress to more than n
+// bytes, guarding against a server sending a decompression bomb; see
+// store.LimitReader. Default is no limit.
+func WithMaxShardBytes(n int64) Option {
+	return func(s *Store) {
+		s.maxShardBytes = n
+	}
+}
+
+// ReadShard fetches and decompresses the content of the given shard.
+func (s *Store) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
+	// Check for cancellation before starting.
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.shardURL(shardID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching shard: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, store.ErrNotFound
+	default:
+		return nil, fmt.Errorf("fetching shard: unexpected status: %s", resp.Status)
+	}
+
+	// Decompress using codec.
+	decompressor, err := s.codec.Reader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("creating decompressor: %w", err)
+	}
+	defer decompressor.Close()
+
+	data, err := io.ReadAll(store.LimitReader(store.ContextReader(ctx, decompressor), s.maxShardBytes))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing shard: %w", err)
+	}
+
+	return data, nil
+}
+
+// Close releases idle connections held by the HTTP client.
+func (s *Store) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// shardURL returns the full URL for a shard.
+func (s *Store) shardURL(shardID int) string {
+	return s.baseURL + s.shardKey(shardID)
+}
+
+// shardKey returns the path of a shard relative to the base URL.
+func (s *Store) shardKey(shardID int) string {
+	return s.prefix + "shards/" + s.shardName(shardID)
+}
+
+// shardName returns the filename for a shard ID.
+func (s *Store) shardName(shardID int) string {
+	name := fmt.Sprintf("%05d", shardID)
+	if ext := s.codec.Extension(); ext != "" {
+		name += "." + ext
+	}
+	return name
+}
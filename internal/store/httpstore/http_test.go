@@ -0,0 +1,160 @@
+package httpstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/discochess/stockpile/internal/codec/zstdcodec"
+	"github.com/discochess/stockpile/internal/store"
+)
+
+// compress returns data compressed with the zstd codec.
+func compress(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := zstdcodec.New().Writer(&buf)
+	if err != nil {
+		t.Fatalf("Writer() error = %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestNew_InvalidURL(t *testing.T) {
+	for _, baseURL := range []string{"", "ftp://example.com", "://bad"} {
+		if _, err := New(baseURL, zstdcodec.New()); err == nil {
+			t.Errorf("New(%q) error = nil, want error", baseURL)
+		}
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", ""},
+		{"prefix", "prefix/"},
+		{"prefix/", "prefix/"},
+		{"/a/b/c/", "a/b/c/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			s := &Store{}
+			WithPrefix(tt.input)(s)
+			if s.prefix != tt.want {
+				t.Errorf("prefix = %q, want %q", s.prefix, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_ReadShard(t *testing.T) {
+	content := []byte(`{"fen":"8/8/8/8/8/8/8/8 w - -","evals":[]}` + "\n")
+	compressed := compress(t, content)
+
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if r.URL.Path != "/cdn/v1/shards/00042.zst" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(compressed)
+	}))
+	defer srv.Close()
+
+	s, err := New(srv.URL+"/cdn/", zstdcodec.New(), WithPrefix("v1"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer s.Close()
+
+	data, err := s.ReadShard(context.Background(), 42)
+	if err != nil {
+		t.Fatalf("ReadShard() error = %v (path %q)", err, gotPath)
+	}
+	if !bytes.Equal(data, content) {
+		t.Errorf("ReadShard() = %q, want %q", data, content)
+	}
+}
+
+func TestStore_ReadShard_NotFound(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	s, err := New(srv.URL, zstdcodec.New())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer s.Close()
+
+	if _, err := s.ReadShard(context.Background(), 1); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ReadShard() error = %v, want %v", err, store.ErrNotFound)
+	}
+}
+
+func TestStore_ReadShard_ServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	s, err := New(srv.URL, zstdcodec.New())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer s.Close()
+
+	_, err = s.ReadShard(context.Background(), 1)
+	if err == nil || errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ReadShard() error = %v, want non-NotFound error", err)
+	}
+}
+
+func TestStore_ReadShard_ContextCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	s, err := New(srv.URL, zstdcodec.New(), WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := s.ReadShard(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadShard() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestStore_shardKey(t *testing.T) {
+	s := &Store{codec: zstdcodec.New()}
+
+	tests := []struct {
+		shardID int
+		want    string
+	}{
+		{0, "shards/00000.zst"},
+		{1, "shards/00001.zst"},
+		{99999, "shards/99999.zst"},
+	}
+
+	for _, tt := range tests {
+		if got := s.shardKey(tt.shardID); got != tt.want {
+			t.Errorf("shardKey(%d) = %q, want %q", tt.shardID, got, tt.want)
+		}
+	}
+}
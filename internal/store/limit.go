@@ -0,0 +1,33 @@
+package store
+
+import (
+	"fmt"
+	"io"
+)
+
+// LimitReader returns a reader that reads from r but fails with an error
+// wrapping ErrShardTooLarge once r yields more than limit bytes. Stores wrap
+// their decompressors with it so that a corrupt or hostile shard cannot
+// decompress into more memory than the process can spare. A limit of zero
+// or less returns r unchanged.
+func LimitReader(r io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+	return &limitReader{r: io.LimitReader(r, limit+1), limit: limit}
+}
+
+type limitReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func (r *limitReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	if r.read > r.limit {
+		return n, fmt.Errorf("%w: over %d bytes decompressed", ErrShardTooLarge, r.limit)
+	}
+	return n, err
+}
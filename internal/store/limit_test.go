@@ -0,0 +1,120 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/discochess/stockpile/internal/codec/zstdcodec"
+	"github.com/discochess/stockpile/internal/store"
+	"github.com/discochess/stockpile/internal/store/diskstore"
+	"github.com/discochess/stockpile/internal/store/gcsstore"
+	"github.com/discochess/stockpile/internal/store/httpstore"
+	"github.com/discochess/stockpile/internal/store/s3store"
+)
+
+func TestLimitReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int64
+		wantErr error
+	}{
+		{"no limit", 0, nil},
+		{"at limit", 10, nil},
+		{"over limit", 9, store.ErrShardTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := io.ReadAll(store.LimitReader(strings.NewReader("0123456789"), tt.limit))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadAll() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && string(data) != "0123456789" {
+				t.Errorf("ReadAll() = %q, want the whole input", data)
+			}
+		})
+	}
+}
+
+func TestReadShard_MaxShardBytes(t *testing.T) {
+	ctx := context.Background()
+	c := zstdcodec.New()
+	shard := bytes.Repeat([]byte("x"), 1000) // Compresses to a few bytes.
+
+	dir := t.TempDir()
+	disk, err := diskstore.New(dir, c)
+	if err != nil {
+		t.Fatalf("diskstore.New() error = %v", err)
+	}
+	if err := disk.WriteShard(ctx, 0, shard); err != nil {
+		t.Fatalf("WriteShard() error = %v", err)
+	}
+
+	var compressed bytes.Buffer
+	w, err := c.Writer(&compressed)
+	if err != nil {
+		t.Fatalf("Writer() error = %v", err)
+	}
+	w.Write(shard)
+	w.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(compressed.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+
+	// Point the cloud clients at srv, which serves the shard for any path.
+	t.Setenv("STORAGE_EMULATOR_HOST", srv.Listener.Addr().String())
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	for _, limit := range []int64{1000, 999} {
+		limited, err := diskstore.New(dir, c, diskstore.WithMaxShardBytes(limit))
+		if err != nil {
+			t.Fatalf("diskstore.New() error = %v", err)
+		}
+		mmap, err := diskstore.NewMmap(dir, c, diskstore.WithMaxShardBytes(limit))
+		if err != nil {
+			t.Fatalf("diskstore.NewMmap() error = %v", err)
+		}
+		persistent, err := diskstore.NewPersistent(dir, c,
+			diskstore.WithStoreOptions(diskstore.WithMaxShardBytes(limit)))
+		if err != nil {
+			t.Fatalf("diskstore.NewPersistent() error = %v", err)
+		}
+		hs, err := httpstore.New(srv.URL, c, httpstore.WithMaxShardBytes(limit))
+		if err != nil {
+			t.Fatalf("httpstore.New() error = %v", err)
+		}
+		gcs, err := gcsstore.New(ctx, "bucket", c, gcsstore.WithMaxShardBytes(limit))
+		if err != nil {
+			t.Fatalf("gcsstore.New() error = %v", err)
+		}
+		s3, err := s3store.New(ctx, "bucket", c, s3store.WithEndpoint(srv.URL), s3store.WithMaxShardBytes(limit))
+		if err != nil {
+			t.Fatalf("s3store.New() error = %v", err)
+		}
+
+		var wantErr error
+		if limit < int64(len(shard)) {
+			wantErr = store.ErrShardTooLarge
+		}
+		for name, st := range map[string]store.Store{
+			"disk": limited, "mmap": mmap, "persistent": persistent, "http": hs, "gcs": gcs, "s3": s3,
+		} {
+			data, err := st.ReadShard(ctx, 0)
+			if !errors.Is(err, wantErr) {
+				t.Errorf("%s: ReadShard() with limit %d error = %v, want %v", name, limit, err, wantErr)
+			} else if err == nil && !bytes.Equal(data, shard) {
+				t.Errorf("%s: ReadShard() with limit %d returned %d bytes, want the shard", name, limit, len(data))
+			}
+			st.Close()
+		}
+	}
+}
@@ -8,8 +8,11 @@ import (
 	"github.com/discochess/stockpile/internal/store"
 )
 
-// Compile-time check that Store implements store.Store.
-var _ store.Store = (*Store)(nil)
+// Compile-time checks that Store implements store.Store and store.Writer.
+var (
+	_ store.Store  = (*Store)(nil)
+	_ store.Writer = (*Store)(nil)
+)
 
 // Store is an in-memory store for testing.
 type Store struct {
@@ -34,8 +37,18 @@ func (s *Store) SetShard(shardID int, data []byte) {
 	s.shards[shardID] = copied
 }
 
+// WriteShard stores a shard in memory. Data is kept uncompressed.
+func (s *Store) WriteShard(ctx context.Context, shardID int, data []byte) error {
+	s.SetShard(shardID, data)
+	return nil
+}
+
 // ReadShard reads a shard from memory.
 func (s *Store) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -2,10 +2,11 @@
 package s3store
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
-	"io"
+	"sort"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -17,8 +18,14 @@ import (
 	"github.com/discochess/stockpile/internal/store"
 )
 
-// Compile-time check that Store implements store.Store.
-var _ store.Store = (*Store)(nil)
+// Compile-time checks that Store implements store.Store, store.Writer,
+// store.Lister and store.Releaser.
+var (
+	_ store.Store    = (*Store)(nil)
+	_ store.Writer   = (*Store)(nil)
+	_ store.Lister   = (*Store)(nil)
+	_ store.Releaser = (*Store)(nil)
+)
 
 // Store is an AWS S3 storage backend.
 type Store struct {
@@ -26,6 +33,8 @@ type Store struct {
 	bucket string
 	prefix string
 	codec  codec.Codec
+
+	maxShardBytes int64
 }
 
 // New creates a new S3 store.
@@ -93,7 +102,17 @@ func WithEndpoint(endpoint string) Option {
 	}
 }
 
-// ReadShard reads and decompresses the content of the given shard.
+// WithMaxShardBytes fails reads of objects that decompress to more than n
+// bytes; see store.LimitReader. Default is no limit.
+func WithMaxShardBytes(n int64) Option {
+	return func(s *Store) error {
+		s.maxShardBytes = n
+		return nil
+	}
+}
+
+// ReadShard reads and decompresses the content of the given shard into a
+// pooled buffer, which the caller may return with ReleaseShard.
 func (s *Store) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
 	// Check for cancellation before starting.
 	select {
@@ -124,7 +143,7 @@ func (s *Store) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
 	}
 	defer decompressor.Close()
 
-	data, err := io.ReadAll(decompressor)
+	data, err := store.ReadAll(store.LimitReader(store.ContextReader(ctx, decompressor), s.maxShardBytes))
 	if err != nil {
 		return nil, fmt.Errorf("decompressing shard: %w", err)
 	}
@@ -132,6 +151,65 @@ func (s *Store) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
 	return data, nil
 }
 
+// ReleaseShard returns data from ReadShard to the shared buffer pool.
+// See store.Releaser for the ownership contract.
+func (s *Store) ReleaseShard(data []byte) {
+	store.Release(data)
+}
+
+// WriteShard compresses data with the codec and uploads it as the given shard.
+func (s *Store) WriteShard(ctx context.Context, shardID int, data []byte) error {
+	var buf bytes.Buffer
+	compressor, err := s.codec.Writer(&buf)
+	if err != nil {
+		return fmt.Errorf("creating compressor: %w", err)
+	}
+	if _, err := compressor.Write(data); err != nil {
+		compressor.Close()
+		return fmt.Errorf("compressing shard: %w", err)
+	}
+	if err := compressor.Close(); err != nil {
+		return fmt.Errorf("finalizing compression: %w", err)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(s.shardKey(shardID)),
+		Body:          bytes.NewReader(buf.Bytes()),
+		ContentLength: aws.Int64(int64(buf.Len())),
+	}
+
+	if _, err := s.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("writing shard: %w", err)
+	}
+
+	return nil
+}
+
+// ListShards returns the IDs of the shard objects under the shards/ prefix.
+func (s *Store) ListShards(ctx context.Context) ([]int, error) {
+	prefix := s.prefix + "shards/"
+	p := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	var ids []int
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing shards: %w", err)
+		}
+		for _, obj := range page.Contents {
+			if id, ok := store.ParseShardName(strings.TrimPrefix(aws.ToString(obj.Key), prefix), s.codec.Extension()); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
 // Close releases resources.
 func (s *Store) Close() error {
 	// S3 client doesn't need explicit closing.
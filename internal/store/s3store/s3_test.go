@@ -7,6 +7,7 @@ import (
 
 	"github.com/klauspost/compress/zstd"
 
+	"github.com/discochess/stockpile/internal/codec/noopcodec"
 	"github.com/discochess/stockpile/internal/codec/zstdcodec"
 	"github.com/discochess/stockpile/internal/store"
 )
@@ -96,6 +97,13 @@ func TestStore_shardName(t *testing.T) {
 	}
 }
 
+func TestStore_shardName_NoExtension(t *testing.T) {
+	s := &Store{codec: noopcodec.New()}
+	if got := s.shardName(42); got != "00042" {
+		t.Errorf("shardName(42) = %q, want %q", got, "00042")
+	}
+}
+
 func TestStore_ErrNotFound_Mapping(t *testing.T) {
 	if store.ErrNotFound == nil {
 		t.Error("store.ErrNotFound should not be nil")
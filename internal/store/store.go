@@ -4,18 +4,64 @@ package store
 import (
 	"context"
 	"errors"
+	"strconv"
+	"strings"
 )
 
 // ErrNotFound is returned when a shard does not exist in the store.
 var ErrNotFound = errors.New("store: shard not found")
 
+// ErrShardTooLarge is returned when a shard decompresses to more than the
+// store's configured limit; see LimitReader.
+var ErrShardTooLarge = errors.New("store: shard too large")
+
 // Store defines the interface for storage backends.
 // Implementations handle path formats and storage details internally.
 type Store interface {
 	// ReadShard reads the content of the given shard.
 	// The returned data may be compressed depending on the implementation.
+	// The caller owns the returned slice; stores that also implement
+	// Releaser accept it back once the caller is done with it.
+	// If ctx is cancelled, ReadShard returns an error wrapping ctx.Err()
+	// instead of finishing the read.
 	ReadShard(ctx context.Context, shardID int) ([]byte, error)
 
 	// Close releases any resources held by the store.
 	Close() error
 }
+
+// Lister is implemented by storage backends that can enumerate their shards.
+type Lister interface {
+	// ListShards returns the IDs of the shards present, in ascending order.
+	// Other files alongside the shards, such as offset indexes or shards
+	// stored with a different codec, are ignored.
+	ListShards(ctx context.Context) ([]int, error)
+}
+
+// ParseShardName returns the shard ID encoded in a shard file name: the
+// decimal ID, zero-padded by the stores, followed by "."+ext unless ext is
+// empty. It reports false for any other name.
+func ParseShardName(name, ext string) (int, bool) {
+	if ext != "" {
+		var ok bool
+		if name, ok = strings.CutSuffix(name, "."+ext); !ok {
+			return 0, false
+		}
+	}
+	if name == "" || strings.TrimLeft(name, "0123456789") != "" {
+		return 0, false
+	}
+	id, err := strconv.Atoi(name)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// Writer is implemented by storage backends that can persist shards.
+type Writer interface {
+	// WriteShard stores the given uncompressed shard content, replacing any
+	// existing shard with the same ID. The implementation compresses the
+	// data using its codec.
+	WriteShard(ctx context.Context, shardID int, data []byte) error
+}
@@ -0,0 +1,31 @@
+package store
+
+import "testing"
+
+func TestParseShardName(t *testing.T) {
+	tests := []struct {
+		name, ext string
+		wantID    int
+		wantOK    bool
+	}{
+		{"00042.zst", "zst", 42, true},
+		{"32767.zst", "zst", 32767, true},
+		{"00042", "", 42, true},
+		{"00042.idx", "zst", 0, false},
+		{"00042.idx", "", 0, false},
+		{"00042.gz", "zst", 0, false},
+		{".tmp-00042.zst-123", "zst", 0, false},
+		{".zst", "zst", 0, false},
+		{"-1.zst", "zst", 0, false},
+		{"manifest.json", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := ParseShardName(tt.name, tt.ext)
+			if id != tt.wantID || ok != tt.wantOK {
+				t.Errorf("ParseShardName(%q, %q) = %d, %v; want %d, %v", tt.name, tt.ext, id, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package tieredstore implements a store that reads through an ordered list
+// of stores, such as a local disk cache in front of a remote bucket.
+package tieredstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/discochess/stockpile/internal/store"
+)
+
+// Compile-time check that Store implements store.Store.
+var _ store.Store = (*Store)(nil)
+
+// Store tries each of its tiers in order until one has the shard.
+type Store struct {
+	tiers        []store.Store
+	writeThrough store.Writer
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithWriteThrough copies shards found in any tier after the first into w,
+// so later reads are served from the first tier. w is typically the first
+// tier itself, e.g. a diskstore in front of a gcsstore.
+// Write failures are ignored since the read already succeeded.
+func WithWriteThrough(w store.Writer) Option {
+	return func(s *Store) {
+		s.writeThrough = w
+	}
+}
+
+// New creates a tiered store that reads from tiers in the given order.
+func New(tiers []store.Store, opts ...Option) *Store {
+	s := &Store{tiers: tiers}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ReadShard returns the shard from the first tier that has it.
+// A tier reporting store.ErrNotFound, or failing with any other error, is
+// skipped. If no tier has the shard, ReadShard returns store.ErrNotFound
+// when every tier reported it missing, or otherwise the first other error.
+func (s *Store) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var firstErr error
+	for i, tier := range s.tiers {
+		data, err := tier.ReadShard(ctx, shardID)
+		if err == nil {
+			if i > 0 && s.writeThrough != nil {
+				_ = s.writeThrough.WriteShard(ctx, shardID, data)
+			}
+			return data, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !errors.Is(err, store.ErrNotFound) && firstErr == nil {
+			firstErr = fmt.Errorf("tier %d: %w", i, err)
+		}
+	}
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, store.ErrNotFound
+}
+
+// Close closes every tier.
+func (s *Store) Close() error {
+	var errs []error
+	for i, tier := range s.tiers {
+		if err := tier.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("closing tier %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
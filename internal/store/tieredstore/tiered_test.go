@@ -0,0 +1,150 @@
+package tieredstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/discochess/stockpile/internal/store"
+	"github.com/discochess/stockpile/internal/store/memstore"
+)
+
+// fakeStore is a memstore that counts reads and can fail every read.
+type fakeStore struct {
+	*memstore.Store
+	reads int
+	err   error
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{Store: memstore.New()}
+}
+
+func (s *fakeStore) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
+	s.reads++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.Store.ReadShard(ctx, shardID)
+}
+
+func TestStore_ReadShard_FirstTier(t *testing.T) {
+	local, remote := newFakeStore(), newFakeStore()
+	local.SetShard(1, []byte("local"))
+	remote.SetShard(1, []byte("remote"))
+
+	s := New([]store.Store{local, remote})
+
+	data, err := s.ReadShard(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("ReadShard() error = %v", err)
+	}
+	if string(data) != "local" {
+		t.Errorf("ReadShard() = %q, want %q", data, "local")
+	}
+	if remote.reads != 0 {
+		t.Errorf("remote reads = %d, want 0 (short-circuit)", remote.reads)
+	}
+}
+
+func TestStore_ReadShard_Fallback(t *testing.T) {
+	local, remote := newFakeStore(), newFakeStore()
+	remote.SetShard(1, []byte("remote"))
+
+	s := New([]store.Store{local, remote})
+
+	data, err := s.ReadShard(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("ReadShard() error = %v", err)
+	}
+	if string(data) != "remote" {
+		t.Errorf("ReadShard() = %q, want %q", data, "remote")
+	}
+	if local.reads != 1 || remote.reads != 1 {
+		t.Errorf("reads = %d local, %d remote, want 1 each", local.reads, remote.reads)
+	}
+
+	// Without write-through, the local tier is left untouched.
+	if _, err := local.Store.ReadShard(context.Background(), 1); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("local shard error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStore_ReadShard_FallbackOnError(t *testing.T) {
+	local, remote := newFakeStore(), newFakeStore()
+	local.err = errors.New("disk failure")
+	remote.SetShard(1, []byte("remote"))
+
+	s := New([]store.Store{local, remote})
+
+	data, err := s.ReadShard(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("ReadShard() error = %v", err)
+	}
+	if string(data) != "remote" {
+		t.Errorf("ReadShard() = %q, want %q", data, "remote")
+	}
+}
+
+func TestStore_ReadShard_NotFound(t *testing.T) {
+	s := New([]store.Store{newFakeStore(), newFakeStore()})
+
+	if _, err := s.ReadShard(context.Background(), 1); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ReadShard() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStore_ReadShard_ReportsOtherErrors(t *testing.T) {
+	errDown := errors.New("service unavailable")
+	local, remote := newFakeStore(), newFakeStore()
+	remote.err = errDown
+
+	s := New([]store.Store{local, remote})
+
+	_, err := s.ReadShard(context.Background(), 1)
+	if !errors.Is(err, errDown) {
+		t.Errorf("ReadShard() error = %v, want %v", err, errDown)
+	}
+}
+
+func TestStore_ReadShard_WriteThrough(t *testing.T) {
+	local, remote := newFakeStore(), newFakeStore()
+	remote.SetShard(1, []byte("remote"))
+
+	s := New([]store.Store{local, remote}, WithWriteThrough(local))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		data, err := s.ReadShard(ctx, 1)
+		if err != nil {
+			t.Fatalf("ReadShard() error = %v", err)
+		}
+		if string(data) != "remote" {
+			t.Errorf("ReadShard() = %q, want %q", data, "remote")
+		}
+	}
+
+	// The second read is served by the local tier.
+	if remote.reads != 1 {
+		t.Errorf("remote reads = %d, want 1", remote.reads)
+	}
+	if local.reads != 2 {
+		t.Errorf("local reads = %d, want 2", local.reads)
+	}
+}
+
+func TestStore_ReadShard_ContextCanceled(t *testing.T) {
+	local, remote := newFakeStore(), newFakeStore()
+	local.err = context.Canceled
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := New([]store.Store{local, remote})
+	if _, err := s.ReadShard(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadShard() error = %v, want %v", err, context.Canceled)
+	}
+	if remote.reads != 0 {
+		t.Errorf("remote reads = %d, want 0", remote.reads)
+	}
+}
@@ -0,0 +1,102 @@
+package stockpile
+
+import "encoding/json"
+
+// Compile-time checks that Eval and PV implement the JSON interfaces.
+var (
+	_ json.Marshaler   = Eval{}
+	_ json.Unmarshaler = (*Eval)(nil)
+	_ json.Marshaler   = PV{}
+	_ json.Unmarshaler = (*PV)(nil)
+)
+
+// evalJSON is the JSON form of an Eval; see Eval.MarshalJSON.
+type evalJSON struct {
+	FEN        string      `json:"fen"`
+	Score      string      `json:"score"`
+	Centipawns *int        `json:"cp,omitempty"`
+	Mate       *int        `json:"mate,omitempty"`
+	Depth      int         `json:"depth"`
+	Knodes     int         `json:"knodes"`
+	PVs        []PV        `json:"pvs"`
+	AllEvals   []EvalEntry `json:"evals,omitempty"`
+}
+
+// pvJSON is the JSON form of a PV; see PV.MarshalJSON.
+type pvJSON struct {
+	Score      string `json:"score"`
+	Centipawns *int   `json:"cp,omitempty"`
+	Mate       *int   `json:"mate,omitempty"`
+	Line       string `json:"line"`
+}
+
+// MarshalJSON encodes the evaluation as an object with these fields:
+//
+//	fen     the position
+//	score   the best line's score as formatted by Score, e.g. "+0.20" or "#3"
+//	cp      the best line's centipawns, omitted for a mate or without PVs
+//	mate    the best line's moves to mate, omitted if there is none
+//	depth   the search depth
+//	knodes  the kilo-nodes searched
+//	pvs     the principal variations, encoded as by PV.MarshalJSON
+//	evals   every evaluation of the position, each with depth, knodes and
+//	        pvs; omitted if empty
+//
+// Scores are relative to the side to move, as in the database.
+func (e Eval) MarshalJSON() ([]byte, error) {
+	out := evalJSON{
+		FEN:      e.FEN,
+		Score:    e.Score(),
+		Depth:    e.Depth,
+		Knodes:   e.Knodes,
+		PVs:      e.PVs,
+		AllEvals: e.AllEvals,
+	}
+	if pv := e.BestPV(); pv != nil {
+		out.Centipawns = pv.Centipawns
+		out.Mate = pv.Mate
+	}
+	if out.PVs == nil {
+		out.PVs = []PV{}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes an evaluation encoded by MarshalJSON. The score, cp
+// and mate fields are derived from the PVs and ignored.
+func (e *Eval) UnmarshalJSON(data []byte) error {
+	var in evalJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*e = Eval{
+		FEN:      in.FEN,
+		Depth:    in.Depth,
+		Knodes:   in.Knodes,
+		PVs:      in.PVs,
+		AllEvals: in.AllEvals,
+	}
+	return nil
+}
+
+// MarshalJSON encodes the line as an object with the fields score, as
+// formatted by Score, cp and mate, each omitted if nil, and line.
+func (pv PV) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pvJSON{
+		Score:      pv.Score(),
+		Centipawns: pv.Centipawns,
+		Mate:       pv.Mate,
+		Line:       pv.Line,
+	})
+}
+
+// UnmarshalJSON decodes a line encoded by MarshalJSON. The score field is
+// derived from cp and mate and ignored.
+func (pv *PV) UnmarshalJSON(data []byte) error {
+	var in pvJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*pv = PV{Centipawns: in.Centipawns, Mate: in.Mate, Line: in.Line}
+	return nil
+}
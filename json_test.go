@@ -0,0 +1,81 @@
+package stockpile
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestEval_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		eval Eval
+		want string
+	}{
+		{
+			name: "centipawns",
+			eval: Eval{
+				FEN:    "8/8/8/8/8/8/8/4K2k w - -",
+				Depth:  20,
+				Knodes: 3,
+				PVs:    []PV{{Centipawns: intPtr(20), Line: "e1e2"}, {Centipawns: intPtr(-5), Line: "e1d2"}},
+			},
+			want: `{"fen":"8/8/8/8/8/8/8/4K2k w - -","score":"+0.20","cp":20,"depth":20,"knodes":3,` +
+				`"pvs":[{"score":"+0.20","cp":20,"line":"e1e2"},{"score":"-0.05","cp":-5,"line":"e1d2"}]}`,
+		},
+		{
+			name: "mate",
+			eval: Eval{
+				FEN:      "8/8/8/8/8/8/8/4K2k b - -",
+				Depth:    40,
+				PVs:      []PV{{Mate: intPtr(3), Line: "h1g2"}},
+				AllEvals: []EvalEntry{{Depth: 40, PVs: []PV{{Mate: intPtr(3), Line: "h1g2"}}}},
+			},
+			want: `{"fen":"8/8/8/8/8/8/8/4K2k b - -","score":"#3","mate":3,"depth":40,"knodes":0,` +
+				`"pvs":[{"score":"#3","mate":3,"line":"h1g2"}],` +
+				`"evals":[{"depth":40,"knodes":0,"pvs":[{"score":"#3","mate":3,"line":"h1g2"}]}]}`,
+		},
+		{
+			name: "no PVs",
+			eval: Eval{FEN: "8/8/8/8/8/8/8/4K2k w - -"},
+			want: `{"fen":"8/8/8/8/8/8/8/4K2k w - -","score":"?","depth":0,"knodes":0,"pvs":[]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Marshal through a pointer, as callers usually hold *Eval.
+			data, err := json.Marshal(&tt.eval)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Marshal() = %s\nwant %s", data, tt.want)
+			}
+
+			var got Eval
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if tt.eval.PVs == nil {
+				tt.eval.PVs = []PV{}
+			}
+			if !reflect.DeepEqual(got, tt.eval) {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tt.eval)
+			}
+		})
+	}
+}
+
+func TestPV_UnmarshalJSON_IgnoresScore(t *testing.T) {
+	var pv PV
+	if err := json.Unmarshal([]byte(`{"score":"#9","cp":150,"line":"e2e4"}`), &pv); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if pv.Centipawns == nil || *pv.Centipawns != 150 || pv.Mate != nil || pv.Line != "e2e4" {
+		t.Errorf("Unmarshal() = %+v, want cp 150 on e2e4", pv)
+	}
+	if got := pv.Score(); got != "+1.50" {
+		t.Errorf("Score() = %q, want %q", got, "+1.50")
+	}
+}
@@ -6,10 +6,16 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/discochess/stockpile/internal/builder"
+	"github.com/discochess/stockpile/internal/codec"
+	_ "github.com/discochess/stockpile/internal/codec/gzipcodec" // Register codecs for manifests.
+	_ "github.com/discochess/stockpile/internal/codec/lz4codec"
+	_ "github.com/discochess/stockpile/internal/codec/noopcodec"
 	"github.com/discochess/stockpile/internal/codec/zstdcodec"
 	"github.com/discochess/stockpile/internal/shard"
-	"github.com/discochess/stockpile/internal/shard/fnvshard"
+	_ "github.com/discochess/stockpile/internal/shard/fnvshard" // Register strategies for manifests.
 	"github.com/discochess/stockpile/internal/shard/materialshard"
+	_ "github.com/discochess/stockpile/internal/shard/pawnshard"
+	_ "github.com/discochess/stockpile/internal/shard/ringshard"
 	"github.com/discochess/stockpile/internal/stats"
 	"github.com/discochess/stockpile/internal/store"
 	"github.com/discochess/stockpile/internal/store/diskstore"
@@ -27,6 +33,15 @@ type options struct {
 	totalShards   int
 	stats         stats.Collector
 	logger        *zap.Logger
+	shardRanges   map[int]fenRange
+	shardMetrics  bool
+	warmupWorkers int
+	trace         func(TraceEvent)
+}
+
+// fenRange is the first and last FEN stored in a shard.
+type fenRange struct {
+	min, max string
 }
 
 // defaultOptions returns the default configuration.
@@ -36,6 +51,7 @@ func defaultOptions() options {
 		totalShards:   32768, // 2^15 shards
 		stats:         stats.NewNoop(),
 		logger:        zap.NewNop(),
+		warmupWorkers: 4,
 	}
 }
 
@@ -86,9 +102,33 @@ func WithLogger(l *zap.Logger) Option {
 	})
 }
 
+// WithShardMetrics enables per-shard hit and miss counters
+// (stats.MetricShardHits and stats.MetricShardMisses), labeled with the
+// shard ID, to find shards that dominate traffic. They are reported only if
+// the stats collector supports labels (stats.LabeledCollector). Disabled by
+// default, since a database can have tens of thousands of shards.
+func WithShardMetrics(enabled bool) Option {
+	return optionFunc(func(o *options) {
+		o.shardMetrics = enabled
+	})
+}
+
+// WithWarmupWorkers sets how many shards Warmup fetches concurrently.
+// Default is 4; values below 1 are treated as 1.
+func WithWarmupWorkers(n int) Option {
+	return optionFunc(func(o *options) {
+		o.warmupWorkers = n
+	})
+}
+
 // WithDataDir configures the client from a data directory.
 // It reads the manifest.json to auto-configure shard count and strategy,
-// and creates a disk-based store with zstd compression.
+// and creates a disk-based store using the manifest's compression codec and
+// dictionary, if the database was built with one.
+// If the manifest lists per-shard FEN ranges, lookups for positions outside
+// their shard's range return ErrNotFound without reading the shard. If the
+// shards are seekable and were built with an offset index, lookups read
+// only the frames that may hold their position instead of whole shards.
 // This is the recommended way to create a client for local data.
 func WithDataDir(dir string) (Option, error) {
 	manifest, err := builder.ReadManifest(dir)
@@ -96,24 +136,130 @@ func WithDataDir(dir string) (Option, error) {
 		return nil, fmt.Errorf("reading manifest: %w", err)
 	}
 
-	st, err := diskstore.New(dir, zstdcodec.New())
+	c, err := codec.ByName(manifestCodec(manifest))
+	if err != nil {
+		return nil, fmt.Errorf("compression in manifest: %w", err)
+	}
+	dict, err := builder.ReadDictionary(dir, manifest)
+	if err != nil {
+		return nil, err
+	}
+	if dict != nil {
+		c = zstdcodec.NewWithDict(dict)
+	}
+
+	// Seekable shards with an offset index can be searched frame by frame,
+	// but the seekable reader decodes frames without a dictionary.
+	seek := manifestCodec(manifest) == "zstd" && manifest.FrameSize > 0 && dict == nil
+	st, err := diskstore.New(dir, c, diskstore.WithFrameSeeking(seek))
 	if err != nil {
 		return nil, fmt.Errorf("creating store: %w", err)
 	}
 
-	var strategy shard.Strategy
-	switch manifest.Strategy {
-	case "material":
-		strategy = materialshard.New()
-	case "fnv32":
-		strategy = fnvshard.New()
-	default:
-		return nil, fmt.Errorf("unknown strategy in manifest: %s", manifest.Strategy)
+	strategy, err := shard.New(manifest.Strategy)
+	if err != nil {
+		return nil, fmt.Errorf("strategy in manifest: %w", err)
+	}
+
+	var ranges map[int]fenRange
+	if len(manifest.Shards) > 0 {
+		ranges = make(map[int]fenRange, len(manifest.Shards))
+		for _, si := range manifest.Shards {
+			ranges[si.ID] = fenRange{min: si.MinFEN, max: si.MaxFEN}
+		}
 	}
 
 	return optionFunc(func(o *options) {
 		o.store = st
 		o.totalShards = manifest.TotalShards
 		o.shardStrategy = strategy
+		o.shardRanges = ranges
 	}), nil
 }
+
+// manifestCodec returns the codec name recorded in a manifest.
+// Manifests written before the field existed are zstd.
+func manifestCodec(m *builder.Manifest) string {
+	if m.Compression == "" {
+		return "zstd"
+	}
+	return m.Compression
+}
+
+// LookupOption configures a single lookup.
+type LookupOption interface {
+	applyLookup(*lookupOptions)
+}
+
+// lookupOptions holds per-lookup configuration.
+type lookupOptions struct {
+	minDepth      int
+	preferDeepest bool
+	rawFEN        bool
+}
+
+// newLookupOptions applies opts on top of the zero configuration.
+func newLookupOptions(opts []LookupOption) lookupOptions {
+	var lo lookupOptions
+	for _, opt := range opts {
+		opt.applyLookup(&lo)
+	}
+	return lo
+}
+
+// lookupOptionFunc wraps a function to implement LookupOption.
+type lookupOptionFunc func(*lookupOptions)
+
+// Compile-time check that lookupOptionFunc implements LookupOption.
+var _ LookupOption = lookupOptionFunc(nil)
+
+func (f lookupOptionFunc) applyLookup(o *lookupOptions) { f(o) }
+
+// LookupConfig is the resolved form of a set of LookupOptions. It lets
+// Lookuper implementations outside this package, such as a remote client,
+// honor the same options as Client.
+type LookupConfig struct {
+	// MinDepth is the minimum best-evaluation depth; zero means no minimum.
+	MinDepth int
+
+	// PreferDeepest reports the deepest evaluation rather than the first.
+	PreferDeepest bool
+
+	// RawFEN searches for FENs as given instead of normalizing them.
+	RawFEN bool
+}
+
+// ResolveLookupOptions applies opts and returns the resulting configuration.
+func ResolveLookupOptions(opts ...LookupOption) LookupConfig {
+	lo := newLookupOptions(opts)
+	return LookupConfig{MinDepth: lo.minDepth, PreferDeepest: lo.preferDeepest, RawFEN: lo.rawFEN}
+}
+
+// WithMinDepth skips positions whose evaluation is shallower than d.
+// The depth compared is that of the record's best (first) evaluation, or
+// its deepest with WithPreferDeepest; records below the threshold are
+// reported as ErrNotFound.
+func WithMinDepth(d int) LookupOption {
+	return lookupOptionFunc(func(o *lookupOptions) {
+		o.minDepth = d
+	})
+}
+
+// WithPreferDeepest makes the top-level Depth, Knodes and PVs of the
+// returned Eval mirror the deepest of the position's evaluations instead of
+// the first listed. Of equally deep evaluations, the first listed wins.
+// AllEvals is unchanged.
+func WithPreferDeepest() LookupOption {
+	return lookupOptionFunc(func(o *lookupOptions) {
+		o.preferDeepest = true
+	})
+}
+
+// WithRawFEN searches for the FEN exactly as given, skipping normalization
+// and validation. It saves a little work for callers whose FENs are already
+// in the database's four-field form; any other FEN is simply not found.
+func WithRawFEN() LookupOption {
+	return lookupOptionFunc(func(o *lookupOptions) {
+		o.rawFEN = true
+	})
+}
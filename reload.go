@@ -0,0 +1,130 @@
+package stockpile
+
+import (
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/discochess/stockpile/internal/shard"
+	"github.com/discochess/stockpile/internal/store"
+)
+
+// dataset is the part of a client's configuration that describes one
+// version of the database. Reload swaps it as a unit, so a call never mixes
+// the store of one version with the shard layout of another.
+type dataset struct {
+	store         store.Store
+	shardStrategy shard.Strategy
+	totalShards   int
+	shardRanges   map[int]fenRange
+
+	// inflight counts calls using the dataset, so that Reload closes the
+	// store only after they finish.
+	inflight sync.WaitGroup
+}
+
+func newDataset(cfg options) *dataset {
+	return &dataset{
+		store:         cfg.store,
+		shardStrategy: cfg.shardStrategy,
+		totalShards:   cfg.totalShards,
+		shardRanges:   cfg.shardRanges,
+	}
+}
+
+// acquire returns the current dataset, which stays open until release is
+// called. It returns ErrClosed if the client is closed.
+func (c *Client) acquire() (*dataset, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed.Load() {
+		return nil, ErrClosed
+	}
+	c.data.inflight.Add(1)
+	return c.data, nil
+}
+
+// release marks a call started by acquire as finished.
+func (d *dataset) release() {
+	d.inflight.Done()
+}
+
+// shardID returns the shard that holds fen.
+func (d *dataset) shardID(fen string) int {
+	return d.shardStrategy.ShardID(fen, d.totalShards)
+}
+
+// inRange reports whether fen can be in the given shard according to the
+// shard ranges from the manifest. Without ranges every FEN is in range.
+func (d *dataset) inRange(shardID int, fen string) bool {
+	if d.shardRanges == nil {
+		return true
+	}
+	r, ok := d.shardRanges[shardID]
+	if !ok {
+		return false // The shard is empty.
+	}
+	return fen >= r.min && fen <= r.max
+}
+
+// releaseShard hands shard data back to the store once a lookup is done
+// with it. Lookups copy what they need out of the shard, so nothing refers
+// to data afterwards. Stores that retain shard data, such as caches, do not
+// implement store.Releaser and are left alone.
+func (d *dataset) releaseShard(data []byte) {
+	if r, ok := d.store.(store.Releaser); ok && data != nil {
+		r.ReleaseShard(data)
+	}
+}
+
+// Reload switches the client to another version of the database, such as a
+// rebuilt data directory, without interrupting lookups:
+//
+//	opt, err := stockpile.WithDataDir("/path/to/new/data")
+//	if err != nil {
+//	    return err
+//	}
+//	if err := client.Reload(opt); err != nil {
+//	    return err
+//	}
+//
+// Only the store-related options are reloaded: WithStore, WithShardStrategy,
+// WithTotalShards and WithDataDir. Those not given take their defaults, as
+// in New. Other options, such as WithStats and WithLogger, are ignored.
+//
+// Calls that started before Reload finish on the previous store; calls that
+// start after it use the new one. Reload waits for the earlier calls, then
+// closes the previous store, so opts must not reuse it. If Reload returns
+// an error before switching, the client keeps its current store and the
+// caller still owns the new one.
+func (c *Client) Reload(opts ...Option) error {
+	cfg := defaultOptions()
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+	if cfg.store == nil {
+		return ErrNoStore
+	}
+	next := newDataset(cfg)
+
+	c.mu.Lock()
+	if c.closed.Load() {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	prev := c.data
+	c.data = next
+	c.mu.Unlock()
+
+	c.logger.Info("database reloaded",
+		zap.Int("totalShards", next.totalShards),
+		zap.String("shardStrategy", next.shardStrategy.Name()),
+	)
+
+	prev.inflight.Wait()
+	if err := prev.store.Close(); err != nil {
+		return fmt.Errorf("closing previous store: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package stockpile
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/discochess/stockpile/internal/store"
+	"github.com/discochess/stockpile/internal/store/memstore"
+)
+
+// versionStore is a single-shard store holding one position, which records
+// whether it was closed and can hold reads until unblocked.
+type versionStore struct {
+	store.Store
+	closed  atomic.Bool
+	reading chan struct{} // Receives once per read, if non-nil.
+	unblock chan struct{} // Reads wait for it to close, if non-nil.
+}
+
+func newVersionStore(fen string) *versionStore {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(`{"fen":"`+fen+`","evals":[{"pvs":[{"cp":5,"line":"e1e2"}],"knodes":1,"depth":1}]}`+"\n"))
+	return &versionStore{Store: mem}
+}
+
+func (s *versionStore) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
+	if s.closed.Load() {
+		return nil, errors.New("read from closed store")
+	}
+	if s.reading != nil {
+		s.reading <- struct{}{}
+	}
+	if s.unblock != nil {
+		<-s.unblock
+	}
+	return s.Store.ReadShard(ctx, shardID)
+}
+
+func (s *versionStore) Close() error {
+	s.closed.Store(true)
+	return nil
+}
+
+const (
+	reloadOldFEN = "8/8/8/8/8/8/8/4K1k1 w - -"
+	reloadNewFEN = "8/8/8/8/8/8/8/4K2k w - -"
+)
+
+func TestClient_Reload(t *testing.T) {
+	oldStore, newStore := newVersionStore(reloadOldFEN), newVersionStore(reloadNewFEN)
+	client, err := New(WithStore(oldStore), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if _, err := client.Lookup(ctx, reloadOldFEN); err != nil {
+		t.Fatalf("Lookup() before reload error = %v", err)
+	}
+
+	if err := client.Reload(WithStore(newStore), WithTotalShards(1)); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if !oldStore.closed.Load() {
+		t.Error("previous store not closed after Reload")
+	}
+	if client.Store() != newStore {
+		t.Error("Store() does not return the reloaded store")
+	}
+	if _, err := client.Lookup(ctx, reloadNewFEN); err != nil {
+		t.Errorf("Lookup() of new position error = %v", err)
+	}
+	if _, err := client.Lookup(ctx, reloadOldFEN); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup() of old position error = %v, want ErrNotFound", err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !newStore.closed.Load() {
+		t.Error("reloaded store not closed by Close")
+	}
+}
+
+func TestClient_Reload_WaitsForInflight(t *testing.T) {
+	oldStore, newStore := newVersionStore(reloadOldFEN), newVersionStore(reloadNewFEN)
+	oldStore.reading = make(chan struct{}, 1)
+	oldStore.unblock = make(chan struct{})
+
+	client, err := New(WithStore(oldStore), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	var lookupErr, reloadErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, lookupErr = client.Lookup(ctx, reloadOldFEN)
+	}()
+	<-oldStore.reading // The lookup holds the old store.
+
+	reloaded := make(chan struct{})
+	go func() {
+		defer close(reloaded)
+		reloadErr = client.Reload(WithStore(newStore), WithTotalShards(1))
+	}()
+
+	// New lookups use the new store while the old one is still busy.
+	for client.Store() != newStore {
+		runtime.Gosched()
+	}
+	if _, err := client.Lookup(ctx, reloadNewFEN); err != nil {
+		t.Errorf("Lookup() during reload error = %v", err)
+	}
+	if oldStore.closed.Load() {
+		t.Error("previous store closed while a lookup was using it")
+	}
+
+	close(oldStore.unblock)
+	wg.Wait()
+	<-reloaded
+	if lookupErr != nil {
+		t.Errorf("in-flight Lookup() error = %v", lookupErr)
+	}
+	if reloadErr != nil {
+		t.Errorf("Reload() error = %v", reloadErr)
+	}
+	if !oldStore.closed.Load() {
+		t.Error("previous store not closed after in-flight lookup finished")
+	}
+}
+
+func TestClient_Reload_Errors(t *testing.T) {
+	oldStore := newVersionStore(reloadOldFEN)
+	client, err := New(WithStore(oldStore), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := client.Reload(WithTotalShards(1)); !errors.Is(err, ErrNoStore) {
+		t.Errorf("Reload() without store error = %v, want ErrNoStore", err)
+	}
+	if client.Store() != oldStore || oldStore.closed.Load() {
+		t.Error("failed Reload() replaced or closed the current store")
+	}
+
+	client.Close()
+	newStore := newVersionStore(reloadNewFEN)
+	if err := client.Reload(WithStore(newStore)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Reload() after Close error = %v, want ErrClosed", err)
+	}
+	if newStore.closed.Load() {
+		t.Error("Reload() after Close closed the caller's store")
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/discochess/stockpile"
+	"github.com/discochess/stockpile/server/stockpilepb"
+)
+
+// Client looks up positions on a remote stockpile Server.
+// It implements stockpile.Lookuper, reporting missing positions as
+// stockpile.ErrNotFound just like a local stockpile.Client.
+// A Client is safe for concurrent use by multiple goroutines.
+type Client struct {
+	rpc  stockpilepb.StockpileClient
+	conn *grpc.ClientConn // Owned connection, nil if supplied by the caller.
+}
+
+// Ensure Client implements stockpile.Lookuper.
+var _ stockpile.Lookuper = (*Client)(nil)
+
+// Dial creates a Client connected to target, e.g. "stockpile:9090".
+// Transport credentials must be supplied in opts.
+func Dial(target string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", target, err)
+	}
+	return &Client{rpc: stockpilepb.NewStockpileClient(conn), conn: conn}, nil
+}
+
+// NewClient creates a Client over an existing connection.
+// Close does not close cc.
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{rpc: stockpilepb.NewStockpileClient(cc)}
+}
+
+// Lookup returns the evaluation for a given FEN position.
+// Returns stockpile.ErrNotFound if the position is not in the database.
+func (c *Client) Lookup(ctx context.Context, fen string, opts ...stockpile.LookupOption) (*stockpile.Eval, error) {
+	cfg := stockpile.ResolveLookupOptions(opts...)
+	resp, err := c.rpc.Lookup(ctx, &stockpilepb.LookupRequest{
+		Fen:      fen,
+		MinDepth: minDepth(cfg),
+		RawFen:   cfg.RawFEN,
+	})
+	if err != nil {
+		return nil, fromStatus(status.Convert(err))
+	}
+	eval := evalFromProto(resp.GetEval())
+	if err := preferDeepest(eval, cfg); err != nil {
+		return nil, err
+	}
+	return eval, nil
+}
+
+// LookupBatch returns the evaluations for multiple FEN positions in a single
+// round trip. The returned slices are index-aligned with fens. If the call
+// itself fails, every position reports that error.
+func (c *Client) LookupBatch(ctx context.Context, fens []string, opts ...stockpile.LookupOption) ([]*stockpile.Eval, []error) {
+	evals := make([]*stockpile.Eval, len(fens))
+	errs := make([]error, len(fens))
+
+	cfg := stockpile.ResolveLookupOptions(opts...)
+	resp, err := c.rpc.LookupBatch(ctx, &stockpilepb.LookupBatchRequest{
+		Fens:     fens,
+		MinDepth: minDepth(cfg),
+		RawFen:   cfg.RawFEN,
+	})
+	if err == nil && len(resp.GetResults()) != len(fens) {
+		err = fmt.Errorf("server returned %d results for %d positions", len(resp.GetResults()), len(fens))
+	}
+	if err != nil {
+		err = fromStatus(status.Convert(err))
+		for i := range errs {
+			errs[i] = err
+		}
+		return evals, errs
+	}
+
+	for i, r := range resp.GetResults() {
+		if code := codes.Code(r.GetCode()); code != codes.OK {
+			errs[i] = fromStatus(status.New(code, r.GetMessage()))
+			continue
+		}
+		eval := evalFromProto(r.GetEval())
+		if errs[i] = preferDeepest(eval, cfg); errs[i] == nil {
+			evals[i] = eval
+		}
+	}
+	return evals, errs
+}
+
+// Close closes the connection if it was opened by Dial.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// minDepth returns the minimum depth to send to the server. The server
+// compares the first evaluation, so with PreferDeepest the depth is instead
+// checked by preferDeepest once the deepest evaluation is known.
+func minDepth(cfg stockpile.LookupConfig) int32 {
+	if cfg.PreferDeepest {
+		return 0
+	}
+	return int32(cfg.MinDepth)
+}
+
+// preferDeepest applies cfg.PreferDeepest to an eval from the server,
+// mirroring the deepest of its AllEvals in the top-level fields, and
+// reports stockpile.ErrNotFound if that is shallower than cfg.MinDepth.
+func preferDeepest(eval *stockpile.Eval, cfg stockpile.LookupConfig) error {
+	if !cfg.PreferDeepest {
+		return nil
+	}
+	if len(eval.AllEvals) == 0 {
+		if cfg.MinDepth > 0 {
+			return stockpile.ErrNotFound
+		}
+		return nil
+	}
+	deepest := eval.AllEvals[0]
+	for _, e := range eval.AllEvals[1:] {
+		if e.Depth > deepest.Depth {
+			deepest = e
+		}
+	}
+	if deepest.Depth < cfg.MinDepth {
+		return stockpile.ErrNotFound
+	}
+	eval.Depth = deepest.Depth
+	eval.Knodes = deepest.Knodes
+	eval.PVs = deepest.PVs
+	return nil
+}
+
+// sentinelError carries a message from the server while matching a
+// stockpile sentinel with errors.Is.
+type sentinelError struct {
+	msg      string
+	sentinel error
+}
+
+func (e *sentinelError) Error() string { return e.msg }
+func (e *sentinelError) Unwrap() error { return e.sentinel }
+
+// fromStatus maps a gRPC status to an error, restoring stockpile sentinels.
+func fromStatus(st *status.Status) error {
+	switch st.Code() {
+	case codes.NotFound:
+		return stockpile.ErrNotFound
+	case codes.DataLoss:
+		// Keep the server's message, which names the shard and line.
+		return &sentinelError{msg: st.Message(), sentinel: stockpile.ErrCorruptShard}
+	case codes.InvalidArgument:
+		return &sentinelError{msg: st.Message(), sentinel: stockpile.ErrInvalidFEN}
+	case codes.Canceled:
+		return context.Canceled
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	default:
+		return st.Err()
+	}
+}
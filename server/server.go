@@ -0,0 +1,185 @@
+// Package server exposes a stockpile.Client over gRPC, and provides a
+// Client that implements stockpile.Lookuper against such a server.
+//
+// Many analysis workers can then share one stockpile service and its
+// shard cache instead of each holding a copy of the database:
+//
+//	lis, _ := net.Listen("tcp", ":9090")
+//	gs := grpc.NewServer()
+//	server.New(client).Register(gs)
+//	gs.Serve(lis)
+//
+// The protocol is defined in stockpilepb/stockpile.proto.
+package server
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/discochess/stockpile"
+	"github.com/discochess/stockpile/server/stockpilepb"
+)
+
+// Server implements the Stockpile gRPC service backed by a stockpile.Client.
+type Server struct {
+	stockpilepb.UnimplementedStockpileServer
+
+	client *stockpile.Client
+}
+
+// Ensure Server implements stockpilepb.StockpileServer.
+var _ stockpilepb.StockpileServer = (*Server)(nil)
+
+// New creates a Server that answers lookups from client.
+// The caller remains responsible for closing client.
+func New(client *stockpile.Client) *Server {
+	return &Server{client: client}
+}
+
+// Register registers the service with a gRPC server.
+func (s *Server) Register(r grpc.ServiceRegistrar) {
+	stockpilepb.RegisterStockpileServer(r, s)
+}
+
+// Lookup returns the evaluation for a single position.
+func (s *Server) Lookup(ctx context.Context, req *stockpilepb.LookupRequest) (*stockpilepb.LookupResponse, error) {
+	eval, err := s.client.Lookup(ctx, req.GetFen(), lookupOptions(req.GetMinDepth(), req.GetRawFen())...)
+	if err != nil {
+		return nil, toStatus(err).Err()
+	}
+	return &stockpilepb.LookupResponse{Eval: evalToProto(eval)}, nil
+}
+
+// LookupBatch returns evaluations for many positions. Per-position errors,
+// including missing positions, are reported in the results.
+func (s *Server) LookupBatch(ctx context.Context, req *stockpilepb.LookupBatchRequest) (*stockpilepb.LookupBatchResponse, error) {
+	evals, errs := s.client.LookupBatch(ctx, req.GetFens(), lookupOptions(req.GetMinDepth(), req.GetRawFen())...)
+
+	results := make([]*stockpilepb.BatchResult, len(evals))
+	for i := range evals {
+		if errs[i] != nil {
+			st := toStatus(errs[i])
+			results[i] = &stockpilepb.BatchResult{
+				Code:    int32(st.Code()),
+				Message: st.Message(),
+			}
+			continue
+		}
+		results[i] = &stockpilepb.BatchResult{Eval: evalToProto(evals[i])}
+	}
+	return &stockpilepb.LookupBatchResponse{Results: results}, nil
+}
+
+// lookupOptions converts request fields to lookup options.
+func lookupOptions(minDepth int32, rawFEN bool) []stockpile.LookupOption {
+	var opts []stockpile.LookupOption
+	if minDepth > 0 {
+		opts = append(opts, stockpile.WithMinDepth(int(minDepth)))
+	}
+	if rawFEN {
+		opts = append(opts, stockpile.WithRawFEN())
+	}
+	return opts
+}
+
+// toStatus maps a client error to a gRPC status.
+func toStatus(err error) *status.Status {
+	switch {
+	case errors.Is(err, stockpile.ErrNotFound):
+		return status.New(codes.NotFound, err.Error())
+	case errors.Is(err, stockpile.ErrClosed):
+		return status.New(codes.Unavailable, err.Error())
+	case errors.Is(err, stockpile.ErrCorruptShard):
+		return status.New(codes.DataLoss, err.Error())
+	case errors.Is(err, stockpile.ErrInvalidFEN):
+		return status.New(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err)
+	default:
+		return status.New(codes.Internal, err.Error())
+	}
+}
+
+// evalToProto converts an Eval to its wire form.
+func evalToProto(e *stockpile.Eval) *stockpilepb.Eval {
+	out := &stockpilepb.Eval{
+		Fen:    e.FEN,
+		Depth:  int32(e.Depth),
+		Knodes: int32(e.Knodes),
+		Pvs:    pvsToProto(e.PVs),
+		Score:  e.Score(),
+	}
+	for _, entry := range e.AllEvals {
+		out.AllEvals = append(out.AllEvals, &stockpilepb.EvalEntry{
+			Depth:  int32(entry.Depth),
+			Knodes: int32(entry.Knodes),
+			Pvs:    pvsToProto(entry.PVs),
+		})
+	}
+	return out
+}
+
+func pvsToProto(pvs []stockpile.PV) []*stockpilepb.PV {
+	out := make([]*stockpilepb.PV, len(pvs))
+	for i, pv := range pvs {
+		out[i] = &stockpilepb.PV{
+			Centipawns: int32Ptr(pv.Centipawns),
+			Mate:       int32Ptr(pv.Mate),
+			Line:       pv.Line,
+		}
+	}
+	return out
+}
+
+// evalFromProto converts a wire Eval back to an Eval.
+func evalFromProto(e *stockpilepb.Eval) *stockpile.Eval {
+	out := &stockpile.Eval{
+		FEN:    e.GetFen(),
+		Depth:  int(e.GetDepth()),
+		Knodes: int(e.GetKnodes()),
+		PVs:    pvsFromProto(e.GetPvs()),
+	}
+	for _, entry := range e.GetAllEvals() {
+		out.AllEvals = append(out.AllEvals, stockpile.EvalEntry{
+			Depth:  int(entry.GetDepth()),
+			Knodes: int(entry.GetKnodes()),
+			PVs:    pvsFromProto(entry.GetPvs()),
+		})
+	}
+	return out
+}
+
+func pvsFromProto(pvs []*stockpilepb.PV) []stockpile.PV {
+	if len(pvs) == 0 {
+		return nil
+	}
+	out := make([]stockpile.PV, len(pvs))
+	for i, pv := range pvs {
+		out[i] = stockpile.PV{
+			Centipawns: intPtr(pv.Centipawns),
+			Mate:       intPtr(pv.Mate),
+			Line:       pv.GetLine(),
+		}
+	}
+	return out
+}
+
+func int32Ptr(v *int) *int32 {
+	if v == nil {
+		return nil
+	}
+	n := int32(*v)
+	return &n
+}
+
+func intPtr(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	n := int(*v)
+	return &n
+}
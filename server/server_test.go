@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/discochess/stockpile"
+	"github.com/discochess/stockpile/internal/store/memstore"
+)
+
+// newTestClient serves a single-shard database over bufconn and returns a
+// remote client for it alongside the local one.
+func newTestClient(t *testing.T) (*Client, *stockpile.Client) {
+	t.Helper()
+
+	mem := memstore.New()
+	mem.SetShard(0, []byte(
+		`{"fen":"8/8/8/8/8/8/8/4K2k b - -","evals":[{"pvs":[{"mate":3,"line":"h1g2"}],"knodes":5,"depth":40},{"pvs":[{"cp":900,"line":"h1g1"}],"knodes":1,"depth":12}]}`+"\n"+
+			`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":20,"line":"e1e2 h1g2"},{"cp":-5,"line":"e1d2"}],"knodes":3,"depth":20}]}`+"\n",
+	))
+	local, err := stockpile.New(stockpile.WithStore(mem), stockpile.WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("stockpile.New() error = %v", err)
+	}
+	t.Cleanup(func() { local.Close() })
+
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	New(local).Register(gs)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	remote, err := Dial("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { remote.Close() })
+
+	return remote, local
+}
+
+func TestClient_Lookup(t *testing.T) {
+	remote, _ := newTestClient(t)
+	ctx := context.Background()
+
+	eval, err := remote.Lookup(ctx, "8/8/8/8/8/8/8/4K2k w - -")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if eval.Depth != 20 || eval.Knodes != 3 {
+		t.Errorf("Depth, Knodes = %d, %d, want 20, 3", eval.Depth, eval.Knodes)
+	}
+	if got := eval.Score(); got != "+0.20" {
+		t.Errorf("Score() = %q, want %q", got, "+0.20")
+	}
+	if len(eval.PVs) != 2 || eval.PVs[1].Line != "e1d2" {
+		t.Errorf("PVs = %+v, want 2 lines ending in e1d2", eval.PVs)
+	}
+	if eval.PVs[0].Mate != nil {
+		t.Errorf("PVs[0].Mate = %d, want nil", *eval.PVs[0].Mate)
+	}
+}
+
+func TestClient_Lookup_MatchesLocal(t *testing.T) {
+	remote, local := newTestClient(t)
+	ctx := context.Background()
+	fen := "8/8/8/8/8/8/8/4K2k b - -"
+
+	for _, l := range []stockpile.Lookuper{local, remote} {
+		eval, err := l.Lookup(ctx, fen)
+		if err != nil {
+			t.Fatalf("%T.Lookup() error = %v", l, err)
+		}
+		if got := eval.Score(); got != "#3" {
+			t.Errorf("%T: Score() = %q, want %q", l, got, "#3")
+		}
+		if len(eval.AllEvals) != 2 || eval.AllEvals[1].Depth != 12 {
+			t.Errorf("%T: AllEvals = %+v, want 2 entries", l, eval.AllEvals)
+		}
+	}
+}
+
+func TestClient_Lookup_NotFound(t *testing.T) {
+	remote, _ := newTestClient(t)
+
+	_, err := remote.Lookup(context.Background(), "8/8/8/8/8/8/8/4K1k1 w - -")
+	if !errors.Is(err, stockpile.ErrNotFound) {
+		t.Errorf("Lookup() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_Lookup_InvalidFEN(t *testing.T) {
+	remote, _ := newTestClient(t)
+
+	_, err := remote.Lookup(context.Background(), "not a fen")
+	if !errors.Is(err, stockpile.ErrInvalidFEN) {
+		t.Errorf("Lookup() error = %v, want ErrInvalidFEN", err)
+	}
+}
+
+func TestClient_Lookup_WithRawFEN(t *testing.T) {
+	remote, _ := newTestClient(t)
+	ctx := context.Background()
+
+	// Raw FENs are searched as given: the move counters are not dropped,
+	// and a malformed FEN is simply not found.
+	for _, fen := range []string{"8/8/8/8/8/8/8/4K2k w - - 0 1", "not a fen"} {
+		if _, err := remote.Lookup(ctx, fen, stockpile.WithRawFEN()); !errors.Is(err, stockpile.ErrNotFound) {
+			t.Errorf("Lookup(%q, WithRawFEN()) error = %v, want ErrNotFound", fen, err)
+		}
+	}
+	if _, err := remote.Lookup(ctx, "8/8/8/8/8/8/8/4K2k w - -", stockpile.WithRawFEN()); err != nil {
+		t.Errorf("Lookup(WithRawFEN()) error = %v", err)
+	}
+
+	_, errs := remote.LookupBatch(ctx, []string{"8/8/8/8/8/8/8/4K2k w - - 0 1", "8/8/8/8/8/8/8/4K2k w - -"}, stockpile.WithRawFEN())
+	if !errors.Is(errs[0], stockpile.ErrNotFound) || errs[1] != nil {
+		t.Errorf("LookupBatch(WithRawFEN()) errors = %v, want [ErrNotFound <nil>]", errs)
+	}
+}
+
+func TestClient_Lookup_WithMinDepth(t *testing.T) {
+	remote, _ := newTestClient(t)
+
+	_, err := remote.Lookup(context.Background(), "8/8/8/8/8/8/8/4K2k w - -", stockpile.WithMinDepth(30))
+	if !errors.Is(err, stockpile.ErrNotFound) {
+		t.Errorf("Lookup(WithMinDepth(30)) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_Lookup_WithPreferDeepest(t *testing.T) {
+	remote, local := newTestClient(t)
+	ctx := context.Background()
+	fen := "8/8/8/8/8/8/8/4K2k b - -"
+
+	// The deepest evaluation is listed first; a shallower one must not win.
+	for _, l := range []stockpile.Lookuper{local, remote} {
+		eval, err := l.Lookup(ctx, fen, stockpile.WithPreferDeepest(), stockpile.WithMinDepth(30))
+		if err != nil {
+			t.Fatalf("%T.Lookup() error = %v", l, err)
+		}
+		if eval.Depth != 40 || eval.Score() != "#3" {
+			t.Errorf("%T: Depth, Score() = %d, %q, want 40, #3", l, eval.Depth, eval.Score())
+		}
+	}
+
+	_, errs := remote.LookupBatch(ctx, []string{fen}, stockpile.WithPreferDeepest(), stockpile.WithMinDepth(50))
+	if !errors.Is(errs[0], stockpile.ErrNotFound) {
+		t.Errorf("LookupBatch(WithMinDepth(50)) error = %v, want ErrNotFound", errs[0])
+	}
+}
+
+func TestClient_LookupBatch(t *testing.T) {
+	remote, _ := newTestClient(t)
+
+	fens := []string{
+		"8/8/8/8/8/8/8/4K2k w - -",
+		"8/8/8/8/8/8/8/4K1k1 w - -",
+		"8/8/8/8/8/8/8/4K2k b - -",
+	}
+	evals, errs := remote.LookupBatch(context.Background(), fens)
+	if len(evals) != len(fens) || len(errs) != len(fens) {
+		t.Fatalf("LookupBatch() returned %d evals and %d errors, want %d", len(evals), len(errs), len(fens))
+	}
+
+	for _, i := range []int{0, 2} {
+		if errs[i] != nil {
+			t.Errorf("errs[%d] = %v, want nil", i, errs[i])
+			continue
+		}
+		if evals[i].FEN != fens[i] {
+			t.Errorf("evals[%d].FEN = %q, want %q", i, evals[i].FEN, fens[i])
+		}
+	}
+	if !errors.Is(errs[1], stockpile.ErrNotFound) {
+		t.Errorf("errs[1] = %v, want ErrNotFound", errs[1])
+	}
+	if evals[1] != nil {
+		t.Errorf("evals[1] = %v, want nil", evals[1])
+	}
+}
+
+func TestClient_LookupBatch_ServerClosed(t *testing.T) {
+	remote, local := newTestClient(t)
+	local.Close()
+
+	_, errs := remote.LookupBatch(context.Background(), []string{"8/8/8/8/8/8/8/4K2k w - -"})
+	if errs[0] == nil || errors.Is(errs[0], stockpile.ErrNotFound) {
+		t.Errorf("errs[0] = %v, want an unavailable error", errs[0])
+	}
+}
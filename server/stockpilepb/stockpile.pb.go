@@ -0,0 +1,617 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: server/stockpilepb/stockpile.proto
+
+package stockpilepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type LookupRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Fen   string                 `protobuf:"bytes,1,opt,name=fen,proto3" json:"fen,omitempty"`
+	// Skip evaluations shallower than this depth. Zero means no minimum.
+	MinDepth int32 `protobuf:"varint,2,opt,name=min_depth,json=minDepth,proto3" json:"min_depth,omitempty"`
+	// Look up fen as given instead of normalizing it; see stockpile.WithRawFEN.
+	RawFen        bool `protobuf:"varint,3,opt,name=raw_fen,json=rawFen,proto3" json:"raw_fen,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupRequest) Reset() {
+	*x = LookupRequest{}
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupRequest) ProtoMessage() {}
+
+func (x *LookupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupRequest.ProtoReflect.Descriptor instead.
+func (*LookupRequest) Descriptor() ([]byte, []int) {
+	return file_server_stockpilepb_stockpile_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LookupRequest) GetFen() string {
+	if x != nil {
+		return x.Fen
+	}
+	return ""
+}
+
+func (x *LookupRequest) GetMinDepth() int32 {
+	if x != nil {
+		return x.MinDepth
+	}
+	return 0
+}
+
+func (x *LookupRequest) GetRawFen() bool {
+	if x != nil {
+		return x.RawFen
+	}
+	return false
+}
+
+type LookupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Eval          *Eval                  `protobuf:"bytes,1,opt,name=eval,proto3" json:"eval,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupResponse) Reset() {
+	*x = LookupResponse{}
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupResponse) ProtoMessage() {}
+
+func (x *LookupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupResponse.ProtoReflect.Descriptor instead.
+func (*LookupResponse) Descriptor() ([]byte, []int) {
+	return file_server_stockpilepb_stockpile_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LookupResponse) GetEval() *Eval {
+	if x != nil {
+		return x.Eval
+	}
+	return nil
+}
+
+type LookupBatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Fens  []string               `protobuf:"bytes,1,rep,name=fens,proto3" json:"fens,omitempty"`
+	// Skip evaluations shallower than this depth. Zero means no minimum.
+	MinDepth int32 `protobuf:"varint,2,opt,name=min_depth,json=minDepth,proto3" json:"min_depth,omitempty"`
+	// Look up fens as given instead of normalizing them; see
+	// stockpile.WithRawFEN.
+	RawFen        bool `protobuf:"varint,3,opt,name=raw_fen,json=rawFen,proto3" json:"raw_fen,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupBatchRequest) Reset() {
+	*x = LookupBatchRequest{}
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupBatchRequest) ProtoMessage() {}
+
+func (x *LookupBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupBatchRequest.ProtoReflect.Descriptor instead.
+func (*LookupBatchRequest) Descriptor() ([]byte, []int) {
+	return file_server_stockpilepb_stockpile_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LookupBatchRequest) GetFens() []string {
+	if x != nil {
+		return x.Fens
+	}
+	return nil
+}
+
+func (x *LookupBatchRequest) GetMinDepth() int32 {
+	if x != nil {
+		return x.MinDepth
+	}
+	return 0
+}
+
+func (x *LookupBatchRequest) GetRawFen() bool {
+	if x != nil {
+		return x.RawFen
+	}
+	return false
+}
+
+type LookupBatchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Index-aligned with LookupBatchRequest.fens.
+	Results       []*BatchResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupBatchResponse) Reset() {
+	*x = LookupBatchResponse{}
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupBatchResponse) ProtoMessage() {}
+
+func (x *LookupBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupBatchResponse.ProtoReflect.Descriptor instead.
+func (*LookupBatchResponse) Descriptor() ([]byte, []int) {
+	return file_server_stockpilepb_stockpile_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LookupBatchResponse) GetResults() []*BatchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// BatchResult is the outcome for one position of a batch.
+type BatchResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Set when code is OK.
+	Eval *Eval `protobuf:"bytes,1,opt,name=eval,proto3" json:"eval,omitempty"`
+	// A google.golang.org/grpc/codes value; NOT_FOUND for missing positions.
+	Code          int32  `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchResult) Reset() {
+	*x = BatchResult{}
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResult) ProtoMessage() {}
+
+func (x *BatchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchResult.ProtoReflect.Descriptor instead.
+func (*BatchResult) Descriptor() ([]byte, []int) {
+	return file_server_stockpilepb_stockpile_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchResult) GetEval() *Eval {
+	if x != nil {
+		return x.Eval
+	}
+	return nil
+}
+
+func (x *BatchResult) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *BatchResult) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// Eval mirrors stockpile.Eval.
+type Eval struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Fen      string                 `protobuf:"bytes,1,opt,name=fen,proto3" json:"fen,omitempty"`
+	Depth    int32                  `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"`
+	Knodes   int32                  `protobuf:"varint,3,opt,name=knodes,proto3" json:"knodes,omitempty"`
+	Pvs      []*PV                  `protobuf:"bytes,4,rep,name=pvs,proto3" json:"pvs,omitempty"`
+	AllEvals []*EvalEntry           `protobuf:"bytes,5,rep,name=all_evals,json=allEvals,proto3" json:"all_evals,omitempty"`
+	// Human-readable score of the best line, e.g. "+0.20" or "#3".
+	Score         string `protobuf:"bytes,6,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Eval) Reset() {
+	*x = Eval{}
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Eval) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Eval) ProtoMessage() {}
+
+func (x *Eval) ProtoReflect() protoreflect.Message {
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Eval.ProtoReflect.Descriptor instead.
+func (*Eval) Descriptor() ([]byte, []int) {
+	return file_server_stockpilepb_stockpile_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Eval) GetFen() string {
+	if x != nil {
+		return x.Fen
+	}
+	return ""
+}
+
+func (x *Eval) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *Eval) GetKnodes() int32 {
+	if x != nil {
+		return x.Knodes
+	}
+	return 0
+}
+
+func (x *Eval) GetPvs() []*PV {
+	if x != nil {
+		return x.Pvs
+	}
+	return nil
+}
+
+func (x *Eval) GetAllEvals() []*EvalEntry {
+	if x != nil {
+		return x.AllEvals
+	}
+	return nil
+}
+
+func (x *Eval) GetScore() string {
+	if x != nil {
+		return x.Score
+	}
+	return ""
+}
+
+// EvalEntry mirrors stockpile.EvalEntry.
+type EvalEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Depth         int32                  `protobuf:"varint,1,opt,name=depth,proto3" json:"depth,omitempty"`
+	Knodes        int32                  `protobuf:"varint,2,opt,name=knodes,proto3" json:"knodes,omitempty"`
+	Pvs           []*PV                  `protobuf:"bytes,3,rep,name=pvs,proto3" json:"pvs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvalEntry) Reset() {
+	*x = EvalEntry{}
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvalEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvalEntry) ProtoMessage() {}
+
+func (x *EvalEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvalEntry.ProtoReflect.Descriptor instead.
+func (*EvalEntry) Descriptor() ([]byte, []int) {
+	return file_server_stockpilepb_stockpile_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *EvalEntry) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *EvalEntry) GetKnodes() int32 {
+	if x != nil {
+		return x.Knodes
+	}
+	return 0
+}
+
+func (x *EvalEntry) GetPvs() []*PV {
+	if x != nil {
+		return x.Pvs
+	}
+	return nil
+}
+
+// PV mirrors stockpile.PV. Scores are relative to the side to move.
+type PV struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Centipawns    *int32                 `protobuf:"varint,1,opt,name=centipawns,proto3,oneof" json:"centipawns,omitempty"`
+	Mate          *int32                 `protobuf:"varint,2,opt,name=mate,proto3,oneof" json:"mate,omitempty"`
+	Line          string                 `protobuf:"bytes,3,opt,name=line,proto3" json:"line,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PV) Reset() {
+	*x = PV{}
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PV) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PV) ProtoMessage() {}
+
+func (x *PV) ProtoReflect() protoreflect.Message {
+	mi := &file_server_stockpilepb_stockpile_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PV.ProtoReflect.Descriptor instead.
+func (*PV) Descriptor() ([]byte, []int) {
+	return file_server_stockpilepb_stockpile_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PV) GetCentipawns() int32 {
+	if x != nil && x.Centipawns != nil {
+		return *x.Centipawns
+	}
+	return 0
+}
+
+func (x *PV) GetMate() int32 {
+	if x != nil && x.Mate != nil {
+		return *x.Mate
+	}
+	return 0
+}
+
+func (x *PV) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
+var File_server_stockpilepb_stockpile_proto protoreflect.FileDescriptor
+
+const file_server_stockpilepb_stockpile_proto_rawDesc = "" +
+	"\n" +
+	"\"server/stockpilepb/stockpile.proto\x12\fstockpile.v1\"W\n" +
+	"\rLookupRequest\x12\x10\n" +
+	"\x03fen\x18\x01 \x01(\tR\x03fen\x12\x1b\n" +
+	"\tmin_depth\x18\x02 \x01(\x05R\bminDepth\x12\x17\n" +
+	"\araw_fen\x18\x03 \x01(\bR\x06rawFen\"8\n" +
+	"\x0eLookupResponse\x12&\n" +
+	"\x04eval\x18\x01 \x01(\v2\x12.stockpile.v1.EvalR\x04eval\"^\n" +
+	"\x12LookupBatchRequest\x12\x12\n" +
+	"\x04fens\x18\x01 \x03(\tR\x04fens\x12\x1b\n" +
+	"\tmin_depth\x18\x02 \x01(\x05R\bminDepth\x12\x17\n" +
+	"\araw_fen\x18\x03 \x01(\bR\x06rawFen\"J\n" +
+	"\x13LookupBatchResponse\x123\n" +
+	"\aresults\x18\x01 \x03(\v2\x19.stockpile.v1.BatchResultR\aresults\"c\n" +
+	"\vBatchResult\x12&\n" +
+	"\x04eval\x18\x01 \x01(\v2\x12.stockpile.v1.EvalR\x04eval\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\xb6\x01\n" +
+	"\x04Eval\x12\x10\n" +
+	"\x03fen\x18\x01 \x01(\tR\x03fen\x12\x14\n" +
+	"\x05depth\x18\x02 \x01(\x05R\x05depth\x12\x16\n" +
+	"\x06knodes\x18\x03 \x01(\x05R\x06knodes\x12\"\n" +
+	"\x03pvs\x18\x04 \x03(\v2\x10.stockpile.v1.PVR\x03pvs\x124\n" +
+	"\tall_evals\x18\x05 \x03(\v2\x17.stockpile.v1.EvalEntryR\ballEvals\x12\x14\n" +
+	"\x05score\x18\x06 \x01(\tR\x05score\"]\n" +
+	"\tEvalEntry\x12\x14\n" +
+	"\x05depth\x18\x01 \x01(\x05R\x05depth\x12\x16\n" +
+	"\x06knodes\x18\x02 \x01(\x05R\x06knodes\x12\"\n" +
+	"\x03pvs\x18\x03 \x03(\v2\x10.stockpile.v1.PVR\x03pvs\"n\n" +
+	"\x02PV\x12#\n" +
+	"\n" +
+	"centipawns\x18\x01 \x01(\x05H\x00R\n" +
+	"centipawns\x88\x01\x01\x12\x17\n" +
+	"\x04mate\x18\x02 \x01(\x05H\x01R\x04mate\x88\x01\x01\x12\x12\n" +
+	"\x04line\x18\x03 \x01(\tR\x04lineB\r\n" +
+	"\v_centipawnsB\a\n" +
+	"\x05_mate2\xa4\x01\n" +
+	"\tStockpile\x12C\n" +
+	"\x06Lookup\x12\x1b.stockpile.v1.LookupRequest\x1a\x1c.stockpile.v1.LookupResponse\x12R\n" +
+	"\vLookupBatch\x12 .stockpile.v1.LookupBatchRequest\x1a!.stockpile.v1.LookupBatchResponseB4Z2github.com/discochess/stockpile/server/stockpilepbb\x06proto3"
+
+var (
+	file_server_stockpilepb_stockpile_proto_rawDescOnce sync.Once
+	file_server_stockpilepb_stockpile_proto_rawDescData []byte
+)
+
+func file_server_stockpilepb_stockpile_proto_rawDescGZIP() []byte {
+	file_server_stockpilepb_stockpile_proto_rawDescOnce.Do(func() {
+		file_server_stockpilepb_stockpile_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_server_stockpilepb_stockpile_proto_rawDesc), len(file_server_stockpilepb_stockpile_proto_rawDesc)))
+	})
+	return file_server_stockpilepb_stockpile_proto_rawDescData
+}
+
+var file_server_stockpilepb_stockpile_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_server_stockpilepb_stockpile_proto_goTypes = []any{
+	(*LookupRequest)(nil),       // 0: stockpile.v1.LookupRequest
+	(*LookupResponse)(nil),      // 1: stockpile.v1.LookupResponse
+	(*LookupBatchRequest)(nil),  // 2: stockpile.v1.LookupBatchRequest
+	(*LookupBatchResponse)(nil), // 3: stockpile.v1.LookupBatchResponse
+	(*BatchResult)(nil),         // 4: stockpile.v1.BatchResult
+	(*Eval)(nil),                // 5: stockpile.v1.Eval
+	(*EvalEntry)(nil),           // 6: stockpile.v1.EvalEntry
+	(*PV)(nil),                  // 7: stockpile.v1.PV
+}
+var file_server_stockpilepb_stockpile_proto_depIdxs = []int32{
+	5, // 0: stockpile.v1.LookupResponse.eval:type_name -> stockpile.v1.Eval
+	4, // 1: stockpile.v1.LookupBatchResponse.results:type_name -> stockpile.v1.BatchResult
+	5, // 2: stockpile.v1.BatchResult.eval:type_name -> stockpile.v1.Eval
+	7, // 3: stockpile.v1.Eval.pvs:type_name -> stockpile.v1.PV
+	6, // 4: stockpile.v1.Eval.all_evals:type_name -> stockpile.v1.EvalEntry
+	7, // 5: stockpile.v1.EvalEntry.pvs:type_name -> stockpile.v1.PV
+	0, // 6: stockpile.v1.Stockpile.Lookup:input_type -> stockpile.v1.LookupRequest
+	2, // 7: stockpile.v1.Stockpile.LookupBatch:input_type -> stockpile.v1.LookupBatchRequest
+	1, // 8: stockpile.v1.Stockpile.Lookup:output_type -> stockpile.v1.LookupResponse
+	3, // 9: stockpile.v1.Stockpile.LookupBatch:output_type -> stockpile.v1.LookupBatchResponse
+	8, // [8:10] is the sub-list for method output_type
+	6, // [6:8] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_server_stockpilepb_stockpile_proto_init() }
+func file_server_stockpilepb_stockpile_proto_init() {
+	if File_server_stockpilepb_stockpile_proto != nil {
+		return
+	}
+	file_server_stockpilepb_stockpile_proto_msgTypes[7].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_server_stockpilepb_stockpile_proto_rawDesc), len(file_server_stockpilepb_stockpile_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_server_stockpilepb_stockpile_proto_goTypes,
+		DependencyIndexes: file_server_stockpilepb_stockpile_proto_depIdxs,
+		MessageInfos:      file_server_stockpilepb_stockpile_proto_msgTypes,
+	}.Build()
+	File_server_stockpilepb_stockpile_proto = out.File
+	file_server_stockpilepb_stockpile_proto_goTypes = nil
+	file_server_stockpilepb_stockpile_proto_depIdxs = nil
+}
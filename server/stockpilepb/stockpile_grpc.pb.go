@@ -0,0 +1,171 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: server/stockpilepb/stockpile.proto
+
+package stockpilepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Stockpile_Lookup_FullMethodName      = "/stockpile.v1.Stockpile/Lookup"
+	Stockpile_LookupBatch_FullMethodName = "/stockpile.v1.Stockpile/LookupBatch"
+)
+
+// StockpileClient is the client API for Stockpile service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Stockpile serves position evaluations from a stockpile database.
+type StockpileClient interface {
+	// Lookup returns the evaluation for a single position.
+	// NOT_FOUND is returned if the position is not in the database.
+	Lookup(ctx context.Context, in *LookupRequest, opts ...grpc.CallOption) (*LookupResponse, error)
+	// LookupBatch returns evaluations for many positions in one round trip.
+	// Per-position failures are reported in the results, not as an RPC error.
+	LookupBatch(ctx context.Context, in *LookupBatchRequest, opts ...grpc.CallOption) (*LookupBatchResponse, error)
+}
+
+type stockpileClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStockpileClient(cc grpc.ClientConnInterface) StockpileClient {
+	return &stockpileClient{cc}
+}
+
+func (c *stockpileClient) Lookup(ctx context.Context, in *LookupRequest, opts ...grpc.CallOption) (*LookupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LookupResponse)
+	err := c.cc.Invoke(ctx, Stockpile_Lookup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stockpileClient) LookupBatch(ctx context.Context, in *LookupBatchRequest, opts ...grpc.CallOption) (*LookupBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LookupBatchResponse)
+	err := c.cc.Invoke(ctx, Stockpile_LookupBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StockpileServer is the server API for Stockpile service.
+// All implementations must embed UnimplementedStockpileServer
+// for forward compatibility.
+//
+// Stockpile serves position evaluations from a stockpile database.
+type StockpileServer interface {
+	// Lookup returns the evaluation for a single position.
+	// NOT_FOUND is returned if the position is not in the database.
+	Lookup(context.Context, *LookupRequest) (*LookupResponse, error)
+	// LookupBatch returns evaluations for many positions in one round trip.
+	// Per-position failures are reported in the results, not as an RPC error.
+	LookupBatch(context.Context, *LookupBatchRequest) (*LookupBatchResponse, error)
+	mustEmbedUnimplementedStockpileServer()
+}
+
+// UnimplementedStockpileServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedStockpileServer struct{}
+
+func (UnimplementedStockpileServer) Lookup(context.Context, *LookupRequest) (*LookupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Lookup not implemented")
+}
+func (UnimplementedStockpileServer) LookupBatch(context.Context, *LookupBatchRequest) (*LookupBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupBatch not implemented")
+}
+func (UnimplementedStockpileServer) mustEmbedUnimplementedStockpileServer() {}
+func (UnimplementedStockpileServer) testEmbeddedByValue()                   {}
+
+// UnsafeStockpileServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StockpileServer will
+// result in compilation errors.
+type UnsafeStockpileServer interface {
+	mustEmbedUnimplementedStockpileServer()
+}
+
+func RegisterStockpileServer(s grpc.ServiceRegistrar, srv StockpileServer) {
+	// If the following call pancis, it indicates UnimplementedStockpileServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Stockpile_ServiceDesc, srv)
+}
+
+func _Stockpile_Lookup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StockpileServer).Lookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Stockpile_Lookup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StockpileServer).Lookup(ctx, req.(*LookupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Stockpile_LookupBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StockpileServer).LookupBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Stockpile_LookupBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StockpileServer).LookupBatch(ctx, req.(*LookupBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Stockpile_ServiceDesc is the grpc.ServiceDesc for Stockpile service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Stockpile_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "stockpile.v1.Stockpile",
+	HandlerType: (*StockpileServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Lookup",
+			Handler:    _Stockpile_Lookup_Handler,
+		},
+		{
+			MethodName: "LookupBatch",
+			Handler:    _Stockpile_LookupBatch_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/stockpilepb/stockpile.proto",
+}
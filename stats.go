@@ -0,0 +1,106 @@
+package stockpile
+
+import (
+	"sync/atomic"
+
+	"github.com/discochess/stockpile/internal/stats"
+	"github.com/discochess/stockpile/internal/store/cachedstore"
+)
+
+// ClientStats is a snapshot of a client's lookup activity since it was
+// created.
+type ClientStats struct {
+	Lookups      int64 // Positions looked up, including batch members.
+	Hits         int64 // Lookups that found the position.
+	Misses       int64 // Lookups that did not find the position.
+	ShardFetches int64 // Shard reads from the store, cached or not.
+
+	// Cache holds shard cache statistics when the client's store caches
+	// shards (see cachedstore); it is nil otherwise.
+	Cache *CacheStats
+}
+
+// CacheStats is a snapshot of the shard cache in front of the store.
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+	Size   int   // Shards currently cached.
+	Bytes  int64 // Size of cached data, if the cache tracks it.
+}
+
+// HitRate returns the fraction (0-1) of cache reads served from the cache.
+func (s CacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// cacheStatser is implemented by stores that report shard cache statistics.
+type cacheStatser interface {
+	Stats() cachedstore.Stats
+}
+
+// Stats returns a snapshot of the client's counters. It only reads atomic
+// counters, so it is cheap enough to poll frequently.
+func (c *Client) Stats() ClientStats {
+	st := ClientStats{
+		Lookups:      c.counters.lookups.Load(),
+		Hits:         c.counters.hits.Load(),
+		Misses:       c.counters.misses.Load(),
+		ShardFetches: c.counters.shardFetches.Load(),
+	}
+	if cs, ok := c.Store().(cacheStatser); ok {
+		cst := cs.Stats()
+		st.Cache = &CacheStats{
+			Hits:   cst.Hits,
+			Misses: cst.Misses,
+			Size:   cst.Size,
+			Bytes:  cst.Bytes,
+		}
+	}
+	return st
+}
+
+// clientCounters keeps the client counters readable by Stats while
+// forwarding every metric to the configured collector.
+type clientCounters struct {
+	next stats.Collector
+
+	lookups      atomic.Int64
+	hits         atomic.Int64
+	misses       atomic.Int64
+	shardFetches atomic.Int64
+}
+
+// Compile-time check that clientCounters implements stats.LabeledCollector.
+var _ stats.LabeledCollector = (*clientCounters)(nil)
+
+func (cc *clientCounters) IncCounter(name string, delta int64) {
+	switch name {
+	case stats.MetricLookups:
+		cc.lookups.Add(delta)
+	case stats.MetricHits:
+		cc.hits.Add(delta)
+	case stats.MetricMisses:
+		cc.misses.Add(delta)
+	case stats.MetricShardFetches:
+		cc.shardFetches.Add(delta)
+	}
+	cc.next.IncCounter(name, delta)
+}
+
+// IncCounterLabeled forwards a labeled counter if the collector supports
+// labels; the client counters are unlabeled.
+func (cc *clientCounters) IncCounterLabeled(name, label, value string, delta int64) {
+	stats.IncCounterLabeled(cc.next, name, label, value, delta)
+}
+
+func (cc *clientCounters) SetGauge(name string, value int64) {
+	cc.next.SetGauge(name, value)
+}
+
+func (cc *clientCounters) ObserveHistogram(name string, value float64) {
+	cc.next.ObserveHistogram(name, value)
+}
@@ -22,10 +22,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/discochess/stockpile/internal/fen"
 	"github.com/discochess/stockpile/internal/search"
 	"github.com/discochess/stockpile/internal/shard"
 	"github.com/discochess/stockpile/internal/stats"
@@ -42,16 +46,38 @@ var (
 
 	// ErrNoStore indicates no store was provided.
 	ErrNoStore = errors.New("stockpile: no store provided")
+
+	// ErrCorruptShard indicates a shard holds malformed data, so a lookup
+	// could not tell whether the position is present.
+	ErrCorruptShard = search.ErrCorruptShard
+
+	// ErrInvalidFEN indicates a lookup was given a malformed FEN.
+	ErrInvalidFEN = fen.ErrInvalidFEN
 )
 
+// Lookuper looks up position evaluations. Client implements it against a
+// local or cloud store; server.Client implements it over gRPC, so callers
+// can swap one for the other.
+type Lookuper interface {
+	Lookup(ctx context.Context, fen string, opts ...LookupOption) (*Eval, error)
+	LookupBatch(ctx context.Context, fens []string, opts ...LookupOption) ([]*Eval, []error)
+}
+
+// Compile-time check that Client implements Lookuper.
+var _ Lookuper = (*Client)(nil)
+
 // Client provides access to the Lichess evaluation database.
 // A Client is safe for concurrent use by multiple goroutines.
 type Client struct {
-	store         store.Store
-	shardStrategy shard.Strategy
-	totalShards   int
+	mu   sync.RWMutex // Guards data; see Reload.
+	data *dataset
+
 	stats         stats.Collector
 	logger        *zap.Logger
+	counters      *clientCounters
+	shardMetrics  bool
+	warmupWorkers int
+	trace         func(TraceEvent)
 	closed        atomic.Bool
 }
 
@@ -63,21 +89,24 @@ func New(opts ...Option) (*Client, error) {
 		opt.apply(&cfg)
 	}
 
-	c := &Client{
-		store:         cfg.store,
-		shardStrategy: cfg.shardStrategy,
-		totalShards:   cfg.totalShards,
-		stats:         cfg.stats,
-		logger:        cfg.logger,
+	if cfg.store == nil {
+		return nil, ErrNoStore
 	}
 
-	if c.store == nil {
-		return nil, ErrNoStore
+	counters := &clientCounters{next: cfg.stats}
+	c := &Client{
+		data:          newDataset(cfg),
+		stats:         counters,
+		logger:        cfg.logger,
+		counters:      counters,
+		shardMetrics:  cfg.shardMetrics,
+		warmupWorkers: max(cfg.warmupWorkers, 1),
+		trace:         cfg.trace,
 	}
 
 	c.logger.Debug("client initialized",
-		zap.Int("totalShards", c.totalShards),
-		zap.String("shardStrategy", c.shardStrategy.Name()),
+		zap.Int("totalShards", cfg.totalShards),
+		zap.String("shardStrategy", cfg.shardStrategy.Name()),
 	)
 
 	return c, nil
@@ -85,44 +114,317 @@ func New(opts ...Option) (*Client, error) {
 
 // Lookup returns the evaluation for a given FEN position.
 // Returns ErrNotFound if the position is not in the database.
-func (c *Client) Lookup(ctx context.Context, fen string) (*Eval, error) {
-	if c.closed.Load() {
-		return nil, ErrClosed
+//
+// The FEN may be a full six-field FEN: it is normalized to the position,
+// side to move, castling rights and en passant square before the lookup.
+// As in the database, an en passant square is kept only if a pawn can
+// capture on it, so FENs from GUIs that always set it still match. A
+// malformed FEN yields an error wrapping ErrInvalidFEN. WithRawFEN skips
+// the normalization.
+func (c *Client) Lookup(ctx context.Context, fen string, opts ...LookupOption) (eval *Eval, err error) {
+	d, err := c.acquire()
+	if err != nil {
+		return nil, err
 	}
+	defer d.release()
 
+	start := time.Now()
 	c.stats.IncCounter(stats.MetricLookups, 1)
+	defer c.observeSince(stats.MetricLookupLatency, start)
+
+	ev, info, ctx := c.startTrace(ctx, fen)
+	if ev != nil {
+		defer func() { c.finishTrace(ev, info, start, err) }()
+	}
+
+	lo := newLookupOptions(opts)
+	fen, err = lookupKey(fen, lo)
+	if err != nil {
+		return nil, err
+	}
 
-	shardID := c.shardStrategy.ShardID(fen, c.totalShards)
+	shardID := d.shardID(fen)
+	if ev != nil {
+		ev.FEN, ev.ShardID = fen, shardID
+	}
+	if !d.inRange(shardID, fen) {
+		c.countMiss(shardID)
+		return nil, ErrNotFound
+	}
 
-	shardData, err := c.fetchShard(ctx, shardID)
+	if found, ok, err := c.searchFrames(ctx, d, shardID, fen, lo); ok {
+		return found, err
+	}
+	shardData, lines, err := c.fetchShard(ctx, d, shardID)
 	if err != nil {
 		return nil, fmt.Errorf("fetching shard %d: %w", shardID, err)
 	}
+	defer d.releaseShard(shardData)
+	if ev != nil {
+		ev.ShardBytes = len(shardData)
+	}
+
+	return c.lookupInShard(shardID, shardData, lines, fen, lo)
+}
+
+// LookupBatch returns the evaluations for multiple FEN positions.
+// Positions are grouped by shard so that each shard is fetched only once,
+// which avoids repeated decompression when many positions share a shard.
+// With a store that reads seekable shards frame by frame, such as the one
+// WithDataDir creates for an indexed database, each position instead reads
+// only the frames that may hold it.
+//
+// The returned slices are index-aligned with fens. A position that is not in
+// the database has a nil Eval and ErrNotFound at its index, and a malformed
+// FEN an error wrapping ErrInvalidFEN; other positions are unaffected. FENs
+// are normalized as in Lookup.
+func (c *Client) LookupBatch(ctx context.Context, fens []string, opts ...LookupOption) ([]*Eval, []error) {
+	evals := make([]*Eval, len(fens))
+	errs := make([]error, len(fens))
 
-	eval, err := c.searchShard(shardData, fen)
+	d, err := c.acquire()
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			c.stats.IncCounter(stats.MetricMisses, 1)
+		for i := range errs {
+			errs[i] = err
 		}
-		return nil, err
+		return evals, errs
 	}
+	defer d.release()
 
-	c.stats.IncCounter(stats.MetricHits, 1)
-	return eval, nil
+	lo := newLookupOptions(opts)
+	c.stats.IncCounter(stats.MetricLookups, int64(len(fens)))
+
+	// Group input indices by shard, keeping shards in first-seen order.
+	var shardOrder []int
+	byShard := make(map[int][]int)
+	keys := make([]string, len(fens))
+	var outOfRange int64
+	for i, fen := range fens {
+		fen, err := lookupKey(fen, lo)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		keys[i] = fen
+
+		shardID := d.shardID(fen)
+		if !d.inRange(shardID, fen) {
+			errs[i] = ErrNotFound
+			outOfRange++
+			c.countShard(stats.MetricShardMisses, shardID)
+			continue
+		}
+		if _, ok := byShard[shardID]; !ok {
+			shardOrder = append(shardOrder, shardID)
+		}
+		byShard[shardID] = append(byShard[shardID], i)
+	}
+
+	if outOfRange > 0 {
+		c.stats.IncCounter(stats.MetricMisses, outOfRange)
+	}
+
+	for _, shardID := range shardOrder {
+		indices := byShard[shardID]
+
+		seeked := 0
+		for _, i := range indices {
+			eval, ok, err := c.searchFrames(ctx, d, shardID, keys[i], lo)
+			if !ok {
+				break
+			}
+			evals[i], errs[i] = eval, err
+			seeked++
+		}
+		indices = indices[seeked:]
+		if len(indices) == 0 {
+			continue
+		}
+
+		shardData, lines, err := c.fetchShard(ctx, d, shardID)
+		if err != nil {
+			err = fmt.Errorf("fetching shard %d: %w", shardID, err)
+			for _, i := range indices {
+				errs[i] = err
+			}
+			continue
+		}
+
+		for _, i := range indices {
+			evals[i], errs[i] = c.lookupInShard(shardID, shardData, lines, keys[i], lo)
+		}
+		d.releaseShard(shardData)
+	}
+
+	return evals, errs
+}
+
+// Warmup fetches the shards holding the given positions so that a caching
+// store has them ready before the first lookups. Each distinct shard is
+// fetched once, with at most WithWarmupWorkers fetches in flight. FENs are
+// normalized as in Lookup, honoring WithRawFEN. Malformed FENs, shards
+// missing from the store and positions outside their shard's range are
+// skipped. Warmup stops early if ctx is canceled.
+func (c *Client) Warmup(ctx context.Context, fens []string, opts ...LookupOption) error {
+	d, err := c.acquire()
+	if err != nil {
+		return err
+	}
+	defer d.release()
+
+	lo := newLookupOptions(opts)
+	seen := make(map[int]bool)
+	var shardIDs []int
+	for _, fen := range fens {
+		fen, err := lookupKey(fen, lo)
+		if err != nil {
+			continue
+		}
+		shardID := d.shardID(fen)
+		if seen[shardID] || !d.inRange(shardID, fen) {
+			continue
+		}
+		seen[shardID] = true
+		shardIDs = append(shardIDs, shardID)
+	}
+
+	ids := make(chan int)
+	errs := make(chan error, len(shardIDs))
+	var wg sync.WaitGroup
+	for i := 0; i < min(c.warmupWorkers, len(shardIDs)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for shardID := range ids {
+				data, _, err := c.fetchShard(ctx, d, shardID)
+				if err != nil && !errors.Is(err, store.ErrNotFound) {
+					errs <- fmt.Errorf("fetching shard %d: %w", shardID, err)
+				}
+				d.releaseShard(data)
+			}
+		}()
+	}
+
+feed:
+	for _, shardID := range shardIDs {
+		select {
+		case ids <- shardID:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(ids)
+	wg.Wait()
+	close(errs)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var all []error
+	for err := range errs {
+		all = append(all, err)
+	}
+	return errors.Join(all...)
 }
 
+// Contains reports whether a FEN position is present in the database.
+// It is cheaper than Lookup because the matching record is not decoded.
+// A missing shard is treated as the position being absent, while a corrupt
+// one yields an error wrapping ErrCorruptShard, as in Lookup. The FEN is
+// normalized as in Lookup.
+func (c *Client) Contains(ctx context.Context, fen string) (bool, error) {
+	d, err := c.acquire()
+	if err != nil {
+		return false, err
+	}
+	defer d.release()
+
+	c.stats.IncCounter(stats.MetricLookups, 1)
+
+	fen, err = lookupKey(fen, lookupOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	shardID := d.shardID(fen)
+	if !d.inRange(shardID, fen) {
+		c.countMiss(shardID)
+		return false, nil
+	}
+
+	shardData, _, err := c.fetchShard(ctx, d, shardID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.countMiss(shardID)
+			return false, nil
+		}
+		return false, fmt.Errorf("fetching shard %d: %w", shardID, err)
+	}
+	defer d.releaseShard(shardData)
+
+	found, err := search.Exists(shardData, fen)
+	if err != nil {
+		c.logger.Error("corrupt shard", zap.Int("shard", shardID), zap.Error(err))
+		return false, fmt.Errorf("shard %d: %w", shardID, err)
+	}
+	if !found {
+		c.countMiss(shardID)
+		return false, nil
+	}
+
+	c.countHit(shardID)
+	return true, nil
+}
+
+// LookupNearest returns up to k evaluations of the positions stored nearest
+// fen in sort order, about half sorting before it and half after, in sorted
+// order; see search.SearchNearest. It is meant for checking why a position
+// is missing, e.g. because its FEN is normalized differently from the
+// database's. Positions are only drawn from the shard that would hold fen,
+// so with a hash sharding strategy they are not its neighbors in the
+// database as a whole. A missing shard yields no positions. The FEN is
+// normalized as in Lookup, honoring WithRawFEN.
+func (c *Client) LookupNearest(ctx context.Context, fen string, k int, opts ...LookupOption) ([]*Eval, error) {
+	d, err := c.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer d.release()
+
+	fen, err = lookupKey(fen, newLookupOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	shardID := d.shardID(fen)
+	shardData, _, err := c.fetchShard(ctx, d, shardID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fetching shard %d: %w", shardID, err)
+	}
+	defer d.releaseShard(shardData)
+
+	records := search.SearchNearest(shardData, fen, k)
+	evals := make([]*Eval, len(records))
+	for i, r := range records {
+		evals[i] = recordToEval(r, primaryEval(r, false))
+	}
+	return evals, nil
+}
 
 // Close releases all resources associated with the client.
 // After Close, the client should not be used.
 func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if !c.closed.CompareAndSwap(false, true) {
 		return ErrClosed
 	}
 
-	if c.store != nil {
-		if err := c.store.Close(); err != nil {
-			return fmt.Errorf("closing store: %w", err)
-		}
+	if err := c.data.store.Close(); err != nil {
+		return fmt.Errorf("closing store: %w", err)
 	}
 
 	return nil
@@ -130,56 +432,195 @@ func (c *Client) Close() error {
 
 // ShardStrategy returns the sharding strategy used by this client.
 func (c *Client) ShardStrategy() shard.Strategy {
-	return c.shardStrategy
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.data.shardStrategy
 }
 
 // Store returns the storage backend used by this client.
 func (c *Client) Store() store.Store {
-	return c.store
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.data.store
 }
 
-// fetchShard fetches a shard from storage.
-func (c *Client) fetchShard(ctx context.Context, shardID int) ([]byte, error) {
+// indexedReader is implemented by stores that can return the line index of
+// a shard along with its data, such as diskstore.PersistentStore.
+type indexedReader interface {
+	ReadShardIndexed(ctx context.Context, shardID int) ([]byte, *search.LineIndex, error)
+}
+
+// fetchShard fetches a shard from the dataset's store, along with its line
+// index if the store keeps one.
+func (c *Client) fetchShard(ctx context.Context, d *dataset, shardID int) ([]byte, *search.LineIndex, error) {
 	c.stats.IncCounter(stats.MetricShardFetches, 1)
-	return c.store.ReadShard(ctx, shardID)
+	defer c.observeSince(stats.MetricShardFetchLatency, time.Now())
+	if r, ok := d.store.(indexedReader); ok {
+		return r.ReadShardIndexed(ctx, shardID)
+	}
+	data, err := d.store.ReadShard(ctx, shardID)
+	return data, nil, err
+}
+
+// observeSince records the time elapsed since start, in seconds, in the
+// named histogram.
+func (c *Client) observeSince(name string, start time.Time) {
+	c.stats.ObserveHistogram(name, time.Since(start).Seconds())
 }
 
-// searchShard searches for a position within shard data.
-// The shard data is expected to be sorted JSONL (already decompressed by store).
-func (c *Client) searchShard(data []byte, fenStr string) (*Eval, error) {
-	record, err := search.Search(data, fenStr)
+// lookupKey returns the FEN to search for: fenStr normalized to the four
+// fields the database stores, with the en passant square only if it can be
+// captured, or fenStr itself with WithRawFEN.
+func lookupKey(fenStr string, lo lookupOptions) (string, error) {
+	if lo.rawFEN {
+		return fenStr, nil
+	}
+	normalized, err := fen.NormalizeStrict(fenStr)
 	if err != nil {
-		if errors.Is(err, search.ErrNotFound) {
-			return nil, ErrNotFound
+		return "", fmt.Errorf("looking up %q: %w", fenStr, err)
+	}
+	return normalized, nil
+}
+
+// lookupInShard searches for a position within fetched shard data and
+// records hit/miss stats; see finishLookup.
+func (c *Client) lookupInShard(shardID int, shardData []byte, lines *search.LineIndex, fen string, lo lookupOptions) (*Eval, error) {
+	start := time.Now()
+	record, err := search.SearchLineIndex(shardData, lines, fen)
+	c.observeSince(stats.MetricSearchLatency, start)
+	return c.finishLookup(shardID, record, err, lo)
+}
+
+// shardSearcher is implemented by stores that can look up a position
+// without reading its whole shard, such as a diskstore.Store reading
+// seekable shards frame by frame. ok is false if the shard does not
+// support it and has to be read whole.
+type shardSearcher interface {
+	SearchShard(ctx context.Context, shardID int, fen string) (record *search.EvalRecord, ok bool, err error)
+}
+
+// searchFrames looks up a position with the dataset store's SearchShard,
+// if it has one, and records hit/miss stats; see finishLookup. ok is false
+// if the lookup has to fetch the whole shard instead.
+func (c *Client) searchFrames(ctx context.Context, d *dataset, shardID int, fen string, lo lookupOptions) (eval *Eval, ok bool, err error) {
+	s, isSearcher := d.store.(shardSearcher)
+	if !isSearcher {
+		return nil, false, nil
+	}
+	start := time.Now()
+	record, ok, err := s.SearchShard(ctx, shardID, fen)
+	if !ok {
+		return nil, false, nil
+	}
+	c.stats.IncCounter(stats.MetricShardFetches, 1)
+	c.observeSince(stats.MetricSearchLatency, start)
+	eval, err = c.finishLookup(shardID, record, err, lo)
+	return eval, true, err
+}
+
+// finishLookup turns the result of searching shardID into an Eval, applying
+// the lookup's record filters, and records hit/miss stats. Corruption is
+// logged and reported with the shard ID rather than counted as a miss.
+func (c *Client) finishLookup(shardID int, record *search.EvalRecord, err error, lo lookupOptions) (*Eval, error) {
+	var eval *Eval
+	if err == nil {
+		eval, err = filterRecord(record, lo)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, search.ErrNotFound), errors.Is(err, ErrNotFound):
+			c.countMiss(shardID)
+			err = ErrNotFound
+		case errors.Is(err, ErrCorruptShard):
+			c.logger.Error("corrupt shard", zap.Int("shard", shardID), zap.Error(err))
+			err = fmt.Errorf("shard %d: %w", shardID, err)
 		}
 		return nil, err
 	}
 
-	// Convert internal record to public Eval type.
-	return recordToEval(record), nil
+	c.countHit(shardID)
+	return eval, nil
 }
 
-// recordToEval converts an internal search.EvalRecord to a public Eval.
-func recordToEval(r *search.EvalRecord) *Eval {
-	eval := &Eval{
-		FEN: r.FEN,
+// countHit records a lookup that found its position in shardID.
+func (c *Client) countHit(shardID int) {
+	c.stats.IncCounter(stats.MetricHits, 1)
+	c.countShard(stats.MetricShardHits, shardID)
+}
+
+// countMiss records a lookup whose position is not in shardID.
+func (c *Client) countMiss(shardID int) {
+	c.stats.IncCounter(stats.MetricMisses, 1)
+	c.countShard(stats.MetricShardMisses, shardID)
+}
+
+// countShard increments the per-shard counter name for shardID, if enabled
+// with WithShardMetrics.
+func (c *Client) countShard(name string, shardID int) {
+	if c.shardMetrics {
+		stats.IncCounterLabeled(c.stats, name, stats.LabelShard, strconv.Itoa(shardID), 1)
 	}
+}
 
-	// Use the first (best) evaluation if available.
-	if len(r.Evals) > 0 {
-		best := r.Evals[0]
-		eval.Depth = best.Depth
-		eval.Knodes = best.Knodes
+// filterRecord applies the lookup's record filters to a found record and
+// converts it to the public Eval type. It returns ErrNotFound if the record
+// is filtered out.
+func filterRecord(record *search.EvalRecord, lo lookupOptions) (*Eval, error) {
+	primary := primaryEval(record, lo.preferDeepest)
+	if lo.minDepth > 0 && (primary < 0 || record.Evals[primary].Depth < lo.minDepth) {
+		return nil, ErrNotFound
+	}
+	return recordToEval(record, primary), nil
+}
+
+// primaryEval returns the index of the record's evaluation that the
+// top-level Eval fields report: the first, or with preferDeepest the
+// deepest, keeping the earliest of equally deep ones. It returns -1 if the
+// record has no evaluations.
+func primaryEval(r *search.EvalRecord, preferDeepest bool) int {
+	if len(r.Evals) == 0 {
+		return -1
+	}
+	primary := 0
+	if preferDeepest {
+		for i, e := range r.Evals {
+			if e.Depth > r.Evals[primary].Depth {
+				primary = i
+			}
+		}
+	}
+	return primary
+}
 
-		// Copy all PVs.
-		eval.PVs = make([]PV, len(best.PVs))
-		for i, pv := range best.PVs {
-			eval.PVs[i] = PV{
+// recordToEval converts an internal search.EvalRecord to a public Eval
+// whose top-level fields mirror the evaluation at index primary, if any.
+func recordToEval(r *search.EvalRecord, primary int) *Eval {
+	eval := &Eval{
+		FEN:      r.FEN,
+		AllEvals: make([]EvalEntry, len(r.Evals)),
+	}
+
+	for i, e := range r.Evals {
+		entry := EvalEntry{
+			Depth:  e.Depth,
+			Knodes: e.Knodes,
+			PVs:    make([]PV, len(e.PVs)),
+		}
+		for j, pv := range e.PVs {
+			entry.PVs[j] = PV{
 				Centipawns: pv.CP,
 				Mate:       pv.Mate,
 				Line:       pv.Line,
 			}
 		}
+		eval.AllEvals[i] = entry
+	}
+
+	if primary >= 0 {
+		best := eval.AllEvals[primary]
+		eval.Depth = best.Depth
+		eval.Knodes = best.Knodes
+		eval.PVs = best.PVs
 	}
 
 	return eval
@@ -1,11 +1,28 @@
 package stockpile
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/discochess/stockpile/internal/builder"
+	"github.com/discochess/stockpile/internal/codec/zstdcodec"
+	"github.com/discochess/stockpile/internal/stats"
 	"github.com/discochess/stockpile/internal/store"
+	"github.com/discochess/stockpile/internal/store/cachedstore"
+	"github.com/discochess/stockpile/internal/store/cachedstore/cachestrategy/lru"
+	"github.com/discochess/stockpile/internal/store/cachedstore/memory"
+	"github.com/discochess/stockpile/internal/store/diskstore"
 	"github.com/discochess/stockpile/internal/store/memstore"
 )
 
@@ -48,8 +65,8 @@ func TestClient_Lookup_Found(t *testing.T) {
 	// Set up a shard with test data in JSONL format.
 	// The shard ID for this FEN would need to be computed, but for testing
 	// we can use a simple approach: put data in shard 0 and use a custom strategy.
-	testFEN := "8/8/8/8/8/8/8/8 w - - 0 1"
-	testData := []byte(`{"fen":"8/8/8/8/8/8/8/8 w - - 0 1","evals":[{"pvs":[{"cp":0,"line":""}],"knodes":1,"depth":1}]}` + "\n")
+	testFEN := "8/8/8/8/8/8/8/8 w - -"
+	testData := []byte(`{"fen":"8/8/8/8/8/8/8/8 w - -","evals":[{"pvs":[{"cp":0,"line":""}],"knodes":1,"depth":1}]}` + "\n")
 
 	// The material shard strategy will put empty board in shard 0.
 	mem.SetShard(0, testData)
@@ -63,7 +80,7 @@ func TestClient_Lookup_Found(t *testing.T) {
 	}
 	defer client.Close()
 
-	eval, err := client.Lookup(context.Background(), testFEN)
+	eval, err := client.Lookup(context.Background(), testFEN+" 0 1")
 	if err != nil {
 		t.Fatalf("Lookup() error = %v", err)
 	}
@@ -73,6 +90,104 @@ func TestClient_Lookup_Found(t *testing.T) {
 	}
 }
 
+func TestClient_Lookup_NormalizesFEN(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":20,"line":"e1e2"}],"knodes":1,"depth":20}]}`+"\n"))
+
+	client, err := New(WithStore(mem), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	full := "8/8/8/8/8/8/8/4K2k w - - 12 40"
+
+	if _, err := client.Lookup(ctx, full); err != nil {
+		t.Errorf("Lookup(%q) error = %v", full, err)
+	}
+	if _, errs := client.LookupBatch(ctx, []string{full}); errs[0] != nil {
+		t.Errorf("LookupBatch(%q) error = %v", full, errs[0])
+	}
+	if found, err := client.Contains(ctx, full); err != nil || !found {
+		t.Errorf("Contains(%q) = %v, %v; want true, nil", full, found, err)
+	}
+	if _, err := client.Lookup(ctx, full, WithRawFEN()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup(%q, WithRawFEN()) error = %v, want ErrNotFound", full, err)
+	}
+
+	for _, bad := range []string{"", "8/8/8/8/8/8/8/4K2k", "8/8/8/8/8/8/8/4K2k x - -"} {
+		if _, err := client.Lookup(ctx, bad); !errors.Is(err, ErrInvalidFEN) {
+			t.Errorf("Lookup(%q) error = %v, want ErrInvalidFEN", bad, err)
+		}
+		if _, errs := client.LookupBatch(ctx, []string{full, bad}); errs[0] != nil || !errors.Is(errs[1], ErrInvalidFEN) {
+			t.Errorf("LookupBatch(%q) errors = %v, want nil then ErrInvalidFEN", bad, errs)
+		}
+	}
+}
+
+func TestClient_Lookup_ClearsUncapturableEnPassant(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(
+		`{"fen":"rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3","evals":[{"pvs":[{"cp":-40,"line":"d4e3"}],"knodes":1,"depth":20}]}`+"\n"+
+			`{"fen":"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq -","evals":[{"pvs":[{"cp":-30,"line":"e7e5"}],"knodes":1,"depth":20}]}`+"\n"))
+
+	client, err := New(WithStore(mem), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	tests := []struct {
+		name   string
+		fen    string
+		wantCP int
+	}{
+		{"GUI-style square after 1.e4", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", -30},
+		{"capturable square kept", "rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 3", -40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eval, err := client.Lookup(context.Background(), tt.fen)
+			if err != nil {
+				t.Fatalf("Lookup(%q) error = %v", tt.fen, err)
+			}
+			if got := *eval.BestPV().Centipawns; got != tt.wantCP {
+				t.Errorf("Lookup(%q) cp = %d, want %d", tt.fen, got, tt.wantCP)
+			}
+		})
+	}
+}
+
+func TestClient_Lookup_CorruptShard(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(
+		`{"fen":"8/8/8/8/8/8/8/4K1k1 w - -","evals":[]}`+"\n"+
+			`{"evals":[]}`+"\n"+
+			`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[]}`+"\n",
+	))
+
+	client, err := New(WithStore(mem), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	_, err = client.Lookup(context.Background(), "8/8/8/8/8/8/8/4K2k w - -")
+	if !errors.Is(err, ErrCorruptShard) || errors.Is(err, ErrNotFound) {
+		t.Fatalf("Lookup() error = %v, want ErrCorruptShard", err)
+	}
+	if !strings.Contains(err.Error(), "shard 0") {
+		t.Errorf("Lookup() error = %q, want it to name the shard", err)
+	}
+
+	found, err := client.Contains(context.Background(), "8/8/8/8/8/8/8/4K2k w - -")
+	if found || !errors.Is(err, ErrCorruptShard) || !strings.Contains(err.Error(), "shard 0") {
+		t.Errorf("Contains() = %v, %v; want false and a shard 0 ErrCorruptShard", found, err)
+	}
+}
+
 func TestClient_Close(t *testing.T) {
 	mem := memstore.New()
 	client, err := New(WithStore(mem))
@@ -135,3 +250,860 @@ func TestWithTotalShards(t *testing.T) {
 	defer client.Close()
 	// Client created successfully with custom shard count.
 }
+
+// countingStore wraps a store and counts ReadShard calls per shard.
+type countingStore struct {
+	store.Store
+	mu    sync.Mutex
+	reads map[int]int
+}
+
+func (s *countingStore) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
+	s.mu.Lock()
+	s.reads[shardID]++
+	s.mu.Unlock()
+	return s.Store.ReadShard(ctx, shardID)
+}
+
+func TestClient_LookupBatch(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(
+		`{"fen":"8/8/8/8/8/8/8/4K2k b - -","evals":[{"pvs":[{"cp":0,"line":""}],"knodes":1,"depth":1}]}`+"\n"+
+			`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":0,"line":""}],"knodes":1,"depth":1}]}`+"\n",
+	))
+	st := &countingStore{Store: mem, reads: make(map[int]int)}
+
+	client, err := New(
+		WithStore(st),
+		WithTotalShards(1), // All positions share a single shard.
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	fens := []string{
+		"8/8/8/8/8/8/8/4K2k w - -",
+		"8/8/8/8/8/8/8/4K1k1 w - -",
+		"8/8/8/8/8/8/8/4K2k b - -",
+	}
+
+	evals, errs := client.LookupBatch(context.Background(), fens)
+	if len(evals) != len(fens) || len(errs) != len(fens) {
+		t.Fatalf("LookupBatch() returned %d evals and %d errors, want %d", len(evals), len(errs), len(fens))
+	}
+
+	for _, i := range []int{0, 2} {
+		if errs[i] != nil {
+			t.Errorf("errs[%d] = %v, want nil", i, errs[i])
+			continue
+		}
+		if evals[i].FEN != fens[i] {
+			t.Errorf("evals[%d].FEN = %q, want %q", i, evals[i].FEN, fens[i])
+		}
+	}
+
+	if !errors.Is(errs[1], ErrNotFound) {
+		t.Errorf("errs[1] = %v, want ErrNotFound", errs[1])
+	}
+	if evals[1] != nil {
+		t.Errorf("evals[1] = %v, want nil", evals[1])
+	}
+
+	if st.reads[0] != 1 {
+		t.Errorf("shard 0 read %d times, want 1", st.reads[0])
+	}
+}
+
+func TestClient_LookupBatch_AfterClose(t *testing.T) {
+	client, err := New(WithStore(memstore.New()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	client.Close()
+
+	_, errs := client.LookupBatch(context.Background(), []string{"a", "b"})
+	for i, err := range errs {
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("errs[%d] = %v, want ErrClosed", i, err)
+		}
+	}
+}
+
+func TestClient_Lookup_AllEvals(t *testing.T) {
+	mem := memstore.New()
+	testFEN := "8/8/8/8/8/8/8/4K2k w - -"
+	mem.SetShard(0, []byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[`+
+		`{"pvs":[{"cp":15,"line":"e1d2"},{"cp":5,"line":"e1f2"}],"knodes":500,"depth":30},`+
+		`{"pvs":[{"cp":40,"line":"e1e2"}],"knodes":20,"depth":12}]}`+"\n"))
+
+	client, err := New(WithStore(mem), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	eval, err := client.Lookup(context.Background(), testFEN)
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+
+	if len(eval.AllEvals) != 2 {
+		t.Fatalf("len(AllEvals) = %d, want 2", len(eval.AllEvals))
+	}
+	if eval.AllEvals[1].Depth != 12 || eval.AllEvals[1].Knodes != 20 {
+		t.Errorf("AllEvals[1] depth/knodes = %d/%d, want 12/20", eval.AllEvals[1].Depth, eval.AllEvals[1].Knodes)
+	}
+	if len(eval.AllEvals[0].PVs) != 2 {
+		t.Errorf("len(AllEvals[0].PVs) = %d, want 2", len(eval.AllEvals[0].PVs))
+	}
+
+	// Top-level fields still describe the first evaluation.
+	if eval.Depth != 30 || eval.Knodes != 500 {
+		t.Errorf("Depth/Knodes = %d/%d, want 30/500", eval.Depth, eval.Knodes)
+	}
+	if pv := eval.BestPV(); pv == nil || pv.Line != "e1d2" {
+		t.Errorf("BestPV() = %v, want line e1d2", pv)
+	}
+}
+
+func TestClient_Lookup_WithMinDepth(t *testing.T) {
+	mem := memstore.New()
+	testFEN := "8/8/8/8/8/8/8/4K2k w - -"
+	mem.SetShard(0, []byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":0,"line":""}],"knodes":1,"depth":18}]}`+"\n"))
+
+	client, err := New(WithStore(mem), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	tests := []struct {
+		name     string
+		minDepth int
+		wantErr  error
+	}{
+		{"no filter", 0, nil},
+		{"below threshold", 10, nil},
+		{"at threshold", 18, nil},
+		{"above threshold", 20, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Lookup(context.Background(), testFEN, WithMinDepth(tt.minDepth))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Lookup() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_Lookup_WithPreferDeepest(t *testing.T) {
+	mem := memstore.New()
+	testFEN := "8/8/8/8/8/8/8/4K2k w - -"
+	mem.SetShard(0, []byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":10,"line":"e1e2"}],"knodes":1,"depth":18},{"pvs":[{"cp":30,"line":"e1d2"},{"cp":5,"line":"e1f2"}],"knodes":9,"depth":30}]}`+"\n"))
+
+	client, err := New(WithStore(mem), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	tests := []struct {
+		name      string
+		opts      []LookupOption
+		wantErr   error
+		wantDepth int
+		wantLine  string
+	}{
+		{"first", nil, nil, 18, "e1e2"},
+		{"deepest", []LookupOption{WithPreferDeepest()}, nil, 30, "e1d2"},
+		{"first below min depth", []LookupOption{WithMinDepth(20)}, ErrNotFound, 0, ""},
+		{"deepest above min depth", []LookupOption{WithPreferDeepest(), WithMinDepth(20)}, nil, 30, "e1d2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eval, err := client.Lookup(context.Background(), testFEN, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Lookup() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if eval.Depth != tt.wantDepth || eval.PVs[0].Line != tt.wantLine {
+				t.Errorf("Depth, PVs[0].Line = %d, %q, want %d, %q", eval.Depth, eval.PVs[0].Line, tt.wantDepth, tt.wantLine)
+			}
+			if len(eval.AllEvals) != 2 || eval.AllEvals[0].Depth != 18 {
+				t.Errorf("AllEvals = %+v, want both entries in database order", eval.AllEvals)
+			}
+		})
+	}
+}
+
+func TestClient_Contains(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[]}`+"\n"))
+
+	client, err := New(WithStore(mem), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+
+	found, err := client.Contains(ctx, "8/8/8/8/8/8/8/4K2k w - -")
+	if err != nil || !found {
+		t.Errorf("Contains() = %v, %v; want true, nil", found, err)
+	}
+
+	found, err = client.Contains(ctx, "8/8/8/8/8/8/8/4K1k1 w - -")
+	if err != nil || found {
+		t.Errorf("Contains() = %v, %v; want false, nil", found, err)
+	}
+}
+
+func TestClient_LookupNearest(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(
+		`{"fen":"8/8/8/8/8/8/8/4K2k b - -","evals":[{"pvs":[{"cp":5,"line":"h1g2"}],"knodes":1,"depth":20}]}`+"\n"+
+			`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":0,"line":"e1e2"}],"knodes":1,"depth":18}]}`+"\n"+
+			`{"fen":"8/8/8/8/8/8/8/4K3 w - -","evals":[]}`+"\n",
+	))
+
+	client, err := New(WithStore(mem), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	// Looked up raw, a FEN with move counters sorts between the two 4K2k
+	// positions.
+	evals, err := client.LookupNearest(context.Background(), "8/8/8/8/8/8/8/4K2k b - - 0 1", 2, WithRawFEN())
+	if err != nil {
+		t.Fatalf("LookupNearest() error = %v", err)
+	}
+	if len(evals) != 2 || evals[0].FEN != "8/8/8/8/8/8/8/4K2k b - -" || evals[1].FEN != "8/8/8/8/8/8/8/4K2k w - -" {
+		t.Fatalf("LookupNearest() = %+v, want the two 4K2k positions", evals)
+	}
+	if evals[1].Depth != 18 {
+		t.Errorf("evals[1].Depth = %d, want 18", evals[1].Depth)
+	}
+
+	// Normalized, it matches a stored position, which is nearest.
+	evals, err = client.LookupNearest(context.Background(), "8/8/8/8/8/8/8/4K2k w - - 5 9", 1)
+	if err != nil {
+		t.Fatalf("LookupNearest() error = %v", err)
+	}
+	if len(evals) != 1 || evals[0].FEN != "8/8/8/8/8/8/8/4K2k w - -" {
+		t.Errorf("LookupNearest() = %+v, want the white 4K2k position", evals)
+	}
+	if _, err := client.LookupNearest(context.Background(), "not a fen", 1); !errors.Is(err, ErrInvalidFEN) {
+		t.Errorf("LookupNearest() of malformed FEN error = %v, want ErrInvalidFEN", err)
+	}
+}
+
+func TestClient_Contains_MissingShard(t *testing.T) {
+	client, err := New(WithStore(memstore.New()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	found, err := client.Contains(context.Background(), "8/8/8/8/8/8/8/4K2k w - -")
+	if err != nil || found {
+		t.Errorf("Contains() = %v, %v; want false, nil", found, err)
+	}
+}
+
+// releasingStore wraps a store, hands out copies of its shards and counts
+// how many are given back through ReleaseShard.
+type releasingStore struct {
+	store.Store
+	mu       sync.Mutex
+	reads    int
+	released int
+}
+
+func (s *releasingStore) ReadShard(ctx context.Context, shardID int) ([]byte, error) {
+	data, err := s.Store.ReadShard(ctx, shardID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.reads++
+	s.mu.Unlock()
+	return bytes.Clone(data), nil
+}
+
+func (s *releasingStore) ReleaseShard(data []byte) {
+	s.mu.Lock()
+	s.released++
+	s.mu.Unlock()
+	clear(data) // Catch any use after release.
+}
+
+func TestClient_ReleasesShards(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":5,"line":"e1e2"}],"knodes":1,"depth":1}]}`+"\n"))
+	st := &releasingStore{Store: mem}
+
+	client, err := New(WithStore(st), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	fen := "8/8/8/8/8/8/8/4K2k w - -"
+
+	eval, err := client.Lookup(ctx, fen)
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if _, err := client.Lookup(ctx, "8/8/8/8/8/8/8/4K1k1 w - -"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup() error = %v, want ErrNotFound", err)
+	}
+	if _, err := client.Contains(ctx, fen); err != nil {
+		t.Errorf("Contains() error = %v", err)
+	}
+	client.LookupBatch(ctx, []string{fen, fen})
+	if err := client.Warmup(ctx, []string{fen}); err != nil {
+		t.Errorf("Warmup() error = %v", err)
+	}
+
+	if st.reads != 5 || st.released != st.reads {
+		t.Errorf("released %d of %d shard reads, want all 5", st.released, st.reads)
+	}
+	// The eval must not alias the released buffer.
+	if eval.FEN != fen || eval.BestPV().Line != "e1e2" {
+		t.Errorf("Lookup() = %+v after release, want intact copy", eval)
+	}
+}
+
+func TestClient_ShardRanges(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":0,"line":""}],"knodes":1,"depth":1}]}`+"\n"))
+	st := &countingStore{Store: mem, reads: make(map[int]int)}
+
+	client, err := New(
+		WithStore(st),
+		WithTotalShards(1),
+		optionFunc(func(o *options) {
+			o.shardRanges = map[int]fenRange{0: {min: "8/8/8/8/8/8/8/4K2k w - -", max: "8/8/8/8/8/8/8/4K2k w - -"}}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+
+	if _, err := client.Lookup(ctx, "8/8/8/8/8/8/8/4K2k w - -"); err != nil {
+		t.Errorf("Lookup() in range error = %v", err)
+	}
+	if _, err := client.Lookup(ctx, "8/8/8/8/8/8/8/k3K3 w - -"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup() out of range error = %v, want ErrNotFound", err)
+	}
+	if found, err := client.Contains(ctx, "1k6/8/8/8/8/8/8/6K1 w - -"); err != nil || found {
+		t.Errorf("Contains() out of range = %v, %v; want false, nil", found, err)
+	}
+
+	if st.reads[0] != 1 {
+		t.Errorf("shard 0 read %d times, want 1 (out-of-range lookups must skip the fetch)", st.reads[0])
+	}
+}
+
+// Compile-time check that lookups through a persistent disk store can use
+// its line indexes.
+var _ indexedReader = (*diskstore.PersistentStore)(nil)
+
+func TestClient_PersistentLineIndex(t *testing.T) {
+	dir := t.TempDir()
+	disk, err := diskstore.New(dir, zstdcodec.New())
+	if err != nil {
+		t.Fatalf("diskstore.New() error = %v", err)
+	}
+	if err := disk.WriteShard(context.Background(), 0, []byte(
+		`{"fen":"8/8/8/8/8/8/8/4K1k1 w - -","evals":[{"pvs":[{"cp":1,"line":""}],"knodes":1,"depth":1}]}`+"\n"+
+			`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":2,"line":""}],"knodes":1,"depth":1}]}`+"\n",
+	)); err != nil {
+		t.Fatalf("WriteShard() error = %v", err)
+	}
+	st, err := diskstore.NewPersistent(dir, zstdcodec.New(), diskstore.WithLineIndex(true))
+	if err != nil {
+		t.Fatalf("NewPersistent() error = %v", err)
+	}
+
+	client, err := New(WithStore(st), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	// Repeat lookups so later ones reuse the index built by the first.
+	ctx := context.Background()
+	for range 2 {
+		eval, err := client.Lookup(ctx, "8/8/8/8/8/8/8/4K2k w - -")
+		if err != nil || eval.Score() != "+0.02" {
+			t.Errorf("Lookup() = %v, %v; want +0.02", eval, err)
+		}
+		if _, err := client.Lookup(ctx, "8/8/8/8/8/8/8/4K3 w - -"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Lookup() of missing position error = %v, want ErrNotFound", err)
+		}
+	}
+	evals, errs := client.LookupBatch(ctx, []string{"8/8/8/8/8/8/8/4K1k1 w - -", "8/8/8/8/8/8/8/4K2k w - -"})
+	for i, want := range []string{"+0.01", "+0.02"} {
+		if errs[i] != nil || evals[i].Score() != want {
+			t.Errorf("LookupBatch()[%d] = %v, %v; want %s", i, evals[i], errs[i], want)
+		}
+	}
+}
+
+func TestWithDataDir_Compression(t *testing.T) {
+	tests := []struct {
+		compression string
+		wantErr     bool
+	}{
+		{"zstd", false},
+		{"gzip", false},
+		{"lz4", false},
+		{"", false}, // Manifests predating the field are zstd.
+		{"brotli", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.compression, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := builder.WriteManifest(dir, &builder.Manifest{
+				TotalShards: 1,
+				Strategy:    "material",
+				Compression: tt.compression,
+			}); err != nil {
+				t.Fatalf("WriteManifest() error = %v", err)
+			}
+
+			_, err := WithDataDir(dir)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WithDataDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithDataDir_Dictionary(t *testing.T) {
+	dir := t.TempDir()
+	var source []byte
+	for i := 0; i < 400; i++ {
+		source = fmt.Appendf(source, `{"fen":"8/8/8/8/8/8/8/4K2k w - - %d","evals":[{"pvs":[{"cp":%d,"line":"e1d1 h1g2"}],"knodes":%d,"depth":30}]}`+"\n", i, i%97, i*31)
+	}
+	sourcePath := filepath.Join(dir, "source.jsonl")
+	if err := os.WriteFile(sourcePath, source, 0644); err != nil {
+		t.Fatalf("writing source: %v", err)
+	}
+	b := builder.NewBuilder(
+		builder.WithOutputDir(dir),
+		builder.WithTotalShards(4),
+		builder.WithDictionary(true),
+		builder.WithCompressionLevel(zstd.SpeedFastest),
+		builder.WithProgress(nil),
+	)
+	if err := b.BuildFromFile(context.Background(), sourcePath, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+	if m, err := builder.ReadManifest(dir); err != nil || m.Dictionary == "" {
+		t.Fatalf("ReadManifest() = %+v, %v; want a dictionary", m, err)
+	}
+
+	opt, err := WithDataDir(dir)
+	if err != nil {
+		t.Fatalf("WithDataDir() error = %v", err)
+	}
+	client, err := New(opt)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	// The source FENs differ only in their fifth field, so look up raw.
+	eval, err := client.Lookup(context.Background(), "8/8/8/8/8/8/8/4K2k w - - 123", WithRawFEN())
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if eval.Knodes != 123*31 {
+		t.Errorf("Lookup() knodes = %d, want %d", eval.Knodes, 123*31)
+	}
+}
+
+func TestWithDataDir_FrameSeeking(t *testing.T) {
+	dir := t.TempDir()
+	var source []byte
+	for i := 0; i < 400; i++ {
+		source = fmt.Appendf(source, `{"fen":"8/8/8/8/8/8/8/4K2k w - - %d","evals":[{"pvs":[{"cp":%d,"line":"e1d1 h1g2"}],"knodes":%d,"depth":30}]}`+"\n", i, i%97, i*31)
+	}
+	sourcePath := filepath.Join(dir, "source.jsonl")
+	if err := os.WriteFile(sourcePath, source, 0644); err != nil {
+		t.Fatalf("writing source: %v", err)
+	}
+	b := builder.NewBuilder(
+		builder.WithOutputDir(dir),
+		builder.WithTotalShards(2),
+		builder.WithBuildIndex(true),
+		builder.WithFrameSize(1024),
+		builder.WithCompressionLevel(zstd.SpeedFastest),
+		builder.WithProgress(nil),
+	)
+	if err := b.BuildFromFile(context.Background(), sourcePath, time.Time{}); err != nil {
+		t.Fatalf("BuildFromFile() error = %v", err)
+	}
+
+	opt, err := WithDataDir(dir)
+	if err != nil {
+		t.Fatalf("WithDataDir() error = %v", err)
+	}
+	client, err := New(opt)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	const fen = "8/8/8/8/8/8/8/4K2k w - - 123"
+	d, err := client.acquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shardID := d.shardID(fen)
+	d.release()
+	if _, ok, err := client.Store().(shardSearcher).SearchShard(ctx, shardID, fen); !ok || err != nil {
+		t.Fatalf("SearchShard() = _, %v, %v; want a frame-seeking lookup", ok, err)
+	}
+
+	// The source FENs differ only in their fifth field, so look up raw.
+	eval, err := client.Lookup(ctx, fen, WithRawFEN())
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if eval.Knodes != 123*31 {
+		t.Errorf("Lookup() knodes = %d, want %d", eval.Knodes, 123*31)
+	}
+	if _, err := client.Lookup(ctx, fen+"0", WithRawFEN()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup() of missing position error = %v, want ErrNotFound", err)
+	}
+
+	fens := []string{"8/8/8/8/8/8/8/4K2k w - - 7", "8/8/8/8/8/8/8/4K2k w - - 399", "8/8/8/8/8/8/8/4K2k w - - 400"}
+	evals, errs := client.LookupBatch(ctx, fens, WithRawFEN())
+	if errs[0] != nil || evals[0].Knodes != 7*31 || errs[1] != nil || evals[1].Knodes != 399*31 {
+		t.Errorf("LookupBatch() = %v, %v; want knodes %d and %d", evals, errs, 7*31, 399*31)
+	}
+	if !errors.Is(errs[2], ErrNotFound) {
+		t.Errorf("LookupBatch() of missing position error = %v, want ErrNotFound", errs[2])
+	}
+}
+
+func TestWithDataDir_Strategy(t *testing.T) {
+	tests := []struct {
+		strategy string
+		wantErr  bool
+	}{
+		{"material", false},
+		{"fnv32", false},
+		{"pawn", false},
+		{"ring", false},
+		{"unknown", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := builder.WriteManifest(dir, &builder.Manifest{
+				TotalShards: 1,
+				Strategy:    tt.strategy,
+			}); err != nil {
+				t.Fatalf("WriteManifest() error = %v", err)
+			}
+
+			opt, err := WithDataDir(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WithDataDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			client, err := New(opt)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer client.Close()
+
+			if got := client.ShardStrategy().Name(); got != tt.strategy {
+				t.Errorf("ShardStrategy().Name() = %q, want %q", got, tt.strategy)
+			}
+		})
+	}
+}
+
+// recordingCollector records histogram observations.
+type recordingCollector struct {
+	stats.Noop
+	mu           sync.Mutex
+	observations map[string][]float64
+}
+
+func (c *recordingCollector) ObserveHistogram(name string, value float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.observations[name] = append(c.observations[name], value)
+}
+
+// labelingCollector records labeled counter increments as
+// "name{value}" keys.
+type labelingCollector struct {
+	stats.Noop
+	mu      sync.Mutex
+	labeled map[string]int64
+}
+
+func (c *labelingCollector) IncCounterLabeled(name, label, value string, delta int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.labeled[name+"{"+value+"}"] += delta
+}
+
+func TestClient_ShardMetrics(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[]}`+"\n"))
+	strategy := fixedStrategy{"8/8/8/8/8/8/8/4K2k w - -": 0, "8/8/8/8/8/8/8/4K1k1 w - -": 0, "8/8/8/8/8/8/8/4K3 w - -": 1}
+
+	for _, enabled := range []bool{false, true} {
+		collector := &labelingCollector{labeled: make(map[string]int64)}
+		client, err := New(WithStore(mem), WithTotalShards(2), WithShardStrategy(strategy),
+			WithStats(collector), WithShardMetrics(enabled))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		ctx := context.Background()
+		client.Lookup(ctx, "8/8/8/8/8/8/8/4K2k w - -")
+		client.LookupBatch(ctx, []string{"8/8/8/8/8/8/8/4K2k w - -", "8/8/8/8/8/8/8/4K1k1 w - -"})
+		client.Contains(ctx, "8/8/8/8/8/8/8/4K3 w - -") // Shard 1 is missing.
+		client.Close()
+
+		want := map[string]int64{}
+		if enabled {
+			want = map[string]int64{
+				stats.MetricShardHits + "{0}":   2,
+				stats.MetricShardMisses + "{0}": 1,
+				stats.MetricShardMisses + "{1}": 1,
+			}
+		}
+		if !maps.Equal(collector.labeled, want) {
+			t.Errorf("WithShardMetrics(%v): labeled counters = %v, want %v", enabled, collector.labeled, want)
+		}
+	}
+}
+
+func TestClient_Lookup_RecordsLatency(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(`{"fen":"8/8/8/8/8/8/8/8 w - -","evals":[{"pvs":[{"cp":0,"line":""}],"knodes":1,"depth":1}]}`+"\n"))
+
+	collector := &recordingCollector{observations: make(map[string][]float64)}
+	client, err := New(
+		WithStore(mem),
+		WithTotalShards(1),
+		WithStats(collector),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Lookup(context.Background(), "8/8/8/8/8/8/8/8 w - - 0 1"); err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+
+	for _, name := range []string{
+		stats.MetricLookupLatency,
+		stats.MetricShardFetchLatency,
+		stats.MetricSearchLatency,
+	} {
+		values := collector.observations[name]
+		if len(values) != 1 {
+			t.Errorf("%s: %d observations, want 1", name, len(values))
+			continue
+		}
+		if values[0] < 0 {
+			t.Errorf("%s: observed %v, want non-negative seconds", name, values[0])
+		}
+	}
+}
+
+func TestClient_Stats(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(`{"fen":"8/8/8/8/8/8/8/8 w - -","evals":[{"pvs":[{"cp":0,"line":""}],"knodes":1,"depth":1}]}`+"\n"))
+
+	client, err := New(WithStore(mem), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Lookup(ctx, "8/8/8/8/8/8/8/8 w - - 0 1")
+	client.Lookup(ctx, "8/8/8/8/8/8/8/K6k w - - 0 1")
+	client.LookupBatch(ctx, []string{"8/8/8/8/8/8/8/8 w - - 0 1", "8/8/8/8/8/8/8/K6k w - - 0 1"})
+
+	st := client.Stats()
+	if st.Lookups != 4 || st.Hits != 2 || st.Misses != 2 {
+		t.Errorf("Stats() lookups/hits/misses = %d/%d/%d, want 4/2/2", st.Lookups, st.Hits, st.Misses)
+	}
+	if st.ShardFetches != 3 {
+		t.Errorf("Stats().ShardFetches = %d, want 3", st.ShardFetches)
+	}
+	if st.Cache != nil {
+		t.Errorf("Stats().Cache = %+v, want nil for an uncached store", st.Cache)
+	}
+}
+
+func TestClient_CountsLookups(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(`{"fen":"8/8/8/8/8/8/8/8 w - -","evals":[{"pvs":[{"cp":0,"line":""}],"knodes":1,"depth":1}]}`+"\n"))
+
+	collector := stats.NewInMemory()
+	client, err := New(WithStore(mem), WithTotalShards(1), WithStats(collector))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Lookup(ctx, "8/8/8/8/8/8/8/8 w - - 0 1")
+	client.Lookup(ctx, "8/8/8/8/8/8/8/K6k w - - 0 1")
+	client.LookupBatch(ctx, []string{"8/8/8/8/8/8/8/8 w - - 0 1", "8/8/8/8/8/8/8/K6k w - - 0 1", "8/8/8/8/8/8/8/K5k1 w - - 0 1"})
+	client.Contains(ctx, "8/8/8/8/8/8/8/8 w - - 0 1")
+
+	for name, want := range map[string]int64{
+		stats.MetricLookups:      6,
+		stats.MetricHits:         3,
+		stats.MetricMisses:       3,
+		stats.MetricShardFetches: 4,
+	} {
+		if got := collector.Counter(name); got != want {
+			t.Errorf("Counter(%s) = %d, want %d", name, got, want)
+		}
+	}
+	if got := collector.HistogramCount(stats.MetricLookupLatency); got != 2 {
+		t.Errorf("HistogramCount(%s) = %d, want 2", stats.MetricLookupLatency, got)
+	}
+}
+
+func TestClient_Stats_Cache(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(`{"fen":"8/8/8/8/8/8/8/8 w - -","evals":[]}`+"\n"))
+
+	strategy, err := lru.New(10)
+	if err != nil {
+		t.Fatalf("lru.New() error = %v", err)
+	}
+	client, err := New(
+		WithStore(cachedstore.New(mem, memory.New(strategy, nil))),
+		WithTotalShards(1),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 4; i++ {
+		client.Lookup(context.Background(), "8/8/8/8/8/8/8/8 w - - 0 1")
+	}
+
+	cache := client.Stats().Cache
+	if cache == nil {
+		t.Fatal("Stats().Cache = nil, want cache stats")
+	}
+	if cache.Hits != 3 || cache.Misses != 1 {
+		t.Errorf("cache hits/misses = %d/%d, want 3/1", cache.Hits, cache.Misses)
+	}
+	if got := cache.HitRate(); got != 0.75 {
+		t.Errorf("HitRate() = %v, want 0.75", got)
+	}
+}
+
+func TestClient_Warmup(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(`{"fen":"a","evals":[]}`+"\n"))
+	mem.SetShard(1, []byte(`{"fen":"b","evals":[]}`+"\n"))
+	st := &countingStore{Store: mem, reads: make(map[int]int)}
+
+	client, err := New(
+		WithStore(st),
+		WithShardStrategy(fixedStrategy{"a": 0, "b": 1, "c": 0, "d": 2}),
+		WithTotalShards(3),
+		WithWarmupWorkers(2),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	// Shard 2 does not exist and is skipped.
+	if err := client.Warmup(context.Background(), []string{"a", "b", "c", "d"}, WithRawFEN()); err != nil {
+		t.Fatalf("Warmup() error = %v", err)
+	}
+	for shardID, want := range map[int]int{0: 1, 1: 1, 2: 1} {
+		if got := st.reads[shardID]; got != want {
+			t.Errorf("shard %d read %d times, want %d", shardID, got, want)
+		}
+	}
+}
+
+func TestClient_Warmup_NormalizesFEN(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(1, []byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[]}`+"\n"))
+	st := &countingStore{Store: mem, reads: make(map[int]int)}
+
+	client, err := New(
+		WithStore(st),
+		WithShardStrategy(fixedStrategy{"8/8/8/8/8/8/8/4K2k w - -": 1}),
+		WithTotalShards(2),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	// The move counters are dropped before sharding; the malformed FEN is
+	// skipped.
+	if err := client.Warmup(context.Background(), []string{"8/8/8/8/8/8/8/4K2k w - - 0 1", "not a fen"}); err != nil {
+		t.Fatalf("Warmup() error = %v", err)
+	}
+	if len(st.reads) != 1 || st.reads[1] != 1 {
+		t.Errorf("shard reads = %v, want shard 1 once", st.reads)
+	}
+}
+
+func TestClient_Warmup_Canceled(t *testing.T) {
+	client, err := New(WithStore(memstore.New()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.Warmup(ctx, []string{"8/8/8/8/8/8/8/8 w - -"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Warmup() error = %v, want context.Canceled", err)
+	}
+}
+
+// fixedStrategy maps FENs to preassigned shard IDs.
+type fixedStrategy map[string]int
+
+func (s fixedStrategy) Name() string { return "fixed" }
+
+func (s fixedStrategy) ShardID(fen string, totalShards int) int { return s[fen] }
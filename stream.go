@@ -0,0 +1,140 @@
+package stockpile
+
+import (
+	"context"
+	"sync"
+)
+
+// streamBatchSize bounds the number of adjacent positions LookupStream looks
+// up in one batch.
+const streamBatchSize = 64
+
+// LookupResult is the outcome of looking up one position in LookupStream.
+type LookupResult struct {
+	// FEN is the position looked up, as received on the input channel.
+	FEN string
+
+	// Eval is the evaluation, or nil if Err is set.
+	Eval *Eval
+
+	// Err is ErrNotFound if the position is not in the database, or the
+	// error that prevented the lookup.
+	Err error
+}
+
+// LookupStream looks up the positions received on in and sends a result
+// for each on the returned channel, in no particular order. At most
+// concurrency batches are looked up at once; values below 1 are treated
+// as 1.
+//
+// Positions that arrive together and share a shard are looked up as one
+// batch, as in LookupBatch, so the shard is fetched once for all of them.
+// A batch is started as soon as no further position is waiting on in,
+// so results are not held back for a slow producer.
+//
+// The returned channel is closed once in is closed and every result has
+// been sent, or once ctx is done; results not yet sent are then dropped.
+// The caller must drain the channel or cancel ctx.
+func (c *Client) LookupStream(ctx context.Context, in <-chan string, concurrency int, opts ...LookupOption) <-chan LookupResult {
+	out := make(chan LookupResult)
+	batches := make(chan []string)
+
+	lo := newLookupOptions(opts)
+	go c.groupStream(ctx, in, lo, batches)
+
+	var wg sync.WaitGroup
+	for range max(concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				evals, errs := c.LookupBatch(ctx, batch, opts...)
+				for i, fen := range batch {
+					select {
+					case out <- LookupResult{FEN: fen, Eval: evals[i], Err: errs[i]}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// groupStream splits the positions received on in into batches of adjacent
+// positions sharing a shard and sends them on batches, which it closes when
+// in is closed or ctx is done.
+func (c *Client) groupStream(ctx context.Context, in <-chan string, lo lookupOptions, batches chan<- []string) {
+	defer close(batches)
+
+	var batch []string
+	batchShard := 0
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		select {
+		case batches <- batch:
+			batch = nil
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		var fen string
+		var ok bool
+		if len(batch) == 0 {
+			select {
+			case fen, ok = <-in:
+			case <-ctx.Done():
+				return
+			}
+		} else {
+			// Only extend the batch with positions that are already waiting.
+			select {
+			case fen, ok = <-in:
+			case <-ctx.Done():
+				return
+			default:
+				if !flush() {
+					return
+				}
+				continue
+			}
+		}
+		if !ok {
+			flush()
+			return
+		}
+
+		shardID := c.streamShardID(fen, lo)
+		if len(batch) > 0 && (shardID != batchShard || len(batch) == streamBatchSize) {
+			if !flush() {
+				return
+			}
+		}
+		batch = append(batch, fen)
+		batchShard = shardID
+	}
+}
+
+// streamShardID returns the shard that holds fen, looked up as LookupBatch
+// does, in the current dataset. It only guides batching: LookupBatch
+// resolves shards again, so a Reload in between costs at most an extra
+// fetch, and reports malformed FENs, which may be batched anywhere.
+func (c *Client) streamShardID(fen string, lo lookupOptions) int {
+	if key, err := lookupKey(fen, lo); err == nil {
+		fen = key
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.data.shardID(fen)
+}
@@ -0,0 +1,111 @@
+package stockpile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/discochess/stockpile/internal/store/memstore"
+)
+
+// newStreamClient returns a single-shard client holding positions
+// "pos000".."pos<n-1>", where a position's depth is its number. The keys
+// are not FENs, so lookups need WithRawFEN.
+func newStreamClient(t *testing.T, n int) *Client {
+	t.Helper()
+	var shard strings.Builder
+	for i := range n {
+		fmt.Fprintf(&shard, `{"fen":"pos%03d","evals":[{"pvs":[{"cp":0,"line":""}],"knodes":1,"depth":%d}]}`+"\n", i, i)
+	}
+	mem := memstore.New()
+	mem.SetShard(0, []byte(shard.String()))
+
+	client, err := New(WithStore(mem), WithTotalShards(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestClient_LookupStream(t *testing.T) {
+	const n = 100
+	client := newStreamClient(t, n)
+
+	in := make(chan string, n+1)
+	for i := range n {
+		in <- fmt.Sprintf("pos%03d", i)
+	}
+	in <- "missing"
+	close(in)
+
+	got := make(map[string]LookupResult)
+	for r := range client.LookupStream(context.Background(), in, 4, WithRawFEN()) {
+		if _, ok := got[r.FEN]; ok {
+			t.Errorf("duplicate result for %q", r.FEN)
+		}
+		got[r.FEN] = r
+	}
+
+	if len(got) != n+1 {
+		t.Fatalf("got %d results, want %d", len(got), n+1)
+	}
+	for i := range n {
+		r := got[fmt.Sprintf("pos%03d", i)]
+		if r.Err != nil || r.Eval == nil || r.Eval.Depth != i {
+			t.Errorf("result for %q = %+v, want depth %d", r.FEN, r, i)
+		}
+	}
+	if r := got["missing"]; !errors.Is(r.Err, ErrNotFound) || r.Eval != nil {
+		t.Errorf("result for missing position = %+v, want ErrNotFound", r)
+	}
+
+	// Positions already waiting are batched: one fetch per streamBatchSize.
+	if fetches := client.Stats().ShardFetches; fetches != 2 {
+		t.Errorf("ShardFetches = %d, want 2", fetches)
+	}
+}
+
+func TestClient_LookupStream_Options(t *testing.T) {
+	client := newStreamClient(t, 20)
+
+	in := make(chan string, 2)
+	in <- "pos005"
+	in <- "pos015"
+	close(in)
+
+	for r := range client.LookupStream(context.Background(), in, 1, WithRawFEN(), WithMinDepth(10)) {
+		wantErr := error(nil)
+		if r.FEN == "pos005" {
+			wantErr = ErrNotFound
+		}
+		if !errors.Is(r.Err, wantErr) {
+			t.Errorf("result for %q error = %v, want %v", r.FEN, r.Err, wantErr)
+		}
+	}
+}
+
+func TestClient_LookupStream_Canceled(t *testing.T) {
+	client := newStreamClient(t, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	in := make(chan string) // Never closed.
+	out := client.LookupStream(ctx, in, 2, WithRawFEN())
+	in <- "pos000"
+	if r := <-out; r.Err != nil {
+		t.Fatalf("result = %+v, want the position", r)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Error("received a result after cancellation")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("output channel not closed after cancellation")
+	}
+}
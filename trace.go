@@ -0,0 +1,64 @@
+package stockpile
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/discochess/stockpile/internal/store"
+)
+
+// TraceEvent describes one Lookup, for debugging slow or missing lookups.
+// See WithTraceCallback.
+type TraceEvent struct {
+	FEN        string        // The FEN searched for, after normalization; as given if invalid.
+	ShardID    int           // Shard that holds FEN, or -1 if FEN was invalid.
+	ShardBytes int           // Decompressed size of the shard; 0 if it was not fetched.
+	CacheHit   bool          // Whether the shard was served from the store's cache.
+	Duration   time.Duration // Time spent in Lookup.
+	Err        error         // The error Lookup returned; nil if the position was found.
+}
+
+// WithTraceCallback calls fn with a TraceEvent at the end of every Lookup.
+// fn is called on the looking-up goroutine, so it must be safe for
+// concurrent use and should return quickly.
+//
+// The same details are logged at debug level if the logger set with
+// WithLogger enables it. With neither a callback nor debug logging, Lookup
+// does no tracing work.
+func WithTraceCallback(fn func(TraceEvent)) Option {
+	return optionFunc(func(o *options) {
+		o.trace = fn
+	})
+}
+
+// startTrace returns an event to fill in during a lookup of fen, and ctx
+// set up to record the details of the shard read, or a nil event and ctx
+// unchanged if the lookup is not traced.
+func (c *Client) startTrace(ctx context.Context, fen string) (*TraceEvent, *store.ReadInfo, context.Context) {
+	if c.trace == nil && !c.logger.Core().Enabled(zap.DebugLevel) {
+		return nil, nil, ctx
+	}
+	info := &store.ReadInfo{}
+	return &TraceEvent{FEN: fen, ShardID: -1}, info, store.WithReadInfo(ctx, info)
+}
+
+// finishTrace completes ev and reports it to the callback and the logger.
+func (c *Client) finishTrace(ev *TraceEvent, info *store.ReadInfo, start time.Time, err error) {
+	ev.CacheHit = info.CacheHit
+	ev.Duration = time.Since(start)
+	ev.Err = err
+
+	if c.trace != nil {
+		c.trace(*ev)
+	}
+	c.logger.Debug("lookup",
+		zap.String("fen", ev.FEN),
+		zap.Int("shard", ev.ShardID),
+		zap.Int("shardBytes", ev.ShardBytes),
+		zap.Bool("cacheHit", ev.CacheHit),
+		zap.Duration("duration", ev.Duration),
+		zap.Error(err),
+	)
+}
@@ -0,0 +1,90 @@
+package stockpile
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/discochess/stockpile/internal/store/cachedstore"
+	"github.com/discochess/stockpile/internal/store/cachedstore/cachestrategy/lru"
+	"github.com/discochess/stockpile/internal/store/cachedstore/memory"
+	"github.com/discochess/stockpile/internal/store/memstore"
+)
+
+func TestClient_Lookup_TraceCallback(t *testing.T) {
+	shard := []byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":0,"line":""}],"knodes":1,"depth":1}]}` + "\n")
+	mem := memstore.New()
+	mem.SetShard(0, shard)
+	strategy, err := lru.New(10)
+	if err != nil {
+		t.Fatalf("lru.New() error = %v", err)
+	}
+
+	var events []TraceEvent
+	client, err := New(
+		WithStore(cachedstore.New(mem, memory.New(strategy, nil))),
+		WithTotalShards(1),
+		WithTraceCallback(func(ev TraceEvent) { events = append(events, ev) }),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	client.Lookup(ctx, "8/8/8/8/8/8/8/4K2k w - - 0 1")
+	client.Lookup(ctx, "8/8/8/8/8/8/8/4K1k1 w - -")
+	client.Lookup(ctx, "not a fen")
+
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	first, second, invalid := events[0], events[1], events[2]
+
+	if first.FEN != "8/8/8/8/8/8/8/4K2k w - -" || first.ShardID != 0 || first.ShardBytes != len(shard) {
+		t.Errorf("first event = %+v, want the normalized FEN in shard 0 of %d bytes", first, len(shard))
+	}
+	if first.CacheHit || first.Err != nil || first.Duration <= 0 {
+		t.Errorf("first event = %+v, want a successful cache miss", first)
+	}
+	if !second.CacheHit || !errors.Is(second.Err, ErrNotFound) {
+		t.Errorf("second event = %+v, want a cache hit reporting ErrNotFound", second)
+	}
+	if invalid.FEN != "not a fen" || invalid.ShardID != -1 || invalid.ShardBytes != 0 || !errors.Is(invalid.Err, ErrInvalidFEN) {
+		t.Errorf("invalid event = %+v, want no shard and ErrInvalidFEN", invalid)
+	}
+}
+
+func TestClient_Lookup_DebugLog(t *testing.T) {
+	mem := memstore.New()
+	mem.SetShard(0, []byte(`{"fen":"8/8/8/8/8/8/8/4K2k w - -","evals":[{"pvs":[{"cp":0,"line":""}],"knodes":1,"depth":1}]}`+"\n"))
+
+	for _, level := range []zapcore.Level{zapcore.DebugLevel, zapcore.InfoLevel} {
+		core, logs := observer.New(level)
+		client, err := New(WithStore(mem), WithTotalShards(1), WithLogger(zap.New(core)))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		client.Lookup(context.Background(), "8/8/8/8/8/8/8/4K2k w - -")
+		client.Close()
+
+		entries := logs.FilterMessage("lookup").All()
+		if level > zapcore.DebugLevel {
+			if len(entries) != 0 {
+				t.Errorf("level %v: logged %d lookups, want none", level, len(entries))
+			}
+			continue
+		}
+		if len(entries) != 1 {
+			t.Fatalf("level %v: logged %d lookups, want 1", level, len(entries))
+		}
+		fields := entries[0].ContextMap()
+		if fields["shard"] != int64(0) || fields["cacheHit"] != false || fields["fen"] != "8/8/8/8/8/8/8/4K2k w - -" {
+			t.Errorf("logged fields = %v", fields)
+		}
+	}
+}